@@ -6,25 +6,46 @@ package apply
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/util/flowcontrol"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"sigs.k8s.io/cli-utils/cmd/printers"
+	jsonprinter "sigs.k8s.io/cli-utils/cmd/printers/json"
+	"sigs.k8s.io/cli-utils/cmd/printers/printer"
+	"sigs.k8s.io/cli-utils/cmd/printers/slack"
 	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
 	"sigs.k8s.io/cli-utils/pkg/manifestreader"
+	"sigs.k8s.io/cli-utils/pkg/util/factory"
 )
 
 func GetApplyRunner(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *ApplyRunner {
+	// The rate limiter settings are read from the getter when the
+	// clients are created, which happens after the flags have been parsed.
+	throttledGetter := &factory.ThrottledRESTClientGetter{
+		Delegate: f,
+	}
+	f = cmdutil.NewFactory(throttledGetter)
 	r := &ApplyRunner{
 		Applier:   apply.NewApplier(f, ioStreams),
 		ioStreams: ioStreams,
 		factory:   f,
+
+		stdinIsTerminal: IsTerminal,
 	}
 	cmd := &cobra.Command{
 		Use:                   "apply (DIRECTORY | STDIN)",
@@ -34,6 +55,20 @@ func GetApplyRunner(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *A
 	}
 
 	cmdutil.CheckErr(r.Applier.SetFlags(cmd))
+	// The recursive flag is usually added as part of the kubectl file
+	// name flags by the applier, but hidden there.
+	// Manifests are read from subdirectories unless it is disabled, so
+	// the flag defaults to true here.
+	recursiveUsage := "Read the manifests from all subdirectories of the directory. " +
+		"Set to false to only read the top-level directory."
+	if flag := cmd.Flags().Lookup("recursive"); flag != nil {
+		flag.Hidden = false
+		flag.Usage = recursiveUsage
+		flag.DefValue = "true"
+		cmdutil.CheckErr(flag.Value.Set("true"))
+	} else {
+		cmd.Flags().BoolP("recursive", "R", true, recursiveUsage)
+	}
 
 	// The following flags are added, but hidden because other code
 	// depend on them when parsing flags. These flags are hidden and unused.
@@ -51,16 +86,146 @@ func GetApplyRunner(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *A
 	cmd.Flags().StringVar(&r.output, "output", printers.DefaultPrinter(),
 		fmt.Sprintf("Output format, must be one of %s", strings.Join(printers.SupportedPrinters(), ",")))
 
+	cmd.Flags().Float32Var(&throttledGetter.QPS, "kube-api-qps", 5.0,
+		"Maximum queries per second to the Kubernetes API server.")
+	cmd.Flags().IntVar(&throttledGetter.Burst, "kube-api-burst", 10,
+		"Maximum burst of queries to the Kubernetes API server.")
+
 	cmd.Flags().DurationVar(&r.period, "poll-period", 2*time.Second,
 		"Polling period for resource statuses.")
-	cmd.Flags().DurationVar(&r.reconcileTimeout, "reconcile-timeout", time.Duration(0),
-		"Timeout threshold for waiting for all resources to reach the Current status.")
+	cmd.Flags().DurationVar(&r.reconcileTimeout, "reconcile-timeout", apply.NoWaitForReconcile,
+		"Timeout threshold for waiting for all resources to reach the Current status. 0 means not waiting, "+
+			"and -1s means waiting until the command is interrupted.")
+	cmd.Flags().StringVar(&r.waitForCondition, "wait-for-condition", "",
+		"Wait for all resources to have this status condition, like \"type=Ready,status=True\", "+
+			"instead of the Current status. Only used together with --reconcile-timeout.")
+	cmd.Flags().BoolVar(&r.waitForJobs, "wait-for-jobs", false,
+		"If true, wait until all applied Jobs have completed successfully. Jobs are not limited by "+
+			"--reconcile-timeout, but a Job that fails, including by exceeding its spec.activeDeadlineSeconds, fails the apply.")
+	cmd.Flags().DurationVar(&r.jobTimeout, "job-timeout", apply.DefaultJobTimeout,
+		"Timeout threshold for waiting for the Jobs to complete with --wait-for-jobs. "+
+			"-1s means waiting until the Jobs complete or fail.")
+	cmd.Flags().BoolVar(&r.statusCheck, "post-apply-status-check", false,
+		"If true and --reconcile-timeout is not set, check the status of all resources once after they "+
+			"have been applied, waiting up to 10 seconds, to report immediate failures.")
+	cmd.Flags().BoolVar(&r.manageClusterScoped, manageClusterScopedFlag, true,
+		"If true, apply and prune cluster-scoped resources, like Namespaces and ClusterRoles. "+
+			"The default will change to false in a future release.")
+	cmd.Flags().StringVar(&r.inventoryFormat, "inventory-format", "",
+		"The format of the inventory object, either v1 or v2. The v2 format also records the resource version "+
+			"of every applied resource, to warn before pruning resources modified since the last apply. "+
+			"If not set, the format of the existing inventory object is kept.")
+	cmd.Flags().BoolVar(&r.watchAndApply, "watch-and-apply", false,
+		"If true, watch the manifest directory and apply again whenever a YAML file changes, until interrupted. "+
+			"Intended for local development only.")
+	cmd.Flags().StringVar(&r.resourceStrategy, "resource-strategy", "merge",
+		"How resources that already exist are updated, must be one of merge, replace. "+
+			"The replace strategy drops any fields set in the cluster that are not in the manifests.")
+	cmd.Flags().StringVar(&r.timeoutBehavior, "timeout-behavior", "fail",
+		"What to do when the reconcile or prune timeout is reached, must be one of fail, continue.")
 	cmd.Flags().BoolVar(&r.noPrune, "no-prune", r.noPrune,
 		"If true, do not prune previously applied objects.")
 	cmd.Flags().StringVar(&r.prunePropagationPolicy, "prune-propagation-policy",
 		"Background", "Propagation policy for pruning")
+	cmd.Flags().StringSliceVar(&r.propagationPolicyMap, "delete-propagation-policy-map", []string{},
+		"Propagation policy for pruning resources of specific kinds, in the format <Kind>.<group>=<Policy>, "+
+			"like Namespace=Foreground or Deployment.apps=Orphan. Takes precedence over --prune-propagation-policy. Can be repeated.")
 	cmd.Flags().DurationVar(&r.pruneTimeout, "prune-timeout", time.Duration(0),
 		"Timeout threshold for waiting for all pruned resources to be deleted")
+	cmd.Flags().BoolVar(&r.pruneUnusedNamespaces, "prune-unused-namespaces", r.pruneUnusedNamespaces,
+		"If true, also prune namespaces that no longer contain any of the applied objects after pruning. "+
+			"Only namespaces labeled with the inventory id of the inventory object are pruned.")
+	cmd.Flags().BoolVar(&r.pruneNamespaceScoped, "prune-namespace-scoped", r.pruneNamespaceScoped,
+		"If true, never prune cluster-scoped objects.")
+	cmd.Flags().Float32Var(&r.pruneQPS, "prune-qps", 0,
+		"Maximum number of delete calls per second when pruning. 0 means no limit.")
+	cmd.Flags().StringVar(&r.applySetID, "apply-set-id", "",
+		"If set, track the applied objects in the parent object of the KEP-2886 ApplySet derived from this id, "+
+			"instead of an inventory object in the manifests.")
+	cmd.Flags().BoolVar(&r.inventoryClusterScoped, "inventory-cluster-scoped", r.inventoryClusterScoped,
+		fmt.Sprintf("If true, store the inventory object in the %s namespace so it can track resources in the "+
+			"whole cluster. Requires permissions to manage ConfigMaps in the %s namespace.",
+			inventory.ClusterInventoryNamespace, inventory.ClusterInventoryNamespace))
+	cmd.Flags().StringVar(&r.patch, "patch", "",
+		"A JSON Patch (RFC 6902) document applied to the resources in the manifests before they are applied.")
+	cmd.Flags().StringSliceVar(&r.patchKinds, "patch-kind", []string{},
+		"If set, the patch from --patch is only applied to resources of the given kinds.")
+	cmd.Flags().StringSliceVar(&r.setImages, "set-image", []string{},
+		"Override the image of all containers with the given name in Deployments, StatefulSets and DaemonSets, "+
+			"in the format <container>=<image>. Can be repeated.")
+	cmd.Flags().BoolVar(&r.autoUpgradeAPIVersions, "auto-upgrade-api-versions", false,
+		"If true, resources using deprecated API versions, like extensions/v1beta1 Ingresses, are rewritten "+
+			"to the current API versions before they are applied.")
+	cmd.Flags().StringSliceVar(&r.setReplicas, "set-replicas", []string{},
+		"Override the replicas of all Deployments and StatefulSets with the given name, "+
+			"in the format <name>=<count>. Can be repeated.")
+	cmd.Flags().StringSliceVar(&r.namespaceMap, "namespace-map", []string{},
+		"Rewrite a namespace in the manifests to another namespace, in the format <src>=<dst>, including "+
+			"namespace references like RoleBinding subjects. Can be repeated.")
+	cmd.Flags().StringArrayVar(&r.annotations, "annotation", []string{},
+		"Add or overwrite an annotation on all resources, in the format <key>=<value>. Can be repeated.")
+	cmd.Flags().StringSliceVar(&r.trimAnnotations, "trim-annotation", []string{},
+		"Remove the annotation with the given key from all resources before applying them. Can be repeated.")
+	cmd.Flags().BoolVar(&r.fromEnvVars, "from-env-vars", r.fromEnvVars,
+		"If true, substitute ${VAR} placeholders in the manifests with the values of environment variables.")
+	cmd.Flags().BoolVar(&r.allowUndefinedVars, "allow-undefined-vars", r.allowUndefinedVars,
+		"If true, leave placeholders for environment variables that are not set as-is instead of failing.")
+	cmd.Flags().IntVar(&r.revisionHistoryLimit, "revision-history-limit", 0,
+		fmt.Sprintf("Number of snapshots of previous applies to keep for rollback, at most %d. 0 disables history.",
+			inventory.MaxRevisionHistoryLimit))
+	cmd.Flags().IntVar(&r.maxResourceHistory, "max-resource-history", 0,
+		fmt.Sprintf("Number of previous revisions of every resource to keep in the inventory object for "+
+			"rollback with --resource, at most %d. 0 disables the resource history.",
+			inventory.MaxRevisionHistoryLimit))
+	cmd.Flags().Int64Var(&r.maxResourceSize, "max-resource-size", 0,
+		fmt.Sprintf("Maximum size in bytes of each resource. Resources exceeding it are rejected before "+
+			"anything is applied. 0 means no limit, %d is a sensible value.", apply.DefaultMaxResourceSize))
+	cmd.Flags().BoolVar(&r.ignoreNotFound, "ignore-not-found", r.ignoreNotFound,
+		"If true, resources that can not be applied since the API server returns NotFound are skipped "+
+			"instead of failing the apply.")
+	cmd.Flags().BoolVar(&r.noInventoryUpdate, "no-inventory-update", r.noInventoryUpdate,
+		"If true, do not write the inventory object to the cluster after apply.")
+	cmd.Flags().BoolVar(&r.partialApply, "partial-apply", false,
+		"If true, apply each resource independently and continue if some of them fail, retrying "+
+			"each failed resource once unless --max-retries is set. Failed resources are reported at "+
+			"the end, and the command exits with code 2 if any failed.")
+	cmd.Flags().IntVar(&r.maxRetries, "max-retries", 0,
+		"Number of times applying a resource is retried if it fails with a transient error, like a server "+
+			"timeout, too many requests, a conflict or an internal server error. If more than 0, the resources "+
+			"are applied one at a time.")
+	cmd.Flags().StringVar(&r.minK8sVersion, "min-k8s-version", "",
+		"If set, the minimum Kubernetes version of the cluster, like 1.25. The apply fails "+
+			"before changing anything if the cluster is older.")
+	cmd.Flags().BoolVar(&r.showLatency, "show-latency", false,
+		"If true, print how long each resource took to apply as a latency bucket. "+
+			"Only supported by the events and json outputs.")
+	cmd.Flags().BoolVar(&r.allowDuplicates, "allow-duplicates", false,
+		"If true, resources found more than once in the manifests with different content are not an error, "+
+			"and the last occurrence is applied.")
+	cmd.Flags().BoolVar(&r.validateSchema, "validate-schema", false,
+		"If true, only validate the resources against the schemas of the API server with a server-side "+
+			"apply dry-run, without changing anything in the cluster. Requires --server-side.")
+	cmd.Flags().BoolVar(&r.forceDelete, "force-delete", false,
+		"If true, prune resources even if their lifecycle annotation says they should be kept or detached. "+
+			"Requires --confirm or --non-interactive.")
+	cmd.Flags().BoolVar(&r.confirm, "confirm", false,
+		"If true and stdin is a terminal, list the resources that will be pruned and ask for "+
+			"confirmation before applying.")
+	cmd.Flags().BoolVar(&r.nonInteractive, "non-interactive", false,
+		"Run without user interaction, so the --confirm prompt is skipped.")
+	cmd.Flags().StringVar(&r.planFile, "plan-file", "",
+		"If set, write the changes the apply would make to this file as JSON instead of applying them.")
+	cmd.Flags().StringVar(&r.fromPlan, "from-plan", "",
+		"Apply the manifests from a plan file written with --plan-file instead of reading them from the "+
+			"directory. Fails if the live state of any of the resources changed since the plan was created.")
+	cmd.Flags().StringVar(&r.outputFile, "output-file", "",
+		"If set, also write the events to this file, in addition to printing them to stdout.")
+	cmd.Flags().StringVar(&r.outputFileFormat, "output-file-format", printers.JSONPrinter,
+		fmt.Sprintf("Output format of the --output-file, must be one of %s",
+			strings.Join(printers.SupportedPrinters(), ",")))
+	cmd.Flags().StringVar(&r.slackWebhookURL, "slack-webhook-url", "",
+		fmt.Sprintf("Slack webhook URL used by the slack output. Defaults to the %s environment variable.",
+			slack.WebhookURLEnvVar))
 
 	r.Command = cmd
 	return r
@@ -70,34 +235,175 @@ func ApplyCommand(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cob
 	return GetApplyRunner(f, ioStreams).Command
 }
 
+// manageClusterScopedFlag is the flag enabling the management of
+// cluster-scoped resources.
+const manageClusterScopedFlag = "manage-cluster-scoped-resources"
+
+// ApplierInterface is the subset of the apply.Applier methods used by
+// the ApplyRunner. It allows a fake applier to be injected in tests.
+type ApplierInterface interface {
+	SetFlags(cmd *cobra.Command) error
+	Initialize(cmd *cobra.Command) error
+	SetInventoryClientFactoryFunc(f func(cmdutil.Factory) (inventory.InventoryClient, error))
+	SetPruneRateLimiter(rateLimiter flowcontrol.RateLimiter)
+	Run(ctx context.Context, objects []*resource.Info, options apply.Options) <-chan event.Event
+	Reset() error
+	BuildPlan(ctx context.Context, objects []*resource.Info, options apply.Options) (*apply.Plan, error)
+	CheckPlan(plan *apply.Plan) error
+}
+
+var _ ApplierInterface = &apply.Applier{}
+
 type ApplyRunner struct {
 	Command   *cobra.Command
 	ioStreams genericclioptions.IOStreams
-	Applier   *apply.Applier
+	Applier   ApplierInterface
 	factory   cmdutil.Factory
 
 	output                 string
 	period                 time.Duration
 	reconcileTimeout       time.Duration
+	waitForCondition       string
+	waitForJobs            bool
+	jobTimeout             time.Duration
+	statusCheck            bool
+	watchAndApply          bool
+	manageClusterScoped    bool
+	inventoryFormat        string
+	resourceStrategy       string
+	timeoutBehavior        string
 	noPrune                bool
 	prunePropagationPolicy string
+	propagationPolicyMap   []string
 	pruneTimeout           time.Duration
+	pruneUnusedNamespaces  bool
+	pruneNamespaceScoped   bool
+	pruneQPS               float32
+	applySetID             string
+	inventoryClusterScoped bool
+	patch                  string
+	patchKinds             []string
+	setImages              []string
+	setReplicas            []string
+	autoUpgradeAPIVersions bool
+	annotations            []string
+	namespaceMap           []string
+	trimAnnotations        []string
+	noInventoryUpdate      bool
+	ignoreNotFound         bool
+	maxResourceSize        int64
+	revisionHistoryLimit   int
+	maxResourceHistory     int
+	planFile               string
+	fromPlan               string
+	slackWebhookURL        string
+	outputFile             string
+	outputFileFormat       string
+	fromEnvVars            bool
+	allowUndefinedVars     bool
+	confirm                bool
+	forceDelete            bool
+	validateSchema         bool
+	allowDuplicates        bool
+	showLatency            bool
+	minK8sVersion          string
+	maxRetries             int
+	partialApply           bool
+	nonInteractive         bool
+
+	eventTransformer EventTransformer
+	// stdinIsTerminal returns whether the reader is an interactive
+	// terminal. It can be replaced in tests.
+	stdinIsTerminal func(io.Reader) bool
+}
+
+// EventTransformer modifies an event before it is printed. If the
+// second return value is false, the event is dropped.
+type EventTransformer func(event.Event) (event.Event, bool)
+
+// SetEventTransformer sets a function that is applied to every event
+// before it is passed to the printer. This allows callers to enrich
+// or filter the events.
+func (r *ApplyRunner) SetEventTransformer(fn EventTransformer) {
+	r.eventTransformer = fn
 }
 
 func (r *ApplyRunner) RunE(cmd *cobra.Command, args []string) error {
+	if r.watchAndApply {
+		return r.runWatchAndApply(cmd, args)
+	}
+	return r.runE(context.Background(), cmd, args)
+}
+
+// runE applies the manifests once. Cancelling the context stops the
+// apply.
+func (r *ApplyRunner) runE(ctx context.Context, cmd *cobra.Command, args []string) error {
 	prunePropPolicy, err := convertPropagationPolicy(r.prunePropagationPolicy)
 	if err != nil {
 		return err
 	}
+	prunePropPolicyMap, err := r.convertPropagationPolicyMap()
+	if err != nil {
+		return err
+	}
+	timeoutBehavior, err := convertTimeoutBehavior(r.timeoutBehavior)
+	if err != nil {
+		return err
+	}
+	resourceStrategy, err := convertResourceStrategy(r.resourceStrategy)
+	if err != nil {
+		return err
+	}
+
+	if r.output == printers.DotPrinter || r.outputFileFormat == printers.DotPrinter {
+		return fmt.Errorf("%s output is only supported in dry-run mode, use the preview command", printers.DotPrinter)
+	}
+
+	if r.planFile != "" && r.fromPlan != "" {
+		return fmt.Errorf("--plan-file and --from-plan can not be used together")
+	}
+	if r.fromPlan != "" && r.applySetID != "" {
+		return fmt.Errorf("--from-plan can not be used together with --apply-set-id")
+	}
+	if err := apply.ValidateForceDelete(r.forceDelete, r.confirm, r.nonInteractive); err != nil {
+		return err
+	}
+
+	var applySetClient *inventory.ApplySetInventoryClient
+	if r.applySetID != "" {
+		applySetClient, err = inventory.NewApplySetInventoryClient(r.factory, r.applySetID)
+		if err != nil {
+			return err
+		}
+		// The inventory clients cache the inventory objects, so every
+		// call must return a new one.
+		r.Applier.SetInventoryClientFactoryFunc(func(f cmdutil.Factory) (inventory.InventoryClient, error) {
+			c, err := inventory.NewApplySetInventoryClient(f, r.applySetID)
+			if err != nil {
+				return nil, err
+			}
+			return c, nil
+		})
+	}
+
+	if r.pruneQPS < 0 {
+		return fmt.Errorf("--prune-qps must not be negative")
+	}
+	if r.pruneQPS > 0 {
+		r.Applier.SetPruneRateLimiter(flowcontrol.NewTokenBucketRateLimiter(r.pruneQPS, 1))
+	}
 
-	cmdutil.CheckErr(r.Applier.Initialize(cmd))
+	if err := r.Applier.Initialize(cmd); err != nil {
+		return err
+	}
 
 	// Only emit status events if we are waiting for status.
 	//TODO: This is not the right way to do this. There are situations where
 	// we do need status events event if we are not waiting for status. The
 	// printers should be updated to handle this.
 	var emitStatusEvents bool
-	if r.reconcileTimeout != time.Duration(0) || r.pruneTimeout != time.Duration(0) {
+	if r.reconcileTimeout != apply.NoWaitForReconcile || r.pruneTimeout != time.Duration(0) || r.waitForJobs ||
+		r.statusCheck {
 		emitStatusEvents = true
 	}
 
@@ -109,11 +415,72 @@ func (r *ApplyRunner) RunE(cmd *cobra.Command, args []string) error {
 	}
 
 	var reader manifestreader.ManifestReader
+	recursive, err := cmd.Flags().GetBool("recursive")
+	if err != nil {
+		return err
+	}
 	readerOptions := manifestreader.ReaderOptions{
-		Factory:   r.factory,
-		Namespace: metav1.NamespaceDefault,
+		Factory:         r.factory,
+		Namespace:       metav1.NamespaceDefault,
+		NoRecursive:     !recursive,
+		AllowDuplicates: r.allowDuplicates,
+		Stdin:           cmd.InOrStdin(),
 	}
-	if len(args) == 0 {
+	if r.autoUpgradeAPIVersions {
+		readerOptions.APIVersionOverrides = manifestreader.DefaultAPIVersionOverrides
+	}
+	if r.fromEnvVars {
+		readerOptions.Transformers = append(readerOptions.Transformers,
+			manifestreader.NewEnvVarTransformer(r.allowUndefinedVars))
+	} else if r.allowUndefinedVars {
+		return fmt.Errorf("--allow-undefined-vars can only be used together with --from-env-vars")
+	}
+	if len(r.setImages) > 0 {
+		setImageTransformer, err := manifestreader.NewSetImageTransformer(r.setImages)
+		if err != nil {
+			return err
+		}
+		readerOptions.Transformers = append(readerOptions.Transformers, setImageTransformer)
+	}
+	if len(r.setReplicas) > 0 {
+		replicaTransformer, err := manifestreader.NewReplicaTransformer(r.setReplicas)
+		if err != nil {
+			return err
+		}
+		readerOptions.Transformers = append(readerOptions.Transformers, replicaTransformer)
+	}
+	if len(r.namespaceMap) > 0 {
+		namespaceMapTransformer, err := manifestreader.NewNamespaceMapTransformer(r.namespaceMap)
+		if err != nil {
+			return err
+		}
+		readerOptions.Transformers = append(readerOptions.Transformers, namespaceMapTransformer)
+	}
+	if len(r.annotations) > 0 {
+		annotationTransformer, err := manifestreader.NewAnnotationTransformer(r.annotations)
+		if err != nil {
+			return err
+		}
+		readerOptions.Transformers = append(readerOptions.Transformers, annotationTransformer)
+	}
+	if r.patch != "" {
+		patchTransformer, err := manifestreader.NewJSONPatchTransformer(r.patch, r.patchKinds)
+		if err != nil {
+			return err
+		}
+		readerOptions.Transformers = append(readerOptions.Transformers, patchTransformer)
+	} else if len(r.patchKinds) > 0 {
+		return fmt.Errorf("--patch-kind can only be used together with --patch")
+	}
+	if r.fromPlan != "" {
+		reader, err = r.planManifestReader(manifestreader.ReaderOptions{
+			Factory:   r.factory,
+			Namespace: metav1.NamespaceDefault,
+		})
+		if err != nil {
+			return err
+		}
+	} else if len(args) == 0 {
 		reader = &manifestreader.StreamManifestReader{
 			ReaderName:    "stdin",
 			Reader:        cmd.InOrStdin(),
@@ -130,11 +497,46 @@ func (r *ApplyRunner) RunE(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Run the applier. It will return a channel where we can receive updates
-	// to keep track of progress and any issues.
-	ch := r.Applier.Run(context.Background(), infos, apply.Options{
+	if applySetClient != nil {
+		if _, found := inventory.FindInventoryObj(infos); found {
+			return fmt.Errorf("inventory object not allowed in the manifests when --apply-set-id is set")
+		}
+		namespace, _, err := r.factory.ToRawKubeConfigLoader().Namespace()
+		if err != nil {
+			return err
+		}
+		inv, err := applySetClient.InventoryObject(applySetNamespace(infos, namespace))
+		if err != nil {
+			return err
+		}
+		if err := applySetClient.SetMembers(inv, infos); err != nil {
+			return err
+		}
+		infos = append(infos, inv)
+	}
+
+	if r.inventoryClusterScoped {
+		inv, found := inventory.FindInventoryObj(infos)
+		if !found {
+			return inventory.NoInventoryObjError{}
+		}
+		if err := inventory.SetClusterScoped(inv); err != nil {
+			return err
+		}
+	}
+
+	var inventoryFormat inventory.InventoryFormat
+	if r.inventoryFormat != "" {
+		inventoryFormat, err = inventory.ParseInventoryFormat(r.inventoryFormat)
+		if err != nil {
+			return err
+		}
+	}
+
+	options := apply.Options{
 		PollInterval:     r.period,
 		ReconcileTimeout: r.reconcileTimeout,
+		TimeoutBehavior:  timeoutBehavior,
 		// If we are not waiting for status, tell the applier to not
 		// emit the events.
 		EmitStatusEvents:       emitStatusEvents,
@@ -142,13 +544,304 @@ func (r *ApplyRunner) RunE(cmd *cobra.Command, args []string) error {
 		DryRun:                 false,
 		PrunePropagationPolicy: prunePropPolicy,
 		PruneTimeout:           r.pruneTimeout,
-	})
+		PruneUnusedNamespaces:  r.pruneUnusedNamespaces,
+		PruneNamespaceScoped:   r.pruneNamespaceScoped,
+		PruneForceDelete:       r.forceDelete,
+		SkipInventoryUpdate:    r.noInventoryUpdate,
+		IgnoreNotFound:         r.ignoreNotFound,
+		MaxResourceSize:        r.maxResourceSize,
+		RevisionHistoryLimit:   r.revisionHistoryLimit,
+		MaxResourceHistory:     r.maxResourceHistory,
+		WaitForJobs:            r.waitForJobs,
+		JobTimeout:             r.jobTimeout,
+
+		ManageClusterScopedResources: &r.manageClusterScoped,
+		PrunePropagationPolicyMap:    prunePropPolicyMap,
+		InventoryFormat:              inventoryFormat,
+		ResourceStrategy:             resourceStrategy,
+		AnnotationsTrimList:          r.trimAnnotations,
+		PostApplyStatusCheck:         r.statusCheck,
+		ValidateSchema:               r.validateSchema,
+		MinimumResourceVersion:       r.minK8sVersion,
+		MaxRetries:                   r.maxRetries,
+	}
+	if r.partialApply {
+		options.ContinueOnError = true
+		if options.MaxRetries == 0 {
+			options.MaxRetries = 1
+		}
+	}
+	if !cmd.Flags().Changed(manageClusterScopedFlag) {
+		mapper, err := r.factory.ToRESTMapper()
+		if err != nil {
+			return err
+		}
+		if hasClusterScoped(infos, mapper) {
+			fmt.Fprintf(r.ioStreams.ErrOut, "WARNING: cluster-scoped resources will not be applied by default "+
+				"in a future release. Set --%s=true to keep applying them.\n", manageClusterScopedFlag)
+		}
+	}
+	if r.waitForCondition != "" {
+		// The empty GroupKind applies the condition to all kinds, but
+		// not to the inventory object.
+		options.WaitForCondition = map[schema.GroupKind]string{
+			{}: r.waitForCondition,
+		}
+	}
+
+	if r.planFile != "" {
+		return r.writePlan(ctx, infos, options)
+	}
+
+	if err := r.confirmPrune(ctx, infos, options); err != nil {
+		return err
+	}
+
+	// The output file is created before applying, so the apply doesn't
+	// happen if it can't be written.
+	var filePrinter printer.Printer
+	if r.outputFile != "" {
+		f, err := os.Create(r.outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		filePrinter = printers.GetPrinter(r.outputFileFormat, genericclioptions.IOStreams{
+			In:     r.ioStreams.In,
+			Out:    f,
+			ErrOut: f,
+		})
+		if jp, ok := filePrinter.(*jsonprinter.Printer); ok {
+			jp.ShowLatency = r.showLatency
+		}
+	}
+
+	// Run the applier. It will return a channel where we can receive updates
+	// to keep track of progress and any issues.
+	ch := r.Applier.Run(ctx, infos, options)
 
 	// The printer will print updates from the channel. It will block
 	// until the channel is closed.
 	printer := printers.GetPrinter(r.output, r.ioStreams)
-	printer.Print(ch, false)
-	return nil
+	if sp, ok := printer.(*slack.Printer); ok && r.slackWebhookURL != "" {
+		sp.WebhookURL = r.slackWebhookURL
+	}
+	if bp, ok := printer.(*apply.BasicPrinter); ok {
+		bp.ShowLatency = r.showLatency
+		// The watch continues after a failed apply, so the error is
+		// returned instead.
+		bp.NoExit = r.watchAndApply
+	}
+	if jp, ok := printer.(*jsonprinter.Printer); ok {
+		jp.ShowLatency = r.showLatency
+	}
+	var partialResult partialApplyResult
+	if r.partialApply {
+		ch = watchPartialApply(ch, &partialResult)
+	}
+	var applyErr error
+	if r.watchAndApply {
+		ch = watchErrors(ch, &applyErr)
+	}
+	if r.eventTransformer != nil {
+		ch = transformEvents(ch, r.eventTransformer)
+	}
+	printEvents(ch, printer, filePrinter)
+	if applyErr != nil {
+		return applyErr
+	}
+	return partialApplyError(partialResult)
+}
+
+// writePlan computes the plan for applying the infos with a dry-run,
+// and writes it to the plan file.
+func (r *ApplyRunner) writePlan(ctx context.Context, infos []*resource.Info, options apply.Options) error {
+	plan, err := r.Applier.BuildPlan(ctx, infos, options)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(r.planFile)
+	if err != nil {
+		return err
+	}
+	if err := apply.WritePlan(f, plan); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// planManifestReader reads the plan file and verifies that the live
+// state of the resources hasn't changed since it was created. Returns
+// a reader for the manifests in the plan. The manifests have already
+// been transformed when the plan was created, so no transformers
+// should be set in the readerOptions.
+func (r *ApplyRunner) planManifestReader(readerOptions manifestreader.ReaderOptions) (manifestreader.ManifestReader, error) {
+	f, err := os.Open(r.fromPlan)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	plan, err := apply.ReadPlan(f)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Applier.CheckPlan(plan); err != nil {
+		return nil, err
+	}
+	docs := make([]string, 0, len(plan.Resources))
+	for _, res := range plan.Resources {
+		data, err := res.Manifest.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, string(data))
+	}
+	return &manifestreader.StreamManifestReader{
+		ReaderName:    r.fromPlan,
+		Reader:        strings.NewReader(strings.Join(docs, "\n---\n")),
+		ReaderOptions: readerOptions,
+	}, nil
+}
+
+// transformEvents returns a channel with the events from the passed
+// channel after applying fn to each of them. Events for which fn returns
+// false are dropped. The returned channel is closed when the passed
+// channel is closed.
+func transformEvents(ch <-chan event.Event, fn EventTransformer) <-chan event.Event {
+	out := make(chan event.Event)
+	go func() {
+		defer close(out)
+		for e := range ch {
+			if transformed, ok := fn(e); ok {
+				out <- transformed
+			}
+		}
+	}()
+	return out
+}
+
+// printEvents prints the events from the channel with the printer. If
+// the filePrinter is not nil, the events are printed with it at the
+// same time. It will block until the channel is closed and both
+// printers are done.
+func printEvents(ch <-chan event.Event, p, filePrinter printer.Printer) {
+	if filePrinter == nil {
+		p.Print(ch, false)
+		return
+	}
+	out := make(chan event.Event)
+	fileOut := make(chan event.Event)
+	go func() {
+		defer close(out)
+		defer close(fileOut)
+		for e := range ch {
+			out <- e
+			fileOut <- e
+		}
+	}()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		filePrinter.Print(fileOut, false)
+	}()
+	p.Print(out, false)
+	wg.Wait()
+}
+
+// hasClusterScoped returns true if any of the infos is a cluster-scoped
+// resource. The manifest readers don't set the mapping of the infos,
+// so the scope is looked up with the RESTMapper. Resources of unknown
+// types are ignored.
+func hasClusterScoped(infos []*resource.Info, mapper meta.RESTMapper) bool {
+	for _, info := range infos {
+		mapping := info.Mapping
+		if mapping == nil {
+			gvk := info.Object.GetObjectKind().GroupVersionKind()
+			var err error
+			mapping, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				continue
+			}
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			return true
+		}
+	}
+	return false
+}
+
+// applySetNamespace returns the namespace for the ApplySet inventory
+// object, which is the namespace of the namespaced resources if there
+// are any, and the passed default namespace otherwise.
+func applySetNamespace(infos []*resource.Info, defaultNamespace string) string {
+	for _, info := range infos {
+		if info.Namespaced() && info.Namespace != "" {
+			return info.Namespace
+		}
+	}
+	return defaultNamespace
+}
+
+// convertTimeoutBehavior converts the timeoutBehavior described as a
+// string to the TimeoutBehavior type that is passed into the Applier.
+func convertTimeoutBehavior(timeoutBehavior string) (apply.TimeoutBehavior, error) {
+	switch timeoutBehavior {
+	case "fail":
+		return apply.TimeoutFail, nil
+	case "continue":
+		return apply.TimeoutContinue, nil
+	default:
+		return apply.TimeoutFail, fmt.Errorf(
+			"timeout behavior must be one of fail, continue")
+	}
+}
+
+// convertPropagationPolicyMap converts the per-kind propagation policies
+// in the format <Kind>.<group>=<Policy> to the map of GroupKinds to
+// policies that is passed into the Applier. The group is omitted for
+// the kinds in the core group. The kinds are verified to be known by
+// the RESTMapper.
+func (r *ApplyRunner) convertPropagationPolicyMap() (map[schema.GroupKind]metav1.DeletionPropagation, error) {
+	if len(r.propagationPolicyMap) == 0 {
+		return nil, nil
+	}
+	mapper, err := r.factory.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	policies := make(map[schema.GroupKind]metav1.DeletionPropagation)
+	for _, p := range r.propagationPolicyMap {
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid propagation policy %q, must be <Kind>.<group>=<Policy>", p)
+		}
+		policy, err := convertPropagationPolicy(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		gk := schema.ParseGroupKind(parts[0])
+		if _, err := mapper.RESTMapping(gk); err != nil {
+			return nil, fmt.Errorf("unknown kind %q in propagation policy %q: %v", parts[0], p, err)
+		}
+		policies[gk] = policy
+	}
+	return policies, nil
+}
+
+// convertResourceStrategy converts the resourceStrategy described as a
+// string to the ResourceStrategy type that is passed into the Applier.
+func convertResourceStrategy(resourceStrategy string) (common.ResourceStrategy, error) {
+	switch resourceStrategy {
+	case "merge":
+		return common.StrategyMerge, nil
+	case "replace":
+		return common.StrategyReplace, nil
+	default:
+		return common.StrategyMerge, fmt.Errorf(
+			"resource strategy must be one of merge, replace")
+	}
 }
 
 // convertPropagationPolicy converts a propagationPolicy described as a
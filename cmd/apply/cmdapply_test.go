@@ -0,0 +1,218 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"sigs.k8s.io/cli-utils/cmd/printers"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/manifestreader"
+)
+
+func TestTransformEvents(t *testing.T) {
+	ch := make(chan event.Event, 3)
+	ch <- event.Event{Type: event.InitType}
+	ch <- event.Event{Type: event.StatusType}
+	ch <- event.Event{
+		Type: event.ErrorType,
+		ErrorEvent: event.ErrorEvent{
+			Err: fmt.Errorf("failed"),
+		},
+	}
+	close(ch)
+
+	// Drop status events and add the cluster name to errors.
+	transformed := transformEvents(ch, func(e event.Event) (event.Event, bool) {
+		switch e.Type {
+		case event.StatusType:
+			return e, false
+		case event.ErrorType:
+			e.ErrorEvent.Err = fmt.Errorf("cluster %s: %v", "test-cluster", e.ErrorEvent.Err)
+		}
+		return e, true
+	})
+
+	var events []event.Event
+	for e := range transformed {
+		events = append(events, e)
+	}
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, event.InitType, events[0].Type)
+		assert.Equal(t, event.ErrorType, events[1].Type)
+		assert.EqualError(t, events[1].ErrorEvent.Err, "cluster test-cluster: failed")
+	}
+}
+
+func TestPrintEventsWithOutputFile(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+			},
+		},
+	}
+	events := []event.Event{
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type:      event.ApplyEventResourceUpdate,
+				Operation: event.Created,
+				Object:    deployment,
+			},
+		},
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type: event.ApplyEventCompleted,
+			},
+		},
+	}
+
+	f, err := ioutil.TempFile("", "output-file")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.Remove(f.Name())
+
+	ioStreams, _, out, _ := genericclioptions.NewTestIOStreams()
+	terminalPrinter := printers.GetPrinter(printers.CSVPrinter, ioStreams)
+	filePrinter := printers.GetPrinter(printers.JSONPrinter, genericclioptions.IOStreams{Out: f, ErrOut: f})
+
+	printEvents(event.ReplayChannel(events, 0), terminalPrinter, filePrinter)
+	if !assert.NoError(t, f.Close()) {
+		return
+	}
+
+	assert.Contains(t, out.String(), "created,apps,Deployment,default,foo")
+	content, err := ioutil.ReadFile(f.Name())
+	if !assert.NoError(t, err) {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if assert.Len(t, lines, 1) {
+		assert.Contains(t, lines[0], `"operation":"created"`)
+		assert.Contains(t, lines[0], `"name":"foo"`)
+	}
+}
+
+func TestConvertPropagationPolicyMap(t *testing.T) {
+	testCases := map[string]struct {
+		policies []string
+
+		expected    map[schema.GroupKind]metav1.DeletionPropagation
+		expectedErr bool
+	}{
+		"core and grouped kinds": {
+			policies: []string{"Namespace=Foreground", "Deployment.apps=Orphan"},
+			expected: map[schema.GroupKind]metav1.DeletionPropagation{
+				{Kind: "Namespace"}:                 metav1.DeletePropagationForeground,
+				{Group: "apps", Kind: "Deployment"}: metav1.DeletePropagationOrphan,
+			},
+		},
+		"kind without its group": {
+			policies:    []string{"Deployment=Orphan"},
+			expectedErr: true,
+		},
+		"resource name instead of kind": {
+			policies:    []string{"namespaces=Foreground"},
+			expectedErr: true,
+		},
+		"invalid policy": {
+			policies:    []string{"Namespace=Later"},
+			expectedErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("default")
+			defer tf.Cleanup()
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			r := GetApplyRunner(tf, ioStreams)
+			r.propagationPolicyMap = tc.policies
+
+			policies, err := r.convertPropagationPolicyMap()
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, policies)
+		})
+	}
+}
+
+func TestHasClusterScoped(t *testing.T) {
+	testCases := map[string]struct {
+		manifests string
+		expected  bool
+	}{
+		"namespaced resources only": {
+			manifests: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+`,
+			expected: false,
+		},
+		"cluster-scoped resource": {
+			manifests: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: bar
+`,
+			expected: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("default")
+			defer tf.Cleanup()
+
+			// The infos are read like the ApplyRunner reads them, so
+			// they don't have their mapping set.
+			reader := &manifestreader.StreamManifestReader{
+				ReaderName: "stdin",
+				Reader:     strings.NewReader(tc.manifests),
+				ReaderOptions: manifestreader.ReaderOptions{
+					Factory:   tf,
+					Namespace: "default",
+				},
+			}
+			infos, err := reader.Read()
+			if !assert.NoError(t, err) {
+				return
+			}
+			mapper, err := tf.ToRESTMapper()
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expected, hasClusterScoped(infos, mapper))
+		})
+	}
+}
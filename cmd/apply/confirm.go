@@ -0,0 +1,119 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/common"
+)
+
+// AbortedExitCode is the exit code when the user declines to prune
+// the resources, the same as when the command is interrupted.
+const AbortedExitCode = 130
+
+// ExitError is returned by the apply command if it should exit with
+// a specific exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// IsTerminal returns true if the reader is a terminal.
+func IsTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// confirmPrune asks the user to confirm pruning the resources which
+// would be pruned by the apply, as found with a dry-run. It returns an
+// ExitError with the AbortedExitCode if the user declines. The prompt
+// is skipped unless --confirm is set and stdin is a terminal, if
+// --non-interactive is set, or if nothing would be pruned.
+func (r *ApplyRunner) confirmPrune(ctx context.Context, infos []*resource.Info, options apply.Options) error {
+	if !r.confirm || r.nonInteractive || options.NoPrune || !r.stdinIsTerminal(r.ioStreams.In) {
+		return nil
+	}
+
+	// The dry-run uses a clone of the Applier, so the state from the
+	// dry-run doesn't affect the apply.
+	applier := r.Applier
+	if a, ok := applier.(*apply.Applier); ok {
+		c, err := a.Clone()
+		if err != nil {
+			return err
+		}
+		applier = c
+	}
+	options.DryRun = true
+	var pruned []*unstructured.Unstructured
+	var err error
+	for e := range applier.Run(ctx, infos, options) {
+		switch e.Type {
+		case event.ErrorType:
+			if err == nil {
+				err = e.ErrorEvent.Err
+			}
+		case event.PruneType:
+			if e.PruneEvent.Type != event.PruneEventResourceUpdate || e.PruneEvent.Operation != event.Pruned {
+				continue
+			}
+			// The previous inventory objects are always pruned.
+			if u, ok := e.PruneEvent.Object.(*unstructured.Unstructured); ok && !common.IsInventoryObject(u) {
+				pruned = append(pruned, u)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(pruned) == 0 {
+		return nil
+	}
+
+	// The prompt goes to stderr, so it isn't mixed with the output of
+	// the command, which might be parsed.
+	fmt.Fprintln(r.ioStreams.ErrOut, "The following resources will be pruned:")
+	for _, u := range pruned {
+		if u.GetNamespace() != "" {
+			fmt.Fprintf(r.ioStreams.ErrOut, "  %s %s/%s\n", u.GetKind(), u.GetNamespace(), u.GetName())
+		} else {
+			fmt.Fprintf(r.ioStreams.ErrOut, "  %s %s\n", u.GetKind(), u.GetName())
+		}
+	}
+	fmt.Fprintf(r.ioStreams.ErrOut, "Prune %d resources? (y/N) ", len(pruned))
+
+	answer, err := bufio.NewReader(r.ioStreams.In).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return ExitError{
+		Code: AbortedExitCode,
+		Err:  fmt.Errorf("apply aborted, %d resources were not pruned", len(pruned)),
+	}
+}
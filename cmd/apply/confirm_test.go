@@ -0,0 +1,197 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/util/flowcontrol"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+)
+
+// fakeApplier returns the events for every call to Run, and records
+// the options it was called with.
+type fakeApplier struct {
+	events  []event.Event
+	options []apply.Options
+}
+
+func (f *fakeApplier) SetFlags(*cobra.Command) error {
+	return nil
+}
+
+func (f *fakeApplier) Initialize(*cobra.Command) error {
+	return nil
+}
+
+func (f *fakeApplier) Run(_ context.Context, _ []*resource.Info, options apply.Options) <-chan event.Event {
+	f.options = append(f.options, options)
+	return event.ReplayChannel(f.events, 0)
+}
+
+func (f *fakeApplier) SetInventoryClientFactoryFunc(func(cmdutil.Factory) (inventory.InventoryClient, error)) {
+}
+
+func (f *fakeApplier) SetPruneRateLimiter(flowcontrol.RateLimiter) {}
+
+func (f *fakeApplier) Reset() error {
+	return nil
+}
+
+func (f *fakeApplier) BuildPlan(context.Context, []*resource.Info, apply.Options) (*apply.Plan, error) {
+	return &apply.Plan{}, nil
+}
+
+func (f *fakeApplier) CheckPlan(*apply.Plan) error {
+	return nil
+}
+
+func TestConfirmPrune(t *testing.T) {
+	pruneEvent := func(kind, name string) event.Event {
+		return event.Event{
+			Type: event.PruneType,
+			PruneEvent: event.PruneEvent{
+				Type:      event.PruneEventResourceUpdate,
+				Operation: event.Pruned,
+				Object: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       kind,
+						"metadata": map[string]interface{}{
+							"name":      name,
+							"namespace": "default",
+						},
+					},
+				},
+			},
+		}
+	}
+	pruneEvents := []event.Event{
+		pruneEvent("ConfigMap", "cm-a"),
+		pruneEvent("ConfigMap", "cm-b"),
+		pruneEvent("Service", "svc"),
+	}
+
+	testCases := map[string]struct {
+		stdin          string
+		confirm        bool
+		nonInteractive bool
+		forceDelete    bool
+		terminal       bool
+		events         []event.Event
+
+		expectedAborted bool
+		expectedPrompt  bool
+	}{
+		"prune proceeds if confirmed": {
+			stdin:          "y\n",
+			confirm:        true,
+			terminal:       true,
+			events:         pruneEvents,
+			expectedPrompt: true,
+		},
+		"prune is aborted if declined": {
+			stdin:           "n\n",
+			confirm:         true,
+			terminal:        true,
+			events:          pruneEvents,
+			expectedAborted: true,
+			expectedPrompt:  true,
+		},
+		"empty answer aborts": {
+			stdin:           "\n",
+			confirm:         true,
+			terminal:        true,
+			events:          pruneEvents,
+			expectedAborted: true,
+			expectedPrompt:  true,
+		},
+		"the prompt lists the resources pruned with --force-delete": {
+			stdin:          "y\n",
+			confirm:        true,
+			forceDelete:    true,
+			terminal:       true,
+			events:         pruneEvents,
+			expectedPrompt: true,
+		},
+		"no prompt without --confirm": {
+			stdin:    "n\n",
+			terminal: true,
+			events:   pruneEvents,
+		},
+		"no prompt if stdin is not a terminal": {
+			stdin:   "n\n",
+			confirm: true,
+			events:  pruneEvents,
+		},
+		"no prompt with --non-interactive": {
+			stdin:          "n\n",
+			confirm:        true,
+			nonInteractive: true,
+			terminal:       true,
+			events:         pruneEvents,
+		},
+		"no prompt if nothing is pruned": {
+			stdin:    "n\n",
+			confirm:  true,
+			terminal: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, out, errOut := genericclioptions.NewTestIOStreams()
+			ioStreams.In = bufio.NewReader(strings.NewReader(tc.stdin))
+			applier := &fakeApplier{events: tc.events}
+			r := &ApplyRunner{
+				Applier:        applier,
+				ioStreams:      ioStreams,
+				confirm:        tc.confirm,
+				nonInteractive: tc.nonInteractive,
+				stdinIsTerminal: func(io.Reader) bool {
+					return tc.terminal
+				},
+			}
+
+			err := r.confirmPrune(context.Background(), nil, apply.Options{
+				PruneForceDelete: tc.forceDelete,
+			})
+			if tc.expectedAborted {
+				exitErr, ok := err.(ExitError)
+				if assert.True(t, ok, "expected an ExitError, got %v", err) {
+					assert.Equal(t, AbortedExitCode, exitErr.Code)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// The prompt is written to stderr.
+			assert.Empty(t, out.String())
+			if tc.expectedPrompt {
+				assert.Contains(t, errOut.String(), "ConfigMap default/cm-a")
+				assert.Contains(t, errOut.String(), "Prune 3 resources? (y/N)")
+				if assert.Len(t, applier.options, 1) {
+					assert.True(t, applier.options[0].DryRun)
+					// The dry-run finds the resources which are only
+					// pruned since their annotations are overridden.
+					assert.Equal(t, tc.forceDelete, applier.options[0].PruneForceDelete)
+				}
+			} else {
+				assert.Empty(t, errOut.String())
+			}
+		})
+	}
+}
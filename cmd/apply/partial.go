@@ -0,0 +1,65 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+// PartialApplyExitCode is the exit code when some of the resources
+// failed to apply with --partial-apply.
+const PartialApplyExitCode = 2
+
+// partialApplyResult records the outcome of an apply with
+// --partial-apply from its events.
+type partialApplyResult struct {
+	// failed is the number of ApplyFailed events.
+	failed int
+	// summary is the last SummaryEvent, or nil if there was none. The
+	// SummaryEvent is sent at the end of the apply, so it is missing
+	// if the apply stopped early, for example because waiting for
+	// the resources timed out.
+	summary *event.SummaryEvent
+}
+
+// watchPartialApply returns a channel with the events from the passed
+// channel, and counts the failed resources and records the summary in
+// result. The result must only be read once the returned channel has
+// been closed, which happens when the passed channel is closed.
+func watchPartialApply(ch <-chan event.Event, result *partialApplyResult) <-chan event.Event {
+	out := make(chan event.Event)
+	go func() {
+		defer close(out)
+		for e := range ch {
+			switch e.Type {
+			case event.ApplyFailedType:
+				result.failed++
+			case event.SummaryType:
+				summary := e.SummaryEvent
+				result.summary = &summary
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+// partialApplyError returns an ExitError with the PartialApplyExitCode
+// if any resources failed to apply according to the ApplyFailed events.
+func partialApplyError(result partialApplyResult) error {
+	if result.failed == 0 {
+		return nil
+	}
+	err := fmt.Errorf("%d resources failed to apply", result.failed)
+	if result.summary != nil {
+		err = fmt.Errorf("%d of %d resources failed to apply", result.failed,
+			len(result.summary.Failed)+len(result.summary.Succeeded))
+	}
+	return ExitError{
+		Code: PartialApplyExitCode,
+		Err:  err,
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestPartialApply(t *testing.T) {
+	id := func(name string) object.ObjMetadata {
+		return object.ObjMetadata{
+			GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+			Namespace: "default",
+			Name:      name,
+		}
+	}
+	cm := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "invalid",
+				"namespace": "default",
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		events []event.Event
+
+		expectedExitCode int
+		expectedMessage  string
+	}{
+		"all resources applied": {
+			events: []event.Event{
+				{Type: event.ApplyType},
+				{Type: event.ApplyType},
+				{
+					Type: event.SummaryType,
+					SummaryEvent: event.SummaryEvent{
+						Succeeded: []object.ObjMetadata{id("a"), id("b")},
+					},
+				},
+			},
+		},
+		"some resources failed": {
+			events: []event.Event{
+				{Type: event.ApplyType},
+				{
+					Type: event.ApplyFailedType,
+					ApplyFailedEvent: event.ApplyFailedEvent{
+						Object: cm,
+					},
+				},
+				{
+					Type: event.SummaryType,
+					SummaryEvent: event.SummaryEvent{
+						Succeeded: []object.ObjMetadata{id("a")},
+						Failed:    []object.ObjMetadata{id("invalid")},
+					},
+				},
+			},
+			expectedExitCode: PartialApplyExitCode,
+			expectedMessage:  "1 of 2 resources failed",
+		},
+		"some resources failed and the apply stopped before the summary": {
+			events: []event.Event{
+				{Type: event.ApplyType},
+				{
+					Type: event.ApplyFailedType,
+					ApplyFailedEvent: event.ApplyFailedEvent{
+						Object: cm,
+					},
+				},
+				{Type: event.ErrorType},
+			},
+			expectedExitCode: PartialApplyExitCode,
+			expectedMessage:  "1 resources failed",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			var result partialApplyResult
+			var count int
+			for range watchPartialApply(event.ReplayChannel(tc.events, 0), &result) {
+				count++
+			}
+			// All events are passed on.
+			assert.Equal(t, len(tc.events), count)
+
+			err := partialApplyError(result)
+			if tc.expectedExitCode == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			exitErr, ok := err.(ExitError)
+			if assert.True(t, ok, "expected an ExitError, got %v", err) {
+				assert.Equal(t, tc.expectedExitCode, exitErr.Code)
+				assert.Contains(t, exitErr.Error(), tc.expectedMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+// watchDebounce is how long to wait after the last change in the
+// manifest directory before applying, so a burst of changes, like
+// when an editor saves several files, results in a single apply.
+const watchDebounce = 500 * time.Millisecond
+
+// runWatchAndApply applies the manifests in the directory, and then
+// applies them again every time a manifest file in the directory
+// changes, until interrupted. An interrupt also stops a running apply.
+func (r *ApplyRunner) runWatchAndApply(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("--watch-and-apply requires a directory")
+	}
+	if r.planFile != "" || r.fromPlan != "" {
+		return fmt.Errorf("--watch-and-apply can not be used together with --plan-file or --from-plan")
+	}
+	fmt.Fprintln(r.ioStreams.ErrOut, "WARNING: --watch-and-apply is a convenience mode for local "+
+		"development and is not intended for production use.")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	watchDirs := func(root string) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return watcher.Add(path)
+			}
+			return nil
+		})
+	}
+	if err := watchDirs(args[0]); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	applyFunc := func() error {
+		// The applier is reused, so the state from the previous apply
+		// must be cleared.
+		if err := r.Applier.Reset(); err != nil {
+			return err
+		}
+		return r.runE(ctx, cmd, args)
+	}
+	if err := applyFunc(); err != nil {
+		fmt.Fprintf(r.ioStreams.ErrOut, "error: %v\n", err)
+	}
+	return watchLoop(watcher.Events, watcher.Errors, ctx.Done(), watchDebounce, applyFunc, watchDirs,
+		r.ioStreams.ErrOut)
+}
+
+// watchLoop calls applyFunc once there have been no changes to
+// manifest files in the events for the debounce interval. Errors from
+// applyFunc are written to errOut, and the loop keeps watching, since
+// they are often caused by a manifest that is only partially saved.
+// New directories are passed to watchDirs so changes in them are
+// watched as well. It returns when done is closed, or if the watcher
+// fails.
+func watchLoop(events <-chan fsnotify.Event, errs <-chan error, done <-chan struct{},
+	debounce time.Duration, applyFunc func() error, watchDirs func(string) error, errOut io.Writer) error {
+	var timer <-chan time.Time
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if e.Op&fsnotify.Create != 0 && isDir(e.Name) {
+				if err := watchDirs(e.Name); err != nil {
+					return err
+				}
+				// The directory might have been moved here together
+				// with its manifests.
+				timer = time.After(debounce)
+				continue
+			}
+			if isManifestFile(e.Name) {
+				timer = time.After(debounce)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-timer:
+			timer = nil
+			if err := applyFunc(); err != nil {
+				fmt.Fprintf(errOut, "error: %v\n", err)
+			}
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// watchErrors passes on the events from the channel, and sets err to
+// the error from the first error event. The err is set before the
+// returned channel is closed.
+func watchErrors(ch <-chan event.Event, err *error) <-chan event.Event {
+	out := make(chan event.Event)
+	go func() {
+		defer close(out)
+		for e := range ch {
+			if e.Type == event.ErrorType && *err == nil {
+				*err = e.ErrorEvent.Err
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+// isDir returns true if the path is an existing directory.
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// isManifestFile returns true if the file is a YAML manifest.
+func isManifestFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -0,0 +1,159 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+func TestWatchLoop(t *testing.T) {
+	events := make(chan fsnotify.Event)
+	errs := make(chan error)
+	stop := make(chan struct{})
+	applied := make(chan struct{}, 10)
+
+	done := make(chan error)
+	go func() {
+		done <- watchLoop(events, errs, stop, 50*time.Millisecond, func() error {
+			applied <- struct{}{}
+			return nil
+		}, func(string) error {
+			return nil
+		}, ioutil.Discard)
+	}()
+
+	// A burst of changes results in a single apply, and changes to
+	// files that are not manifests are ignored.
+	events <- fsnotify.Event{Name: "manifests/deployment.yaml", Op: fsnotify.Write}
+	events <- fsnotify.Event{Name: "manifests/service.yml", Op: fsnotify.Create}
+	events <- fsnotify.Event{Name: "manifests/.deployment.yaml.swp", Op: fsnotify.Write}
+	events <- fsnotify.Event{Name: "manifests/deployment.yaml", Op: fsnotify.Write}
+	select {
+	case <-applied:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected an apply")
+	}
+	events <- fsnotify.Event{Name: "manifests/README.md", Op: fsnotify.Write}
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 0, len(applied))
+
+	close(stop)
+	assert.NoError(t, <-done)
+}
+
+func TestWatchLoopApplyError(t *testing.T) {
+	events := make(chan fsnotify.Event, 2)
+	events <- fsnotify.Event{Name: "deployment.yaml", Op: fsnotify.Write}
+	stop := make(chan struct{})
+	var errOut bytes.Buffer
+
+	applies := 0
+	err := watchLoop(events, make(chan error), stop, time.Millisecond, func() error {
+		applies++
+		if applies == 1 {
+			// The loop keeps watching after a failed apply.
+			events <- fsnotify.Event{Name: "deployment.yaml", Op: fsnotify.Write}
+			return fmt.Errorf("apply failed")
+		}
+		close(stop)
+		return nil
+	}, func(string) error {
+		return nil
+	}, &errOut)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, applies)
+	assert.Contains(t, errOut.String(), "apply failed")
+}
+
+func TestWatchLoopApplyErrorEvent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "inventory.yaml"), []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: inventory
+  namespace: default
+  labels:
+    cli-utils.sigs.k8s.io/inventory-id: test
+`), 0600)
+	assert.NoError(t, err)
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	r := GetApplyRunner(tf, ioStreams)
+	r.factory = tf
+	r.watchAndApply = true
+	// Every apply fails with an error event, which must not make the
+	// printer exit the process.
+	r.Applier = &fakeApplier{
+		events: []event.Event{
+			{
+				Type: event.ErrorType,
+				ErrorEvent: event.ErrorEvent{
+					Err: fmt.Errorf("apply failed"),
+				},
+			},
+		},
+	}
+
+	events := make(chan fsnotify.Event, 2)
+	events <- fsnotify.Event{Name: "inventory.yaml", Op: fsnotify.Write}
+	stop := make(chan struct{})
+	var errOut bytes.Buffer
+	applies := 0
+	err = watchLoop(events, make(chan error), stop, time.Millisecond, func() error {
+		applies++
+		if applies == 1 {
+			events <- fsnotify.Event{Name: "inventory.yaml", Op: fsnotify.Write}
+		} else {
+			close(stop)
+		}
+		return r.runE(context.Background(), r.Command, []string{dir})
+	}, func(string) error {
+		return nil
+	}, &errOut)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, applies)
+	assert.Equal(t, 2, strings.Count(errOut.String(), "error: apply failed"))
+}
+
+func TestWatchLoopNewDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sub := filepath.Join(dir, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0700))
+
+	events := make(chan fsnotify.Event, 1)
+	events <- fsnotify.Event{Name: sub, Op: fsnotify.Create}
+	stop := make(chan struct{})
+
+	var watched []string
+	err = watchLoop(events, make(chan error), stop, time.Millisecond, func() error {
+		close(stop)
+		return nil
+	}, func(path string) error {
+		watched = append(watched, path)
+		return nil
+	}, ioutil.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{sub}, watched)
+}
@@ -9,6 +9,7 @@ import (
 	"k8s.io/kubectl/pkg/cmd/util"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/i18n"
+	cmdapply "sigs.k8s.io/cli-utils/cmd/apply"
 	"sigs.k8s.io/cli-utils/pkg/apply"
 )
 
@@ -23,9 +24,14 @@ func NewCmdDestroy(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra
 		Use:                   "destroy (DIRECTORY | STDIN)",
 		DisableFlagsInUseLine: true,
 		Short:                 i18n.T("Destroy all the resources related to configuration"),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			paths := args
-			cmdutil.CheckErr(destroyer.Initialize(cmd, paths))
+			if err := destroyer.Initialize(cmd, paths); err != nil {
+				return err
+			}
+			if err := confirmForceDelete(destroyer, ioStreams, cmdapply.IsTerminal); err != nil {
+				return err
+			}
 
 			// Run the destroyer. It will return a channel where we can receive updates
 			// to keep track of progress and any issues.
@@ -34,6 +40,7 @@ func NewCmdDestroy(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra
 			// The printer will print updates from the channel. It will block
 			// until the channel is closed.
 			printer.Print(ch, false)
+			return nil
 		},
 	}
 
@@ -0,0 +1,46 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package destroy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdapply "sigs.k8s.io/cli-utils/cmd/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+)
+
+// confirmForceDelete asks the user to confirm destroying with
+// --force-delete, the same way the apply command asks to confirm
+// pruning. It returns an ExitError with the AbortedExitCode if the
+// user declines. The prompt is skipped unless --force-delete and
+// --confirm are set and stdin is a terminal, or if --non-interactive
+// is set.
+func confirmForceDelete(d *apply.Destroyer, ioStreams genericclioptions.IOStreams,
+	stdinIsTerminal func(io.Reader) bool) error {
+	if !d.ForceDelete || !d.Confirm || d.NonInteractive || !stdinIsTerminal(ioStreams.In) {
+		return nil
+	}
+
+	// The prompt goes to stderr, so it isn't mixed with the output of
+	// the command, which might be parsed.
+	fmt.Fprint(ioStreams.ErrOut, "Delete all resources in the inventory regardless of "+
+		"their lifecycle annotations? (y/N) ")
+
+	answer, err := bufio.NewReader(ioStreams.In).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return cmdapply.ExitError{
+		Code: cmdapply.AbortedExitCode,
+		Err:  fmt.Errorf("destroy aborted, no resources were deleted"),
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package destroy
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdapply "sigs.k8s.io/cli-utils/cmd/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+)
+
+func TestConfirmForceDelete(t *testing.T) {
+	testCases := map[string]struct {
+		stdin          string
+		forceDelete    bool
+		confirm        bool
+		nonInteractive bool
+		terminal       bool
+
+		expectedAborted bool
+		expectedPrompt  bool
+	}{
+		"destroy proceeds if confirmed": {
+			stdin:          "y\n",
+			forceDelete:    true,
+			confirm:        true,
+			terminal:       true,
+			expectedPrompt: true,
+		},
+		"destroy is aborted if declined": {
+			stdin:           "n\n",
+			forceDelete:     true,
+			confirm:         true,
+			terminal:        true,
+			expectedAborted: true,
+			expectedPrompt:  true,
+		},
+		"empty answer aborts": {
+			stdin:           "\n",
+			forceDelete:     true,
+			confirm:         true,
+			terminal:        true,
+			expectedAborted: true,
+			expectedPrompt:  true,
+		},
+		"no prompt without --force-delete": {
+			stdin:    "n\n",
+			confirm:  true,
+			terminal: true,
+		},
+		"no prompt without --confirm": {
+			stdin:          "n\n",
+			forceDelete:    true,
+			nonInteractive: false,
+			terminal:       true,
+		},
+		"no prompt if stdin is not a terminal": {
+			stdin:       "n\n",
+			forceDelete: true,
+			confirm:     true,
+		},
+		"no prompt with --non-interactive": {
+			stdin:          "n\n",
+			forceDelete:    true,
+			confirm:        true,
+			nonInteractive: true,
+			terminal:       true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, out, errOut := genericclioptions.NewTestIOStreams()
+			ioStreams.In = bufio.NewReader(strings.NewReader(tc.stdin))
+			d := &apply.Destroyer{
+				ForceDelete:    tc.forceDelete,
+				Confirm:        tc.confirm,
+				NonInteractive: tc.nonInteractive,
+			}
+
+			err := confirmForceDelete(d, ioStreams, func(io.Reader) bool {
+				return tc.terminal
+			})
+			if tc.expectedAborted {
+				exitErr, ok := err.(cmdapply.ExitError)
+				if assert.True(t, ok, "expected an ExitError, got %v", err) {
+					assert.Equal(t, cmdapply.AbortedExitCode, exitErr.Code)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// The prompt is written to stderr.
+			assert.Empty(t, out.String())
+			if tc.expectedPrompt {
+				assert.Contains(t, errOut.String(), "(y/N)")
+			} else {
+				assert.Empty(t, errOut.String())
+			}
+		})
+	}
+}
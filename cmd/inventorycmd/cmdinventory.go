@@ -0,0 +1,88 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventorycmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/manifestreader"
+)
+
+// NewCmdInventory creates the `inventory` command, which groups the
+// subcommands operating on the inventory objects in the cluster.
+func NewCmdInventory(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: i18n.T("Manage the inventory objects of a configuration"),
+	}
+	cmd.AddCommand(NewCmdMigrateFormat(f, ioStreams))
+	return cmd
+}
+
+// NewCmdMigrateFormat creates the `inventory migrate-format` command.
+// It converts the inventory objects in the cluster for the inventory
+// object template in a directory to another inventory format.
+func NewCmdMigrateFormat(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var to string
+
+	cmd := &cobra.Command{
+		Use:                   "migrate-format DIRECTORY --to=v2",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Convert the inventory objects of a configuration to another format"),
+		Args:                  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, err := inventory.ParseInventoryFormat(to)
+			cmdutil.CheckErr(err)
+
+			// Only the inventory object template is used from the
+			// manifests in the directory.
+			infos, err := (&manifestreader.PathManifestReader{
+				Path: args[0],
+				ReaderOptions: manifestreader.ReaderOptions{
+					Factory:   f,
+					Namespace: metav1.NamespaceDefault,
+					Stdin:     cmd.InOrStdin(),
+				},
+			}).Read()
+			cmdutil.CheckErr(err)
+			inv, found := inventory.FindInventoryObj(infos)
+			if !found {
+				cmdutil.CheckErr(inventory.NoInventoryObjError{})
+			}
+
+			invClient, err := inventory.NewInventoryClient(f)
+			cmdutil.CheckErr(err)
+			invs, err := invClient.GetPreviousInventoryObjects(inv)
+			cmdutil.CheckErr(err)
+			if len(invs) == 0 {
+				cmdutil.CheckErr(fmt.Errorf("no inventory objects found in the cluster for inventory %s", inv.Name))
+			}
+
+			dynamicClient, err := f.DynamicClient()
+			cmdutil.CheckErr(err)
+			mapper, err := f.ToRESTMapper()
+			cmdutil.CheckErr(err)
+			for _, i := range invs {
+				if u, ok := i.Object.(*unstructured.Unstructured); ok && inventory.GetInventoryFormat(u) == format {
+					fmt.Fprintf(ioStreams.Out, "inventory %s/%s already in format %s\n", i.Namespace, i.Name, format)
+					continue
+				}
+				cmdutil.CheckErr(inventory.MigrateFormat(dynamicClient, mapper, i, format))
+				fmt.Fprintf(ioStreams.Out, "inventory %s/%s migrated to format %s\n", i.Namespace, i.Name, format)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", string(inventory.InventoryFormatV2),
+		"The inventory format to convert to, either v1 or v2.")
+
+	return cmd
+}
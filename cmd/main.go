@@ -4,6 +4,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"os"
 	"strings"
@@ -16,7 +17,9 @@ import (
 	"sigs.k8s.io/cli-utils/cmd/destroy"
 	"sigs.k8s.io/cli-utils/cmd/diff"
 	"sigs.k8s.io/cli-utils/cmd/initcmd"
+	"sigs.k8s.io/cli-utils/cmd/inventorycmd"
 	"sigs.k8s.io/cli-utils/cmd/preview"
+	"sigs.k8s.io/cli-utils/cmd/rollback"
 	"sigs.k8s.io/cli-utils/cmd/status"
 	"sigs.k8s.io/cli-utils/pkg/util/factory"
 
@@ -49,7 +52,7 @@ func main() {
 		ErrOut: os.Stderr,
 	}
 
-	names := []string{"init", "apply", "preview", "diff", "destroy", "status"}
+	names := []string{"init", "apply", "preview", "diff", "destroy", "rollback", "status"}
 	initCmd := initcmd.NewCmdInit(ioStreams)
 	updateHelp(names, initCmd)
 	applyCmd := apply.ApplyCommand(f, ioStreams)
@@ -60,15 +63,22 @@ func main() {
 	updateHelp(names, diffCmd)
 	destroyCmd := destroy.NewCmdDestroy(f, ioStreams)
 	updateHelp(names, destroyCmd)
+	rollbackCmd := rollback.NewCmdRollback(f, ioStreams)
+	updateHelp(names, rollbackCmd)
 	statusCmd := status.StatusCommand()
 	updateHelp(names, statusCmd)
+	inventoryCmd := inventorycmd.NewCmdInventory(f, ioStreams)
 
-	cmd.AddCommand(initCmd, applyCmd, diffCmd, destroyCmd, previewCmd, statusCmd)
+	cmd.AddCommand(initCmd, applyCmd, diffCmd, destroyCmd, previewCmd, rollbackCmd, statusCmd, inventoryCmd)
 
 	logs.InitLogs()
 	defer logs.FlushLogs()
 
 	if err := cmd.Execute(); err != nil {
+		var exitErr apply.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
@@ -5,12 +5,16 @@ package preview
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cli-utils/cmd/printers"
 	"sigs.k8s.io/cli-utils/pkg/apply"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/common"
@@ -18,7 +22,9 @@ import (
 )
 
 var (
-	noPrune = false
+	noPrune  = false
+	showDiff = false
+	output   = printers.DefaultPrinter()
 )
 
 // NewCmdPreview creates the `preview` command
@@ -26,10 +32,6 @@ func NewCmdPreview(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *co
 	applier := apply.NewApplier(f, ioStreams)
 	destroyer := apply.NewDestroyer(f, ioStreams)
 
-	printer := &apply.BasicPrinter{
-		IOStreams: ioStreams,
-	}
-
 	cmd := &cobra.Command{
 		Use:                   "preview (DIRECTORY | STDIN)",
 		DisableFlagsInUseLine: true,
@@ -53,6 +55,7 @@ func NewCmdPreview(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *co
 				readerOptions := manifestreader.ReaderOptions{
 					Factory:   f,
 					Namespace: metav1.NamespaceDefault,
+					Stdin:     cmd.InOrStdin(),
 				}
 				if len(args) == 0 {
 					reader = &manifestreader.StreamManifestReader{
@@ -75,6 +78,9 @@ func NewCmdPreview(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *co
 					EmitStatusEvents: false,
 					NoPrune:          noPrune,
 					DryRun:           true,
+					ShowDiff:         showDiff,
+					// Matches the default of the apply command.
+					ManageClusterScopedResources: pointer.BoolPtr(true),
 				})
 			} else {
 				ch = destroyer.Run()
@@ -82,11 +88,16 @@ func NewCmdPreview(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *co
 
 			// The printer will print updates from the channel. It will block
 			// until the channel is closed.
+			printer := printers.GetPrinter(output, ioStreams)
 			printer.Print(ch, true)
 		},
 	}
 
 	cmd.Flags().BoolVar(&noPrune, "no-prune", noPrune, "If true, do not prune previously applied objects.")
+	cmd.Flags().BoolVar(&showDiff, "show-diff", showDiff,
+		"If true, print the field-level diff between the live and the desired state of each resource.")
+	cmd.Flags().StringVar(&output, "output", output,
+		fmt.Sprintf("Output format, must be one of %s", strings.Join(printers.SupportedPrinters(), ",")))
 	cmdutil.CheckErr(applier.SetFlags(cmd))
 
 	// The following flags are added, but hidden because other code
@@ -0,0 +1,110 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package csv
+
+import (
+	"encoding/csv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+var header = []string{"timestamp", "operation", "group", "kind", "namespace", "name", "status", "error"}
+
+// Printer writes a line of comma-separated values for every
+// resource event, preceded by a header line.
+type Printer struct {
+	IOStreams genericclioptions.IOStreams
+
+	// now returns the timestamp for each line. Defaults to time.Now
+	// if not set.
+	now func() time.Time
+}
+
+// Print writes the events from the channel as CSV to StdOut. It will
+// block until the channel is closed.
+func (p *Printer) Print(ch <-chan event.Event, _ bool) {
+	w := csv.NewWriter(p.IOStreams.Out)
+	_ = w.Write(header)
+	w.Flush()
+	for e := range ch {
+		row, ok := p.toRow(e)
+		if !ok {
+			continue
+		}
+		_ = w.Write(row)
+		w.Flush()
+	}
+}
+
+// toRow converts the event into a row, or returns false if the event
+// doesn't concern a single resource or an error.
+func (p *Printer) toRow(e event.Event) ([]string, bool) {
+	switch e.Type {
+	case event.ErrorType:
+		return p.row("error", object.ObjMetadata{}, "", e.ErrorEvent.Err.Error()), true
+	case event.ApplyType:
+		if e.ApplyEvent.Type != event.ApplyEventResourceUpdate {
+			return nil, false
+		}
+		return p.objectRow(strings.ToLower(e.ApplyEvent.Operation.String()), e.ApplyEvent.Object), true
+	case event.PruneType:
+		if e.PruneEvent.Type != event.PruneEventResourceUpdate {
+			return nil, false
+		}
+		return p.objectRow(strings.ToLower(e.PruneEvent.Operation.String()), e.PruneEvent.Object), true
+	case event.DeleteType:
+		if e.DeleteEvent.Type != event.DeleteEventResourceUpdate {
+			return nil, false
+		}
+		return p.objectRow(strings.ToLower(e.DeleteEvent.Operation.String()), e.DeleteEvent.Object), true
+	case event.StatusType:
+		se := e.StatusEvent
+		switch se.EventType {
+		case pollevent.ResourceUpdateEvent:
+			var errMsg string
+			if se.Resource.Error != nil {
+				errMsg = se.Resource.Error.Error()
+			}
+			return p.row("status", se.Resource.Identifier, se.Resource.Status.String(), errMsg), true
+		case pollevent.ErrorEvent:
+			return p.row("status", object.ObjMetadata{}, "", se.Error.Error()), true
+		}
+	}
+	return nil, false
+}
+
+func (p *Printer) objectRow(operation string, obj runtime.Object) []string {
+	id := object.ObjMetadata{
+		GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
+	}
+	if acc, err := meta.Accessor(obj); err == nil {
+		id.Name = acc.GetName()
+		id.Namespace = acc.GetNamespace()
+	}
+	return p.row(operation, id, "", "")
+}
+
+func (p *Printer) row(operation string, id object.ObjMetadata, status, errMsg string) []string {
+	now := p.now
+	if now == nil {
+		now = time.Now
+	}
+	return []string{
+		now().UTC().Format(time.RFC3339),
+		operation,
+		id.GroupKind.Group,
+		id.GroupKind.Kind,
+		id.Namespace,
+		id.Name,
+		status,
+		errMsg,
+	}
+}
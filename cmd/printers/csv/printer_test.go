@@ -0,0 +1,92 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package csv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestPrinter(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+			},
+		},
+	}
+	events := []event.Event{
+		{Type: event.InitType},
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type:      event.ApplyEventResourceUpdate,
+				Operation: event.Created,
+				Object:    deployment,
+			},
+		},
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type: event.ApplyEventCompleted,
+			},
+		},
+		{
+			Type: event.StatusType,
+			StatusEvent: pollevent.Event{
+				EventType: pollevent.ResourceUpdateEvent,
+				Resource: &pollevent.ResourceStatus{
+					Identifier: object.ObjMetadata{
+						GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+						Namespace: "default",
+						Name:      "foo",
+					},
+					Status: status.CurrentStatus,
+				},
+			},
+		},
+		{
+			Type: event.ErrorType,
+			ErrorEvent: event.ErrorEvent{
+				Err: fmt.Errorf("failed, with a comma"),
+			},
+		},
+	}
+
+	ioStreams, _, out, _ := genericclioptions.NewTestIOStreams()
+	printer := &Printer{
+		IOStreams: ioStreams,
+		now: func() time.Time {
+			return time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+		},
+	}
+
+	printer.Print(event.ReplayChannel(events, 0), false)
+
+	rows, err := csv.NewReader(out).ReadAll()
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected := [][]string{
+		{"timestamp", "operation", "group", "kind", "namespace", "name", "status", "error"},
+		{"2020-06-01T12:00:00Z", "created", "apps", "Deployment", "default", "foo", "", ""},
+		{"2020-06-01T12:00:00Z", "status", "apps", "Deployment", "default", "foo", "Current", ""},
+		{"2020-06-01T12:00:00Z", "error", "", "", "", "", "", "failed, with a comma"},
+	}
+	assert.Equal(t, expected, rows)
+}
@@ -0,0 +1,160 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package dot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// Printer writes the resources from a dry-run as a graph in the
+// Graphviz DOT format. Resources are grouped by namespace, and the
+// edges point from a resource to the resources that depend on it:
+// a Namespace to the resources in it, and a CustomResourceDefinition
+// to its custom resources.
+type Printer struct {
+	IOStreams genericclioptions.IOStreams
+}
+
+// Print collects the applied resources from the channel and writes
+// the graph to StdOut once the channel is closed. The graph is only
+// written for previews, since it describes what would be applied.
+func (p *Printer) Print(ch <-chan event.Event, preview bool) {
+	var objs []*unstructured.Unstructured
+	seen := make(map[object.ObjMetadata]bool)
+	for e := range ch {
+		if e.Type == event.ErrorType {
+			fmt.Fprintf(p.IOStreams.ErrOut, "%s\n", e.ErrorEvent.Err.Error())
+			continue
+		}
+		if e.Type != event.ApplyType || e.ApplyEvent.Type != event.ApplyEventResourceUpdate {
+			continue
+		}
+		u, ok := toUnstructured(e.ApplyEvent.Object)
+		if !ok {
+			continue
+		}
+		id := objMetadata(u)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		objs = append(objs, u)
+	}
+	if !preview {
+		fmt.Fprintf(p.IOStreams.ErrOut, "dot output is only supported in dry-run mode\n")
+		return
+	}
+	writeGraph(p.IOStreams.Out, objs)
+}
+
+// writeGraph writes the DOT graph for the objects to w.
+func writeGraph(w io.Writer, objs []*unstructured.Unstructured) {
+	byNamespace := make(map[string][]*unstructured.Unstructured)
+	var namespaces []string
+	for _, obj := range objs {
+		ns := obj.GetNamespace()
+		if _, found := byNamespace[ns]; !found {
+			namespaces = append(namespaces, ns)
+		}
+		byNamespace[ns] = append(byNamespace[ns], obj)
+	}
+	sort.Strings(namespaces)
+
+	fmt.Fprintln(w, "digraph resources {")
+	for i, ns := range namespaces {
+		indent := "  "
+		if ns != "" {
+			fmt.Fprintf(w, "  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(w, "    label=%s;\n", quote(ns))
+			indent = "    "
+		}
+		for _, obj := range byNamespace[ns] {
+			fmt.Fprintf(w, "%s%s [label=%s];\n", indent, nodeID(obj),
+				quote(fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())))
+		}
+		if ns != "" {
+			fmt.Fprintln(w, "  }")
+		}
+	}
+	for _, edge := range dependencies(objs) {
+		fmt.Fprintf(w, "  %s -> %s;\n", nodeID(edge[0]), nodeID(edge[1]))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// dependencies returns the edges between the objects as pairs of
+// the object depended on and the dependent object.
+func dependencies(objs []*unstructured.Unstructured) [][2]*unstructured.Unstructured {
+	namespaces := make(map[string]*unstructured.Unstructured)
+	crds := make(map[schema.GroupKind]*unstructured.Unstructured)
+	for _, obj := range objs {
+		gk := obj.GroupVersionKind().GroupKind()
+		switch {
+		case gk == schema.GroupKind{Kind: "Namespace"}:
+			namespaces[obj.GetName()] = obj
+		case gk.Kind == "CustomResourceDefinition" && gk.Group == "apiextensions.k8s.io":
+			group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+			kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+			crds[schema.GroupKind{Group: group, Kind: kind}] = obj
+		}
+	}
+
+	var edges [][2]*unstructured.Unstructured
+	for _, obj := range objs {
+		if ns, found := namespaces[obj.GetNamespace()]; found {
+			edges = append(edges, [2]*unstructured.Unstructured{ns, obj})
+		}
+		if crd, found := crds[obj.GroupVersionKind().GroupKind()]; found {
+			edges = append(edges, [2]*unstructured.Unstructured{crd, obj})
+		}
+	}
+	return edges
+}
+
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, bool) {
+	if obj == nil {
+		return nil, false
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, true
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, false
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	return u, true
+}
+
+func objMetadata(obj *unstructured.Unstructured) object.ObjMetadata {
+	return object.ObjMetadata{
+		GroupKind: obj.GroupVersionKind().GroupKind(),
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+}
+
+// nodeID returns the quoted identifier of the node for the object,
+// which is unique across groups, kinds and namespaces.
+func nodeID(obj *unstructured.Unstructured) string {
+	id := objMetadata(obj)
+	return quote(strings.Join([]string{id.GroupKind.Group, id.GroupKind.Kind, id.Namespace, id.Name}, "/"))
+}
+
+// quote returns s as a DOT quoted string.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
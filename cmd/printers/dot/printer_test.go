@@ -0,0 +1,94 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package dot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+func newObj(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func TestPrinter(t *testing.T) {
+	namespace := newObj("v1", "Namespace", "", "foo")
+	crd := newObj("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "crontabs.example.com")
+	_ = unstructured.SetNestedField(crd.Object, "example.com", "spec", "group")
+	_ = unstructured.SetNestedField(crd.Object, "CronTab", "spec", "names", "kind")
+	deployment := newObj("apps/v1", "Deployment", "foo", "bar")
+	cronTab := newObj("example.com/v1", "CronTab", "foo", "baz")
+
+	testCases := map[string]struct {
+		preview        bool
+		expectedOut    string
+		expectedErrOut string
+	}{
+		"preview writes the graph": {
+			preview: true,
+			expectedOut: strings.Join([]string{
+				`digraph resources {`,
+				`  "/Namespace//foo" [label="Namespace/foo"];`,
+				`  "apiextensions.k8s.io/CustomResourceDefinition//crontabs.example.com" [label="CustomResourceDefinition/crontabs.example.com"];`,
+				`  subgraph cluster_1 {`,
+				`    label="foo";`,
+				`    "apps/Deployment/foo/bar" [label="Deployment/bar"];`,
+				`    "example.com/CronTab/foo/baz" [label="CronTab/baz"];`,
+				`  }`,
+				`  "/Namespace//foo" -> "apps/Deployment/foo/bar";`,
+				`  "/Namespace//foo" -> "example.com/CronTab/foo/baz";`,
+				`  "apiextensions.k8s.io/CustomResourceDefinition//crontabs.example.com" -> "example.com/CronTab/foo/baz";`,
+				`}`,
+				``,
+			}, "\n"),
+		},
+		"apply is an error": {
+			preview:        false,
+			expectedErrOut: "dot output is only supported in dry-run mode\n",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			errOut := &bytes.Buffer{}
+			p := &Printer{
+				IOStreams: genericclioptions.IOStreams{
+					Out:    out,
+					ErrOut: errOut,
+				},
+			}
+
+			ch := make(chan event.Event)
+			go func() {
+				defer close(ch)
+				for _, obj := range []*unstructured.Unstructured{namespace, crd, deployment, cronTab, deployment} {
+					ch <- event.Event{
+						Type: event.ApplyType,
+						ApplyEvent: event.ApplyEvent{
+							Type:      event.ApplyEventResourceUpdate,
+							Operation: event.Created,
+							Object:    obj,
+						},
+					}
+				}
+			}()
+			p.Print(ch, tc.preview)
+
+			assert.Equal(t, tc.expectedOut, out.String())
+			assert.Equal(t, tc.expectedErrOut, errOut.String())
+		})
+	}
+}
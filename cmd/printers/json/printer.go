@@ -0,0 +1,160 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package json
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// Printer writes a JSON object on a separate line (NDJSON) for every
+// resource event.
+type Printer struct {
+	IOStreams genericclioptions.IOStreams
+
+	// ShowLatency makes the printer include the duration and the
+	// latency bucket of the apply events.
+	ShowLatency bool
+
+	// now returns the timestamp for each line. Defaults to time.Now
+	// if not set.
+	now func() time.Time
+}
+
+// line is the JSON representation of a single event.
+type line struct {
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	Operation string `json:"operation,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Retried and RetryCount are only set for errors which occurred
+	// after exhausting the retries.
+	Retried    bool `json:"retried,omitempty"`
+	RetryCount int  `json:"retryCount,omitempty"`
+	// Conditions are the raw conditions of the resource in status
+	// events.
+	Conditions []status.BasicCondition `json:"conditions,omitempty"`
+	// Previous is the live state of the resource before it was applied.
+	// Only set for updates if the apply fetched the previous objects.
+	Previous map[string]interface{} `json:"previous,omitempty"`
+	// Duration and LatencyBucket are only set for apply events if
+	// ShowLatency is set.
+	Duration      string `json:"duration,omitempty"`
+	LatencyBucket string `json:"latencyBucket,omitempty"`
+}
+
+// Print writes the events from the channel as NDJSON to StdOut. It
+// will block until the channel is closed.
+func (p *Printer) Print(ch <-chan event.Event, _ bool) {
+	enc := json.NewEncoder(p.IOStreams.Out)
+	for e := range ch {
+		l, ok := p.toLine(e)
+		if !ok {
+			continue
+		}
+		_ = enc.Encode(l)
+	}
+}
+
+// toLine converts the event into a line, or returns false if the event
+// doesn't concern a single resource or an error.
+func (p *Printer) toLine(e event.Event) (line, bool) {
+	switch e.Type {
+	case event.ErrorType:
+		l := p.line("error", "", object.ObjMetadata{}, "", e.ErrorEvent.Err.Error())
+		l.Retried = e.ErrorEvent.Retried
+		l.RetryCount = e.ErrorEvent.RetryCount
+		return l, true
+	case event.ApplyType:
+		if e.ApplyEvent.Type != event.ApplyEventResourceUpdate {
+			return line{}, false
+		}
+		return p.applyLine(e.ApplyEvent), true
+	case event.ApplyFailedType:
+		l := p.objectLine("apply", "Failed", e.ApplyFailedEvent.Object)
+		l.Error = e.ApplyFailedEvent.Err.Error()
+		return l, true
+	case event.PruneType:
+		if e.PruneEvent.Type != event.PruneEventResourceUpdate {
+			return line{}, false
+		}
+		return p.objectLine("prune", e.PruneEvent.Operation.String(), e.PruneEvent.Object), true
+	case event.DeleteType:
+		if e.DeleteEvent.Type != event.DeleteEventResourceUpdate {
+			return line{}, false
+		}
+		return p.objectLine("delete", e.DeleteEvent.Operation.String(), e.DeleteEvent.Object), true
+	case event.StatusType:
+		se := e.StatusEvent
+		switch se.EventType {
+		case pollevent.ResourceUpdateEvent:
+			var errMsg string
+			if se.Resource.Error != nil {
+				errMsg = se.Resource.Error.Error()
+			}
+			l := p.line("status", "", se.Resource.Identifier, se.Resource.Status.String(), errMsg)
+			l.Conditions = se.Resource.Conditions
+			return l, true
+		case pollevent.ErrorEvent:
+			return p.line("status", "", object.ObjMetadata{}, "", se.Error.Error()), true
+		}
+	}
+	return line{}, false
+}
+
+// applyLine converts a resource update apply event into a line.
+func (p *Printer) applyLine(ae event.ApplyEvent) line {
+	l := p.objectLine("apply", ae.Operation.String(), ae.Object)
+	if ae.Previous != nil {
+		l.Previous = ae.Previous.Object
+	}
+	if p.ShowLatency && ae.Duration > 0 {
+		l.Duration = ae.Duration.String()
+		l.LatencyBucket = ae.LatencyBucket
+	}
+	return l
+}
+
+func (p *Printer) objectLine(eventType, operation string, obj runtime.Object) line {
+	id := object.ObjMetadata{
+		GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
+	}
+	if acc, err := meta.Accessor(obj); err == nil {
+		id.Name = acc.GetName()
+		id.Namespace = acc.GetNamespace()
+	}
+	return p.line(eventType, strings.ToLower(operation), id, "", "")
+}
+
+func (p *Printer) line(eventType, operation string, id object.ObjMetadata, status, errMsg string) line {
+	now := p.now
+	if now == nil {
+		now = time.Now
+	}
+	return line{
+		Timestamp: now().UTC().Format(time.RFC3339),
+		Type:      eventType,
+		Operation: operation,
+		Group:     id.GroupKind.Group,
+		Kind:      id.GroupKind.Kind,
+		Namespace: id.Namespace,
+		Name:      id.Name,
+		Status:    status,
+		Error:     errMsg,
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package json
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestPrinter(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+			},
+		},
+	}
+	events := []event.Event{
+		{Type: event.InitType},
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type:      event.ApplyEventResourceUpdate,
+				Operation: event.Created,
+				Object:    deployment,
+			},
+		},
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type: event.ApplyEventCompleted,
+			},
+		},
+		{
+			Type: event.StatusType,
+			StatusEvent: pollevent.Event{
+				EventType: pollevent.ResourceUpdateEvent,
+				Resource: &pollevent.ResourceStatus{
+					Identifier: object.ObjMetadata{
+						GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+						Namespace: "default",
+						Name:      "foo",
+					},
+					Status: status.CurrentStatus,
+				},
+			},
+		},
+		{
+			Type: event.ErrorType,
+			ErrorEvent: event.ErrorEvent{
+				Err: fmt.Errorf("failed"),
+			},
+		},
+		{
+			Type: event.ErrorType,
+			ErrorEvent: event.ErrorEvent{
+				Err:        fmt.Errorf("failed again"),
+				Retried:    true,
+				RetryCount: 2,
+			},
+		},
+	}
+
+	ioStreams, _, out, _ := genericclioptions.NewTestIOStreams()
+	printer := &Printer{
+		IOStreams: ioStreams,
+		now: func() time.Time {
+			return time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+		},
+	}
+
+	printer.Print(event.ReplayChannel(events, 0), false)
+
+	expected := []string{
+		`{"timestamp":"2020-06-01T12:00:00Z","type":"apply","operation":"created","group":"apps",` +
+			`"kind":"Deployment","namespace":"default","name":"foo"}`,
+		`{"timestamp":"2020-06-01T12:00:00Z","type":"status","group":"apps","kind":"Deployment",` +
+			`"namespace":"default","name":"foo","status":"Current"}`,
+		`{"timestamp":"2020-06-01T12:00:00Z","type":"error","error":"failed"}`,
+		`{"timestamp":"2020-06-01T12:00:00Z","type":"error","error":"failed again","retried":true,"retryCount":2}`,
+	}
+	assert.Equal(t, expected, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
+
+func TestPrinterOptionalFields(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+			},
+		},
+	}
+	previous := deployment.DeepCopy()
+	previous.SetLabels(map[string]string{"app": "foo"})
+	events := []event.Event{
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type:          event.ApplyEventResourceUpdate,
+				Operation:     event.Configured,
+				Object:        deployment,
+				Previous:      previous,
+				Duration:      50 * time.Millisecond,
+				LatencyBucket: event.LatencyFast,
+			},
+		},
+		{
+			Type: event.StatusType,
+			StatusEvent: pollevent.Event{
+				EventType: pollevent.ResourceUpdateEvent,
+				Resource: &pollevent.ResourceStatus{
+					Identifier: object.ObjMetadata{
+						GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+						Namespace: "default",
+						Name:      "foo",
+					},
+					Status: status.CurrentStatus,
+					Conditions: []status.BasicCondition{
+						{Type: "Available", Status: "True"},
+						{Type: "Progressing", Status: "True", Reason: "NewReplicaSetAvailable"},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		showLatency bool
+		expected    []string
+	}{
+		"without latency": {
+			expected: []string{
+				`{"timestamp":"2020-06-01T12:00:00Z","type":"apply","operation":"configured","group":"apps",` +
+					`"kind":"Deployment","namespace":"default","name":"foo","previous":{"apiVersion":"apps/v1",` +
+					`"kind":"Deployment","metadata":{"labels":{"app":"foo"},"name":"foo","namespace":"default"}}}`,
+				`{"timestamp":"2020-06-01T12:00:00Z","type":"status","group":"apps","kind":"Deployment",` +
+					`"namespace":"default","name":"foo","status":"Current","conditions":[` +
+					`{"type":"Available","status":"True"},` +
+					`{"type":"Progressing","status":"True","reason":"NewReplicaSetAvailable"}]}`,
+			},
+		},
+		"with latency": {
+			showLatency: true,
+			expected: []string{
+				`{"timestamp":"2020-06-01T12:00:00Z","type":"apply","operation":"configured","group":"apps",` +
+					`"kind":"Deployment","namespace":"default","name":"foo","previous":{"apiVersion":"apps/v1",` +
+					`"kind":"Deployment","metadata":{"labels":{"app":"foo"},"name":"foo","namespace":"default"}},` +
+					// The encoder escapes the < of the latency bucket.
+					`"duration":"50ms","latencyBucket":"fast \u003c100ms"}`,
+				`{"timestamp":"2020-06-01T12:00:00Z","type":"status","group":"apps","kind":"Deployment",` +
+					`"namespace":"default","name":"foo","status":"Current","conditions":[` +
+					`{"type":"Available","status":"True"},` +
+					`{"type":"Progressing","status":"True","reason":"NewReplicaSetAvailable"}]}`,
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, out, _ := genericclioptions.NewTestIOStreams()
+			printer := &Printer{
+				IOStreams:   ioStreams,
+				ShowLatency: tc.showLatency,
+				now: func() time.Time {
+					return time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+				},
+			}
+
+			printer.Print(event.ReplayChannel(events, 0), false)
+
+			assert.Equal(t, tc.expected, strings.Split(strings.TrimSpace(out.String()), "\n"))
+		})
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package printers
+
+import (
+	"fmt"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+// NullPrinter discards all events. It is meant for callers that only
+// care about the side effects of a command, so nothing is written
+// for a successful run. Errors are still written to ErrOut, since
+// they would otherwise be lost.
+type NullPrinter struct {
+	IOStreams genericclioptions.IOStreams
+}
+
+// Print drains the channel without printing anything other than
+// errors. It will block until the channel is closed.
+func (n *NullPrinter) Print(ch <-chan event.Event, _ bool) {
+	for e := range ch {
+		if e.Type == event.ErrorType && n.IOStreams.ErrOut != nil {
+			fmt.Fprintf(n.IOStreams.ErrOut, "error: %v\n", e.ErrorEvent.Err)
+		}
+	}
+}
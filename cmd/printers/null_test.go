@@ -0,0 +1,114 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package printers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+func TestNullPrinter(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		events         []event.Event
+		expectedErrOut string
+	}{
+		"successful apply prints nothing": {
+			events: []event.Event{
+				{
+					Type: event.InitType,
+				},
+				{
+					Type: event.ApplyType,
+					ApplyEvent: event.ApplyEvent{
+						Type:      event.ApplyEventResourceUpdate,
+						Operation: event.Created,
+						Object:    deployment,
+					},
+				},
+				{
+					Type: event.ApplyType,
+					ApplyEvent: event.ApplyEvent{
+						Type: event.ApplyEventCompleted,
+					},
+				},
+				{
+					Type: event.PruneType,
+					PruneEvent: event.PruneEvent{
+						Type: event.PruneEventCompleted,
+					},
+				},
+			},
+		},
+		"errors are written to ErrOut": {
+			events: []event.Event{
+				{
+					Type: event.ErrorType,
+					ErrorEvent: event.ErrorEvent{
+						Err: fmt.Errorf("failed"),
+					},
+				},
+			},
+			expectedErrOut: "error: failed\n",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, out, errOut := genericclioptions.NewTestIOStreams()
+			printer := GetPrinter(NonePrinter, ioStreams)
+			assert.IsType(t, &NullPrinter{}, printer)
+
+			printer.Print(event.ReplayChannel(tc.events, 0), false)
+
+			assert.Equal(t, 0, out.Len())
+			assert.Equal(t, tc.expectedErrOut, errOut.String())
+		})
+	}
+}
+
+func TestNullPrinterDrainsChannel(t *testing.T) {
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	printer := &NullPrinter{IOStreams: ioStreams}
+
+	// The channel is unbuffered, so every send blocks until the
+	// printer has received the event.
+	ch := make(chan event.Event)
+	done := make(chan struct{})
+	go func() {
+		printer.Print(ch, false)
+		close(done)
+	}()
+
+	for i := 0; i < 100; i++ {
+		select {
+		case ch <- event.Event{Type: event.ApplyType}:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out sending event %d to the printer", i)
+		}
+	}
+	close(ch)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("printer did not return after the channel was closed")
+	}
+}
@@ -4,8 +4,14 @@
 package printers
 
 import (
+	"os"
+
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/cmd/printers/csv"
+	"sigs.k8s.io/cli-utils/cmd/printers/dot"
+	"sigs.k8s.io/cli-utils/cmd/printers/json"
 	"sigs.k8s.io/cli-utils/cmd/printers/printer"
+	"sigs.k8s.io/cli-utils/cmd/printers/slack"
 	"sigs.k8s.io/cli-utils/cmd/printers/table"
 	"sigs.k8s.io/cli-utils/pkg/apply"
 )
@@ -13,14 +19,43 @@ import (
 const (
 	EventsPrinter = "events"
 	TablePrinter  = "table"
+	SlackPrinter  = "slack"
+	CSVPrinter    = "csv"
+	JSONPrinter   = "json"
+	// DotPrinter writes the resources as a Graphviz DOT graph. It
+	// is only supported in dry-run mode.
+	DotPrinter = "dot"
+	// NonePrinter discards all output other than errors.
+	NonePrinter = "none"
 )
 
 func GetPrinter(printerType string, ioStreams genericclioptions.IOStreams) printer.Printer {
-	switch printerType { //nolint:gocritic
+	switch printerType {
 	case TablePrinter:
 		return &table.Printer{
 			IOStreams: ioStreams,
 		}
+	case CSVPrinter:
+		return &csv.Printer{
+			IOStreams: ioStreams,
+		}
+	case JSONPrinter:
+		return &json.Printer{
+			IOStreams: ioStreams,
+		}
+	case DotPrinter:
+		return &dot.Printer{
+			IOStreams: ioStreams,
+		}
+	case NonePrinter:
+		return &NullPrinter{
+			IOStreams: ioStreams,
+		}
+	case SlackPrinter:
+		return &slack.Printer{
+			IOStreams:  ioStreams,
+			WebhookURL: os.Getenv(slack.WebhookURLEnvVar),
+		}
 	default:
 		return &apply.BasicPrinter{
 			IOStreams: ioStreams,
@@ -29,7 +64,7 @@ func GetPrinter(printerType string, ioStreams genericclioptions.IOStreams) print
 }
 
 func SupportedPrinters() []string {
-	return []string{EventsPrinter, TablePrinter}
+	return []string{EventsPrinter, TablePrinter, SlackPrinter, CSVPrinter, JSONPrinter, DotPrinter, NonePrinter}
 }
 
 func DefaultPrinter() string {
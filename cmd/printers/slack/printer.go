@@ -0,0 +1,143 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+)
+
+// WebhookURLEnvVar is the environment variable that is used for the
+// webhook URL if none is provided through the command line.
+const WebhookURLEnvVar = "SLACK_WEBHOOK_URL"
+
+// postTimeout is how long posting the message to the webhook may take,
+// so an unresponsive webhook doesn't block the command forever.
+const postTimeout = 30 * time.Second
+
+// httpClient is the subset of the http.Client used by the Printer.
+type httpClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Printer collects a summary of the events from the channel, and
+// posts it as a message to a Slack incoming webhook once the
+// channel has been closed.
+type Printer struct {
+	IOStreams  genericclioptions.IOStreams
+	WebhookURL string
+
+	// client is used for posting the message. Defaults to an
+	// http.Client with a timeout of postTimeout if not set.
+	client httpClient
+}
+
+// summary keeps track of the outcome of the operation.
+type summary struct {
+	applied  int
+	pruned   int
+	deleted  int
+	failures []string
+}
+
+// Print consumes all events from the channel and posts the summary
+// to the webhook after the channel has been closed. The events are
+// only counted while reading them, so the channel is never blocked
+// by the printer.
+func (p *Printer) Print(ch <-chan event.Event, preview bool) {
+	s := &summary{}
+	for e := range ch {
+		s.process(e)
+	}
+
+	if p.WebhookURL == "" {
+		_, _ = fmt.Fprintf(p.IOStreams.ErrOut,
+			"no Slack webhook URL provided; set --slack-webhook-url or %s\n", WebhookURLEnvVar)
+		return
+	}
+	if err := p.post(s.message(preview)); err != nil {
+		_, _ = fmt.Fprintf(p.IOStreams.ErrOut, "error posting to Slack: %v\n", err)
+	}
+}
+
+func (s *summary) process(e event.Event) {
+	switch e.Type {
+	case event.ErrorType:
+		s.failures = append(s.failures, e.ErrorEvent.Err.Error())
+	case event.ApplyType:
+		if e.ApplyEvent.Type == event.ApplyEventResourceUpdate {
+			s.applied++
+		}
+	case event.PruneType:
+		if e.PruneEvent.Type == event.PruneEventResourceUpdate &&
+			e.PruneEvent.Operation == event.Pruned {
+			s.pruned++
+		}
+	case event.DeleteType:
+		if e.DeleteEvent.Type == event.DeleteEventResourceUpdate &&
+			e.DeleteEvent.Operation == event.Deleted {
+			s.deleted++
+		}
+	case event.StatusType:
+		// The poller doesn't set the Resource of error events, so
+		// only the error is reported.
+		if e.StatusEvent.EventType == pollevent.ErrorEvent {
+			s.failures = append(s.failures, e.StatusEvent.Error.Error())
+		}
+	}
+}
+
+// message formats the summary as the text of the Slack message.
+func (s *summary) message(preview bool) string {
+	var b strings.Builder
+	title := "Apply completed"
+	if preview {
+		title = "Preview completed"
+	}
+	if len(s.failures) > 0 {
+		title += " with failures"
+	}
+	fmt.Fprintf(&b, "*%s*\n", title)
+	fmt.Fprintf(&b, "%d resource(s) applied, %d pruned, %d deleted",
+		s.applied, s.pruned, s.deleted)
+	for _, f := range s.failures {
+		fmt.Fprintf(&b, "\n• %s", f)
+	}
+	return b.String()
+}
+
+// post sends the message to the webhook.
+func (p *Printer) post(text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := p.client
+	if client == nil {
+		client = &http.Client{Timeout: postTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+)
+
+type fakeHTTPClient struct {
+	requests []*http.Request
+	bodies   []string
+}
+
+func (f *fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.requests = append(f.requests, req)
+	f.bodies = append(f.bodies, string(b))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       ioutil.NopCloser(strings.NewReader("ok")),
+	}, nil
+}
+
+func TestPrinter(t *testing.T) {
+	testCases := map[string]struct {
+		events  []event.Event
+		preview bool
+
+		expectedText string
+	}{
+		"applied and pruned resources": {
+			events: []event.Event{
+				{Type: event.ApplyType, ApplyEvent: event.ApplyEvent{Type: event.ApplyEventResourceUpdate}},
+				{Type: event.ApplyType, ApplyEvent: event.ApplyEvent{Type: event.ApplyEventResourceUpdate}},
+				{Type: event.ApplyType, ApplyEvent: event.ApplyEvent{Type: event.ApplyEventCompleted}},
+				{Type: event.PruneType, PruneEvent: event.PruneEvent{
+					Type:      event.PruneEventResourceUpdate,
+					Operation: event.Pruned,
+				}},
+				{Type: event.PruneType, PruneEvent: event.PruneEvent{
+					Type:      event.PruneEventResourceUpdate,
+					Operation: event.PruneSkipped,
+				}},
+			},
+			expectedText: "*Apply completed*\n2 resource(s) applied, 1 pruned, 0 deleted",
+		},
+		"preview with failure": {
+			events: []event.Event{
+				{Type: event.ApplyType, ApplyEvent: event.ApplyEvent{Type: event.ApplyEventResourceUpdate}},
+				{Type: event.ErrorType, ErrorEvent: event.ErrorEvent{Err: fmt.Errorf("boom")}},
+			},
+			preview:      true,
+			expectedText: "*Preview completed with failures*\n1 resource(s) applied, 0 pruned, 0 deleted\n• boom",
+		},
+		"polling error without resource": {
+			events: []event.Event{
+				{Type: event.StatusType, StatusEvent: pollevent.Event{
+					EventType: pollevent.ErrorEvent,
+					Error:     fmt.Errorf("polling failed"),
+				}},
+			},
+			expectedText: "*Apply completed with failures*\n0 resource(s) applied, 0 pruned, 0 deleted\n• polling failed",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams()
+			client := &fakeHTTPClient{}
+			printer := &Printer{
+				IOStreams:  ioStreams,
+				WebhookURL: "https://hooks.slack.com/services/test",
+				client:     client,
+			}
+
+			printer.Print(event.ReplayChannel(tc.events, 0), tc.preview)
+
+			if !assert.Len(t, client.requests, 1) {
+				return
+			}
+			req := client.requests[0]
+			assert.Equal(t, http.MethodPost, req.Method)
+			assert.Equal(t, "https://hooks.slack.com/services/test", req.URL.String())
+			assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+			var body map[string]string
+			assert.NoError(t, json.Unmarshal([]byte(client.bodies[0]), &body))
+			assert.Equal(t, tc.expectedText, body["text"])
+		})
+	}
+}
+
+func TestPrinterWithoutWebhookURL(t *testing.T) {
+	ioStreams, _, _, errOut := genericclioptions.NewTestIOStreams()
+	client := &fakeHTTPClient{}
+	printer := &Printer{
+		IOStreams: ioStreams,
+		client:    client,
+	}
+
+	ch := make(chan event.Event)
+	close(ch)
+	printer.Print(ch, false)
+
+	assert.Empty(t, client.requests)
+	assert.Contains(t, errOut.String(), WebhookURLEnvVar)
+}
@@ -5,9 +5,13 @@ package table
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+	"time"
 
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/cli-utils/pkg/print/table"
 )
 
@@ -72,3 +76,71 @@ func TestActionColumnDef(t *testing.T) {
 		})
 	}
 }
+
+func TestPrinter_Print(t *testing.T) {
+	testCases := map[string]struct {
+		events       []event.Event
+		expectedRows int
+	}{
+		"no resources": {
+			events: []event.Event{
+				{
+					Type:      event.InitType,
+					InitEvent: event.InitEvent{},
+				},
+			},
+			expectedRows: 0,
+		},
+		"resources for apply and prune": {
+			events: []event.Event{
+				{
+					Type: event.InitType,
+					InitEvent: event.InitEvent{
+						ResourceGroups: []event.ResourceGroup{
+							{
+								Action:      event.ApplyAction,
+								Identifiers: []object.ObjMetadata{depID},
+							},
+							{
+								Action:      event.PruneAction,
+								Identifiers: []object.ObjMetadata{customID},
+							},
+						},
+					},
+				},
+				{
+					Type: event.ApplyType,
+					ApplyEvent: event.ApplyEvent{
+						Type: event.ApplyEventCompleted,
+					},
+				},
+				{
+					Type: event.PruneType,
+					PruneEvent: event.PruneEvent{
+						Type: event.PruneEventCompleted,
+					},
+				},
+			},
+			expectedRows: 2,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, out, _ := genericclioptions.NewTestIOStreams()
+			printer := &Printer{
+				IOStreams: ioStreams,
+			}
+
+			printer.Print(event.ReplayChannel(tc.events, 10*time.Millisecond), false)
+
+			// The table is printed repeatedly, so only the rows after
+			// the last header are counted.
+			output := out.String()
+			lastTable := output[strings.LastIndex(output, "NAMESPACE"):]
+			if want, got := tc.expectedRows+1, strings.Count(lastTable, "\n"); want != got {
+				t.Errorf("expected %d rows, but got %d", tc.expectedRows, got-1)
+			}
+		})
+	}
+}
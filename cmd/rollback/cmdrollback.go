@@ -0,0 +1,196 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/manifestreader"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// NewCmdRollback creates the `rollback` command. It re-applies the
+// resources from one of the snapshots stored when applying with
+// --revision-history-limit. With --resource, it re-applies the
+// configuration in the directory with a single resource replaced by
+// one of its revisions stored when applying with --max-resource-history.
+func NewCmdRollback(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	applier := apply.NewApplier(f, ioStreams)
+	printer := &apply.BasicPrinter{
+		IOStreams: ioStreams,
+	}
+	var revision int
+	var resourceKey string
+
+	cmd := &cobra.Command{
+		Use:                   "rollback DIRECTORY",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Re-apply the resources from a previous apply of a configuration"),
+		Args:                  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(applier.Initialize(cmd))
+
+			readerOptions := manifestreader.ReaderOptions{
+				Factory:   f,
+				Namespace: metav1.NamespaceDefault,
+				Stdin:     cmd.InOrStdin(),
+			}
+			// Only the inventory object template is used from the
+			// manifests in the directory, unless a single resource
+			// is rolled back.
+			infos, err := (&manifestreader.PathManifestReader{
+				Path:          args[0],
+				ReaderOptions: readerOptions,
+			}).Read()
+			cmdutil.CheckErr(err)
+			inv, found := inventory.FindInventoryObj(infos)
+			if !found {
+				cmdutil.CheckErr(inventory.NoInventoryObjError{})
+			}
+
+			if resourceKey != "" {
+				objects, err := rollbackResource(f, readerOptions, infos, inv, resourceKey, revision)
+				cmdutil.CheckErr(err)
+				// Only the resource differs from the configuration
+				// in the directory, so nothing is pruned.
+				ch := applier.Run(context.Background(), objects, apply.Options{
+					NoPrune:                      true,
+					ManageClusterScopedResources: pointer.BoolPtr(true),
+				})
+				printer.Print(ch, false)
+				return
+			}
+
+			dynamicClient, err := f.DynamicClient()
+			cmdutil.CheckErr(err)
+			history, err := inventory.ListHistory(dynamicClient, inv)
+			cmdutil.CheckErr(err)
+			snapshot, found := findRevision(history, revision)
+			if !found {
+				cmdutil.CheckErr(fmt.Errorf("revision %d not found in the history of inventory %s", revision, inv.Name))
+			}
+
+			objects, err := (&manifestreader.StreamManifestReader{
+				ReaderName:    snapshot.Name,
+				Reader:        strings.NewReader(snapshot.Manifests),
+				ReaderOptions: readerOptions,
+			}).Read()
+			cmdutil.CheckErr(err)
+			objects = append(objects, inv)
+
+			// Run the applier. It will return a channel where we can receive updates
+			// to keep track of progress and any issues.
+			// The snapshot is restored as it was applied, including
+			// any cluster-scoped resources.
+			ch := applier.Run(context.Background(), objects, apply.Options{
+				ManageClusterScopedResources: pointer.BoolPtr(true),
+			})
+
+			// The printer will print updates from the channel. It will block
+			// until the channel is closed.
+			printer.Print(ch, false)
+		},
+	}
+
+	cmd.Flags().IntVar(&revision, "revision", 0,
+		"The sequence number of the snapshot, or of the resource revision with --resource, to roll back to.")
+	cmd.Flags().StringVar(&resourceKey, "resource", "",
+		"Only roll back this resource, given as <group>/<kind>/<namespace>/<name>, like apps/Deployment/default/app. "+
+			"The group is empty for core resources, like /ConfigMap/default/config.")
+	_ = cmd.MarkFlagRequired("revision")
+	cmdutil.CheckErr(applier.SetFlags(cmd))
+
+	// The following flags are added, but hidden because other code
+	// depend on them when parsing flags. These flags are hidden and unused.
+	var unusedBool bool
+	cmd.Flags().BoolVar(&unusedBool, "dry-run", unusedBool, "NOT USED")
+	_ = cmd.Flags().MarkHidden("dry-run")
+	cmdutil.AddValidateFlags(cmd)
+	_ = cmd.Flags().MarkHidden("validate")
+	// Server-side flags are hidden for now.
+	cmdutil.AddServerSideApplyFlags(cmd)
+	_ = cmd.Flags().MarkHidden("server-side")
+	_ = cmd.Flags().MarkHidden("force-conflicts")
+	_ = cmd.Flags().MarkHidden("field-manager")
+
+	return cmd
+}
+
+// rollbackResource returns the resources in infos, with the resource
+// identified by resourceKey replaced by the provided revision of it
+// from the resource history of the inventory objects in the cluster.
+// The resource is added if it is not in infos.
+func rollbackResource(f cmdutil.Factory, readerOptions manifestreader.ReaderOptions, infos []*resource.Info,
+	inv *resource.Info, resourceKey string, revision int) ([]*resource.Info, error) {
+	id, err := inventory.ParseResourceHistoryKey(resourceKey)
+	if err != nil {
+		return nil, err
+	}
+	invClient, err := inventory.NewInventoryClient(f)
+	if err != nil {
+		return nil, err
+	}
+	invs, err := invClient.GetPreviousInventoryObjects(inv)
+	if err != nil {
+		return nil, err
+	}
+	manifest, found := "", false
+	for _, i := range invs {
+		u, ok := i.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		history, err := inventory.GetResourceHistory(u)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range history[inventory.ResourceHistoryKey(id)] {
+			if r.Revision == revision {
+				manifest, found = r.Manifest, true
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("revision %d of resource %s not found in the history of inventory %s",
+			revision, resourceKey, inv.Name)
+	}
+
+	restored, err := (&manifestreader.StreamManifestReader{
+		ReaderName:    resourceKey,
+		Reader:        strings.NewReader(manifest),
+		ReaderOptions: readerOptions,
+	}).Read()
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]*resource.Info, 0, len(infos)+len(restored))
+	for _, info := range infos {
+		if object.InfoToObjMeta(info) != id {
+			objects = append(objects, info)
+		}
+	}
+	return append(objects, restored...), nil
+}
+
+// findRevision returns the snapshot with the provided sequence number.
+func findRevision(history []inventory.History, revision int) (inventory.History, bool) {
+	for _, h := range history {
+		if h.Sequence == revision {
+			return h, true
+		}
+	}
+	return inventory.History{}, false
+}
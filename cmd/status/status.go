@@ -5,11 +5,15 @@ package status
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-errors/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/cli-utils/cmd/status/printers"
@@ -17,6 +21,7 @@ import (
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/aggregator"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/collector"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/statusreaders"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -50,6 +55,10 @@ func GetStatusRunner() *StatusRunner {
 	c.Flags().StringVar(&r.Output, "output", "table", "output format.")
 	c.Flags().BoolVar(&r.WaitForDeletion, "wait-for-deletion", false,
 		"wait for all resources to be deleted instead of reconciled.")
+	c.Flags().StringArrayVar(&r.StatusFields, "status-fields", []string{},
+		"compute the status of a resource type from two fields, given as "+
+			"<resource>:<current>=<desired>. For example, "+
+			"'deployments:.status.readyReplicas=.spec.replicas'.")
 
 	r.Command = c
 	return r
@@ -68,6 +77,7 @@ type StatusRunner struct {
 	PollForever        bool
 	WaitForDeletion    bool
 	Output             string
+	StatusFields       []string
 	Command            *cobra.Command
 }
 
@@ -88,6 +98,13 @@ func (r *StatusRunner) runE(c *cobra.Command, args []string) error {
 	}
 
 	poller := polling.NewStatusPoller(k8sClient, mapper)
+	if len(r.StatusFields) > 0 {
+		extractors, err := parseStatusFields(r.StatusFields, mapper)
+		if err != nil {
+			return err
+		}
+		poller.SetJSONPathExtractors(extractors)
+	}
 
 	captureFilter := &CaptureIdentifiersFilter{
 		Mapper: mapper,
@@ -176,3 +193,26 @@ func getNotifierFunc(cancelFunc context.CancelFunc, desired status.Status) colle
 		}
 	}
 }
+
+// parseStatusFields parses the values of the --status-fields flag into
+// JSONPath extractors keyed by the GroupKind of the resource.
+func parseStatusFields(fields []string, mapper meta.RESTMapper) (map[schema.GroupKind]statusreaders.JSONPathExtractor, error) {
+	extractors := make(map[schema.GroupKind]statusreaders.JSONPathExtractor)
+	for _, field := range fields {
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid status fields %q: expected <resource>:<current>=<desired>", field)
+		}
+		gr := schema.ParseGroupResource(parts[0])
+		gvk, err := mapper.KindFor(gr.WithVersion(""))
+		if err != nil {
+			return nil, errors.WrapPrefix(err, fmt.Sprintf("error finding kind for %q", parts[0]), 1)
+		}
+		extractor, err := statusreaders.ParseJSONPathExtractor(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		extractors[gvk.GroupKind()] = extractor
+	}
+	return extractors, nil
+}
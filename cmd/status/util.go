@@ -74,12 +74,7 @@ func isValidKubernetesResource(id yaml.ResourceIdentifier) bool {
 }
 
 // isInventoryObject checks if the provided map of labels contain the
-// inventory object label key.
+// inventory object label.
 func isInventoryObject(labels map[string]string) bool {
-	for key := range labels {
-		if key == common.InventoryLabel {
-			return true
-		}
-	}
-	return false
+	return common.HasInventoryLabel(labels)
 }
@@ -5,15 +5,24 @@ package apply
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"time"
 
 	"github.com/go-errors/errors"
+	"github.com/go-logr/logr"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	k8sversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog"
 	"k8s.io/kubectl/pkg/cmd/apply"
 	"k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/scheme"
@@ -23,8 +32,10 @@ import (
 	"sigs.k8s.io/cli-utils/pkg/apply/prune"
 	"sigs.k8s.io/cli-utils/pkg/apply/solver"
 	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/cli-utils/pkg/inventory"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling"
+	"sigs.k8s.io/cli-utils/pkg/manifestreader"
 	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -36,6 +47,14 @@ import (
 // handled by a separate printer with the KubectlPrinterAdapter bridging
 // between the two.
 func NewApplier(factory util.Factory, ioStreams genericclioptions.IOStreams) *Applier {
+	return NewApplierWithOptions(factory, ioStreams)
+}
+
+// NewApplierWithOptions returns a new Applier like NewApplier, configured
+// with the passed ApplierOptions. The options are applied in order,
+// after the defaults have been set.
+func NewApplierWithOptions(factory util.Factory, ioStreams genericclioptions.IOStreams,
+	opts ...ApplierOption) *Applier {
 	applyOptions := apply.NewApplyOptions(ioStreams)
 	a := &Applier{
 		ApplyOptions: applyOptions,
@@ -46,8 +65,14 @@ func NewApplier(factory util.Factory, ioStreams genericclioptions.IOStreams) *Ap
 		ioStreams:    ioStreams,
 	}
 	a.infoHelperFactoryFunc = a.infoHelperFactory
+	a.serverVersionFunc = a.serverVersion
 	a.InventoryFactoryFunc = inventory.WrapInventoryObj
+	a.InventoryClientFactoryFunc = newInventoryClient
 	a.PruneOptions.InventoryFactoryFunc = inventory.WrapInventoryObj
+	a.SetLogger(common.NullLogger{})
+	for _, opt := range opts {
+		opt(a)
+	}
 	return a
 }
 
@@ -69,13 +94,29 @@ type Applier struct {
 	PruneOptions *prune.PruneOptions
 	StatusPoller poller.Poller
 	invClient    inventory.InventoryClient
+	logger       logr.Logger
+	// eventBus receives the events of every apply if set.
+	eventBus *event.EventBus
+
+	// lastInventory is the inventory object as updated at the end of
+	// the last successful apply.
+	lastInventory *unstructured.Unstructured
+	// inventoryInfo is the inventory object template found by the
+	// last call to Run.
+	inventoryInfo *resource.Info
 
 	// infoHelperFactoryFunc is used to create a new instance of the
 	// InfoHelper. It is defined here so we can override it in unit tests.
 	infoHelperFactoryFunc func() info.InfoHelper
+	// serverVersionFunc returns the version of the API server. It is
+	// defined here so we can override it in unit tests.
+	serverVersionFunc func() (*k8sversion.Info, error)
 	// InventoryFactoryFunc wraps and returns an interface for the
 	// object which will load and store the inventory.
 	InventoryFactoryFunc func(*resource.Info) inventory.Inventory
+	// InventoryClientFactoryFunc creates the client used to retrieve
+	// the inventory objects from the cluster.
+	InventoryClientFactoryFunc func(util.Factory) (inventory.InventoryClient, error)
 }
 
 // Initialize sets up the Applier for actually doing an apply against
@@ -91,7 +132,7 @@ func (a *Applier) Initialize(cmd *cobra.Command) error {
 		return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
 	}
 	a.ApplyOptions.PostProcessorFn = nil // Turn off the default kubectl pruning
-	a.invClient, err = inventory.NewInventoryClient(a.factory)
+	a.invClient, err = a.InventoryClientFactoryFunc(a.factory)
 	if err != nil {
 		return err
 	}
@@ -151,15 +192,143 @@ func (a *Applier) newStatusPoller() (poller.Poller, error) {
 	return polling.NewStatusPoller(c, mapper), nil
 }
 
+// SetLogger sets the logger used for structured logging by the applier,
+// including when applying and pruning resources. A no-op logger is used
+// if no logger is set.
+func (a *Applier) SetLogger(logger logr.Logger) {
+	a.logger = logger
+	a.PruneOptions.Logger = logger
+}
+
+// SetEventBus sets an EventBus which the events of Run and
+// ApplyWithInventory are published on before they are sent on the
+// returned channel. The handlers are invoked synchronously, so a slow
+// handler delays the apply. The subscribers stay registered across
+// applies.
+func (a *Applier) SetEventBus(bus *event.EventBus) {
+	a.eventBus = bus
+}
+
+// SetInventoryClientFactoryFunc sets the function creating the client
+// used to retrieve the inventory objects from the cluster. It must
+// return a new client for every call, since the inventory clients
+// cache the inventory objects. It must be called before Initialize.
+func (a *Applier) SetInventoryClientFactoryFunc(f func(util.Factory) (inventory.InventoryClient, error)) {
+	a.InventoryClientFactoryFunc = f
+}
+
+// SetPruneRateLimiter sets the RateLimiter limiting the rate of
+// delete calls when pruning.
+func (a *Applier) SetPruneRateLimiter(rateLimiter flowcontrol.RateLimiter) {
+	a.PruneOptions.RateLimiter = rateLimiter
+}
+
+// GetInventoryInfo returns the inventory object template identified
+// by the last call to Run, either from the applied objects or from the
+// InventoryName and InventoryNamespace options, or nil if Run hasn't
+// read the inventory yet. The object in the cluster is named after the
+// template with a suffix of the inventory hash. It should be called
+// after the event channel returned by Run has been closed.
+func (a *Applier) GetInventoryInfo() *resource.Info {
+	return a.inventoryInfo
+}
+
+// Reset clears the state accumulated by the previous calls to Run, so
+// the Applier can be reused for another apply. The set of applied
+// objects and the inventory objects read from the cluster are used for
+// computing what should be pruned, so they must be cleared between
+// runs. If the Applier has been initialized, a new inventory client is
+// created, since the inventory client caches the inventory objects.
+func (a *Applier) Reset() error {
+	// The sets are cleared in place since the PruneOptions share
+	// the set of applied object UIDs with the ApplyOptions.
+	clearSet(a.ApplyOptions.VisitedUids)
+	clearSet(a.ApplyOptions.VisitedNamespaces)
+	a.lastInventory = nil
+	a.inventoryInfo = nil
+	return a.resetInventoryClient()
+}
+
+// Clone returns a new Applier with the same configuration, which can
+// be used independently of and concurrently with this one. The clone
+// shares the status poller and the event bus, but gets its own inventory client, copies
+// of the ApplyOptions, PruneOptions and the clients set with
+// SetDynamicClient, SetRESTMapper and SetDiscoveryClient, and none of
+// the state from previous calls to Run.
+func (a *Applier) Clone() (*Applier, error) {
+	applyOptions := *a.ApplyOptions
+	applyOptions.VisitedUids = sets.NewString()
+	applyOptions.VisitedNamespaces = sets.NewString()
+
+	c := &Applier{
+		factory:      cloneFactory(a.factory),
+		ioStreams:    a.ioStreams,
+		ApplyOptions: &applyOptions,
+		// The PruneOptions must share the set of applied object UIDs
+		// with the ApplyOptions, as in NewApplier.
+		PruneOptions:               a.PruneOptions.Copy(applyOptions.VisitedUids),
+		StatusPoller:               a.StatusPoller,
+		invClient:                  a.invClient,
+		logger:                     a.logger,
+		eventBus:                   a.eventBus,
+		InventoryFactoryFunc:       a.InventoryFactoryFunc,
+		InventoryClientFactoryFunc: a.InventoryClientFactoryFunc,
+	}
+	c.infoHelperFactoryFunc = c.infoHelperFactory
+	c.serverVersionFunc = c.serverVersion
+	if err := c.resetInventoryClient(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// resetInventoryClient replaces the inventory client of an initialized
+// Applier with a new one, since the inventory client caches the
+// inventory objects it has read.
+func (a *Applier) resetInventoryClient() error {
+	if a.invClient == nil {
+		return nil
+	}
+	invClient, err := a.InventoryClientFactoryFunc(a.factory)
+	if err != nil {
+		return err
+	}
+	a.invClient = invClient
+	if err := a.PruneOptions.Initialize(a.factory, invClient); err != nil {
+		return errors.WrapPrefix(err, "error setting up PruneOptions", 1)
+	}
+	return nil
+}
+
+func clearSet(s sets.String) {
+	for k := range s {
+		delete(s, k)
+	}
+}
+
+// newInventoryClient returns the default InventoryClient, which looks
+// up the inventory objects in the cluster by the inventory label.
+func newInventoryClient(factory util.Factory) (inventory.InventoryClient, error) {
+	return inventory.NewInventoryClient(factory)
+}
+
 // infoHelperFactory returns a new instance of the InfoHelper.
 func (a *Applier) infoHelperFactory() info.InfoHelper {
 	return info.NewInfoHelper(a.factory, a.ApplyOptions.Namespace)
 }
 
 // prepareObjects handles ordering of resources and sets up the inventory object
-// based on the provided inventory object template.
-func (a *Applier) prepareObjects(infos []*resource.Info) (*ResourceObjects, error) {
+// based on the provided inventory object template, or on the
+// InventoryName and InventoryNamespace options if set.
+func (a *Applier) prepareObjects(infos []*resource.Info, options Options) (*ResourceObjects, error) {
 	resources, invs := splitInfos(infos)
+	if options.InventoryName != "" {
+		invNamespace := options.InventoryNamespace
+		if invNamespace == "" {
+			invNamespace = a.ApplyOptions.Namespace
+		}
+		invs = append(invs, inventory.NewInventoryObjectTemplate(options.InventoryName, invNamespace))
+	}
 
 	if len(invs) == 0 {
 		return nil, inventory.NoInventoryObjError{}
@@ -170,7 +339,20 @@ func (a *Applier) prepareObjects(infos []*resource.Info) (*ResourceObjects, erro
 		}
 	}
 
+	// The names must be resolved before the inventory object is
+	// created, since it records the names of the resources.
+	if options.GenerateName {
+		if err := a.resolveGeneratedNames(invs[0], resources); err != nil {
+			return nil, err
+		}
+	}
+
+	a.inventoryInfo = invs[0]
 	inv := a.InventoryFactoryFunc(invs[0])
+	inv.SetAnnotations(options.InventoryAnnotations)
+	inv.SetAnnotations(map[string]string{
+		common.ApplierVersionAnnotation: a.GetApplierVersion(),
+	})
 	inventoryObject, err := inventory.CreateInventoryObj(inv, resources)
 	if err != nil {
 		return nil, err
@@ -182,6 +364,14 @@ func (a *Applier) prepareObjects(infos []*resource.Info) (*ResourceObjects, erro
 		return nil, err
 	}
 
+	format := options.InventoryFormat
+	if format == "" {
+		format = previousInventoryFormat(previousInventories)
+	}
+	if u, ok := inventoryObject.Object.(*unstructured.Unstructured); ok {
+		inventory.SetInventoryFormat(u, format)
+	}
+
 	sort.Sort(ResourceInfos(resources))
 
 	if !validateNamespace(resources) {
@@ -195,6 +385,19 @@ func (a *Applier) prepareObjects(infos []*resource.Info) (*ResourceObjects, erro
 	}, nil
 }
 
+// previousInventoryFormat returns the format of the previous inventory
+// objects, so the format is kept across applies. It returns v2 if any
+// of them is in the v2 format.
+func previousInventoryFormat(previous []*resource.Info) inventory.InventoryFormat {
+	for _, inv := range previous {
+		u, ok := inv.Object.(*unstructured.Unstructured)
+		if ok && inventory.GetInventoryFormat(u) == inventory.InventoryFormatV2 {
+			return inventory.InventoryFormatV2
+		}
+	}
+	return inventory.InventoryFormatV1
+}
+
 // ResourceObjects contains information about the resources that
 // will be applied and the existing inventories used to determine
 // resources that should be pruned.
@@ -247,6 +450,22 @@ func (r *ResourceObjects) AllIds() []object.ObjMetadata {
 	return append(r.IdsForApply(), r.IdsForPrune()...)
 }
 
+// pruneCandidates returns the identifiers of the resources that might
+// be pruned. Cluster-scoped resources are never pruned if pruning is
+// restricted to namespaced resources, so they are left out.
+func pruneCandidates(ids []object.ObjMetadata, options Options) []object.ObjMetadata {
+	if !options.PruneNamespaceScoped {
+		return ids
+	}
+	var candidates []object.ObjMetadata
+	for _, id := range ids {
+		if id.Namespace != "" {
+			candidates = append(candidates, id)
+		}
+	}
+	return candidates
+}
+
 // splitInfos takes a slice of resource.Info objects and splits it
 // into one slice that contains the inventory object templates and
 // another one that contains the remaining resources.
@@ -255,7 +474,7 @@ func splitInfos(infos []*resource.Info) ([]*resource.Info, []*resource.Info) {
 	resources := make([]*resource.Info, 0)
 
 	for _, info := range infos {
-		if inventory.IsInventoryObject(info.Object) {
+		if u, _ := info.Object.(*unstructured.Unstructured); common.IsInventoryObject(u) {
 			inventoryObjectTemplates = append(inventoryObjectTemplates, info)
 		} else {
 			resources = append(resources, info)
@@ -272,44 +491,166 @@ func splitInfos(infos []*resource.Info) ([]*resource.Info, []*resource.Info) {
 // before all the given resources have been applied to the cluster. Any
 // cancellation or timeout will only affect how long we Wait for the
 // resources to become current.
+// The inventory object template is taken from the objects. Use
+// ApplyWithInventory to pass it explicitly.
 func (a *Applier) Run(ctx context.Context, objects []*resource.Info, options Options) <-chan event.Event {
-	eventChannel := make(chan event.Event)
+	resources, invs := splitInfos(objects)
+	if len(invs) != 1 {
+		// Let ApplyWithInventory report the missing or
+		// multiple inventory object templates.
+		return a.ApplyWithInventory(ctx, objects, nil, options)
+	}
+	return a.ApplyWithInventory(ctx, resources, invs[0], options)
+}
+
+// ApplyWithInventory works like Run, but takes the inventory object
+// template as a separate parameter instead of finding it among the
+// resources. The infos must not contain an inventory object template.
+// The invInfo can be nil if the InventoryName option is set.
+func (a *Applier) ApplyWithInventory(ctx context.Context, infos []*resource.Info, invInfo *resource.Info,
+	options Options) <-chan event.Event {
 	setDefaults(&options)
+	eventChannel := make(chan event.Event, eventChannelBufferSize(options.EventChannelBufferSize))
+
+	objects := infos
+	if invInfo != nil {
+		objects = append([]*resource.Info{invInfo}, infos...)
+	}
 
 	go func() {
 		defer close(eventChannel)
 
-		// This provides us with a slice of all the objects that will be
-		// applied to the cluster. This takes care of ordering resources
-		// and handling the inventory object.
-		resourceObjects, err := a.prepareObjects(objects)
+		if invInfo != nil {
+			if u, _ := invInfo.Object.(*unstructured.Unstructured); !common.IsInventoryObject(u) {
+				handleError(eventChannel, inventory.NoInventoryObjError{})
+				return
+			}
+		}
+
+		if options.RevisionHistoryLimit < 0 || options.RevisionHistoryLimit > inventory.MaxRevisionHistoryLimit {
+			handleError(eventChannel, fmt.Errorf("revision history limit must be between 0 and %d",
+				inventory.MaxRevisionHistoryLimit))
+			return
+		}
+
+		if options.MinimumResourceVersion != "" {
+			if err := a.checkServerVersion(options.MinimumResourceVersion); err != nil {
+				handleError(eventChannel, err)
+				return
+			}
+		}
+
+		if options.ValidateSchema {
+			if !a.ApplyOptions.ServerSideApply {
+				handleError(eventChannel, fmt.Errorf("schema validation requires server-side apply"))
+				return
+			}
+			// The resources are only validated, so nothing is
+			// changed in the cluster.
+			options.DryRun = true
+			options.NoPrune = true
+		}
+
+		waitForConditions, err := parseWaitForConditions(options.WaitForCondition)
 		if err != nil {
 			handleError(eventChannel, err)
 			return
 		}
 
+		if len(options.AnnotationsTrimList) > 0 {
+			objects, err = (&manifestreader.AnnotationTrimTransformer{
+				Annotations: options.AnnotationsTrimList,
+			}).Transform(objects)
+			if err != nil {
+				handleError(eventChannel, err)
+				return
+			}
+		}
+
 		mapper, err := a.factory.ToRESTMapper()
 		if err != nil {
 			handleError(eventChannel, err)
 			return
 		}
 
+		objects = manageClusterScoped(eventChannel, objects, mapper, &options)
+
+		if options.MaxResourceSize > 0 {
+			tooLarge, err := resourcesTooLarge(objects, options.MaxResourceSize)
+			if err != nil {
+				handleError(eventChannel, err)
+				return
+			}
+			if len(tooLarge) > 0 {
+				for _, e := range tooLarge {
+					eventChannel <- event.Event{
+						Type:                  event.ResourceTooLargeType,
+						ResourceTooLargeEvent: e,
+					}
+				}
+				handleError(eventChannel, fmt.Errorf("%d resource(s) exceed the maximum size of %d bytes",
+					len(tooLarge), options.MaxResourceSize))
+				return
+			}
+		}
+
+		// This provides us with a slice of all the objects that will be
+		// applied to the cluster. This takes care of ordering resources
+		// and handling the inventory object.
+		a.logger.Info("Reading inventory", "objects", len(objects))
+		resourceObjects, err := a.prepareObjects(objects, options)
+		if err != nil {
+			a.logger.Error(err, "Failed to read inventory")
+			handleError(eventChannel, err)
+			return
+		}
+		a.logger.Info("Read inventory",
+			"inventory", resourceObjects.CurrentInventory.Name,
+			"namespace", resourceObjects.CurrentInventory.Namespace,
+			"previousInventories", len(resourceObjects.PreviousInventories))
+
 		// Fetch the queue (channel) of tasks that should be executed.
 		taskQueue := (&solver.TaskQueueSolver{
 			ApplyOptions: a.ApplyOptions,
 			PruneOptions: a.PruneOptions,
 			InfoHelper:   a.infoHelperFactoryFunc(),
 			Mapper:       mapper,
+			Logger:       a.logger,
 		}).BuildTaskQueue(resourceObjects, solver.Options{
 			ReconcileTimeout:       options.ReconcileTimeout,
 			Prune:                  !options.NoPrune,
 			DryRun:                 options.DryRun,
 			PrunePropagationPolicy: options.PrunePropagationPolicy,
 			PruneTimeout:           options.PruneTimeout,
+			PruneUnusedNamespaces:  options.PruneUnusedNamespaces,
+			PruneNamespaceScoped:   options.PruneNamespaceScoped,
+			PruneContinueOnError:   options.PruneContinueOnError,
+			PruneForceDelete:       options.PruneForceDelete,
+			NoPruneAnnotationKey:   options.NoPruneAnnotationKey,
+			NoPruneAnnotationValue: options.NoPruneAnnotationValue,
+			GarbageCollect:         options.GarbageCollect,
+			ShowDiff:               options.ShowDiff,
+			SkipInventoryUpdate:    options.SkipInventoryUpdate,
+			OwnerReferencePolicy:   options.OwnerReferencePolicy,
+			IgnoreNotFound:         options.IgnoreNotFound,
+			MaxRetries:             options.MaxRetries,
+			ContinueOnError:        options.ContinueOnError,
+			WaitForConditions:      waitForConditions,
+			HealthPolicy:           options.HealthPolicy,
+			FetchPreviousObject:    options.FetchPreviousObject,
+			WaitForJobs:            options.WaitForJobs,
+			JobTimeout:             options.JobTimeout,
+			LabelMutationPolicy:    options.LabelMutationPolicy,
+			ResourceStrategy:       options.ResourceStrategy,
+
+			PrunePropagationPolicyMap: options.PrunePropagationPolicyMap,
+			PostApplyStatusCheck:      options.PostApplyStatusCheck,
+			ServerDryRun:              options.ValidateSchema,
 		})
 
 		// Send event to inform the caller about the resources that
 		// will be applied/pruned.
+		pruneIds := pruneCandidates(resourceObjects.IdsForPrune(), options)
 		eventChannel <- event.Event{
 			Type: event.InitType,
 			InitEvent: event.InitEvent{
@@ -320,32 +661,237 @@ func (a *Applier) Run(ctx context.Context, objects []*resource.Info, options Opt
 					},
 					{
 						Action:      event.PruneAction,
-						Identifiers: resourceObjects.IdsForPrune(),
+						Identifiers: pruneIds,
 					},
 				},
 			},
 		}
 
 		// Create a new TaskStatusRunner to execute the taskQueue.
+		a.logger.Info("Applying resources",
+			"apply", len(resourceObjects.IdsForApply()),
+			"prune", len(pruneIds),
+			"dryRun", options.DryRun)
 		runner := taskrunner.NewTaskStatusRunner(resourceObjects.AllIds(), a.StatusPoller)
 		err = runner.Run(ctx, taskQueue, eventChannel, taskrunner.Options{
-			PollInterval:     options.PollInterval,
-			UseCache:         true,
-			EmitStatusEvents: options.EmitStatusEvents,
+			PollInterval:      options.PollInterval,
+			UseCache:          true,
+			EmitStatusEvents:  options.EmitStatusEvents || options.PostApplyStatusCheck,
+			ContinueOnTimeout: options.TimeoutBehavior == TimeoutContinue,
+			WaitForJobs:       options.WaitForJobs,
 		})
 		if err != nil {
+			a.logger.Error(err, "Failed to apply resources")
 			handleError(eventChannel, err)
+			return
+		}
+		if !options.DryRun {
+			// The inventory object is left untouched if the inventory
+			// is not updated.
+			if !options.SkipInventoryUpdate {
+				a.logger.Info("Updating inventory",
+					"inventory", resourceObjects.CurrentInventory.Name,
+					"namespace", resourceObjects.CurrentInventory.Namespace)
+				err = a.updateInventory(resourceObjects, mapper, options)
+				if err != nil {
+					a.logger.Error(err, "Failed to update inventory")
+					handleError(eventChannel, err)
+					return
+				}
+				a.logger.Info("Updated inventory")
+			}
+			if options.RevisionHistoryLimit > 0 {
+				err = a.saveHistory(objects, resourceObjects.Resources, options.RevisionHistoryLimit)
+				if err != nil {
+					handleError(eventChannel, err)
+					return
+				}
+			}
+		}
+		a.logger.Info("Applied resources")
+	}()
+	return a.publishEvents(eventChannel, options)
+}
+
+// publishEvents publishes the events from the channel on the EventBus
+// of the Applier before passing them on to the returned channel. The
+// channel is returned as is if no EventBus is set.
+func (a *Applier) publishEvents(ch <-chan event.Event, options Options) <-chan event.Event {
+	if a.eventBus == nil {
+		return ch
+	}
+	out := make(chan event.Event, eventChannelBufferSize(options.EventChannelBufferSize))
+	go func() {
+		defer close(out)
+		for e := range ch {
+			a.eventBus.Publish(e)
+			out <- e
 		}
 	}()
-	return eventChannel
+	return out
+}
+
+// updateInventory records the apply time, the resource versions and,
+// if enabled, the resource history in the current inventory object
+// after the resources have been applied.
+func (a *Applier) updateInventory(resourceObjects *ResourceObjects, mapper meta.RESTMapper, options Options) error {
+	if err := a.recordApplyTime(resourceObjects.CurrentInventory, mapper, time.Now()); err != nil {
+		return err
+	}
+	if err := a.recordResourceVersions(resourceObjects, mapper); err != nil {
+		return err
+	}
+	if options.MaxResourceHistory > 0 {
+		return a.recordResourceHistory(resourceObjects, mapper, options.MaxResourceHistory)
+	}
+	return nil
+}
+
+// RunWithCallback performs the Apply step like Run, but blocks until
+// the operation has completed. The onEvent function is invoked for
+// every event in the order they are emitted. The error from the first
+// error event is returned, or nil if the apply succeeded.
+func (a *Applier) RunWithCallback(ctx context.Context, objects []*resource.Info, options Options,
+	onEvent func(event.Event)) error {
+	var err error
+	for e := range a.Run(ctx, objects, options) {
+		if e.Type == event.ErrorType && err == nil {
+			err = e.ErrorEvent.Err
+		}
+		if onEvent != nil {
+			onEvent(e)
+		}
+	}
+	return err
 }
 
+// Destroy deletes all resources tracked by the provided inventory object
+// from the cluster, as well as the inventory object itself. This is the
+// library equivalent of the destroy command and doesn't require any
+// manifests other than the inventory object. Only the DryRun,
+// PrunePropagationPolicy, PrunePropagationPolicyMap, PruneUnusedNamespaces,
+// PruneContinueOnError, PruneForceDelete, NoPruneAnnotationKey and
+// NoPruneAnnotationValue fields of the options are used, together with
+// the PruneOptions of the Applier. Progress is
+// reported as Delete events on the returned channel. The Applier must
+// have been initialized.
+func (a *Applier) Destroy(ctx context.Context, inventoryObject *resource.Info,
+	options Options) (<-chan event.Event, error) {
+	if a.invClient == nil {
+		return nil, fmt.Errorf("applier has not been initialized")
+	}
+	if inventoryObject == nil {
+		return nil, inventory.NoInventoryObjError{}
+	}
+	if u, _ := inventoryObject.Object.(*unstructured.Unstructured); !common.IsInventoryObject(u) {
+		return nil, inventory.NoInventoryObjError{}
+	}
+	setDefaults(&options)
+
+	// The inventory client caches the inventory objects it has read,
+	// so a new one is needed to read the inventory being destroyed.
+	invClient, err := a.InventoryClientFactoryFunc(a.factory)
+	if err != nil {
+		return nil, err
+	}
+	// Copy the PruneOptions with an empty set of UIDs, so every object
+	// in the inventory is pruned.
+	pruneOptions := a.PruneOptions.Copy(sets.NewString())
+	if err := pruneOptions.Initialize(a.factory, invClient); err != nil {
+		return nil, errors.WrapPrefix(err, "error setting up PruneOptions", 1)
+	}
+
+	// Work on a copy of the inventory object, since the inventory
+	// is cleared before pruning.
+	inv := *inventoryObject
+	inv.Object = inventoryObject.Object.DeepCopyObject()
+	infos := []*resource.Info{&inv}
+	if err := inventory.ClearInventoryObj(infos); err != nil {
+		return nil, err
+	}
+
+	eventChannel := make(chan event.Event)
+	go func() {
+		defer close(eventChannel)
+		if err := ctx.Err(); err != nil {
+			handleError(eventChannel, err)
+			return
+		}
+		runDestroy(eventChannel, pruneOptions, infos, prune.Options{
+			DryRun:                 options.DryRun,
+			PropagationPolicy:      options.PrunePropagationPolicy,
+			PropagationPolicyMap:   options.PrunePropagationPolicyMap,
+			PruneUnusedNamespaces:  options.PruneUnusedNamespaces,
+			ContinueOnError:        options.PruneContinueOnError,
+			ForceDelete:            options.PruneForceDelete,
+			NoPruneAnnotationKey:   options.NoPruneAnnotationKey,
+			NoPruneAnnotationValue: options.NoPruneAnnotationValue,
+		})
+	}()
+	return eventChannel, nil
+}
+
+// DefaultMaxResourceSize is the recommended value for the
+// MaxResourceSize option. It matches the default maximum size of a
+// request to the API server.
+const DefaultMaxResourceSize int64 = 1024 * 1024
+
+// DefaultEventChannelBufferSize is the buffer size of the event
+// channel returned by Run if the EventChannelBufferSize option is not
+// set.
+const DefaultEventChannelBufferSize = 100
+
+// UnbufferedEventChannel can be used as the EventChannelBufferSize to
+// make Run return an unbuffered event channel.
+const UnbufferedEventChannel = -1
+
+// InfinitePruneTimeout can be used as the PruneTimeout to wait for
+// all pruned resources to be deleted until the context is cancelled.
+const InfinitePruneTimeout = -1 * time.Second
+
+// NoWaitForReconcile can be used as the ReconcileTimeout to not wait
+// for the applied resources to be reconciled. It is the default.
+const NoWaitForReconcile = time.Duration(0)
+
+// WaitForeverReconcile can be used as the ReconcileTimeout to wait for
+// all applied resources to be reconciled until the context is
+// cancelled.
+const WaitForeverReconcile = -1 * time.Second
+
+// DefaultJobTimeout is the JobTimeout if none is set.
+const DefaultJobTimeout = time.Hour
+
+// WaitForeverJobs can be used as the JobTimeout to wait for the Jobs
+// to complete or fail until the context is cancelled.
+const WaitForeverJobs = -1 * time.Second
+
+// TimeoutBehavior defines what the applier should do if waiting for
+// resources to reach the desired status times out.
+type TimeoutBehavior int
+
+const (
+	// TimeoutFail means that the applier stops and returns a
+	// TimeoutError on the event channel. This is the default.
+	TimeoutFail TimeoutBehavior = iota
+	// TimeoutContinue means that the applier emits a TimeoutEvent for
+	// each resource that didn't reach the desired status and then
+	// continues with the remaining tasks.
+	TimeoutContinue
+)
+
 type Options struct {
 	// ReconcileTimeout defines whether the applier should wait
 	// until all applied resources have been reconciled, and if so,
-	// how long to wait.
+	// how long to wait. The default of NoWaitForReconcile doesn't
+	// wait, and WaitForeverReconcile, or any other negative value,
+	// waits until the context is cancelled.
 	ReconcileTimeout time.Duration
 
+	// TimeoutBehavior defines whether the applier should fail or
+	// continue if the ReconcileTimeout or PruneTimeout is reached.
+	// The default is TimeoutFail.
+	TimeoutBehavior TimeoutBehavior
+
 	// PollInterval defines how often we should poll for the status
 	// of resources.
 	PollInterval time.Duration
@@ -367,10 +913,247 @@ type Options struct {
 	// default is to use the Background policy.
 	PrunePropagationPolicy metav1.DeletionPropagation
 
+	// PrunePropagationPolicyMap defines the deletion propagation policy
+	// for objects of specific kinds, like Foreground for Namespaces. It
+	// takes precedence over the PrunePropagationPolicy for the kinds in
+	// the map.
+	PrunePropagationPolicyMap map[schema.GroupKind]metav1.DeletionPropagation
+
 	// PruneTimeout defines whether we should wait for all resources
 	// to be fully deleted after pruning, and if so, how long we should
-	// wait.
+	// wait. A zero value means we don't wait, while InfinitePruneTimeout
+	// means we wait until the context is cancelled.
 	PruneTimeout time.Duration
+
+	// PruneUnusedNamespaces defines whether namespaces should be
+	// pruned if none of the objects known by the inventory remain
+	// in them after pruning. Only namespaces labeled with the inventory
+	// id of the inventory object are pruned, and never the default and
+	// kube-* namespaces.
+	PruneUnusedNamespaces bool
+
+	// PruneNamespaceScoped defines whether pruning should be restricted
+	// to namespaced resources, so cluster-scoped resources are never
+	// pruned.
+	PruneNamespaceScoped bool
+
+	// PruneContinueOnError defines whether pruning should continue with
+	// the remaining objects if deleting one of them fails. The errors
+	// are reported once pruning has finished.
+	PruneContinueOnError bool
+
+	// PruneForceDelete defines whether pruned objects should be
+	// deleted even if their lifecycle annotation says they should be
+	// kept or detached.
+	PruneForceDelete bool
+
+	// NoPruneAnnotationKey and NoPruneAnnotationValue override the
+	// annotation which prevents resources from being pruned, for
+	// teams using their own annotation. If either is set, resources
+	// with the annotation set to the value are kept, and the
+	// "on-remove" annotation is ignored. They default to the
+	// "on-remove" annotation and "keep".
+	NoPruneAnnotationKey   string
+	NoPruneAnnotationValue string
+
+	// RevisionHistoryLimit defines how many snapshots of the applied
+	// resources should be kept in history ConfigMaps next to the
+	// inventory object, so a previous apply can be rolled back. A
+	// snapshot is stored after every successful apply. The default of
+	// 0 disables history, and the maximum is
+	// inventory.MaxRevisionHistoryLimit.
+	RevisionHistoryLimit int
+
+	// MaxResourceHistory defines how many of the last applied
+	// revisions of every resource should be kept on the inventory
+	// object, so a single resource can be rolled back. The oldest
+	// revisions are dropped if the history would exceed
+	// inventory.MaxResourceHistorySize. It is ignored if the
+	// inventory is not updated.
+	// The default of 0 disables the resource history, and the maximum
+	// is inventory.MaxRevisionHistoryLimit.
+	MaxResourceHistory int
+
+	// InventoryAnnotations are added to the annotations of the
+	// inventory object, for example to record the commit or the user
+	// the apply was done from. They are merged with the annotations of
+	// the inventory object template.
+	InventoryAnnotations map[string]string
+
+	// PatchLabelSelector restricts ApplyPatch to the resources whose
+	// labels match the selector. All tracked resources are patched if
+	// it is empty. It is not used by Run.
+	PatchLabelSelector string
+
+	// MinimumResourceVersion is the minimum Kubernetes version of the
+	// API server, like "1.25", for resources or behaviors which
+	// require it. If the server is older, the apply fails before
+	// anything is changed in the cluster.
+	MinimumResourceVersion string
+
+	// ValidateSchema defines whether the resources should only be
+	// validated by the API server, against the schema of their types,
+	// instead of being applied. The resources are sent with
+	// server-side apply and dryRun=All, so nothing is persisted and
+	// nothing is pruned. Admission webhooks are still called by the
+	// API server, if they support dry-run. Requires server-side apply.
+	ValidateSchema bool
+
+	// GarbageCollect defines whether resources in the inventory whose
+	// type is no longer known by the API server, for example since the
+	// CRD has been removed, should be dropped from the inventory during
+	// prune. If false, pruning fails for such resources.
+	GarbageCollect bool
+
+	// ShowDiff defines whether the diff between the live state and the
+	// desired state should be included in the apply events. This is only
+	// supported during dry-run.
+	ShowDiff bool
+
+	// SkipInventoryUpdate defines whether the inventory should be left
+	// untouched in the cluster. If true, the inventory object is not
+	// applied and previous inventory objects are not deleted during
+	// prune. Events are emitted as usual.
+	SkipInventoryUpdate bool
+
+	// MaxResourceSize defines the maximum size in bytes of the JSON
+	// representation of each resource. If any resource exceeds it, a
+	// ResourceTooLarge event is emitted for it and the apply fails
+	// before making any changes in the cluster. A zero value means
+	// there is no limit. DefaultMaxResourceSize is a sensible value.
+	MaxResourceSize int64
+
+	// WaitForCondition maps resource kinds to a status condition
+	// expression, like "type=Ready,status=True". When waiting for the
+	// applied resources to reconcile, resources of those kinds are
+	// waited on until they have the condition, instead of until they
+	// have the Current status. The expression for the empty GroupKind
+	// applies to all kinds without their own entry, except for the
+	// inventory object.
+	WaitForCondition map[schema.GroupKind]string
+
+	// IgnoreNotFound defines whether NotFound errors from the API server
+	// when applying a resource should be ignored. If true, a NotFound
+	// event is emitted for the resource instead of an error event, and
+	// the apply continues with the other resources.
+	IgnoreNotFound bool
+
+	// MaxRetries defines how many times applying a resource is retried
+	// if it fails with a transient error, like a server timeout, too
+	// many requests, a conflict or an internal server error. Waiting
+	// for a retry stops when the context is cancelled. If it is more
+	// than zero, the resources are applied one at a time. If all
+	// retries fail, the error event has Retried set.
+	MaxRetries int
+
+	// ContinueOnError defines whether the apply should continue with
+	// the other resources if applying a resource fails. An ApplyFailed
+	// event is emitted for each resource that failed instead of an
+	// error event, and a final Summary event lists the resources that
+	// were applied and those that failed. Failed resources are neither
+	// waited for nor pruned.
+	ContinueOnError bool
+
+	// GenerateName defines whether resources that only have
+	// metadata.generateName set should be supported. Such resources get
+	// a generated name on the first apply, and later applies update the
+	// resource with the generated name stored in the inventory. The
+	// generateName is recorded in the GenerateNameAnnotation, so only
+	// resources whose name was generated are updated this way.
+	GenerateName bool
+
+	// OwnerReferencePolicy defines whether an owner reference to the
+	// inventory object should be added to the applied resources. The
+	// default is OwnerRefNone.
+	OwnerReferencePolicy common.OwnerRefPolicy
+
+	// InventoryName defines the name of the inventory object. If set,
+	// the inventory object is created from it and InventoryNamespace
+	// instead of from an inventory object template in the manifests,
+	// and the manifests must not contain one.
+	InventoryName string
+
+	// InventoryNamespace defines the namespace of the inventory object
+	// when InventoryName is set. If empty, the default namespace is
+	// used.
+	InventoryNamespace string
+
+	// EventChannelBufferSize defines the buffer size of the event
+	// channel returned by Run. A larger buffer lets the apply continue
+	// while the caller is slow to consume events. If not set,
+	// DefaultEventChannelBufferSize is used. UnbufferedEventChannel
+	// makes the channel unbuffered.
+	EventChannelBufferSize int
+
+	// HealthPolicy defines whether the applied resources must be
+	// reconciled before the ReconcileTimeout is reached. With
+	// HealthPolicyOptional, a TimeoutEvent is emitted for resources
+	// that haven't been reconciled instead of failing. It can be
+	// overridden for a single resource with the health policy
+	// annotation. The default is HealthPolicyRequired.
+	HealthPolicy common.HealthPolicy
+
+	// FetchPreviousObject defines whether the live state of each
+	// resource should be fetched before it is applied, and included
+	// as the Previous object in the apply events. This requires an
+	// extra GET call for every resource.
+	FetchPreviousObject bool
+
+	// WaitForJobs defines whether the apply should wait until all the
+	// applied Jobs have completed successfully. Jobs are waited on
+	// with the JobTimeout instead of the ReconcileTimeout, and a Job
+	// that fails, including by exceeding its
+	// spec.activeDeadlineSeconds, emits a StatusFailedEvent and fails
+	// the apply.
+	WaitForJobs bool
+
+	// JobTimeout defines how long to wait for the Jobs to complete
+	// with WaitForJobs. A zero value means DefaultJobTimeout, and
+	// WaitForeverJobs waits until the Jobs complete or fail, or the
+	// context is cancelled.
+	JobTimeout time.Duration
+
+	// LabelMutationPolicy defines how the apply may change the labels
+	// of resources that already exist in the cluster, for resources
+	// whose controllers react to label changes. The default is
+	// LabelMutationAllowAll.
+	LabelMutationPolicy common.LabelMutationPolicy
+
+	// AnnotationsTrimList contains the keys of annotations that are
+	// removed from all resources before they are applied, like
+	// annotations added by other tools to exported manifests.
+	AnnotationsTrimList []string
+
+	// ResourceStrategy defines how resources that already exist in the
+	// cluster are updated. The default is StrategyMerge, a three-way
+	// strategic merge patch. StrategyReplace replaces the resources
+	// with an update instead, except during dry-run.
+	ResourceStrategy common.ResourceStrategy
+
+	// ManageClusterScopedResources defines whether cluster-scoped
+	// resources, like Namespaces and ClusterRoles, should be applied
+	// and pruned. Since they affect the whole cluster, they are
+	// skipped if it is false, and a SkipEvent is emitted for each of
+	// them. If it is nil, they are still applied and pruned, but a
+	// deprecation warning is logged, since they will be skipped by
+	// default in a future release.
+	ManageClusterScopedResources *bool
+
+	// InventoryFormat defines the format of the inventory object. The
+	// v2 format also records the resource version of every applied
+	// resource, so pruning can warn about resources that were modified
+	// since the last apply. If not set, the format of the existing
+	// inventory object is kept, or v1 is used for a new one.
+	InventoryFormat inventory.InventoryFormat
+
+	// PostApplyStatusCheck defines whether the applier should check
+	// the status of the applied resources once after they have been
+	// applied, when there is no ReconcileTimeout. It waits up to 10
+	// seconds for the status of every resource to be computed, and
+	// emits status events, so immediate failures like a Pod in
+	// CrashLoopBackOff are reported. Resources that are still in
+	// progress don't fail the apply.
+	PostApplyStatusCheck bool
 }
 
 // setDefaults set the options to the default values if they
@@ -382,15 +1165,133 @@ func setDefaults(o *Options) {
 	if o.PrunePropagationPolicy == metav1.DeletionPropagation("") {
 		o.PrunePropagationPolicy = metav1.DeletePropagationBackground
 	}
+	if o.EventChannelBufferSize == 0 {
+		o.EventChannelBufferSize = DefaultEventChannelBufferSize
+	}
+	if o.JobTimeout == time.Duration(0) {
+		o.JobTimeout = DefaultJobTimeout
+	}
+}
+
+// eventChannelBufferSize returns the buffer size for the event channel
+// for the EventChannelBufferSize option.
+func eventChannelBufferSize(size int) int {
+	if size < 0 {
+		return 0
+	}
+	return size
 }
 
 func handleError(eventChannel chan event.Event, err error) {
+	errorEvent := event.ErrorEvent{
+		Err: err,
+	}
+	if retryErr, ok := taskrunner.IsRetriesExhaustedError(err); ok {
+		errorEvent.Retried = true
+		errorEvent.RetryCount = retryErr.RetryCount
+	}
 	eventChannel <- event.Event{
-		Type: event.ErrorType,
-		ErrorEvent: event.ErrorEvent{
-			Err: err,
-		},
+		Type:       event.ErrorType,
+		ErrorEvent: errorEvent,
+	}
+}
+
+// parseWaitForConditions parses the condition expressions of the
+// WaitForCondition option.
+func parseWaitForConditions(exprs map[schema.GroupKind]string) (map[schema.GroupKind]taskrunner.StatusCondition, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	conditions := make(map[schema.GroupKind]taskrunner.StatusCondition, len(exprs))
+	for gk, expr := range exprs {
+		sc, err := taskrunner.ParseStatusCondition(expr)
+		if err != nil {
+			return nil, err
+		}
+		conditions[gk] = sc
+	}
+	return conditions, nil
+}
+
+// resourcesTooLarge returns an event for each of the resources whose
+// JSON representation is larger than maxSize bytes.
+func resourcesTooLarge(infos []*resource.Info, maxSize int64) ([]event.ResourceTooLargeEvent, error) {
+	var tooLarge []event.ResourceTooLargeEvent
+	for _, info := range infos {
+		data, err := json.Marshal(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		if size := int64(len(data)); size > maxSize {
+			tooLarge = append(tooLarge, event.ResourceTooLargeEvent{
+				Object:  info.Object,
+				Size:    size,
+				MaxSize: maxSize,
+			})
+		}
+	}
+	return tooLarge, nil
+}
+
+// manageClusterScoped returns the resources to apply according to the
+// ManageClusterScopedResources option. If cluster-scoped resources are
+// not managed, a SkipEvent is sent for each of them and pruning is
+// restricted to namespace-scoped resources.
+func manageClusterScoped(eventChannel chan<- event.Event, objects []*resource.Info,
+	mapper meta.RESTMapper, options *Options) []*resource.Info {
+	if options.ManageClusterScopedResources != nil && *options.ManageClusterScopedResources {
+		return objects
+	}
+	namespaced, clusterScoped := splitClusterScoped(objects, mapper)
+	if options.ManageClusterScopedResources == nil {
+		if len(clusterScoped) > 0 {
+			klog.Warningf("%d cluster-scoped resources will not be applied by default in a future release; "+
+				"set ManageClusterScopedResources to keep applying them", len(clusterScoped))
+		}
+		return objects
+	}
+	for _, info := range clusterScoped {
+		eventChannel <- event.Event{
+			Type: event.SkipType,
+			SkipEvent: event.SkipEvent{
+				Object: info.Object,
+				Reason: event.ClusterScopedNotManagedReason,
+			},
+		}
+	}
+	// Cluster-scoped resources are not pruned either, since they are
+	// not managed. The ones tracked by the previous inventory objects
+	// stay tracked by them.
+	options.PruneNamespaceScoped = true
+	return namespaced
+}
+
+// splitClusterScoped splits the infos into the namespace-scoped and
+// the cluster-scoped resources. Resources whose type is unknown, like
+// custom resources whose CRD is applied together with them, are
+// considered namespace-scoped.
+func splitClusterScoped(infos []*resource.Info, mapper meta.RESTMapper) ([]*resource.Info, []*resource.Info) {
+	var namespaced, clusterScoped []*resource.Info
+	for _, info := range infos {
+		if isClusterScoped(info, mapper) {
+			clusterScoped = append(clusterScoped, info)
+		} else {
+			namespaced = append(namespaced, info)
+		}
+	}
+	return namespaced, clusterScoped
+}
+
+func isClusterScoped(info *resource.Info, mapper meta.RESTMapper) bool {
+	if info.Mapping != nil {
+		return info.Mapping.Scope.Name() == meta.RESTScopeNameRoot
+	}
+	gvk := info.Object.GetObjectKind().GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false
 	}
+	return mapping.Scope.Name() == meta.RESTScopeNameRoot
 }
 
 // validateNamespace returns true if all the objects in the passed
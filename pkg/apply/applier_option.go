@@ -0,0 +1,55 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+// ApplierOption configures an Applier created by NewApplierWithOptions.
+type ApplierOption func(*Applier)
+
+// WithLogger sets the logger used for structured logging by the
+// applier. See SetLogger.
+func WithLogger(logger logr.Logger) ApplierOption {
+	return func(a *Applier) {
+		a.SetLogger(logger)
+	}
+}
+
+// WithDynamicClient sets the dynamic client used for all API calls
+// made by the applier. See SetDynamicClient.
+func WithDynamicClient(client dynamic.Interface) ApplierOption {
+	return func(a *Applier) {
+		a.SetDynamicClient(client)
+	}
+}
+
+// WithRESTMapper sets the RESTMapper used by the applier. See
+// SetRESTMapper.
+func WithRESTMapper(mapper meta.RESTMapper) ApplierOption {
+	return func(a *Applier) {
+		a.SetRESTMapper(mapper)
+	}
+}
+
+// WithDiscoveryClient sets the discovery client used by the applier.
+// See SetDiscoveryClient.
+func WithDiscoveryClient(client discovery.CachedDiscoveryInterface) ApplierOption {
+	return func(a *Applier) {
+		a.SetDiscoveryClient(client)
+	}
+}
+
+// WithEventBus sets the EventBus the events of every apply are
+// published on. See SetEventBus.
+func WithEventBus(bus *event.EventBus) ApplierOption {
+	return func(a *Applier) {
+		a.SetEventBus(bus)
+	}
+}
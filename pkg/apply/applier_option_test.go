@@ -0,0 +1,83 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+func TestNewApplierWithOptions(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	mapper, err := tf.ToRESTMapper()
+	if !assert.NoError(t, err) {
+		return
+	}
+	discoveryClient, err := tf.ToDiscoveryClient()
+	if !assert.NoError(t, err) {
+		return
+	}
+	client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+	logger := &recordingLogger{}
+	bus := event.NewEventBus()
+
+	testCases := map[string]struct {
+		option ApplierOption
+		check  func(t *testing.T, applier *Applier)
+	}{
+		"WithLogger": {
+			option: WithLogger(logger),
+			check: func(t *testing.T, applier *Applier) {
+				assert.True(t, applier.logger == logger, "expected the applier logger")
+				assert.True(t, applier.PruneOptions.Logger == logger, "expected the prune logger")
+			},
+		},
+		"WithDynamicClient": {
+			option: WithDynamicClient(client),
+			check: func(t *testing.T, applier *Applier) {
+				dynamicClient, err := applier.factory.DynamicClient()
+				assert.NoError(t, err)
+				assert.True(t, dynamicClient == client, "expected the injected dynamic client")
+			},
+		},
+		"WithRESTMapper": {
+			option: WithRESTMapper(mapper),
+			check: func(t *testing.T, applier *Applier) {
+				m, err := applier.factory.ToRESTMapper()
+				assert.NoError(t, err)
+				assert.True(t, m == mapper, "expected the injected RESTMapper")
+			},
+		},
+		"WithEventBus": {
+			option: WithEventBus(bus),
+			check: func(t *testing.T, applier *Applier) {
+				assert.True(t, applier.eventBus == bus, "expected the event bus")
+			},
+		},
+		"WithDiscoveryClient": {
+			option: WithDiscoveryClient(discoveryClient),
+			check: func(t *testing.T, applier *Applier) {
+				d, err := applier.factory.ToDiscoveryClient()
+				assert.NoError(t, err)
+				assert.True(t, d == discoveryClient, "expected the injected discovery client")
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplierWithOptions(tf, ioStreams, tc.option)
+			tc.check(t, applier)
+		})
+	}
+}
@@ -11,26 +11,37 @@ import (
 	"net/http"
 	"path"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr"
+	openapi_v2 "github.com/googleapis/gnostic/OpenAPIv2"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/discovery"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/rest/fake"
 	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/apply/info"
+	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/cli-utils/pkg/inventory"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling"
@@ -66,6 +77,21 @@ var (
 			basePath:    "/namespaces/%s/deployments",
 			factoryFunc: func() runtime.Object { return &appsv1.Deployment{} },
 		},
+		"pod": {
+			manifest: `
+  kind: Pod
+  apiVersion: v1
+  metadata:
+    name: foo
+    namespace: default
+  spec:
+    containers:
+    - name: app
+      image: app
+`,
+			basePath:    "/namespaces/%s/pods",
+			factoryFunc: func() runtime.Object { return &v1.Pod{} },
+		},
 	}
 )
 
@@ -91,6 +117,8 @@ type expectedEvent struct {
 func TestApplier(t *testing.T) {
 	testCases := map[string]struct {
 		namespace          string
+		inventoryName      string
+		explicitInventory  bool
 		resources          []resourceInfo
 		handlers           []handler
 		reconcileTimeout   time.Duration
@@ -132,6 +160,75 @@ func TestApplier(t *testing.T) {
 				},
 			},
 		},
+		"apply with inventory object from options": {
+			namespace:     "default",
+			inventoryName: "foo",
+			resources: []resourceInfo{
+				resources["deployment"],
+			},
+			handlers: []handler{
+				&nsHandler{},
+				&inventoryObjectHandler{},
+				&genericHandler{
+					resourceInfo: resources["deployment"],
+					namespace:    "default",
+				},
+			},
+			reconcileTimeout: time.Duration(0),
+			prune:            false,
+			expectedEventTypes: []expectedEvent{
+				{
+					eventType: event.InitType,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventResourceUpdate,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventResourceUpdate,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventCompleted,
+				},
+			},
+		},
+		"apply with explicit inventory object": {
+			namespace:         "default",
+			explicitInventory: true,
+			resources: []resourceInfo{
+				resources["deployment"],
+				resources["inventoryObject"],
+			},
+			handlers: []handler{
+				&nsHandler{},
+				&inventoryObjectHandler{},
+				&genericHandler{
+					resourceInfo: resources["deployment"],
+					namespace:    "default",
+				},
+			},
+			reconcileTimeout: time.Duration(0),
+			prune:            false,
+			expectedEventTypes: []expectedEvent{
+				{
+					eventType: event.InitType,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventResourceUpdate,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventResourceUpdate,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventCompleted,
+				},
+			},
+		},
 		"first apply with inventory object": {
 			namespace: "default",
 			resources: []resourceInfo{
@@ -222,6 +319,7 @@ func TestApplier(t *testing.T) {
 			defer tf.Cleanup()
 
 			tf.UnstructuredClient = newFakeRESTClient(t, tc.handlers)
+			tf.FakeDynamicClient = newFakeDynamicClient(t, infos)
 
 			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
 			applier := NewApplier(tf, ioStreams)
@@ -250,12 +348,27 @@ func TestApplier(t *testing.T) {
 				}
 			}
 
+			logger := &recordingLogger{}
+			applier.SetLogger(logger)
+
 			ctx := context.Background()
-			eventChannel := applier.Run(ctx, infos, Options{
-				ReconcileTimeout: tc.reconcileTimeout,
-				EmitStatusEvents: true,
-				NoPrune:          !tc.prune,
-			})
+			options := Options{
+				ReconcileTimeout:   tc.reconcileTimeout,
+				EmitStatusEvents:   true,
+				NoPrune:            !tc.prune,
+				InventoryName:      tc.inventoryName,
+				InventoryNamespace: tc.namespace,
+			}
+			var eventChannel <-chan event.Event
+			if tc.explicitInventory {
+				resourceInfos, invs := splitInfos(infos)
+				if !assert.Len(t, invs, 1) {
+					return
+				}
+				eventChannel = applier.ApplyWithInventory(ctx, resourceInfos, invs[0], options)
+			} else {
+				eventChannel = applier.Run(ctx, infos, options)
+			}
 
 			var events []event.Event
 			for e := range eventChannel {
@@ -285,10 +398,448 @@ func TestApplier(t *testing.T) {
 					assert.Fail(t, "unexpected event type %s", expected.eventType.String())
 				}
 			}
+
+			messages := logger.messages()
+			assert.Contains(t, messages, "Reading inventory")
+			assert.Contains(t, messages, "Applying resource")
+			assert.Contains(t, messages, "Updating inventory")
+			assert.Contains(t, messages, "Applied resources")
+		})
+	}
+}
+
+func TestApplierEventBus(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	bus := event.NewEventBus()
+	var published []event.Event
+	bus.Subscribe(event.ErrorType, func(e event.Event) {
+		published = append(published, e)
+	})
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplierWithOptions(tf, ioStreams, WithEventBus(bus))
+
+	// Every apply fails before reaching the cluster, and the error event
+	// is published on the bus as well as sent on the channel.
+	for i := 1; i <= 2; i++ {
+		var received []event.Event
+		for e := range applier.ApplyWithInventory(context.Background(), nil, inventoryObjInfo, Options{
+			RevisionHistoryLimit: -1,
+		}) {
+			received = append(received, e)
+		}
+		if assert.Len(t, received, 1) {
+			assert.Equal(t, event.ErrorType, received[0].Type)
+		}
+		assert.Len(t, published, i)
+	}
+}
+
+func TestApplierRunWithCallback(t *testing.T) {
+	testCases := map[string]struct {
+		resources []resourceInfo
+
+		expectedEventTypes []event.Type
+		expectedErr        bool
+	}{
+		"successful apply": {
+			resources: []resourceInfo{
+				resources["deployment"],
+				resources["inventoryObject"],
+			},
+			expectedEventTypes: []event.Type{
+				event.InitType,
+				event.ApplyType,
+				event.ApplyType,
+				event.ApplyType,
+			},
+		},
+		"missing inventory object": {
+			resources: []resourceInfo{
+				resources["deployment"],
+			},
+			expectedEventTypes: []event.Type{
+				event.ErrorType,
+			},
+			expectedErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			infos, err := createInfos(tc.resources)
+			assert.NoError(t, err)
+
+			tf := cmdtesting.NewTestFactory().WithNamespace("default")
+			defer tf.Cleanup()
+
+			dynamicClient := newFakeDynamicClient(t, infos)
+			tf.FakeDynamicClient = dynamicClient
+			tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+				&nsHandler{},
+				&inventoryObjectHandler{},
+				&genericHandler{
+					resourceInfo: resources["deployment"],
+					namespace:    "default",
+				},
+			})
+
+			applier := newInitializedApplier(t, tf)
+
+			assert.Nil(t, applier.GetLastApplyTime())
+			assert.Nil(t, applier.GetInventoryInfo())
+
+			var eventTypes []event.Type
+			err = applier.RunWithCallback(context.Background(), infos, Options{
+				NoPrune: true,
+			}, func(e event.Event) {
+				eventTypes = append(eventTypes, e.Type)
+			})
+
+			if tc.expectedErr {
+				assert.Error(t, err)
+				assert.Nil(t, applier.GetLastApplyTime())
+			} else {
+				assert.NoError(t, err)
+				assertLastApplyTime(t, applier, dynamicClient)
+				inv := applier.GetInventoryInfo()
+				if assert.NotNil(t, inv) {
+					assert.Equal(t, "foo", inv.Name)
+					assert.Equal(t, "default", inv.Namespace)
+				}
+			}
+			assert.Equal(t, tc.expectedEventTypes, eventTypes)
+		})
+	}
+}
+
+func TestApplierAnnotationsTrimList(t *testing.T) {
+	infos, err := createInfos([]resourceInfo{
+		resources["deployment"],
+		resources["inventoryObject"],
+	})
+	assert.NoError(t, err)
+	for _, info := range infos {
+		if info.Object.GetObjectKind().GroupVersionKind().Kind != "Deployment" {
+			continue
+		}
+		acc, err := meta.Accessor(info.Object)
+		assert.NoError(t, err)
+		acc.SetAnnotations(map[string]string{
+			"helm.sh/chart":        "foo-1.0.0",
+			"example.com/preserve": "true",
+		})
+	}
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+
+	tf.FakeDynamicClient = newFakeDynamicClient(t, infos)
+	deploymentHandler := &patchRecordingHandler{
+		handler: &genericHandler{
+			resourceInfo: resources["deployment"],
+			namespace:    "default",
+		},
+	}
+	tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+		&nsHandler{},
+		&inventoryObjectHandler{},
+		deploymentHandler,
+	})
+
+	applier := newInitializedApplier(t, tf)
+
+	err = applier.RunWithCallback(context.Background(), infos, Options{
+		NoPrune:             true,
+		AnnotationsTrimList: []string{"helm.sh/chart"},
+	}, func(event.Event) {})
+	assert.NoError(t, err)
+
+	if !assert.Len(t, deploymentHandler.patches, 1) {
+		return
+	}
+	assert.NotContains(t, deploymentHandler.patches[0], "helm.sh/chart")
+	assert.Contains(t, deploymentHandler.patches[0], "example.com/preserve")
+}
+
+func TestApplierPostApplyStatusCheck(t *testing.T) {
+	infos, err := createInfos([]resourceInfo{
+		resources["pod"],
+		resources["inventoryObject"],
+	})
+	assert.NoError(t, err)
+
+	// The pod enters CrashLoopBackOff right after it has been applied.
+	crashLooping := &unstructured.Unstructured{}
+	err = runtime.DecodeInto(codec, []byte(resources["pod"].manifest), crashLooping)
+	assert.NoError(t, err)
+	err = unstructured.SetNestedField(crashLooping.Object, "Running", "status", "phase")
+	assert.NoError(t, err)
+	err = unstructured.SetNestedSlice(crashLooping.Object, []interface{}{
+		map[string]interface{}{
+			"name": "app",
+			"state": map[string]interface{}{
+				"waiting": map[string]interface{}{
+					"reason": "CrashLoopBackOff",
+				},
+			},
+		},
+	}, "status", "containerStatuses")
+	assert.NoError(t, err)
+	result, err := status.Compute(crashLooping)
+	if !assert.NoError(t, err) {
+		return
+	}
+	podID := toIdentifier(t, resources["pod"], "default")
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+
+	tf.FakeDynamicClient = newFakeDynamicClient(t, infos)
+	tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+		&nsHandler{},
+		&inventoryObjectHandler{},
+		&genericHandler{
+			resourceInfo: resources["pod"],
+			namespace:    "default",
+		},
+	})
+
+	applier := newInitializedApplier(t, tf)
+	start := make(chan struct{})
+	close(start)
+	applier.StatusPoller = &fakePoller{
+		start: start,
+		events: []pollevent.Event{
+			{
+				EventType: pollevent.ResourceUpdateEvent,
+				Resource: &pollevent.ResourceStatus{
+					Identifier: podID,
+					Status:     result.Status,
+					Message:    result.Message,
+					Resource:   crashLooping,
+				},
+			},
+		},
+	}
+
+	var statuses []status.Status
+	err = applier.RunWithCallback(context.Background(), infos, Options{
+		NoPrune:              true,
+		PostApplyStatusCheck: true,
+	}, func(e event.Event) {
+		assert.NotEqual(t, event.TimeoutType, e.Type)
+		if e.Type == event.StatusType && e.StatusEvent.Resource != nil &&
+			e.StatusEvent.Resource.Identifier == podID {
+			statuses = append(statuses, e.StatusEvent.Resource.Status)
+		}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []status.Status{status.FailedStatus}, statuses)
+}
+
+func TestApplierValidateSchema(t *testing.T) {
+	infos, err := createInfos([]resourceInfo{
+		resources["deployment"],
+		resources["inventoryObject"],
+	})
+	assert.NoError(t, err)
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+
+	tf.FakeDynamicClient = newFakeDynamicClient(t, infos)
+	validationHandler := &schemaValidationHandler{
+		resourceInfo: resources["deployment"],
+		namespace:    "default",
+	}
+	tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+		validationHandler,
+		&nsHandler{},
+		&inventoryObjectHandler{},
+		&genericHandler{
+			resourceInfo: resources["deployment"],
+			namespace:    "default",
+		},
+	})
+
+	applier := newInitializedApplier(t, tf)
+	applier.ApplyOptions.ServerSideApply = true
+	// kubectl checks in the OpenAPI schema that the types support
+	// dry-run before sending them with dryRun=All.
+	applier.ApplyOptions.DiscoveryClient = &dryRunDiscoveryClient{
+		gvks: []schema.GroupVersionKind{
+			{Version: "v1", Kind: "ConfigMap"},
+			{Group: "apps", Version: "v1", Kind: "Deployment"},
+		},
+	}
+
+	var errs []error
+	for e := range applier.Run(context.Background(), infos, Options{
+		ValidateSchema: true,
+	}) {
+		switch e.Type {
+		case event.ErrorType:
+			errs = append(errs, e.ErrorEvent.Err)
+		case event.ApplyFailedType:
+			errs = append(errs, e.ApplyFailedEvent.Err)
+		}
+	}
+
+	assert.NotZero(t, validationHandler.dryRunPatches)
+	assert.Zero(t, validationHandler.patches)
+	if !assert.Len(t, errs, 1) {
+		return
+	}
+	assert.True(t, apierrors.IsInvalid(errs[0]), "expected an Invalid error, got %v", errs[0])
+	assert.Contains(t, errs[0].Error(), `unknown field "replica"`)
+}
+
+// schemaValidationHandler acts as an API server validating resources
+// with a server-side apply dry-run. It rejects the dry-run PATCH
+// requests for its resource with an Invalid error, accepts the
+// dry-run PATCH requests for any other resource, and counts the PATCH
+// requests which are not dry-run.
+type schemaValidationHandler struct {
+	resourceInfo  resourceInfo
+	namespace     string
+	dryRunPatches int
+	patches       int
+}
+
+func (s *schemaValidationHandler) handle(t *testing.T, req *http.Request) (*http.Response, bool, error) {
+	if req.Method != http.MethodPatch {
+		return nil, false, nil
+	}
+	if req.URL.Query().Get("dryRun") != metav1.DryRunAll {
+		s.patches++
+		return nil, false, nil
+	}
+	s.dryRunPatches++
+
+	obj := &unstructured.Unstructured{}
+	err := runtime.DecodeInto(codec, []byte(s.resourceInfo.manifest), obj)
+	if err != nil {
+		return nil, false, err
+	}
+	resourcePath := path.Join(fmt.Sprintf(s.resourceInfo.basePath, s.namespace), obj.GetName())
+	if req.URL.Path == resourcePath {
+		errStatus := apierrors.NewInvalid(obj.GroupVersionKind().GroupKind(), obj.GetName(), field.ErrorList{
+			field.Invalid(field.NewPath("spec", "replica"), nil, `unknown field "replica"`),
+		}).ErrStatus
+		bodyRC := ioutil.NopCloser(bytes.NewReader(toJSONBytes(t, &errStatus)))
+		return &http.Response{StatusCode: http.StatusUnprocessableEntity, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, true, nil
+	}
+
+	// The dry-run returns the object as it would have been applied.
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	bodyRC := ioutil.NopCloser(bytes.NewReader(b))
+	return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, true, nil
+}
+
+// dryRunDiscoveryClient is a discovery client whose OpenAPI schema
+// declares that PATCH requests support dry-run for the given types.
+type dryRunDiscoveryClient struct {
+	discovery.DiscoveryInterface
+	gvks []schema.GroupVersionKind
+}
+
+func (d *dryRunDiscoveryClient) OpenAPISchema() (*openapi_v2.Document, error) {
+	paths := &openapi_v2.Paths{}
+	for _, gvk := range d.gvks {
+		paths.Path = append(paths.Path, &openapi_v2.NamedPathItem{
+			Name: gvk.String(),
+			Value: &openapi_v2.PathItem{
+				Patch: &openapi_v2.Operation{
+					Parameters: []*openapi_v2.ParametersItem{
+						{
+							Oneof: &openapi_v2.ParametersItem_Parameter{
+								Parameter: &openapi_v2.Parameter{
+									Oneof: &openapi_v2.Parameter_NonBodyParameter{
+										NonBodyParameter: &openapi_v2.NonBodyParameter{
+											Oneof: &openapi_v2.NonBodyParameter_QueryParameterSubSchema{
+												QueryParameterSubSchema: &openapi_v2.QueryParameterSubSchema{
+													Name: "dryRun",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					VendorExtension: []*openapi_v2.NamedAny{
+						{
+							Name: "x-kubernetes-group-version-kind",
+							Value: &openapi_v2.Any{
+								Yaml: fmt.Sprintf("group: %q\nversion: %q\nkind: %q\n",
+									gvk.Group, gvk.Version, gvk.Kind),
+							},
+						},
+					},
+				},
+			},
 		})
 	}
+	return &openapi_v2.Document{Paths: paths}, nil
+}
+
+// patchRecordingHandler records the bodies of the PATCH requests
+// before passing them on to the wrapped handler.
+type patchRecordingHandler struct {
+	handler handler
+	patches []string
+}
+
+func (p *patchRecordingHandler) handle(t *testing.T, req *http.Request) (*http.Response, bool, error) {
+	if req.Method == http.MethodPatch {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		p.patches = append(p.patches, string(b))
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+	return p.handler.handle(t, req)
+}
+
+// recordingLogger is a logr.Logger that records the messages
+// of all log entries.
+type recordingLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+var _ logr.Logger = &recordingLogger{}
+
+func (r *recordingLogger) record(msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+}
+
+func (r *recordingLogger) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.msgs...)
 }
 
+func (r *recordingLogger) Info(msg string, _ ...interface{}) { r.record(msg) }
+
+func (r *recordingLogger) Enabled() bool { return true }
+
+func (r *recordingLogger) Error(_ error, msg string, _ ...interface{}) { r.record(msg) }
+
+func (r *recordingLogger) V(_ int) logr.InfoLogger { return r }
+
+func (r *recordingLogger) WithValues(_ ...interface{}) logr.Logger { return r }
+
+func (r *recordingLogger) WithName(_ string) logr.Logger { return r }
+
 var namespace = "test-namespace"
 
 var inventoryObjInfo = &resource.Info{
@@ -473,12 +1024,28 @@ func TestValidateNamespace(t *testing.T) {
 func TestReadAndPrepareObjects(t *testing.T) {
 	testCases := map[string]struct {
 		resources     []*resource.Info
+		options       Options
 		expectedError bool
 	}{
 		"no inventory object": {
 			resources:     []*resource.Info{obj1Info},
 			expectedError: true,
 		},
+		"inventory object from options": {
+			resources: []*resource.Info{obj1Info, clusterScopedObjInfo},
+			options: Options{
+				InventoryName:      "test-inventory",
+				InventoryNamespace: namespace,
+			},
+			expectedError: false,
+		},
+		"inventory object from both options and manifests": {
+			resources: []*resource.Info{inventoryObjInfo, obj1Info},
+			options: Options{
+				InventoryName: "test-inventory",
+			},
+			expectedError: true,
+		},
 		"multiple inventory objects": {
 			resources:     []*resource.Info{inventoryObjInfo, inventoryObjInfo},
 			expectedError: true,
@@ -514,7 +1081,7 @@ func TestReadAndPrepareObjects(t *testing.T) {
 
 			applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
 
-			resourceObjects, err := applier.prepareObjects(tc.resources)
+			resourceObjects, err := applier.prepareObjects(tc.resources, tc.options)
 
 			if tc.expectedError {
 				if err == nil {
@@ -529,7 +1096,7 @@ func TestReadAndPrepareObjects(t *testing.T) {
 			}
 
 			inventoryObj := resourceObjects.CurrentInventory
-			if !inventory.IsInventoryObject(inventoryObj.Object) {
+			if u, _ := inventoryObj.Object.(*unstructured.Unstructured); !common.IsInventoryObject(u) {
 				t.Errorf(
 					"expected first item to be inventory object, but it wasn't")
 			}
@@ -540,13 +1107,783 @@ func TestReadAndPrepareObjects(t *testing.T) {
 				t.Error(err)
 			}
 
-			if want, got := len(tc.resources)-1, len(pastObjs); want != got {
+			wantObjs := len(tc.resources) - 1
+			if tc.options.InventoryName != "" {
+				wantObjs = len(tc.resources)
+			}
+			if want, got := wantObjs, len(pastObjs); want != got {
 				t.Errorf("expected %d resources in inventory, got %d", want, got)
 			}
 		})
 	}
 }
 
+func TestPrepareObjectsInventoryAnnotations(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("namespace")
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+	applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
+
+	resourceObjects, err := applier.prepareObjects([]*resource.Info{inventoryObjInfo, obj1Info}, Options{
+		InventoryAnnotations: map[string]string{
+			"last-apply-commit": "abc123",
+			"applied-by":        "user@example.com",
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u := resourceObjects.CurrentInventory.Object.(*unstructured.Unstructured)
+	annotations := u.GetAnnotations()
+	assert.Equal(t, "abc123", annotations["last-apply-commit"])
+	assert.Equal(t, "user@example.com", annotations["applied-by"])
+	assert.NotEmpty(t, annotations[common.InventoryHash])
+}
+
+func TestApplierDestroy(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+	tf.FakeDynamicClient = dynamicfake.NewSimpleDynamicClient(scheme.Scheme,
+		obj1Info.Object, obj2Info.Object)
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+
+	// The previous inventory tracks both objects.
+	inv := *inventoryObjInfo
+	inv.Object = inventoryObjInfo.Object.DeepCopyObject()
+	pastInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(&inv),
+		[]*resource.Info{obj1Info, obj2Info})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
+	applier.InventoryClientFactoryFunc = func(cmdutil.Factory) (inventory.InventoryClient, error) {
+		return inventory.NewFakeInventoryClient([]*resource.Info{pastInventory}), nil
+	}
+
+	eventChannel, err := applier.Destroy(context.Background(), inventoryObjInfo, Options{
+		DryRun: true,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var deleted []string
+	var events []event.Event
+	for e := range eventChannel {
+		events = append(events, e)
+		if e.Type == event.DeleteType && e.DeleteEvent.Type == event.DeleteEventResourceUpdate {
+			assert.Equal(t, event.Deleted, e.DeleteEvent.Operation)
+			deleted = append(deleted, getName(e.DeleteEvent.Object))
+		}
+	}
+
+	assert.Contains(t, deleted, "obj1")
+	assert.Contains(t, deleted, "obj2")
+	if assert.NotEmpty(t, events) {
+		last := events[len(events)-1]
+		assert.Equal(t, event.DeleteType, last.Type)
+		assert.Equal(t, event.DeleteEventCompleted, last.DeleteEvent.Type)
+	}
+}
+
+func TestApplierDestroyNoPruneAnnotation(t *testing.T) {
+	protected := obj1Info.Object.(*unstructured.Unstructured).DeepCopy()
+	protected.SetAnnotations(map[string]string{"example.com/protect": "true"})
+	protectedInfo := *obj1Info
+	protectedInfo.Object = protected
+
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+	tf.FakeDynamicClient = dynamicfake.NewSimpleDynamicClient(scheme.Scheme,
+		protected, obj2Info.Object)
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+
+	inv := *inventoryObjInfo
+	inv.Object = inventoryObjInfo.Object.DeepCopyObject()
+	pastInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(&inv),
+		[]*resource.Info{&protectedInfo, obj2Info})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
+	applier.InventoryClientFactoryFunc = func(cmdutil.Factory) (inventory.InventoryClient, error) {
+		return inventory.NewFakeInventoryClient([]*resource.Info{pastInventory}), nil
+	}
+
+	eventChannel, err := applier.Destroy(context.Background(), inventoryObjInfo, Options{
+		DryRun:                 true,
+		NoPruneAnnotationKey:   "example.com/protect",
+		NoPruneAnnotationValue: "true",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var deleted []string
+	for e := range eventChannel {
+		if e.Type == event.DeleteType && e.DeleteEvent.Type == event.DeleteEventResourceUpdate &&
+			e.DeleteEvent.Operation == event.Deleted {
+			deleted = append(deleted, getName(e.DeleteEvent.Object))
+		}
+	}
+	assert.NotContains(t, deleted, "obj1")
+	assert.Contains(t, deleted, "obj2")
+}
+
+// TestApplierRunThenDestroy verifies that Destroy reads the inventory
+// from the cluster instead of reusing the inventory objects read by
+// the previous call to Run.
+func TestApplierRunThenDestroy(t *testing.T) {
+	infos, err := createInfos([]resourceInfo{
+		resources["deployment"],
+		resources["inventoryObject"],
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+	deployment, _ := splitInfos(infos)
+	tf.FakeDynamicClient = newFakeDynamicClient(t, infos, deployment[0].Object)
+	tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+		&nsHandler{},
+		&inventoryObjectHandler{},
+		&genericHandler{
+			resourceInfo: resources["deployment"],
+			namespace:    "default",
+		},
+	})
+	applier := newInitializedApplier(t, tf)
+
+	err = applier.RunWithCallback(context.Background(), infos, Options{}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	eventChannel, err := applier.Destroy(context.Background(), applier.GetInventoryInfo(), Options{
+		DryRun: true,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var deletedKinds []string
+	for e := range eventChannel {
+		assert.NotEqual(t, event.ErrorType, e.Type)
+		if e.Type == event.DeleteType && e.DeleteEvent.Type == event.DeleteEventResourceUpdate {
+			deletedKinds = append(deletedKinds, e.DeleteEvent.Object.GetObjectKind().GroupVersionKind().Kind)
+		}
+	}
+	// The inventory written by Run tracks the Deployment.
+	assert.Contains(t, deletedKinds, "Deployment")
+	assert.Contains(t, deletedKinds, "ConfigMap")
+}
+
+func TestApplierReset(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+	visitedUids := applier.ApplyOptions.VisitedUids
+	visitedUids.Insert("uid1", "uid2")
+	applier.ApplyOptions.VisitedNamespaces.Insert(namespace)
+	applier.lastInventory = inventoryObjInfo.Object.(*unstructured.Unstructured)
+	applier.inventoryInfo = inventoryObjInfo
+
+	assert.NoError(t, applier.Reset())
+
+	assert.Empty(t, applier.ApplyOptions.VisitedUids)
+	assert.Empty(t, applier.ApplyOptions.VisitedNamespaces)
+	assert.Nil(t, applier.GetLastApplyTime())
+	assert.Nil(t, applier.GetInventoryInfo())
+
+	// The set shared with the PruneOptions must still be used
+	// after the reset.
+	applier.ApplyOptions.VisitedUids.Insert("uid3")
+	assert.True(t, visitedUids.Has("uid3"))
+}
+
+// TestApplierRunTwice verifies that a reset Applier prunes the
+// resources applied by its previous run.
+func TestApplierRunTwice(t *testing.T) {
+	firstInfos, err := createInfos([]resourceInfo{
+		resources["deployment"],
+		resources["inventoryObject"],
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	secondInfos, err := createInfos([]resourceInfo{
+		resources["pod"],
+		resources["inventoryObject"],
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	secondResources, secondInvs := splitInfos(secondInfos)
+	secondInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(secondInvs[0]), secondResources)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	firstResources, _ := splitInfos(firstInfos)
+	deployment := firstResources[0].Object.(*unstructured.Unstructured).DeepCopy()
+	deployment.SetUID("deployment-uid")
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+	dynamicClient := newFakeDynamicClient(t, firstInfos, secondInventory.Object, deployment)
+	tf.FakeDynamicClient = dynamicClient
+	tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+		&nsHandler{},
+		&inventoryObjectHandler{},
+		&genericHandler{
+			resourceInfo: resources["deployment"],
+			namespace:    "default",
+		},
+		&genericHandler{
+			resourceInfo: resources["pod"],
+			namespace:    "default",
+		},
+	})
+	applier := newInitializedApplier(t, tf)
+
+	err = applier.RunWithCallback(context.Background(), firstInfos, Options{}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, applier.Reset()) {
+		t.FailNow()
+	}
+	err = applier.RunWithCallback(context.Background(), secondInfos, Options{}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// The Deployment is only tracked by the inventory written by the
+	// first run, so the second run must prune it.
+	_, err = dynamicClient.Resource(appsv1.SchemeGroupVersion.WithResource("deployments")).
+		Namespace("default").Get(deployment.GetName(), metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "expected the deployment to be pruned, got %v", err)
+}
+
+func TestApplierClone(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+	applier.ApplyOptions.Namespace = namespace
+	applier.ApplyOptions.VisitedUids.Insert("uid1")
+	applier.lastInventory = inventoryObjInfo.Object.(*unstructured.Unstructured)
+	originalClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+	applier.SetDynamicClient(originalClient)
+
+	clone, err := applier.Clone()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The configuration is copied, but not the state.
+	assert.Equal(t, namespace, clone.ApplyOptions.Namespace)
+	assert.Empty(t, clone.ApplyOptions.VisitedUids)
+	assert.Nil(t, clone.lastInventory)
+	dynamicClient, err := clone.factory.DynamicClient()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, dynamicClient == originalClient, "expected the original's dynamic client")
+
+	// Configuration changes on the clone don't affect the original.
+	clone.ApplyOptions.Namespace = "other-namespace"
+	clone.PruneOptions.DryRunCallback = func(*unstructured.Unstructured) {}
+	clone.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(scheme.Scheme))
+	assert.Equal(t, namespace, applier.ApplyOptions.Namespace)
+	assert.Nil(t, applier.PruneOptions.DryRunCallback)
+	dynamicClient, err = applier.factory.DynamicClient()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, dynamicClient == originalClient, "expected the original's dynamic client")
+
+	// Both appliers can record applied objects concurrently without
+	// seeing each other's objects.
+	var wg sync.WaitGroup
+	for i, a := range []*Applier{applier, clone} {
+		wg.Add(1)
+		go func(i int, a *Applier) {
+			defer wg.Done()
+			_ = a.Reset()
+			for j := 0; j < 100; j++ {
+				a.ApplyOptions.VisitedUids.Insert(fmt.Sprintf("applier%d-uid%d", i, j))
+			}
+		}(i, a)
+	}
+	wg.Wait()
+	assert.Equal(t, 100, applier.ApplyOptions.VisitedUids.Len())
+	assert.Equal(t, 100, clone.ApplyOptions.VisitedUids.Len())
+}
+
+// TestApplierCloneRun verifies that a clone applying another inventory
+// doesn't prune the resources of the inventory applied by the original.
+func TestApplierCloneRun(t *testing.T) {
+	fooInfos, err := createInfos([]resourceInfo{
+		resources["deployment"],
+		resources["inventoryObject"],
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	barInfos, err := createInfos([]resourceInfo{
+		resources["pod"],
+		{
+			manifest: `
+  kind: ConfigMap
+  apiVersion: v1
+  metadata:
+    labels:
+      cli-utils.sigs.k8s.io/inventory-id: other
+    name: bar
+    namespace: default
+`,
+		},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	fooResources, fooInvs := splitInfos(fooInfos)
+	fooInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(fooInvs[0]), fooResources)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	barResources, barInvs := splitInfos(barInfos)
+	barInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(barInvs[0]), barResources)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	// The inventory of the original already exists, so it is read
+	// and cached by the inventory client of the original.
+	fooConfigMap := &v1.ConfigMap{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(
+		fooInventory.Object.(*unstructured.Unstructured).Object, fooConfigMap)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	deployment := fooResources[0].Object.(*unstructured.Unstructured).DeepCopy()
+	deployment.SetUID("deployment-uid")
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+	dynamicClient := newFakeDynamicClient(t, fooInfos, barInventory.Object, deployment)
+	tf.FakeDynamicClient = dynamicClient
+	tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+		&nsHandler{},
+		&inventoryObjectHandler{inventoryObj: fooConfigMap},
+		&genericHandler{
+			resourceInfo: resources["deployment"],
+			namespace:    "default",
+		},
+		&genericHandler{
+			resourceInfo: resources["pod"],
+			namespace:    "default",
+		},
+	})
+	applier := newInitializedApplier(t, tf)
+
+	err = applier.RunWithCallback(context.Background(), fooInfos, Options{}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	clone, err := applier.Clone()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	err = clone.RunWithCallback(context.Background(), barInfos, Options{}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	_, err = dynamicClient.Resource(appsv1.SchemeGroupVersion.WithResource("deployments")).
+		Namespace("default").Get(deployment.GetName(), metav1.GetOptions{})
+	assert.NoError(t, err, "expected the deployment of the original's inventory to be kept")
+	_, err = dynamicClient.Resource(v1.SchemeGroupVersion.WithResource("configmaps")).
+		Namespace("default").Get(fooInventory.Name, metav1.GetOptions{})
+	assert.NoError(t, err, "expected the original's inventory to be kept")
+}
+
+func TestApplierMaxResourceSize(t *testing.T) {
+	cmInfo := func(value string) *resource.Info {
+		return &resource.Info{
+			Namespace: namespace,
+			Name:      "cm",
+			Object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name":      "cm",
+						"namespace": namespace,
+					},
+					"data": map[string]interface{}{
+						"key": value,
+					},
+				},
+			},
+		}
+	}
+	// The size of the ConfigMap is the size of the value plus
+	// the size of the rest of the JSON document.
+	baseSize := int64(len(`{"apiVersion":"v1","data":{"key":""},"kind":"ConfigMap",` +
+		`"metadata":{"name":"cm","namespace":"` + namespace + `"}}`))
+	maxSize := baseSize + 100
+
+	testCases := map[string]struct {
+		info             *resource.Info
+		expectedTooLarge bool
+	}{
+		"resource just below the limit": {
+			info:             cmInfo(strings.Repeat("a", 99)),
+			expectedTooLarge: false,
+		},
+		"resource at the limit": {
+			info:             cmInfo(strings.Repeat("a", 100)),
+			expectedTooLarge: false,
+		},
+		"resource just above the limit": {
+			info:             cmInfo(strings.Repeat("a", 101)),
+			expectedTooLarge: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tooLarge, err := resourcesTooLarge([]*resource.Info{tc.info}, maxSize)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			if !tc.expectedTooLarge {
+				assert.Empty(t, tooLarge)
+				return
+			}
+			if assert.Len(t, tooLarge, 1) {
+				assert.Equal(t, tc.info.Object, tooLarge[0].Object)
+				assert.Equal(t, maxSize+1, tooLarge[0].Size)
+				assert.Equal(t, maxSize, tooLarge[0].MaxSize)
+			}
+		})
+	}
+}
+
+func TestApplierApplyWithInventoryErrors(t *testing.T) {
+	testCases := map[string]struct {
+		infos         []*resource.Info
+		invInfo       *resource.Info
+		expectedError error
+	}{
+		"not an inventory object": {
+			infos:         []*resource.Info{obj1Info},
+			invInfo:       obj2Info,
+			expectedError: inventory.NoInventoryObjError{},
+		},
+		"no inventory object": {
+			infos:         []*resource.Info{obj1Info},
+			expectedError: inventory.NoInventoryObjError{},
+		},
+		"inventory object also in infos": {
+			infos:   []*resource.Info{inventoryObjInfo, obj1Info},
+			invInfo: inventoryObjInfo,
+			expectedError: inventory.MultipleInventoryObjError{
+				InventoryObjectTemplates: []*resource.Info{inventoryObjInfo, inventoryObjInfo},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplier(tf, ioStreams)
+			applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
+
+			var events []event.Event
+			for e := range applier.ApplyWithInventory(context.Background(), tc.infos, tc.invInfo, Options{}) {
+				events = append(events, e)
+			}
+			if !assert.Len(t, events, 1) {
+				return
+			}
+			assert.Equal(t, event.ErrorType, events[0].Type)
+			assert.Equal(t, tc.expectedError, events[0].ErrorEvent.Err)
+		})
+	}
+}
+
+func TestApplierRunEventChannelBufferSize(t *testing.T) {
+	testCases := map[string]struct {
+		bufferSize       int
+		expectedCapacity int
+	}{
+		"default buffer size": {
+			bufferSize:       0,
+			expectedCapacity: DefaultEventChannelBufferSize,
+		},
+		"unbuffered": {
+			bufferSize:       UnbufferedEventChannel,
+			expectedCapacity: 0,
+		},
+		"large buffer": {
+			bufferSize:       1000,
+			expectedCapacity: 1000,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplier(tf, ioStreams)
+
+			// The resources are too large, so the apply fails with three
+			// events before making any changes in the cluster.
+			eventChannel := applier.Run(context.Background(), []*resource.Info{inventoryObjInfo, obj1Info}, Options{
+				MaxResourceSize:        10,
+				EventChannelBufferSize: tc.bufferSize,
+			})
+			assert.Equal(t, tc.expectedCapacity, cap(eventChannel))
+
+			if tc.expectedCapacity == 0 {
+				// With an unbuffered channel, the events must be
+				// drained concurrently for the apply to make progress.
+				done := make(chan int)
+				go func() {
+					count := 0
+					for range eventChannel {
+						count++
+					}
+					done <- count
+				}()
+				select {
+				case count := <-done:
+					assert.Equal(t, 3, count)
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out draining events")
+				}
+				return
+			}
+
+			// With a buffered channel, the apply completes without
+			// anyone reading the events.
+			assert.Eventually(t, func() bool {
+				return len(eventChannel) == 3
+			}, 5*time.Second, 10*time.Millisecond)
+			var count int
+			for range eventChannel {
+				count++
+			}
+			assert.Equal(t, 3, count)
+		})
+	}
+}
+
+func TestManageClusterScoped(t *testing.T) {
+	manage := true
+	dontManage := false
+
+	testCases := map[string]struct {
+		manageClusterScoped *bool
+
+		expectedObjects      []*resource.Info
+		expectedSkipped      []*resource.Info
+		expectNamespaceScope bool
+	}{
+		"cluster-scoped resources are managed if unset": {
+			manageClusterScoped: nil,
+			expectedObjects:     []*resource.Info{obj1Info, clusterScopedObjInfo},
+		},
+		"cluster-scoped resources are managed when enabled": {
+			manageClusterScoped: &manage,
+			expectedObjects:     []*resource.Info{obj1Info, clusterScopedObjInfo},
+		},
+		"cluster-scoped resources are skipped when disabled": {
+			manageClusterScoped:  &dontManage,
+			expectedObjects:      []*resource.Info{obj1Info},
+			expectedSkipped:      []*resource.Info{clusterScopedObjInfo},
+			expectNamespaceScope: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event, 10)
+			options := Options{
+				ManageClusterScopedResources: tc.manageClusterScoped,
+			}
+
+			objects := manageClusterScoped(eventChannel, []*resource.Info{obj1Info, clusterScopedObjInfo},
+				testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme), &options)
+			close(eventChannel)
+
+			assert.Equal(t, tc.expectedObjects, objects)
+			var skipped []*resource.Info
+			for e := range eventChannel {
+				assert.Equal(t, event.SkipType, e.Type)
+				assert.Equal(t, event.ClusterScopedNotManagedReason, e.SkipEvent.Reason)
+				for _, info := range tc.expectedSkipped {
+					if info.Object == e.SkipEvent.Object {
+						skipped = append(skipped, info)
+					}
+				}
+			}
+			assert.Equal(t, tc.expectedSkipped, skipped)
+			assert.Equal(t, tc.expectNamespaceScope, options.PruneNamespaceScoped)
+		})
+	}
+}
+
+// TestApplierRunKeepsUnmanagedClusterScopedTracked verifies that the
+// cluster-scoped resources tracked by the previous inventory stay
+// tracked if cluster-scoped resources are no longer managed.
+func TestApplierRunKeepsUnmanagedClusterScopedTracked(t *testing.T) {
+	infos, err := createInfos([]resourceInfo{
+		resources["deployment"],
+		resources["inventoryObject"],
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, invs := splitInfos(infos)
+	pastTemplate := *invs[0]
+	pastTemplate.Object = invs[0].Object.DeepCopyObject()
+	pastInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(&pastTemplate),
+		[]*resource.Info{clusterScopedObjInfo})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	pastInventory.Name = "foo-previous"
+	pastInventory.Object.(*unstructured.Unstructured).SetName(pastInventory.Name)
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+	dynamicClient := newFakeDynamicClient(t, infos,
+		pastInventory.Object.DeepCopyObject(), clusterScopedObjInfo.Object.DeepCopyObject())
+	tf.FakeDynamicClient = dynamicClient
+	tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+		&nsHandler{},
+		&previousInventoryHandler{inventoryObj: pastInventory.Object.(*unstructured.Unstructured)},
+		&inventoryObjectHandler{},
+		&genericHandler{
+			resourceInfo: resources["deployment"],
+			namespace:    "default",
+		},
+	})
+	applier := newInitializedApplier(t, tf)
+
+	dontManage := false
+	err = applier.RunWithCallback(context.Background(), append(infos, clusterScopedObjInfo), Options{
+		ManageClusterScopedResources: &dontManage,
+	}, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// The previous inventory object is kept, since it still tracks
+	// the ClusterRole.
+	inv, err := dynamicClient.Resource(v1.SchemeGroupVersion.WithResource("configmaps")).
+		Namespace("default").Get(pastInventory.Name, metav1.GetOptions{})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	objs, err := inventory.WrapInventoryObj(&resource.Info{Object: inv}).Load()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, objs, object.InfoToObjMeta(clusterScopedObjInfo))
+}
+
+func TestApplierRunRejectsTooLargeResources(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+
+	var eventTypes []event.Type
+	for e := range applier.Run(context.Background(), []*resource.Info{inventoryObjInfo, obj1Info}, Options{
+		MaxResourceSize: 10,
+	}) {
+		eventTypes = append(eventTypes, e.Type)
+	}
+	assert.Equal(t, []event.Type{
+		event.ResourceTooLargeType,
+		event.ResourceTooLargeType,
+		event.ErrorType,
+	}, eventTypes)
+}
+
+func TestApplierDestroyRequiresInventoryObject(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+	applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
+
+	_, err := applier.Destroy(context.Background(), obj1Info, Options{})
+	assert.Error(t, err)
+}
+
+func TestHandleErrorRetried(t *testing.T) {
+	testCases := map[string]struct {
+		err error
+
+		expectedRetried    bool
+		expectedRetryCount int
+	}{
+		"first attempt failure": {
+			err: fmt.Errorf("failed"),
+		},
+		"exhausted retries": {
+			err: taskrunner.RetriesExhaustedError{
+				Err:        fmt.Errorf("failed"),
+				RetryCount: 2,
+			},
+			expectedRetried:    true,
+			expectedRetryCount: 2,
+		},
+		"wrapped exhausted retries": {
+			err: fmt.Errorf("apply failed: %w", taskrunner.RetriesExhaustedError{
+				Err:        fmt.Errorf("failed"),
+				RetryCount: 2,
+			}),
+			expectedRetried:    true,
+			expectedRetryCount: 2,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event, 1)
+			handleError(eventChannel, tc.err)
+			e := <-eventChannel
+
+			assert.Equal(t, event.ErrorType, e.Type)
+			assert.Equal(t, tc.err, e.ErrorEvent.Err)
+			assert.Equal(t, tc.expectedRetried, e.ErrorEvent.Retried)
+			assert.Equal(t, tc.expectedRetryCount, e.ErrorEvent.RetryCount)
+		})
+	}
+}
+
 func toJSONBytes(t *testing.T, obj runtime.Object) []byte {
 	objBytes, err := runtime.Encode(unstructured.NewJSONFallbackEncoder(codec), obj)
 	if !assert.NoError(t, err) {
@@ -598,6 +1935,70 @@ func toIdentifier(t *testing.T, resourceInfo resourceInfo, namespace string) obj
 	}
 }
 
+// newFakeDynamicClient returns a fake dynamic client containing the
+// inventory object created from the infos, so the applier can record
+// the last apply time on it, and the passed objects.
+func newFakeDynamicClient(t *testing.T, infos []*resource.Info, objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	resources, invs := splitInfos(infos)
+	if len(invs) > 0 {
+		inv, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(invs[0]), resources)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		objs = append(objs, inv.Object)
+	}
+	return dynamicfake.NewSimpleDynamicClient(scheme.Scheme, objs...)
+}
+
+// assertLastApplyTime verifies that the last apply time annotation has
+// been written to the inventory object in the cluster, and that it
+// matches the time returned by the applier.
+func assertLastApplyTime(t *testing.T, applier *Applier, client *dynamicfake.FakeDynamicClient) {
+	lastApplyTime := applier.GetLastApplyTime()
+	if !assert.NotNil(t, lastApplyTime) {
+		return
+	}
+	assert.WithinDuration(t, time.Now(), *lastApplyTime, time.Minute)
+
+	list, err := client.Resource(v1.SchemeGroupVersion.WithResource("configmaps")).
+		Namespace(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if !assert.NoError(t, err) || !assert.Len(t, list.Items, 1) {
+		return
+	}
+	assert.Equal(t, lastApplyTime.UTC().Format(time.RFC3339),
+		list.Items[0].GetAnnotations()[common.LastApplyTimeAnnotation])
+}
+
+// newInitializedApplier returns an Applier initialized with the test
+// factory, which uses a fake status poller and InfoHelper. The
+// inventory client reads the inventory objects from the factory.
+func newInitializedApplier(t *testing.T, tf *cmdtesting.TestFactory) *Applier {
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+
+	cmd := &cobra.Command{}
+	_ = applier.SetFlags(cmd)
+	var notUsedFlag bool
+	// This flag needs to be set as there is a dependency on it.
+	cmd.Flags().BoolVar(&notUsedFlag, "dry-run", notUsedFlag, "")
+	cmdutil.AddValidateFlags(cmd)
+	cmdutil.AddServerSideApplyFlags(cmd)
+	if err := applier.Initialize(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := make(chan struct{})
+	close(start)
+	applier.StatusPoller = &fakePoller{
+		start: start,
+	}
+	applier.infoHelperFactoryFunc = func() info.InfoHelper {
+		return &fakeInfoHelper{
+			factory: tf,
+		}
+	}
+	return applier
+}
+
 func createInfos(resources []resourceInfo) ([]*resource.Info, error) {
 	var infos []*resource.Info
 	for _, ri := range resources {
@@ -697,7 +2098,11 @@ func (i *inventoryObjectHandler) handle(t *testing.T, req *http.Request) (*http.
 			},
 			Items: []v1.ConfigMap{},
 		}
-		if i.inventoryObj != nil {
+		selector, err := labels.Parse(req.URL.Query().Get("labelSelector"))
+		if err != nil {
+			return nil, false, err
+		}
+		if i.inventoryObj != nil && selector.Matches(labels.Set(i.inventoryObj.Labels)) {
 			cmList.Items = append(cmList.Items, *i.inventoryObj)
 		}
 		bodyRC := ioutil.NopCloser(bytes.NewReader(toJSONBytes(t, &cmList)))
@@ -705,15 +2110,46 @@ func (i *inventoryObjectHandler) handle(t *testing.T, req *http.Request) (*http.
 	}
 
 	if req.Method == http.MethodGet && invObjPathRegex.Match([]byte(req.URL.Path)) {
-		if i.inventoryObj == nil {
+		if i.inventoryObj == nil || path.Base(req.URL.Path) != i.inventoryObj.Name {
 			return &http.Response{StatusCode: http.StatusNotFound, Header: cmdtesting.DefaultHeader(), Body: cmdtesting.StringBody("")}, true, nil
 		}
 		bodyRC := ioutil.NopCloser(bytes.NewReader(toJSONBytes(t, i.inventoryObj)))
 		return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, true, nil
 	}
+
+	if req.Method == http.MethodPatch && invObjPathRegex.Match([]byte(req.URL.Path)) &&
+		i.inventoryObj != nil && path.Base(req.URL.Path) == i.inventoryObj.Name {
+		bodyRC := ioutil.NopCloser(bytes.NewReader(toJSONBytes(t, i.inventoryObj)))
+		return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, true, nil
+	}
 	return nil, false, nil
 }
 
+// previousInventoryHandler handles list requests for the inventory
+// objects by returning a previous inventory object.
+type previousInventoryHandler struct {
+	inventoryObj *unstructured.Unstructured
+}
+
+func (p *previousInventoryHandler) handle(_ *testing.T, req *http.Request) (*http.Response, bool, error) {
+	if req.Method != http.MethodGet || !cmPathRegex.Match([]byte(req.URL.Path)) {
+		return nil, false, nil
+	}
+	list := &unstructured.UnstructuredList{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+		},
+		Items: []unstructured.Unstructured{*p.inventoryObj},
+	}
+	b, err := list.MarshalJSON()
+	if err != nil {
+		return nil, false, err
+	}
+	bodyRC := ioutil.NopCloser(bytes.NewReader(b))
+	return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, true, nil
+}
+
 // nsHandler can handle requests for a namespace. It will behave as if
 // every requested namespace exists. It simply fetches the name of the requested
 // namespace from the url and creates a new namespace type with the provided
@@ -28,6 +28,13 @@ const (
 // We need to support different printers for different output formats.
 type BasicPrinter struct {
 	IOStreams genericclioptions.IOStreams
+	// ShowLatency makes the printer include the latency bucket of
+	// each applied resource, if it is known.
+	ShowLatency bool
+	// NoExit makes the printer continue after printing an error event
+	// instead of exiting the process, so the caller can handle the
+	// error.
+	NoExit bool
 }
 
 type applyStats struct {
@@ -116,6 +123,22 @@ func (b *BasicPrinter) Print(ch <-chan event.Event, preview bool) {
 			b.processPruneEvent(e.PruneEvent, pruneStats, printFunc)
 		case event.DeleteType:
 			b.processDeleteEvent(e.DeleteEvent, deleteStats, printFunc)
+		case event.TimeoutType:
+			b.processTimeoutEvent(e.TimeoutEvent, printFunc)
+		case event.NotFoundType:
+			b.processNotFoundEvent(e.NotFoundEvent, printFunc)
+		case event.ResourceTooLargeType:
+			b.processResourceTooLargeEvent(e.ResourceTooLargeEvent, printFunc)
+		case event.GarbageCollectedType:
+			b.processGarbageCollectedEvent(e.GarbageCollectedEvent, printFunc)
+		case event.StatusFailedType:
+			b.processStatusFailedEvent(e.StatusFailedEvent, printFunc)
+		case event.SkipType:
+			b.processSkipEvent(e.SkipEvent, printFunc)
+		case event.ApplyFailedType:
+			b.processApplyFailedEvent(e.ApplyFailedEvent, printFunc)
+		case event.SummaryType:
+			b.processSummaryEvent(e.SummaryEvent, printFunc)
 		}
 	}
 }
@@ -124,6 +147,7 @@ func (b *BasicPrinter) processErrorEvent(ee event.ErrorEvent, c *statusCollector
 	p printFunc) {
 	p("\nFatal error: %s", ee.Err.Error())
 
+	exitCode := defaultExitErrorCode
 	if timeoutErr, ok := taskrunner.IsTimeoutError(ee.Err); ok {
 		for _, id := range timeoutErr.Identifiers {
 			ls, found := c.latestStatus[id]
@@ -136,9 +160,55 @@ func (b *BasicPrinter) processErrorEvent(ee event.ErrorEvent, c *statusCollector
 			p("%s/%s %s %s", id.GroupKind.Kind,
 				id.Name, ls.Resource.Status, ls.Resource.Message)
 		}
-		os.Exit(timeoutExitErrorCode)
+		exitCode = timeoutExitErrorCode
 	}
-	os.Exit(defaultExitErrorCode)
+	if b.NoExit {
+		return
+	}
+	os.Exit(exitCode)
+}
+
+func (b *BasicPrinter) processTimeoutEvent(te event.TimeoutEvent, p printFunc) {
+	id := te.Identifier
+	p("%s timed out after %v", resourceIDToString(id.GroupKind, id.Name), te.Timeout)
+}
+
+func (b *BasicPrinter) processStatusFailedEvent(sfe event.StatusFailedEvent, p printFunc) {
+	id := sfe.Identifier
+	p("%s failed: %s", resourceIDToString(id.GroupKind, id.Name), sfe.Message)
+}
+
+func (b *BasicPrinter) processSkipEvent(se event.SkipEvent, p printFunc) {
+	gvk := se.Object.GetObjectKind().GroupVersionKind()
+	p("%s skipped: %s", resourceIDToString(gvk.GroupKind(), getName(se.Object)), se.Reason)
+}
+
+func (b *BasicPrinter) processApplyFailedEvent(afe event.ApplyFailedEvent, p printFunc) {
+	gvk := afe.Object.GetObjectKind().GroupVersionKind()
+	p("%s failed: %s", resourceIDToString(gvk.GroupKind(), getName(afe.Object)), afe.Err.Error())
+}
+
+func (b *BasicPrinter) processSummaryEvent(se event.SummaryEvent, p printFunc) {
+	p("%d resource(s) succeeded, %d failed", len(se.Succeeded), len(se.Failed))
+	for _, id := range se.Failed {
+		p("%s failed", resourceIDToString(id.GroupKind, id.Name))
+	}
+}
+
+func (b *BasicPrinter) processNotFoundEvent(nfe event.NotFoundEvent, p printFunc) {
+	gvk := nfe.Object.GetObjectKind().GroupVersionKind()
+	p("%s not found: %s", resourceIDToString(gvk.GroupKind(), getName(nfe.Object)), nfe.Err.Error())
+}
+
+func (b *BasicPrinter) processResourceTooLargeEvent(rte event.ResourceTooLargeEvent, p printFunc) {
+	gvk := rte.Object.GetObjectKind().GroupVersionKind()
+	p("%s is too large: %d bytes exceeds the maximum of %d bytes",
+		resourceIDToString(gvk.GroupKind(), getName(rte.Object)), rte.Size, rte.MaxSize)
+}
+
+func (b *BasicPrinter) processGarbageCollectedEvent(gce event.GarbageCollectedEvent, p printFunc) {
+	id := gce.Identifier
+	p("%s removed from inventory", resourceIDToString(id.GroupKind, id.Name))
 }
 
 func (b *BasicPrinter) processApplyEvent(ae event.ApplyEvent, as *applyStats,
@@ -162,8 +232,16 @@ func (b *BasicPrinter) processApplyEvent(ae event.ApplyEvent, as *applyStats,
 		gvk := obj.GetObjectKind().GroupVersionKind()
 		name := getName(obj)
 		as.inc(ae.Operation)
-		p("%s %s", resourceIDToString(gvk.GroupKind(), name),
-			strings.ToLower(ae.Operation.String()))
+		if b.ShowLatency && ae.LatencyBucket != "" {
+			p("%s %s (%s)", resourceIDToString(gvk.GroupKind(), name),
+				strings.ToLower(ae.Operation.String()), ae.LatencyBucket)
+		} else {
+			p("%s %s", resourceIDToString(gvk.GroupKind(), name),
+				strings.ToLower(ae.Operation.String()))
+		}
+		if ae.Diff != "" {
+			fmt.Fprint(b.IOStreams.Out, ae.Diff)
+		}
 	}
 }
 
@@ -208,7 +286,11 @@ func (b *BasicPrinter) processPruneEvent(pe event.PruneEvent, ps *pruneStats, p
 			p("%s %s", resourceIDToString(gvk.GroupKind(), name), "pruned")
 		case event.PruneSkipped:
 			ps.incSkipped()
-			p("%s %s", resourceIDToString(gvk.GroupKind(), name), "prune skipped")
+			if pe.Reason != "" {
+				p("%s %s (%s)", resourceIDToString(gvk.GroupKind(), name), "prune skipped", pe.Reason)
+			} else {
+				p("%s %s", resourceIDToString(gvk.GroupKind(), name), "prune skipped")
+			}
 		}
 	}
 }
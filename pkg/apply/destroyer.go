@@ -9,8 +9,10 @@ import (
 	"github.com/go-errors/errors"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/kubectl/pkg/cmd/apply"
 	"k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
@@ -45,12 +47,31 @@ type Destroyer struct {
 	PruneOptions *prune.PruneOptions
 
 	DryRun bool
+	// LabelSelector restricts the destroy to the resources whose labels
+	// in the cluster match it. The other resources are kept in the
+	// inventory. All resources are destroyed if it is empty.
+	LabelSelector string
+	// ForceDelete makes the destroy delete resources even if their
+	// lifecycle annotation says they should be kept or detached. It
+	// requires either Confirm or NonInteractive to be set.
+	ForceDelete bool
+	// Confirm means the user is asked to confirm ForceDelete before
+	// the destroy. The prompt is shown by the destroy command.
+	Confirm bool
+	// NonInteractive means the destroy is not run by a user who could
+	// confirm ForceDelete, so no confirmation is required.
+	NonInteractive bool
+
+	labelSelector labels.Selector
 }
 
 // Initialize sets up the Destroyer for actually doing an destroy against
 // a cluster. This involves validating command line inputs and configuring
 // clients for communicating with the cluster.
 func (d *Destroyer) Initialize(cmd *cobra.Command, paths []string) error {
+	if err := ValidateForceDelete(d.ForceDelete, d.Confirm, d.NonInteractive); err != nil {
+		return err
+	}
 	fileNameFlags, err := common.DemandOneDirectory(paths)
 	if err != nil {
 		return err
@@ -69,6 +90,13 @@ func (d *Destroyer) Initialize(cmd *cobra.Command, paths []string) error {
 		return errors.WrapPrefix(err, "error setting up PruneOptions", 1)
 	}
 
+	if d.LabelSelector != "" {
+		d.labelSelector, err = labels.Parse(d.LabelSelector)
+		if err != nil {
+			return fmt.Errorf("invalid label selector %q: %w", d.LabelSelector, err)
+		}
+	}
+
 	// Propagate dry-run flags.
 	d.ApplyOptions.DryRun = d.DryRun
 	return nil
@@ -97,43 +125,66 @@ func (d *Destroyer) Run() <-chan event.Event {
 		// will catch the same problems.
 		_ = inventory.ClearInventoryObj(infos)
 
-		// Start the event transformer goroutine so we can transform
-		// the Prune events emitted from the Prune function to Delete
-		// Events. That we use Prune to implement destroy is an
-		// implementation detail and the events should not be Prune events.
-		tempChannel, completedChannel := runPruneEventTransformer(ch)
-		err = d.PruneOptions.Prune(infos, tempChannel, prune.Options{
+		runDestroy(ch, d.PruneOptions, infos, prune.Options{
 			DryRun:            d.DryRun,
 			PropagationPolicy: metav1.DeletePropagationBackground,
+			LabelSelector:     d.labelSelector,
+			ForceDelete:       d.ForceDelete,
 		})
-		// Close the tempChannel to signal to the event transformer that
-		// it should terminate.
-		close(tempChannel)
-		// Wait for the event transformer to complete processing all
-		// events and shut down before we continue.
-		<-completedChannel
-		if err != nil {
-			// If we see an error here we just report it on the channel and then
-			// give up. Eventually we might be able to determine which errors
-			// are fatal and which might allow us to continue.
-			ch <- event.Event{
-				Type: event.ErrorType,
-				ErrorEvent: event.ErrorEvent{
-					Err: errors.WrapPrefix(err, "error pruning resources", 1),
-				},
-			}
-			return
-		}
-		ch <- event.Event{
-			Type: event.DeleteType,
-			DeleteEvent: event.DeleteEvent{
-				Type: event.DeleteEventCompleted,
-			},
-		}
 	}()
 	return ch
 }
 
+// ValidateForceDelete returns an error if force delete is used
+// interactively without being confirmed. It is shared by the apply
+// and destroy commands.
+func ValidateForceDelete(forceDelete, confirm, nonInteractive bool) error {
+	if forceDelete && !confirm && !nonInteractive {
+		return fmt.Errorf("--force-delete deletes resources regardless of their lifecycle annotations " +
+			"and must be confirmed with --confirm, or used with --non-interactive")
+	}
+	return nil
+}
+
+// runDestroy deletes all objects known by the inventory by pruning
+// with the provided PruneOptions. The PruneOptions must have been
+// created with an empty set of UIDs, so every object is pruned. All
+// events are emitted as Delete events on the eventChannel, followed
+// by either a DeleteEventCompleted or an error event.
+func runDestroy(eventChannel chan event.Event, po *prune.PruneOptions,
+	infos []*resource.Info, o prune.Options) {
+	// Start the event transformer goroutine so we can transform
+	// the Prune events emitted from the Prune function to Delete
+	// Events. That we use Prune to implement destroy is an
+	// implementation detail and the events should not be Prune events.
+	tempChannel, completedChannel := runPruneEventTransformer(eventChannel)
+	err := po.Prune(infos, tempChannel, o)
+	// Close the tempChannel to signal to the event transformer that
+	// it should terminate.
+	close(tempChannel)
+	// Wait for the event transformer to complete processing all
+	// events and shut down before we continue.
+	<-completedChannel
+	if err != nil {
+		// If we see an error here we just report it on the channel and then
+		// give up. Eventually we might be able to determine which errors
+		// are fatal and which might allow us to continue.
+		eventChannel <- event.Event{
+			Type: event.ErrorType,
+			ErrorEvent: event.ErrorEvent{
+				Err: errors.WrapPrefix(err, "error pruning resources", 1),
+			},
+		}
+		return
+	}
+	eventChannel <- event.Event{
+		Type: event.DeleteType,
+		DeleteEvent: event.DeleteEvent{
+			Type: event.DeleteEventCompleted,
+		},
+	}
+}
+
 // SetFlags configures the command line flags needed for destroy
 // This is a temporary solution as we should separate the configuration
 // of cobra flags from the Destroyer.
@@ -145,6 +196,14 @@ func (d *Destroyer) SetFlags(cmd *cobra.Command) error {
 			return err
 		}
 	}
+	cmd.Flags().StringVar(&d.LabelSelector, "label-selector", "",
+		"Only destroy the resources matching this label selector. The other resources are kept in the inventory.")
+	cmd.Flags().BoolVar(&d.ForceDelete, "force-delete", false,
+		"Delete resources even if their lifecycle annotation says they should be kept or detached.")
+	cmd.Flags().BoolVar(&d.Confirm, "confirm", false,
+		"Ask for confirmation before destroying with --force-delete. Requires stdin to be a terminal.")
+	cmd.Flags().BoolVar(&d.NonInteractive, "non-interactive", false,
+		"Run without user interaction, so --force-delete does not need to be confirmed.")
 	d.ApplyOptions.RecordFlags.AddFlags(cmd)
 	_ = cmd.Flags().MarkHidden("record")
 	_ = cmd.Flags().MarkHidden("cascade")
@@ -168,6 +227,12 @@ func runPruneEventTransformer(eventChannel chan event.Event) (chan event.Event,
 	go func() {
 		defer close(completedChannel)
 		for msg := range tempEventChannel {
+			// Events that are not about pruning a resource, like
+			// garbage collected resources, are passed on unchanged.
+			if msg.Type != event.PruneType {
+				eventChannel <- msg
+				continue
+			}
 			eventChannel <- event.Event{
 				Type: event.DeleteType,
 				DeleteEvent: event.DeleteEvent{
@@ -0,0 +1,144 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// The diff package computes the field-level changes that an apply
+// would make to a resource. The changes are computed by doing the
+// same three-way merge between the last-applied configuration, the
+// desired state and the live state that the applier does, and
+// are returned as a unified diff.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// lastAppliedAnnotation is the annotation used by the applier to store
+// the last applied configuration on a resource.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Diff returns the unified diff between the live state of a resource and
+// the state the resource will have after the desired state has been
+// applied. If the live object is nil, the resource doesn't exist in the
+// cluster and the full desired state is returned as additions. An empty
+// string is returned if applying the desired state will not change
+// the resource.
+func Diff(live, desired runtime.Object) (string, error) {
+	if desired == nil {
+		return "", fmt.Errorf("desired object is nil")
+	}
+	name := resourceName(desired)
+	modified, err := json.Marshal(desired)
+	if err != nil {
+		return "", err
+	}
+
+	if live == nil {
+		desiredYaml, err := yaml.JSONToYAML(modified)
+		if err != nil {
+			return "", err
+		}
+		return unifiedDiff(name, "", string(desiredYaml))
+	}
+
+	current, err := json.Marshal(live)
+	if err != nil {
+		return "", err
+	}
+	var original []byte
+	if acc, err := meta.Accessor(live); err == nil {
+		original = []byte(acc.GetAnnotations()[lastAppliedAnnotation])
+	}
+	gvk := desired.GetObjectKind().GroupVersionKind()
+	merged, err := mergedObject(original, modified, current, gvk)
+	if err != nil {
+		return "", err
+	}
+
+	// Round-trip both objects through the same representation so
+	// differences in formatting doesn't show up in the diff.
+	liveYaml, err := normalizedYaml(current)
+	if err != nil {
+		return "", err
+	}
+	mergedYaml, err := normalizedYaml(merged)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(name, liveYaml, mergedYaml)
+}
+
+// mergedObject computes the three-way merge patch between the original,
+// modified and current configuration and applies it to the current
+// configuration. Types known to the scheme use a strategic merge patch,
+// while other types (like custom resources) use a json merge patch.
+func mergedObject(original, modified, current []byte, gvk schema.GroupVersionKind) ([]byte, error) {
+	versionedObject, err := scheme.Scheme.New(gvk)
+	switch {
+	case runtime.IsNotRegisteredError(err):
+		patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, current)
+		if err != nil {
+			return nil, err
+		}
+		return jsonpatch.MergePatch(current, patch)
+	case err != nil:
+		return nil, err
+	default:
+		lookupPatchMeta, err := strategicpatch.NewPatchMetaFromStruct(versionedObject)
+		if err != nil {
+			return nil, err
+		}
+		patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, current, lookupPatchMeta, true)
+		if err != nil {
+			return nil, err
+		}
+		return strategicpatch.StrategicMergePatch(current, patch, versionedObject)
+	}
+}
+
+// normalizedYaml converts the passed json into yaml with sorted keys.
+func normalizedYaml(data []byte) (string, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// unifiedDiff returns the unified diff between the two strings.
+func unifiedDiff(name, from, to string) (string, error) {
+	if from == to {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: "live/" + name,
+		ToFile:   "desired/" + name,
+		Context:  3,
+	})
+}
+
+// resourceName returns the kind and name of the object in the
+// format used as the file names in the diff.
+func resourceName(obj runtime.Object) string {
+	var name string
+	if acc, err := meta.Accessor(obj); err == nil {
+		name = acc.GetName()
+	}
+	return fmt.Sprintf("%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, name)
+}
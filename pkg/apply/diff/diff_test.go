@@ -0,0 +1,88 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func deployment(replicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+			},
+		},
+	}
+}
+
+func changedLines(d string) []string {
+	var lines []string
+	for _, l := range strings.Split(d, "\n") {
+		if strings.HasPrefix(l, "+++") || strings.HasPrefix(l, "---") {
+			continue
+		}
+		if strings.HasPrefix(l, "+") || strings.HasPrefix(l, "-") {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func TestDiff(t *testing.T) {
+	testCases := map[string]struct {
+		live         runtime.Object
+		desired      runtime.Object
+		expectedDiff []string
+	}{
+		"resource doesn't exist": {
+			live:    nil,
+			desired: deployment(1),
+			expectedDiff: []string{
+				"+apiVersion: apps/v1",
+				"+kind: Deployment",
+				"+metadata:",
+				"+  name: foo",
+				"+  namespace: default",
+				"+spec:",
+				"+  replicas: 1",
+			},
+		},
+		"single field changed": {
+			live:    deployment(1),
+			desired: deployment(3),
+			expectedDiff: []string{
+				"-  replicas: 1",
+				"+  replicas: 3",
+			},
+		},
+		"no changes": {
+			live:         deployment(1),
+			desired:      deployment(1),
+			expectedDiff: nil,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			d, err := Diff(tc.live, tc.desired)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDiff, changedLines(d))
+			if tc.expectedDiff != nil {
+				assert.Contains(t, d, "desired/Deployment/foo")
+			}
+		})
+	}
+}
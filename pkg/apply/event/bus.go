@@ -0,0 +1,73 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"sync"
+)
+
+// Handler is a function that is invoked with every event
+// of the type it has been subscribed to.
+type Handler func(Event)
+
+// EventBus fans out the events from a channel to the handlers that
+// have subscribed to the type of each event. This allows consumers to
+// only handle the types of events they care about, for example:
+//
+//	bus := event.NewEventBus()
+//	bus.Subscribe(event.ApplyType, handleApply)
+//	bus.Run(applier.Run(ctx, infos, options))
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[Type][]Handler
+}
+
+// NewEventBus returns a new EventBus without any subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[Type][]Handler),
+	}
+}
+
+// Subscribe registers the handler for events of the given type.
+// Handlers for the same type are invoked in the order they
+// were registered.
+func (b *EventBus) Subscribe(eventType Type, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[eventType] = append(b.subscribers[eventType], handler)
+}
+
+// Run reads events from the channel and invokes the subscribed handlers
+// for each event in the goroutine of the caller. It blocks until the
+// channel is closed, after which all subscribers are unregistered.
+func (b *EventBus) Run(ch <-chan Event) {
+	defer b.unsubscribeAll()
+	for e := range ch {
+		b.Publish(e)
+	}
+}
+
+// Publish invokes the handlers subscribed to the type of the event in
+// the goroutine of the caller. Unlike Run, it keeps the subscribers
+// registered, so the same bus can handle the events of several applies.
+func (b *EventBus) Publish(e Event) {
+	for _, handler := range b.handlers(e.Type) {
+		handler(e)
+	}
+}
+
+// handlers returns the handlers subscribed to the given type.
+func (b *EventBus) handlers(eventType Type) []Handler {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Handler{}, b.subscribers[eventType]...)
+}
+
+// unsubscribeAll removes all subscribers from the bus.
+func (b *EventBus) unsubscribeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = make(map[Type][]Handler)
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+
+	var applyEvents []Event
+	bus.Subscribe(ApplyType, func(e Event) {
+		applyEvents = append(applyEvents, e)
+	})
+	var pruneCount int
+	bus.Subscribe(PruneType, func(Event) {
+		pruneCount++
+	})
+
+	ch := make(chan Event, 4)
+	ch <- Event{Type: ApplyType, ApplyEvent: ApplyEvent{Type: ApplyEventResourceUpdate}}
+	ch <- Event{Type: PruneType}
+	ch <- Event{Type: StatusType}
+	ch <- Event{Type: ApplyType, ApplyEvent: ApplyEvent{Type: ApplyEventCompleted}}
+	close(ch)
+
+	bus.Run(ch)
+
+	if assert.Len(t, applyEvents, 2) {
+		for _, e := range applyEvents {
+			assert.Equal(t, ApplyType, e.Type)
+		}
+		assert.Equal(t, ApplyEventCompleted, applyEvents[1].ApplyEvent.Type)
+	}
+	assert.Equal(t, 1, pruneCount)
+
+	// Closing the source channel unregisters all subscribers.
+	assert.Empty(t, bus.handlers(ApplyType))
+	assert.Empty(t, bus.handlers(PruneType))
+}
+
+func TestEventBusPublish(t *testing.T) {
+	bus := NewEventBus()
+	var errorCount int
+	bus.Subscribe(ErrorType, func(Event) {
+		errorCount++
+	})
+
+	bus.Publish(Event{Type: ErrorType})
+	bus.Publish(Event{Type: ApplyType})
+	bus.Publish(Event{Type: ErrorType})
+
+	assert.Equal(t, 2, errorCount)
+	// Publish keeps the subscribers registered.
+	assert.Len(t, bus.handlers(ErrorType), 1)
+}
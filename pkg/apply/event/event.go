@@ -4,6 +4,9 @@
 package event
 
 import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
 	"sigs.k8s.io/cli-utils/pkg/object"
@@ -20,6 +23,14 @@ const (
 	StatusType
 	PruneType
 	DeleteType
+	TimeoutType
+	NotFoundType
+	ResourceTooLargeType
+	GarbageCollectedType
+	StatusFailedType
+	SkipType
+	ApplyFailedType
+	SummaryType
 )
 
 // Event is the type of the objects that will be returned through
@@ -52,6 +63,38 @@ type Event struct {
 	// DeleteEvent contains information about object that have been
 	// deleted.
 	DeleteEvent DeleteEvent
+
+	// TimeoutEvent contains information about a resource that didn't
+	// reach the desired status before the timeout.
+	TimeoutEvent TimeoutEvent
+
+	// NotFoundEvent contains information about a resource that could
+	// not be applied since the API server returned NotFound.
+	NotFoundEvent NotFoundEvent
+
+	// ResourceTooLargeEvent contains information about a resource that
+	// was rejected since it exceeds the maximum size.
+	ResourceTooLargeEvent ResourceTooLargeEvent
+
+	// GarbageCollectedEvent contains information about a resource that
+	// was removed from the inventory since its type no longer exists.
+	GarbageCollectedEvent GarbageCollectedEvent
+
+	// StatusFailedEvent contains information about a resource that
+	// got the Failed status while waiting for it.
+	StatusFailedEvent StatusFailedEvent
+
+	// SkipEvent contains information about a resource that was not
+	// applied.
+	SkipEvent SkipEvent
+
+	// ApplyFailedEvent contains information about a resource that
+	// failed to apply when the apply continues on errors.
+	ApplyFailedEvent ApplyFailedEvent
+
+	// SummaryEvent contains the resources that were applied and the
+	// resources that failed when the apply continues on errors.
+	SummaryEvent SummaryEvent
 }
 
 type InitEvent struct {
@@ -73,6 +116,79 @@ type ResourceGroup struct {
 
 type ErrorEvent struct {
 	Err error
+	// Retried is true if the error occurred after all the retries of
+	// the failed operation had been exhausted, rather than on the
+	// first attempt.
+	Retried bool
+	// RetryCount is the number of retries before the error occurred.
+	RetryCount int
+}
+
+// TimeoutEvent is emitted for every resource that had not reached
+// the desired status when waiting for it timed out, if the applier
+// has been configured to continue after timeouts.
+type TimeoutEvent struct {
+	Identifier object.ObjMetadata
+	Timeout    time.Duration
+}
+
+// NotFoundEvent is emitted for every resource where applying it
+// failed with a NotFound error, if the applier has been configured
+// to ignore such errors.
+type NotFoundEvent struct {
+	Object runtime.Object
+	Err    error
+}
+
+// ResourceTooLargeEvent is emitted for every resource whose serialized
+// size exceeds the maximum resource size, in bytes.
+type ResourceTooLargeEvent struct {
+	Object  runtime.Object
+	Size    int64
+	MaxSize int64
+}
+
+// GarbageCollectedEvent is emitted for every resource in the inventory
+// whose type is no longer known by the API server, for example since
+// the CRD was removed, if pruning has been configured to garbage
+// collect such resources.
+type GarbageCollectedEvent struct {
+	Identifier object.ObjMetadata
+}
+
+// StatusFailedEvent is emitted for a resource that got the Failed
+// status while waiting for it, if the wait has been configured to
+// fail on failed resources, like when waiting for Jobs.
+type StatusFailedEvent struct {
+	Identifier object.ObjMetadata
+	Message    string
+}
+
+// ClusterScopedNotManagedReason is the reason of the SkipEvent for
+// cluster-scoped resources when the applier hasn't been configured to
+// manage them.
+const ClusterScopedNotManagedReason = "cluster-scoped resource management not enabled"
+
+// SkipEvent is emitted for every resource that is not applied, with
+// the reason it was skipped.
+type SkipEvent struct {
+	Object runtime.Object
+	Reason string
+}
+
+// ApplyFailedEvent is emitted for every resource that failed to apply,
+// if the applier has been configured to continue on errors.
+type ApplyFailedEvent struct {
+	Object runtime.Object
+	Err    error
+}
+
+// SummaryEvent is emitted at the end of an apply which continues on
+// errors, with the resources that were applied and the resources that
+// failed to apply.
+type SummaryEvent struct {
+	Succeeded []object.ObjMetadata
+	Failed    []object.ObjMetadata
 }
 
 //go:generate stringer -type=ApplyEventType
@@ -83,13 +199,23 @@ const (
 	ApplyEventCompleted
 )
 
+// ApplyEventOperation describes the change an apply made to a
+// resource in the cluster.
 //go:generate stringer -type=ApplyEventOperation
 type ApplyEventOperation int
 
 const (
+	// ServersideApplied means the resource was applied using
+	// server-side apply.
 	ServersideApplied ApplyEventOperation = iota
+	// Created means the resource did not exist in the cluster
+	// and was created.
 	Created
+	// Unchanged means the resource already existed in the cluster
+	// and applying it did not change it.
 	Unchanged
+	// Configured means the resource already existed in the cluster
+	// and was updated.
 	Configured
 )
 
@@ -97,6 +223,20 @@ type ApplyEvent struct {
 	Type      ApplyEventType
 	Operation ApplyEventOperation
 	Object    runtime.Object
+	// Diff contains the unified diff between the live state and the
+	// desired state of the resource. It is only set during dry-run
+	// if diffs have been requested.
+	Diff string
+	// Previous is the live state of the resource before it was
+	// applied. It is only set if fetching the previous object has
+	// been requested, and is nil for resources that were created.
+	Previous *unstructured.Unstructured
+	// Duration is how long the resource took to apply. It is zero
+	// if it is unknown.
+	Duration time.Duration
+	// LatencyBucket is the latency bucket of the Duration, see
+	// LatencyBucketFor. It is empty if the Duration is not set.
+	LatencyBucket string
 }
 
 //go:generate stringer -type=PruneEventType
@@ -119,6 +259,9 @@ type PruneEvent struct {
 	Type      PruneEventType
 	Operation PruneEventOperation
 	Object    runtime.Object
+	// Reason explains why the resource was not pruned. It is only
+	// set for some PruneSkipped events.
+	Reason string
 }
 
 //go:generate stringer -type=DeleteEventType
@@ -0,0 +1,31 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import "time"
+
+// The latency buckets of the ApplyEvents, for coarse-grained analysis
+// of how long the resources took to apply.
+const (
+	LatencyFast     = "fast <100ms"
+	LatencyMedium   = "medium <1s"
+	LatencySlow     = "slow <10s"
+	LatencyVerySlow = "very-slow >=10s"
+)
+
+// LatencyBucketFor returns the latency bucket for the duration. The
+// lower bound of each bucket is inclusive and the upper bound is
+// exclusive.
+func LatencyBucketFor(d time.Duration) string {
+	switch {
+	case d < 100*time.Millisecond:
+		return LatencyFast
+	case d < time.Second:
+		return LatencyMedium
+	case d < 10*time.Second:
+		return LatencySlow
+	default:
+		return LatencyVerySlow
+	}
+}
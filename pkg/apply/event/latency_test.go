@@ -0,0 +1,33 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyBucketFor(t *testing.T) {
+	testCases := map[string]struct {
+		duration time.Duration
+		expected string
+	}{
+		"zero":                 {duration: 0, expected: LatencyFast},
+		"just below 100ms":     {duration: 100*time.Millisecond - 1, expected: LatencyFast},
+		"exactly 100ms":        {duration: 100 * time.Millisecond, expected: LatencyMedium},
+		"just below 1s":        {duration: time.Second - 1, expected: LatencyMedium},
+		"exactly 1s":           {duration: time.Second, expected: LatencySlow},
+		"just below 10s":       {duration: 10*time.Second - 1, expected: LatencySlow},
+		"exactly 10s":          {duration: 10 * time.Second, expected: LatencyVerySlow},
+		"much longer than 10s": {duration: time.Hour, expected: LatencyVerySlow},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.expected, LatencyBucketFor(tc.duration))
+		})
+	}
+}
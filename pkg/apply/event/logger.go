@@ -0,0 +1,88 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"strings"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// EventLogger mirrors events to a logr.Logger. Events about a single
+// resource are logged at Info level with a short message and the
+// operation and the identifier of the resource as structured fields.
+// Error events are logged at Error level. It can be subscribed to an
+// EventBus, for example:
+//
+//	el := event.NewEventLogger(logger)
+//	bus.Subscribe(event.ApplyType, el.Log)
+//	bus.Subscribe(event.ErrorType, el.Log)
+type EventLogger struct {
+	logger logr.Logger
+}
+
+// NewEventLogger returns a new EventLogger that logs to the
+// provided logger.
+func NewEventLogger(logger logr.Logger) *EventLogger {
+	return &EventLogger{
+		logger: logger,
+	}
+}
+
+// Log logs the provided event. Events that don't concern a single
+// resource or an error are ignored.
+func (l *EventLogger) Log(e Event) {
+	switch e.Type {
+	case ErrorType:
+		l.logger.Error(e.ErrorEvent.Err, "operation failed")
+	case ApplyType:
+		if e.ApplyEvent.Type == ApplyEventResourceUpdate {
+			l.logObject("resource applied", e.ApplyEvent.Operation.String(), e.ApplyEvent.Object)
+		}
+	case PruneType:
+		if e.PruneEvent.Type == PruneEventResourceUpdate {
+			l.logObject("resource pruned", e.PruneEvent.Operation.String(), e.PruneEvent.Object)
+		}
+	case DeleteType:
+		if e.DeleteEvent.Type == DeleteEventResourceUpdate {
+			l.logObject("resource deleted", e.DeleteEvent.Operation.String(), e.DeleteEvent.Object)
+		}
+	case StatusType:
+		if e.StatusEvent.EventType == pollevent.ResourceUpdateEvent {
+			l.log("resource status", e.StatusEvent.Resource.Status.String(), e.StatusEvent.Resource.Identifier)
+		}
+	case TimeoutType:
+		l.log("resource timed out", "Timeout", e.TimeoutEvent.Identifier)
+	case StatusFailedType:
+		l.log("resource failed", "Failed", e.StatusFailedEvent.Identifier)
+	case SkipType:
+		l.logObject("resource skipped", "Skipped", e.SkipEvent.Object)
+	case ApplyFailedType:
+		l.logObject("resource failed to apply", "Failed", e.ApplyFailedEvent.Object)
+	}
+}
+
+func (l *EventLogger) logObject(msg, operation string, obj runtime.Object) {
+	id := object.ObjMetadata{
+		GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
+	}
+	if acc, err := meta.Accessor(obj); err == nil {
+		id.Name = acc.GetName()
+		id.Namespace = acc.GetNamespace()
+	}
+	l.log(msg, operation, id)
+}
+
+func (l *EventLogger) log(msg, operation string, id object.ObjMetadata) {
+	l.logger.Info(msg,
+		"operation", strings.ToLower(operation),
+		"group", id.GroupKind.Group,
+		"kind", id.GroupKind.Kind,
+		"namespace", id.Namespace,
+		"name", id.Name)
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestEventLogger(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+			},
+		},
+	}
+	testErr := fmt.Errorf("this is a test error")
+
+	testCases := map[string]struct {
+		event         Event
+		expectedEntry *logEntry
+	}{
+		"apply event is logged at info level": {
+			event: Event{
+				Type: ApplyType,
+				ApplyEvent: ApplyEvent{
+					Type:      ApplyEventResourceUpdate,
+					Operation: Created,
+					Object:    deployment,
+				},
+			},
+			expectedEntry: &logEntry{
+				msg: "resource applied",
+				keysAndValues: []interface{}{
+					"operation", "created",
+					"group", "apps",
+					"kind", "Deployment",
+					"namespace", "default",
+					"name", "foo",
+				},
+			},
+		},
+		"prune event is logged at info level": {
+			event: Event{
+				Type: PruneType,
+				PruneEvent: PruneEvent{
+					Type:      PruneEventResourceUpdate,
+					Operation: Pruned,
+					Object:    deployment,
+				},
+			},
+			expectedEntry: &logEntry{
+				msg: "resource pruned",
+				keysAndValues: []interface{}{
+					"operation", "pruned",
+					"group", "apps",
+					"kind", "Deployment",
+					"namespace", "default",
+					"name", "foo",
+				},
+			},
+		},
+		"error event is logged at error level": {
+			event: Event{
+				Type: ErrorType,
+				ErrorEvent: ErrorEvent{
+					Err: testErr,
+				},
+			},
+			expectedEntry: &logEntry{
+				err: testErr,
+				msg: "operation failed",
+			},
+		},
+		"completed event is not logged": {
+			event: Event{
+				Type: ApplyType,
+				ApplyEvent: ApplyEvent{
+					Type: ApplyEventCompleted,
+				},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			logger := &testLogger{}
+			NewEventLogger(logger).Log(tc.event)
+
+			if tc.expectedEntry == nil {
+				assert.Empty(t, logger.entries)
+				return
+			}
+			if assert.Len(t, logger.entries, 1) {
+				assert.Equal(t, *tc.expectedEntry, logger.entries[0])
+			}
+		})
+	}
+}
+
+type logEntry struct {
+	err           error
+	msg           string
+	keysAndValues []interface{}
+}
+
+// testLogger is a logr.Logger that records all log entries.
+type testLogger struct {
+	entries []logEntry
+}
+
+var _ logr.Logger = &testLogger{}
+
+func (l *testLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, logEntry{msg: msg, keysAndValues: keysAndValues})
+}
+
+func (l *testLogger) Enabled() bool { return true }
+
+func (l *testLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, logEntry{err: err, msg: msg, keysAndValues: keysAndValues})
+}
+
+func (l *testLogger) V(_ int) logr.InfoLogger { return l }
+
+func (l *testLogger) WithValues(_ ...interface{}) logr.Logger { return l }
+
+func (l *testLogger) WithName(_ string) logr.Logger { return l }
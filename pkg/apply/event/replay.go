@@ -0,0 +1,33 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"time"
+)
+
+// ReplayChannel returns a channel that provides the events in order and
+// is then closed. It is mostly useful for testing consumers of the
+// event channel returned by the applier, like printers. If delay is
+// zero, the channel is buffered and already contains all the events.
+// Otherwise the events are written from a separate goroutine, waiting
+// for the delay before each event.
+func ReplayChannel(events []Event, delay time.Duration) <-chan Event {
+	ch := make(chan Event, len(events))
+	if delay <= 0 {
+		for _, e := range events {
+			ch <- e
+		}
+		close(ch)
+		return ch
+	}
+	go func() {
+		defer close(ch)
+		for _, e := range events {
+			time.Sleep(delay)
+			ch <- e
+		}
+	}()
+	return ch
+}
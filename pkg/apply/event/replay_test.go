@@ -0,0 +1,40 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayChannel(t *testing.T) {
+	events := []Event{
+		{Type: InitType},
+		{Type: ApplyType},
+		{Type: PruneType},
+	}
+
+	testCases := map[string]struct {
+		delay time.Duration
+	}{
+		"without delay": {},
+		"with delay": {
+			delay: 10 * time.Millisecond,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			start := time.Now()
+			var types []Type
+			for e := range ReplayChannel(events, tc.delay) {
+				types = append(types, e.Type)
+			}
+			assert.Equal(t, []Type{InitType, ApplyType, PruneType}, types)
+			assert.True(t, time.Since(start) >= time.Duration(len(events))*tc.delay)
+		})
+	}
+}
@@ -17,11 +17,19 @@ func _() {
 	_ = x[StatusType-3]
 	_ = x[PruneType-4]
 	_ = x[DeleteType-5]
+	_ = x[TimeoutType-6]
+	_ = x[NotFoundType-7]
+	_ = x[ResourceTooLargeType-8]
+	_ = x[GarbageCollectedType-9]
+	_ = x[StatusFailedType-10]
+	_ = x[SkipType-11]
+	_ = x[ApplyFailedType-12]
+	_ = x[SummaryType-13]
 }
 
-const _Type_name = "InitTypeErrorTypeApplyTypeStatusTypePruneTypeDeleteType"
+const _Type_name = "InitTypeErrorTypeApplyTypeStatusTypePruneTypeDeleteTypeTimeoutTypeNotFoundTypeResourceTooLargeTypeGarbageCollectedTypeStatusFailedTypeSkipTypeApplyFailedTypeSummaryType"
 
-var _Type_index = [...]uint8{0, 8, 17, 26, 36, 45, 55}
+var _Type_index = [...]uint8{0, 8, 17, 26, 36, 45, 55, 66, 78, 98, 118, 134, 142, 157, 168}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {
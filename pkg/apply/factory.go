@@ -0,0 +1,98 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+// overrideFactory is a Factory that returns the configured clients
+// instead of the ones derived from the wrapped Factory, so all the
+// components created from the Factory use the same clients.
+type overrideFactory struct {
+	util.Factory
+
+	dynamicClient   dynamic.Interface
+	mapper          meta.RESTMapper
+	discoveryClient discovery.CachedDiscoveryInterface
+}
+
+var _ util.Factory = &overrideFactory{}
+
+// DynamicClient returns the configured dynamic client, or the one from
+// the wrapped Factory if none is set.
+func (f *overrideFactory) DynamicClient() (dynamic.Interface, error) {
+	if f.dynamicClient != nil {
+		return f.dynamicClient, nil
+	}
+	return f.Factory.DynamicClient()
+}
+
+// ToRESTMapper returns the configured RESTMapper, or the one from the
+// wrapped Factory if none is set.
+func (f *overrideFactory) ToRESTMapper() (meta.RESTMapper, error) {
+	if f.mapper != nil {
+		return f.mapper, nil
+	}
+	return f.Factory.ToRESTMapper()
+}
+
+// ToDiscoveryClient returns the configured discovery client, or the
+// one from the wrapped Factory if none is set.
+func (f *overrideFactory) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	if f.discoveryClient != nil {
+		return f.discoveryClient, nil
+	}
+	return f.Factory.ToDiscoveryClient()
+}
+
+// cloneFactory returns a copy of the Factory if it is an
+// overrideFactory, so overriding a client on a clone of an Applier
+// doesn't change the original.
+func cloneFactory(f util.Factory) util.Factory {
+	if o, ok := f.(*overrideFactory); ok {
+		c := *o
+		return &c
+	}
+	return f
+}
+
+// overrides replaces the Factory of the Applier with a new
+// overrideFactory and returns it. The new overrideFactory keeps the
+// overrides set previously, but is never shared with another Applier,
+// so setting a client doesn't change the Factory of a clone.
+func (a *Applier) overrides() *overrideFactory {
+	f := &overrideFactory{Factory: a.factory}
+	if o, ok := a.factory.(*overrideFactory); ok {
+		c := *o
+		f = &c
+	}
+	a.factory = f
+	return f
+}
+
+// SetDynamicClient sets the dynamic client used for all API calls
+// made by the Applier, instead of the one derived from the Factory.
+// It must be called before Initialize.
+func (a *Applier) SetDynamicClient(client dynamic.Interface) {
+	a.overrides().dynamicClient = client
+}
+
+// SetRESTMapper sets the RESTMapper used by the Applier, instead of
+// the one derived from the Factory. It must be called before
+// Initialize.
+func (a *Applier) SetRESTMapper(mapper meta.RESTMapper) {
+	a.overrides().mapper = mapper
+}
+
+// SetDiscoveryClient sets the discovery client used by the Applier,
+// instead of the one derived from the Factory. It doesn't change the
+// RESTMapper, which must be set separately with SetRESTMapper. It must
+// be called before Initialize.
+func (a *Applier) SetDiscoveryClient(client discovery.CachedDiscoveryInterface) {
+	a.overrides().discoveryClient = client
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
+)
+
+func TestApplierSetDynamicClient(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+
+	live := obj1Info.Object.(*unstructured.Unstructured).DeepCopy()
+	client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, live)
+	applier.SetDynamicClient(client)
+	mapper, err := tf.ToRESTMapper()
+	if !assert.NoError(t, err) {
+		return
+	}
+	applier.SetRESTMapper(mapper)
+
+	dynamicClient, err := applier.factory.DynamicClient()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, dynamicClient == client, "expected the injected dynamic client")
+
+	// The live state is read with the injected client, while the
+	// factory's own fake client is empty.
+	hash, err := applier.liveStateHash(live)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, hash)
+
+	// Setting another override keeps the previous ones.
+	applier.SetDiscoveryClient(nil)
+	dynamicClient, err = applier.factory.DynamicClient()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, dynamicClient == client, "expected the injected dynamic client")
+}
+
+func TestApplierSetDynamicClientAfterClone(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+	client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+	applier.SetDynamicClient(client)
+	clone, err := applier.Clone()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Overriding a client on the original doesn't change the clone.
+	applier.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(scheme.Scheme))
+	dynamicClient, err := clone.factory.DynamicClient()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, dynamicClient == client, "expected the clone to keep its dynamic client")
+}
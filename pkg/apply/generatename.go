@@ -0,0 +1,136 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+const (
+	// generatedNameSuffixLength is the length of the random suffix
+	// appended to the generateName of a resource. It matches the
+	// length used by the API server.
+	generatedNameSuffixLength = 5
+	// maxGenerateNameLength is the maximum length of the generateName
+	// prefix. Longer values are truncated.
+	maxGenerateNameLength = 63 - generatedNameSuffixLength
+)
+
+// resolveGeneratedNames sets the name of the resources that only have
+// metadata.generateName set. If a resource created from the same
+// generateName is tracked by the previous inventory objects of the
+// provided inventory object template, its name is used so the existing
+// resource is updated. Otherwise a new name is generated the same way
+// the API server does it. The generateName is recorded in the
+// GenerateNameAnnotation of the resource, so only resources whose name
+// was generated are reused. The resource is not created here; it is
+// created by the apply task after the objects and the inventory have
+// been validated, so it is always recorded in the inventory.
+func (a *Applier) resolveGeneratedNames(inv *resource.Info, infos []*resource.Info) error {
+	storedObjs, err := a.invClient.GetStoredObjRefs(inv)
+	if err != nil {
+		return err
+	}
+	// Sort the stored objects so resources sharing the same
+	// generateName are matched deterministically.
+	sort.Slice(storedObjs, func(i, j int) bool {
+		return storedObjs[i].String() < storedObjs[j].String()
+	})
+	dynamicClient, err := a.factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	mapper, err := a.factory.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+
+	used := make(map[object.ObjMetadata]bool)
+	for _, info := range infos {
+		obj, ok := info.Object.(*unstructured.Unstructured)
+		if !ok || obj.GetName() != "" || obj.GetGenerateName() == "" {
+			continue
+		}
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return err
+		}
+		client := dynamicClient.Resource(mapping.Resource).Namespace(info.Namespace)
+
+		name, found, err := findGeneratedName(client, storedObjs, used, gvk.GroupKind(), info.Namespace,
+			obj.GetGenerateName())
+		if err != nil {
+			return err
+		}
+		if !found {
+			name = generateName(obj.GetGenerateName())
+		}
+		obj.SetName(name)
+		info.Name = name
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[common.GenerateNameAnnotation] = obj.GetGenerateName()
+		obj.SetAnnotations(annotations)
+	}
+	return nil
+}
+
+// generateName returns a name built from the generateName prefix and a
+// random suffix.
+func generateName(base string) string {
+	if len(base) > maxGenerateNameLength {
+		base = base[:maxGenerateNameLength]
+	}
+	return base + utilrand.String(generatedNameSuffixLength)
+}
+
+// findGeneratedName returns the name of a stored object of the given
+// GroupKind and namespace whose name was generated from generateName,
+// and that has not already been used for another resource. Only
+// objects in the cluster with the generateName in their
+// GenerateNameAnnotation match, so resources that were explicitly
+// given a name with the same prefix are never reused.
+func findGeneratedName(client dynamic.ResourceInterface, storedObjs []object.ObjMetadata,
+	used map[object.ObjMetadata]bool, gk schema.GroupKind, namespace, generateName string) (string, bool, error) {
+	prefix := generateName
+	if len(prefix) > maxGenerateNameLength {
+		prefix = prefix[:maxGenerateNameLength]
+	}
+	for _, stored := range storedObjs {
+		if used[stored] || stored.GroupKind != gk || stored.Namespace != namespace {
+			continue
+		}
+		if !strings.HasPrefix(stored.Name, prefix) ||
+			len(stored.Name) != len(prefix)+generatedNameSuffixLength {
+			continue
+		}
+		obj, err := client.Get(stored.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if obj.GetAnnotations()[common.GenerateNameAnnotation] != generateName {
+			continue
+		}
+		used[stored] = true
+		return stored.Name, true, nil
+	}
+	return "", false, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+)
+
+func TestResolveGeneratedNames(t *testing.T) {
+	testCases := map[string]struct {
+		storedNames       []string
+		generatedNames    map[string]string
+		explicitNames     []string
+		expectedName      string
+		expectedGenerated bool
+	}{
+		"first apply generates a name": {
+			expectedGenerated: true,
+		},
+		"later apply uses the stored name": {
+			storedNames:    []string{"cm-fghij"},
+			generatedNames: map[string]string{"cm-fghij": "cm-"},
+			expectedName:   "cm-fghij",
+		},
+		"stored name for other generateName is not used": {
+			storedNames:       []string{"other-fghij"},
+			generatedNames:    map[string]string{"other-fghij": "other-"},
+			expectedGenerated: true,
+		},
+		"explicitly named resource with the prefix is not used": {
+			storedNames:       []string{"cm-fghij"},
+			explicitNames:     []string{"cm-fghij"},
+			expectedGenerated: true,
+		},
+		"stored resource no longer exists": {
+			storedNames:       []string{"cm-fghij"},
+			expectedGenerated: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+
+			var storedInfos []*resource.Info
+			var clusterObjs []runtime.Object
+			for _, name := range tc.storedNames {
+				storedInfos = append(storedInfos, configMapInfo(name, ""))
+			}
+			for name, generateName := range tc.generatedNames {
+				obj := configMapInfo(name, "").Object.(*unstructured.Unstructured)
+				obj.SetAnnotations(map[string]string{common.GenerateNameAnnotation: generateName})
+				clusterObjs = append(clusterObjs, obj)
+			}
+			for _, name := range tc.explicitNames {
+				clusterObjs = append(clusterObjs, configMapInfo(name, "").Object)
+			}
+			tf.FakeDynamicClient = dynamicfake.NewSimpleDynamicClient(scheme.Scheme, clusterObjs...)
+			var created bool
+			tf.FakeDynamicClient.PrependReactor("create", "configmaps",
+				func(action clienttesting.Action) (bool, runtime.Object, error) {
+					created = true
+					return false, nil, nil
+				})
+
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplier(tf, ioStreams)
+			inv := *inventoryObjInfo
+			inv.Object = inventoryObjInfo.Object.DeepCopyObject()
+			pastInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(&inv), storedInfos)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{pastInventory})
+
+			info := configMapInfo("", "cm-")
+			currentInv := *inventoryObjInfo
+			currentInv.Object = inventoryObjInfo.Object.DeepCopyObject()
+			err = applier.resolveGeneratedNames(&currentInv, []*resource.Info{info})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			obj := info.Object.(*unstructured.Unstructured)
+			name := obj.GetName()
+			assert.Equal(t, name, info.Name)
+			assert.Equal(t, "cm-", obj.GetAnnotations()[common.GenerateNameAnnotation])
+			if tc.expectedGenerated {
+				assert.True(t, strings.HasPrefix(name, "cm-"))
+				assert.Len(t, name, len("cm-")+generatedNameSuffixLength)
+			} else {
+				assert.Equal(t, tc.expectedName, name)
+			}
+			// Resources must not be created before the objects and the
+			// inventory have been validated.
+			assert.False(t, created)
+		})
+	}
+}
+
+func configMapInfo(name, generateName string) *resource.Info {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+			},
+		},
+	}
+	if name != "" {
+		obj.SetName(name)
+	}
+	if generateName != "" {
+		obj.SetGenerateName(generateName)
+	}
+	return &resource.Info{
+		Namespace: namespace,
+		Name:      name,
+		Object:    obj,
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+)
+
+// GetLastApplyTime returns the time of the last successful apply, as
+// stored in the annotation on the inventory object, or nil if the
+// Applier hasn't completed a successful apply. It should be called
+// after the event channel returned by Run has been closed.
+func (a *Applier) GetLastApplyTime() *time.Time {
+	if a.lastInventory == nil {
+		return nil
+	}
+	value, found := a.lastInventory.GetAnnotations()[common.LastApplyTimeAnnotation]
+	if !found {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// saveHistory stores a snapshot of the applied resources next to the
+// inventory object template found in objects.
+func (a *Applier) saveHistory(objects, resources []*resource.Info, limit int) error {
+	inv, found := inventory.FindInventoryObj(objects)
+	if !found {
+		return inventory.NoInventoryObjError{}
+	}
+	dynamicClient, err := a.factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	return inventory.SaveHistory(dynamicClient, inv, resources, limit)
+}
+
+// recordResourceHistory adds the applied resources to the resource
+// history of the current inventory object in the cluster, keeping the
+// last limit revisions of each.
+func (a *Applier) recordResourceHistory(resourceObjects *ResourceObjects, mapper meta.RESTMapper, limit int) error {
+	dynamicClient, err := a.factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	return inventory.RecordResourceHistory(dynamicClient, mapper, resourceObjects.CurrentInventory,
+		resourceObjects.PreviousInventories, resourceObjects.Resources, limit)
+}
+
+// recordResourceVersions records the resource versions of the applied
+// resources in the current inventory object in the cluster, if it is
+// in the v2 inventory format.
+func (a *Applier) recordResourceVersions(resourceObjects *ResourceObjects, mapper meta.RESTMapper) error {
+	inv, ok := resourceObjects.CurrentInventory.Object.(*unstructured.Unstructured)
+	if !ok || inventory.GetInventoryFormat(inv) != inventory.InventoryFormatV2 {
+		return nil
+	}
+	dynamicClient, err := a.factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	invInfo := &resource.Info{
+		Name:      resourceObjects.CurrentInventory.Name,
+		Namespace: resourceObjects.CurrentInventory.Namespace,
+		Object:    inv,
+	}
+	return inventory.RecordResourceVersions(dynamicClient, mapper, invInfo, resourceObjects.Resources)
+}
+
+// recordApplyTime sets the last apply time annotation on the inventory
+// object in the cluster to the provided time.
+func (a *Applier) recordApplyTime(inv *resource.Info, mapper meta.RESTMapper, now time.Time) error {
+	dynamicClient, err := a.factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	mapping, err := mapper.RESTMapping(inv.Object.GetObjectKind().GroupVersionKind().GroupKind())
+	if err != nil {
+		return err
+	}
+	client := dynamicClient.Resource(mapping.Resource).Namespace(inv.Namespace)
+	obj, err := client.Get(inv.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[common.LastApplyTimeAnnotation] = now.UTC().Format(time.RFC3339)
+	obj.SetAnnotations(annotations)
+	updated, err := client.Update(obj, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	a.lastInventory = updated
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// ApplyPatch applies a RFC 6902 JSON Patch to the live state of every
+// resource tracked by the inventory identified by the InventoryName
+// and InventoryNamespace options whose labels match the
+// PatchLabelSelector option. Resources that no longer exist in the
+// cluster are skipped. Unlike Run, no manifests are needed and the
+// resources are neither pruned nor waited for. Since patching never
+// creates resources, the inventory is left unchanged. The inventory is
+// read with a new inventory client, since the one of the Applier caches
+// the inventory objects read during earlier runs. The Applier must
+// have been initialized.
+func (a *Applier) ApplyPatch(ctx context.Context, patch []byte, options Options) error {
+	if _, err := jsonpatch.DecodePatch(patch); err != nil {
+		return fmt.Errorf("invalid JSON patch: %v", err)
+	}
+	selector := labels.Everything()
+	if options.PatchLabelSelector != "" {
+		var err error
+		selector, err = labels.Parse(options.PatchLabelSelector)
+		if err != nil {
+			return fmt.Errorf("invalid label selector %q: %w", options.PatchLabelSelector, err)
+		}
+	}
+	if options.InventoryName == "" {
+		return inventory.NoInventoryObjError{}
+	}
+	invNamespace := options.InventoryNamespace
+	if invNamespace == "" {
+		invNamespace = a.ApplyOptions.Namespace
+	}
+	inventoryObject := inventory.NewInventoryObjectTemplate(options.InventoryName, invNamespace)
+
+	tracked, err := a.ListTrackedResources(ctx, inventoryObject)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := a.factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	patchOptions := metav1.PatchOptions{}
+	if options.DryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+	for _, tr := range tracked {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if tr.Missing {
+			continue
+		}
+		accessor, err := meta.Accessor(tr.Object)
+		if err != nil {
+			return err
+		}
+		if !selector.Matches(labels.Set(accessor.GetLabels())) {
+			continue
+		}
+		_, err = dynamicClient.Resource(tr.Mapping.Resource).Namespace(tr.Namespace).
+			Patch(tr.Name, types.JSONPatchType, patch, patchOptions)
+		if err != nil {
+			id := object.ObjMetadata{
+				Namespace: tr.Namespace,
+				Name:      tr.Name,
+				GroupKind: tr.Mapping.GroupVersionKind.GroupKind(),
+			}
+			return fmt.Errorf("error patching %s: %w", id, err)
+		}
+	}
+	return nil
+}
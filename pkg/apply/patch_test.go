@@ -0,0 +1,137 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+)
+
+func TestApplyPatch(t *testing.T) {
+	pod := func(name, app string) *resource.Info {
+		return &resource.Info{
+			Namespace: namespace,
+			Name:      name,
+			Object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": map[string]interface{}{
+						"name":      name,
+						"namespace": namespace,
+						"labels": map[string]interface{}{
+							"app": app,
+						},
+					},
+				},
+			},
+		}
+	}
+	pods := []*resource.Info{pod("web-1", "web"), pod("web-2", "web"), pod("db", "db")}
+	patch := []byte(`[{"op": "add", "path": "/metadata/labels/patched", "value": "true"}]`)
+
+	testCases := map[string]struct {
+		options Options
+		patch   []byte
+
+		expectedPatched []string
+		expectedError   bool
+	}{
+		"only matching resources are patched": {
+			options: Options{
+				InventoryName:      "test-inventory",
+				PatchLabelSelector: "app=web",
+			},
+			patch:           patch,
+			expectedPatched: []string{"web-1", "web-2"},
+		},
+		"all resources are patched without selector": {
+			options: Options{
+				InventoryName: "test-inventory",
+			},
+			patch:           patch,
+			expectedPatched: []string{"web-1", "web-2", "db"},
+		},
+		"invalid patch": {
+			options: Options{
+				InventoryName: "test-inventory",
+			},
+			patch:         []byte(`{"op": "add"}`),
+			expectedError: true,
+		},
+		"invalid label selector": {
+			options: Options{
+				InventoryName:      "test-inventory",
+				PatchLabelSelector: "app in (",
+			},
+			patch:         patch,
+			expectedError: true,
+		},
+		"no inventory": {
+			patch:         patch,
+			expectedError: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+			client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme,
+				pods[0].Object.DeepCopyObject(), pods[1].Object.DeepCopyObject(), pods[2].Object.DeepCopyObject())
+			tf.FakeDynamicClient = client
+
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplier(tf, ioStreams)
+			inv := *inventoryObjInfo
+			inv.Object = inventoryObjInfo.Object.DeepCopyObject()
+			pastInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(&inv), pods)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			// The cached inventory client of the Applier is stale, as it
+			// would be after a Run, so the inventory must be read with
+			// a new client.
+			applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
+			applier.InventoryClientFactoryFunc = func(cmdutil.Factory) (inventory.InventoryClient, error) {
+				return inventory.NewFakeInventoryClient([]*resource.Info{pastInventory}), nil
+			}
+
+			err = applier.ApplyPatch(context.Background(), tc.patch, tc.options)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			patched := make(map[string]bool)
+			for _, name := range tc.expectedPatched {
+				patched[name] = true
+			}
+			for _, p := range pods {
+				live, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).
+					Namespace(namespace).Get(p.Name, metav1.GetOptions{})
+				if !assert.NoError(t, err) {
+					continue
+				}
+				_, found := live.GetLabels()["patched"]
+				assert.Equal(t, patched[p.Name], found, "patched label on %s", p.Name)
+			}
+		})
+	}
+}
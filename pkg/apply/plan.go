@@ -0,0 +1,215 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// Plan contains the changes an apply would make to the cluster, as
+// computed by a dry-run. It can be stored and later applied with
+// exactly the same manifests, as long as the live state of the
+// resources hasn't changed in the meantime.
+type Plan struct {
+	Resources []PlannedResource `json:"resources"`
+}
+
+// PlannedResource is a single resource in a Plan.
+type PlannedResource struct {
+	// Manifest is the manifest of the resource that will be applied.
+	Manifest *unstructured.Unstructured `json:"manifest"`
+	// Operation is the operation the dry-run reported for the
+	// resource, like "created" or "configured". It is empty if the
+	// dry-run didn't report an operation for the resource.
+	Operation string `json:"operation,omitempty"`
+	// LiveStateHash is the hash of the live state of the resource
+	// when the plan was created, or empty if the resource didn't
+	// exist.
+	LiveStateHash string `json:"liveStateHash,omitempty"`
+}
+
+// PlanConflictError is returned when the live state of some of the
+// resources in a plan has changed since the plan was created.
+type PlanConflictError struct {
+	Identifiers []object.ObjMetadata
+}
+
+func (e PlanConflictError) Error() string {
+	ids := make([]string, 0, len(e.Identifiers))
+	for _, id := range e.Identifiers {
+		ids = append(ids, id.String())
+	}
+	return fmt.Sprintf("live state changed since the plan was created: %s", strings.Join(ids, ", "))
+}
+
+// BuildPlan performs a dry-run apply of the objects and returns the
+// resulting plan. The DryRun field of the options is ignored. The
+// Applier must have been initialized.
+func (a *Applier) BuildPlan(ctx context.Context, objects []*resource.Info, options Options) (*Plan, error) {
+	plan := &Plan{}
+	for _, info := range objects {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("resource %s/%s is not an Unstructured", info.Namespace, info.Name)
+		}
+		hash, err := a.liveStateHash(u)
+		if err != nil {
+			return nil, err
+		}
+		plan.Resources = append(plan.Resources, PlannedResource{
+			Manifest:      u.DeepCopy(),
+			LiveStateHash: hash,
+		})
+	}
+
+	options.DryRun = true
+	operations := make(map[object.ObjMetadata]string)
+	var err error
+	for e := range a.Run(ctx, objects, options) {
+		switch e.Type {
+		case event.ErrorType:
+			if err == nil {
+				err = e.ErrorEvent.Err
+			}
+		case event.ApplyType:
+			if e.ApplyEvent.Type != event.ApplyEventResourceUpdate {
+				continue
+			}
+			if id, idErr := objectIdentifier(e.ApplyEvent.Object); idErr == nil {
+				operations[id] = strings.ToLower(e.ApplyEvent.Operation.String())
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range plan.Resources {
+		if id, idErr := objectIdentifier(plan.Resources[i].Manifest); idErr == nil {
+			plan.Resources[i].Operation = operations[id]
+		}
+	}
+	return plan, nil
+}
+
+// CheckPlan verifies that the live state of every resource in the plan
+// is the same as when the plan was created. Returns a
+// PlanConflictError listing the changed resources otherwise.
+func (a *Applier) CheckPlan(plan *Plan) error {
+	var conflicts []object.ObjMetadata
+	for _, r := range plan.Resources {
+		hash, err := a.liveStateHash(r.Manifest)
+		if err != nil {
+			return err
+		}
+		if hash == r.LiveStateHash {
+			continue
+		}
+		id, err := objectIdentifier(r.Manifest)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, id)
+	}
+	if len(conflicts) > 0 {
+		return PlanConflictError{Identifiers: conflicts}
+	}
+	return nil
+}
+
+// WritePlan writes the plan as JSON to w.
+func WritePlan(w io.Writer, plan *Plan) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(plan)
+}
+
+// ReadPlan reads a plan written by WritePlan from r.
+func ReadPlan(r io.Reader) (*Plan, error) {
+	plan := &Plan{}
+	if err := json.NewDecoder(r).Decode(plan); err != nil {
+		return nil, fmt.Errorf("reading plan: %w", err)
+	}
+	return plan, nil
+}
+
+// serverManagedFields are the fields of the live state that the
+// server updates on its own. They are not part of the hash, so
+// status updates by controllers don't conflict with a plan.
+var serverManagedFields = [][]string{
+	{"status"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "managedFields"},
+	{"metadata", "generation"},
+}
+
+// liveStateHash returns the hash of the live state of the resource in
+// the cluster, ignoring the server-managed fields, or an empty string
+// if it doesn't exist. The type of a custom resource is unknown if its
+// CRD is applied together with it, so the resource doesn't exist yet.
+func (a *Applier) liveStateHash(u *unstructured.Unstructured) (string, error) {
+	mapper, err := a.factory.ToRESTMapper()
+	if err != nil {
+		return "", err
+	}
+	mapping, err := mapper.RESTMapping(u.GroupVersionKind().GroupKind())
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	dynamicClient, err := a.factory.DynamicClient()
+	if err != nil {
+		return "", err
+	}
+	namespace := u.GetNamespace()
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		namespace = ""
+	}
+	live, err := dynamicClient.Resource(mapping.Resource).Namespace(namespace).
+		Get(u.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, field := range serverManagedFields {
+		unstructured.RemoveNestedField(live.Object, field...)
+	}
+	data, err := json.Marshal(live)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// objectIdentifier returns the identifier of the object.
+func objectIdentifier(obj runtime.Object) (object.ObjMetadata, error) {
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		return object.ObjMetadata{}, err
+	}
+	return object.ObjMetadata{
+		GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
+		Namespace: acc.GetNamespace(),
+		Name:      acc.GetName(),
+	}, nil
+}
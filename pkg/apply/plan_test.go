@@ -0,0 +1,151 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestWriteReadPlan(t *testing.T) {
+	plan := &Plan{
+		Resources: []PlannedResource{
+			{
+				Manifest:      obj1Info.Object.(*unstructured.Unstructured),
+				Operation:     "configured",
+				LiveStateHash: "abc",
+			},
+			{
+				Manifest:  inventoryObjInfo.Object.(*unstructured.Unstructured),
+				Operation: "created",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, WritePlan(&buf, plan)) {
+		return
+	}
+	read, err := ReadPlan(&buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, plan, read)
+}
+
+func TestCheckPlan(t *testing.T) {
+	testCases := map[string]struct {
+		liveLabels map[string]string
+		liveStatus map[string]interface{}
+		conflicts  []object.ObjMetadata
+	}{
+		"live state unchanged": {},
+		"only server-managed fields changed": {
+			liveStatus: map[string]interface{}{"phase": "Running"},
+		},
+		"live state changed": {
+			liveLabels: map[string]string{"changed": "true"},
+			conflicts: []object.ObjMetadata{
+				{
+					GroupKind: v1.SchemeGroupVersion.WithKind("Pod").GroupKind(),
+					Namespace: namespace,
+					Name:      "obj1",
+				},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+
+			live := obj1Info.Object.(*unstructured.Unstructured).DeepCopy()
+			client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, live)
+			tf.FakeDynamicClient = client
+
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplier(tf, ioStreams)
+
+			hash, err := applier.liveStateHash(live)
+			if !assert.NoError(t, err) {
+				return
+			}
+			plan := &Plan{
+				Resources: []PlannedResource{
+					{
+						Manifest:      obj1Info.Object.(*unstructured.Unstructured),
+						LiveStateHash: hash,
+					},
+					{
+						// Doesn't exist in the cluster, so the hash is empty.
+						Manifest: inventoryObjInfo.Object.(*unstructured.Unstructured),
+					},
+				},
+			}
+
+			if tc.liveLabels != nil || tc.liveStatus != nil {
+				if tc.liveLabels != nil {
+					live.SetLabels(tc.liveLabels)
+				}
+				if tc.liveStatus != nil {
+					live.Object["status"] = tc.liveStatus
+					live.SetResourceVersion("2")
+					live.SetGeneration(2)
+				}
+				_, err = client.Resource(v1.SchemeGroupVersion.WithResource("pods")).
+					Namespace(namespace).Update(live, metav1.UpdateOptions{})
+				if !assert.NoError(t, err) {
+					return
+				}
+			}
+
+			err = applier.CheckPlan(plan)
+			if len(tc.conflicts) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if !assert.IsType(t, PlanConflictError{}, err) {
+				return
+			}
+			assert.Equal(t, tc.conflicts, err.(PlanConflictError).Identifiers)
+		})
+	}
+}
+
+func TestLiveStateHashUnknownType(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+
+	// The CRD of the custom resource is applied together with it, so
+	// its type is not known yet.
+	cr := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":      "widget",
+				"namespace": namespace,
+			},
+		},
+	}
+	hash, err := applier.liveStateHash(cr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, hash)
+}
@@ -13,19 +13,27 @@ package prune
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/go-logr/logr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog"
 	"k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 // PruneOptions encapsulates the necessary information to
@@ -47,6 +55,23 @@ type PruneOptions struct {
 	// InventoryFactoryFunc wraps and returns an interface for the
 	// object which will load and store the inventory.
 	InventoryFactoryFunc func(*resource.Info) inventory.Inventory
+	// Logger is used for structured logging. Can be nil.
+	Logger logr.Logger
+	// DryRunCallback is called with each object that would be pruned
+	// when running with DryRun, before the prune event for it is sent.
+	// Can be nil.
+	DryRunCallback func(obj *unstructured.Unstructured)
+	// RateLimiter limits the rate of delete calls to the API server.
+	// Each delete call waits for RateLimiter.Accept. Can be nil.
+	RateLimiter flowcontrol.RateLimiter
+	// PrePruneFilter is called with each object that is about to be
+	// pruned. Returning false skips pruning the object and keeps it
+	// in the inventory. Can be nil.
+	PrePruneFilter func(*resource.Info) bool
+	// SkipFinalizers lists finalizers protecting objects from being
+	// pruned. Objects with any of them in their finalizers are skipped
+	// and kept in the inventory.
+	SkipFinalizers []string
 }
 
 // NewPruneOptions returns a struct (PruneOptions) encapsulating the necessary
@@ -58,6 +83,16 @@ func NewPruneOptions(currentUids sets.String) *PruneOptions {
 	return po
 }
 
+// Copy returns a copy of the PruneOptions which computes the prune set
+// from the passed set of currently applied object UIDs. All other
+// options are copied, so the copy must be initialized again if it
+// should use different clients.
+func (po *PruneOptions) Copy(currentUids sets.String) *PruneOptions {
+	c := *po
+	c.currentUids = currentUids
+	return &c
+}
+
 func (po *PruneOptions) Initialize(factory util.Factory, invClient inventory.InventoryClient) error {
 	var err error
 	po.invClient = invClient
@@ -73,6 +108,15 @@ func (po *PruneOptions) Initialize(factory util.Factory, invClient inventory.Inv
 	return nil
 }
 
+// logger returns the Logger of the PruneOptions, or a no-op logger
+// if none has been set.
+func (po *PruneOptions) logger() logr.Logger {
+	if po.Logger == nil {
+		return common.NullLogger{}
+	}
+	return po.Logger
+}
+
 // Options defines a set of parameters that can be used to tune
 // the behavior of the pruner.
 type Options struct {
@@ -81,12 +125,83 @@ type Options struct {
 	DryRun bool
 
 	PropagationPolicy metav1.DeletionPropagation
+
+	// PropagationPolicyMap defines the deletion propagation policy for
+	// objects of specific kinds. It takes precedence over the
+	// PropagationPolicy for the kinds in the map.
+	PropagationPolicyMap map[schema.GroupKind]metav1.DeletionPropagation
+
+	// PruneUnusedNamespaces defines whether namespaces that no longer
+	// contain any of the objects known by the inventory after pruning
+	// should also be pruned. Only namespaces owned by the inventory,
+	// which have the InventoryLabel with the same value as the current
+	// inventory object, are pruned, so shared namespaces are never
+	// deleted. The default and kube-* namespaces are never pruned.
+	PruneUnusedNamespaces bool
+
+	// NamespaceScoped defines whether pruning should be restricted to
+	// namespaced resources. If true, cluster-scoped resources are
+	// never pruned and are kept in the inventory.
+	NamespaceScoped bool
+
+	// ContinueOnError defines whether pruning should continue with the
+	// remaining objects if deleting an object fails. The errors are
+	// returned together once all objects have been processed, and the
+	// previous inventory objects are kept so the objects that could not
+	// be deleted are still tracked.
+	ContinueOnError bool
+
+	// LabelSelector restricts pruning to the objects whose labels in
+	// the cluster match the selector. Objects that don't match are left
+	// in the cluster and kept in the inventory. If nil, all objects are
+	// pruned.
+	LabelSelector labels.Selector
+
+	// GarbageCollect defines whether objects in the inventory whose
+	// type is no longer known by the API server should be removed from
+	// the inventory instead of failing the prune. This happens when
+	// a CRD is removed from the cluster together with all its
+	// instances.
+	GarbageCollect bool
+
+	// ForceDelete defines whether objects should be deleted even if
+	// their lifecycle annotation says they should be kept or detached.
+	// This also applies to unused namespaces.
+	ForceDelete bool
+
+	// NoPruneAnnotationKey and NoPruneAnnotationValue override the key
+	// and value of the annotation which prevents objects from being
+	// pruned. If either is set, objects are kept if they have the
+	// annotation with the value, and the "on-remove" annotation is not
+	// checked. The key defaults to the "on-remove" annotation and the
+	// value defaults to "keep".
+	NoPruneAnnotationKey   string
+	NoPruneAnnotationValue string
+
+	// KeepObjects lists objects which must neither be pruned nor
+	// removed from the inventory, for example the resources which
+	// failed to apply, since their UIDs are not among the currently
+	// applied objects.
+	KeepObjects []object.ObjMetadata
+
+	// SkipInventoryUpdate defines whether the inventory in the cluster
+	// should be left untouched. If true, the previous inventory objects
+	// are not deleted since the current inventory object has not
+	// been applied.
+	SkipInventoryUpdate bool
+
+	// DeleteCallback is called with the identifier of each previously
+	// applied object that is deleted from the cluster. Objects that
+	// are skipped are never deleted, so it is not called for them.
+	// Can be nil.
+	DeleteCallback func(id object.ObjMetadata)
 }
 
 // Prune deletes the set of resources which were previously applied
 // (retrieved from previous inventory objects) but omitted in
 // the current apply. Prune also delete all previous inventory
-// objects. Returns an error if there was a problem.
+// objects, unless SkipInventoryUpdate is set. Returns an error if
+// there was a problem.
 func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan<- event.Event, o Options) error {
 	currentInventoryObject, found := inventory.FindInventoryObj(currentObjects)
 	if !found {
@@ -103,10 +218,39 @@ func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan
 	}
 	klog.V(4).Infof("prune %d currently applied objects", len(po.currentUids))
 	klog.V(4).Infof("prune %d previously applied objects", len(pastObjs))
+	// Keep track of the namespaces that still contain objects known by
+	// the inventory, and the namespaces where objects have been pruned.
+	// Only used if unused namespaces should be pruned.
+	usedNamespaces := namespacesForInfos(currentObjects)
+	prunedNamespaces := sets.NewString()
+	deletedNamespaces := sets.NewString()
+	var deleteErrs []error
+	// Objects that are removed from the inventory, and objects that
+	// are retained in it without being currently applied.
+	var prunedObjs []object.ObjMetadata
+	var retainedObjs []object.ObjMetadata
+	// Resource versions recorded by the previous applies, only looked
+	// up once an object is about to be pruned.
+	var storedVersions map[object.ObjMetadata]string
+	keepObjects := make(map[object.ObjMetadata]bool)
+	for _, id := range o.KeepObjects {
+		keepObjects[id] = true
+	}
 	// Iterate through set of all previously applied objects.
 	for _, past := range pastObjs {
 		mapping, err := po.mapper.RESTMapping(past.GroupKind)
 		if err != nil {
+			if o.GarbageCollect && meta.IsNoMatchError(err) {
+				klog.V(4).Infof("prune object type no longer exists; removing from inventory: %s", past)
+				prunedObjs = append(prunedObjs, past)
+				eventChannel <- event.Event{
+					Type: event.GarbageCollectedType,
+					GarbageCollectedEvent: event.GarbageCollectedEvent{
+						Identifier: past,
+					},
+				}
+				continue
+			}
 			return err
 		}
 		namespacedClient := po.client.Resource(mapping.Resource).Namespace(past.Namespace)
@@ -114,6 +258,7 @@ func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan
 		if err != nil {
 			// Object not found in cluster, so no need to delete it; skip to next object.
 			if apierrors.IsNotFound(err) {
+				prunedObjs = append(prunedObjs, past)
 				continue
 			}
 			return err
@@ -129,32 +274,158 @@ func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan
 		klog.V(7).Infof("prune previously applied object UID: %s", uid)
 		if po.currentUids.Has(uid) {
 			klog.V(7).Infof("prune object in current apply; do not prune: %s", uid)
+			usedNamespaces.Insert(past.Namespace)
+			continue
+		}
+		if keepObjects[past] {
+			klog.V(7).Infof("prune object is kept; do not prune: %s", uid)
+			usedNamespaces.Insert(past.Namespace)
+			retainedObjs = append(retainedObjs, past)
+			continue
+		}
+		if o.LabelSelector != nil && !o.LabelSelector.Matches(labels.Set(metadata.GetLabels())) {
+			klog.V(7).Infof("prune object does not match label selector; do not prune: %s", uid)
+			usedNamespaces.Insert(past.Namespace)
+			retainedObjs = append(retainedObjs, past)
 			continue
 		}
-		// Handle lifecycle directive preventing deletion.
-		if preventDeleteAnnotation(metadata.GetAnnotations()) {
-			klog.V(7).Infof("prune object lifecycle directive; do not prune: %s", uid)
+		if o.NamespaceScoped && past.Namespace == "" {
+			klog.V(7).Infof("prune object is cluster-scoped; do not prune: %s", uid)
+			usedNamespaces.Insert(past.Namespace)
+			retainedObjs = append(retainedObjs, past)
+			e := createPruneEvent(obj, event.PruneSkipped)
+			e.PruneEvent.Reason = namespaceScopedSkipReason
+			eventChannel <- e
+			continue
+		}
+		if po.PrePruneFilter != nil && !po.PrePruneFilter(&resource.Info{
+			Name:      past.Name,
+			Namespace: past.Namespace,
+			Mapping:   mapping,
+			Object:    obj,
+		}) {
+			klog.V(7).Infof("prune object filtered by PrePruneFilter; do not prune: %s", uid)
+			usedNamespaces.Insert(past.Namespace)
+			retainedObjs = append(retainedObjs, past)
+			e := createPruneEvent(obj, event.PruneSkipped)
+			e.PruneEvent.Reason = prePruneFilterSkipReason
+			eventChannel <- e
+			continue
+		}
+		if po.hasSkipFinalizer(metadata.GetFinalizers()) {
+			klog.V(7).Infof("prune object has protected finalizer; do not prune: %s", uid)
+			usedNamespaces.Insert(past.Namespace)
+			retainedObjs = append(retainedObjs, past)
+			e := createPruneEvent(obj, event.PruneSkipped)
+			e.PruneEvent.Reason = protectedFinalizerSkipReason
+			eventChannel <- e
+			continue
+		}
+		// Handle lifecycle directives preventing deletion.
+		lifecycle := o.lifecycleDirective(metadata.GetAnnotations())
+		if o.ForceDelete && lifecycle != LifecycleDelete {
+			klog.V(7).Infof("prune object lifecycle directive %s overridden by force delete: %s", lifecycle, uid)
+			lifecycle = LifecycleDelete
+		}
+		if lifecycle != LifecycleDelete {
+			klog.V(7).Infof("prune object lifecycle directive %s; do not prune: %s", lifecycle, uid)
+			usedNamespaces.Insert(past.Namespace)
+			// Detached objects are released from the inventory, while
+			// kept objects stay tracked by it.
+			if lifecycle == LifecycleDetach {
+				prunedObjs = append(prunedObjs, past)
+			} else {
+				retainedObjs = append(retainedObjs, past)
+			}
 			eventChannel <- createPruneEvent(obj, event.PruneSkipped)
 			continue
 		}
-		if !o.DryRun {
-			klog.V(7).Infof("prune object delete: %s/%s", past.Namespace, past.Name)
-			err = namespacedClient.Delete(past.Name, &metav1.DeleteOptions{})
+		if storedVersions == nil {
+			storedVersions, err = po.storedResourceVersions(currentInventoryObject)
 			if err != nil {
 				return err
 			}
 		}
+		if rv, found := storedVersions[past]; found && rv != metadata.GetResourceVersion() {
+			klog.Warningf("prune object modified since it was last applied (resourceVersion %s, last applied %s): %s",
+				metadata.GetResourceVersion(), rv, past)
+			po.logger().Info("Pruning resource modified since last apply", "kind", past.GroupKind.Kind,
+				"namespace", past.Namespace, "name", past.Name)
+		}
+		po.logger().Info("Pruning resource", "kind", past.GroupKind.Kind,
+			"namespace", past.Namespace, "name", past.Name, "dryRun", o.DryRun)
+		if o.DryRun {
+			po.dryRunCallback(obj)
+		} else {
+			klog.V(7).Infof("prune object delete: %s/%s", past.Namespace, past.Name)
+			po.acceptRateLimit()
+			err = namespacedClient.Delete(past.Name, o.deleteOptions(past.GroupKind))
+			if err != nil {
+				po.logger().Error(err, "Failed to prune resource", "kind", past.GroupKind.Kind,
+					"namespace", past.Namespace, "name", past.Name)
+				if !o.ContinueOnError {
+					return err
+				}
+				usedNamespaces.Insert(past.Namespace)
+				deleteErrs = append(deleteErrs, err)
+				continue
+			}
+			if o.DeleteCallback != nil {
+				o.DeleteCallback(past)
+			}
+		}
+		if past.GroupKind == namespaceGK {
+			deletedNamespaces.Insert(past.Name)
+		} else {
+			prunedNamespaces.Insert(past.Namespace)
+		}
+		prunedObjs = append(prunedObjs, past)
 		eventChannel <- createPruneEvent(obj, event.Pruned)
 	}
+	// Namespaces are cluster-scoped, so they are never pruned if pruning
+	// is restricted to namespaced resources.
+	if o.PruneUnusedNamespaces && !o.NamespaceScoped {
+		unusedNamespaces := prunedNamespaces.Difference(usedNamespaces).Difference(deletedNamespaces)
+		err = po.pruneUnusedNamespaces(unusedNamespaces, currentInventoryObject, eventChannel, o)
+		if err != nil {
+			return err
+		}
+	}
+	if len(deleteErrs) > 0 {
+		return utilerrors.NewAggregate(deleteErrs)
+	}
+	if o.SkipInventoryUpdate {
+		klog.V(4).Infof("prune skipping deletion of previous inventory objects")
+		return nil
+	}
+	// The current inventory object only tracks the currently applied
+	// objects, so the objects that were retained without being currently
+	// applied are added to it before the previous inventory objects
+	// tracking them are deleted.
+	if len(retainedObjs) > 0 && !o.DryRun {
+		klog.V(4).Infof("prune adding %d retained objects to current inventory object", len(retainedObjs))
+		// Copy the info, since its object is replaced with the
+		// updated inventory object.
+		inv := *currentInventoryObject
+		err = inventory.AddInventoryEntries(po.client, po.mapper, &inv, retainedObjs)
+		if err != nil {
+			return err
+		}
+	}
 	// Delete previous inventory objects.
 	pastInventories, err := po.invClient.GetPreviousInventoryObjects(currentInventoryObject)
 	if err != nil {
 		return err
 	}
+	pastInventories, err = po.withoutAppliedInventories(pastInventories)
+	if err != nil {
+		return err
+	}
 	for _, pastGroupInfo := range pastInventories {
 		if !o.DryRun {
 			klog.V(7).Infof("prune delete previous inventory object: %s/%s",
 				pastGroupInfo.Namespace, pastGroupInfo.Name)
+			po.acceptRateLimit()
 			err = po.client.Resource(pastGroupInfo.Mapping.Resource).
 				Namespace(pastGroupInfo.Namespace).
 				Delete(pastGroupInfo.Name, &metav1.DeleteOptions{
@@ -169,12 +440,232 @@ func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan
 	return nil
 }
 
-// preventDeleteAnnotation returns true if the "onRemove:keep"
-// annotation exists within the annotation map; false otherwise.
-func preventDeleteAnnotation(annotations map[string]string) bool {
-	for annotation, value := range annotations {
-		if annotation == common.OnRemoveAnnotation {
-			if value == common.OnRemoveKeep {
+// withoutAppliedInventories returns the previous inventory objects
+// that were not applied as part of the current apply. The parent object
+// of an ApplySet keeps its name, so it is found as a previous inventory
+// object before it is applied again.
+func (po *PruneOptions) withoutAppliedInventories(inventories []*resource.Info) ([]*resource.Info, error) {
+	var past []*resource.Info
+	for _, inv := range inventories {
+		metadata, err := meta.Accessor(inv.Object)
+		if err != nil {
+			return nil, err
+		}
+		if uid := string(metadata.GetUID()); uid != "" && po.currentUids.Has(uid) {
+			klog.V(7).Infof("prune inventory object in current apply; do not prune: %s/%s", inv.Namespace, inv.Name)
+			continue
+		}
+		past = append(past, inv)
+	}
+	return past, nil
+}
+
+// storedResourceVersions returns the resource versions of the objects
+// as recorded in the previous inventory objects in the v2 inventory
+// format. Inventory objects in the v1 format don't contribute any.
+func (po *PruneOptions) storedResourceVersions(currentInventoryObject *resource.Info) (map[object.ObjMetadata]string, error) {
+	versions := make(map[object.ObjMetadata]string)
+	pastInventories, err := po.invClient.GetPreviousInventoryObjects(currentInventoryObject)
+	if err != nil {
+		return nil, err
+	}
+	for _, inv := range pastInventories {
+		u, ok := inv.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		for id, rv := range inventory.GetResourceVersions(u) {
+			versions[id] = rv
+		}
+	}
+	return versions, nil
+}
+
+// pruneUnusedNamespaces deletes the passed namespaces. The namespaces
+// are computed from the objects known by the inventory, so we never
+// look at other objects living in these namespaces. Namespaces that
+// are protected, aren't owned by the inventory, doesn't exist in the
+// cluster or have the lifecycle directive preventing deletion are
+// not deleted.
+func (po *PruneOptions) pruneUnusedNamespaces(namespaces sets.String, currentInventoryObject *resource.Info,
+	eventChannel chan<- event.Event, o Options) error {
+	inv, err := meta.Accessor(currentInventoryObject.Object)
+	if err != nil {
+		return err
+	}
+	inventoryID := inv.GetLabels()[common.InventoryLabel]
+	mapping, err := po.mapper.RESTMapping(namespaceGK)
+	if err != nil {
+		return err
+	}
+	namespaceClient := po.client.Resource(mapping.Resource)
+	for _, ns := range namespaces.List() {
+		// Cluster-scoped objects don't live in a namespace.
+		if ns == metav1.NamespaceNone {
+			continue
+		}
+		if isProtectedNamespace(ns) {
+			klog.V(7).Infof("prune namespace is protected; do not prune: %s", ns)
+			continue
+		}
+		obj, err := namespaceClient.Get(ns, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if id := obj.GetLabels()[common.InventoryLabel]; inventoryID == "" || id != inventoryID {
+			klog.V(7).Infof("prune namespace not owned by inventory; do not prune: %s", ns)
+			continue
+		}
+		if !o.ForceDelete && o.lifecycleDirective(obj.GetAnnotations()) != LifecycleDelete {
+			klog.V(7).Infof("prune namespace lifecycle directive; do not prune: %s", ns)
+			eventChannel <- createPruneEvent(obj, event.PruneSkipped)
+			continue
+		}
+		if o.DryRun {
+			po.dryRunCallback(obj)
+		} else {
+			klog.V(7).Infof("prune unused namespace delete: %s", ns)
+			po.acceptRateLimit()
+			err = namespaceClient.Delete(ns, o.deleteOptions(namespaceGK))
+			if err != nil {
+				return err
+			}
+		}
+		eventChannel <- createPruneEvent(obj, event.Pruned)
+	}
+	return nil
+}
+
+// acceptRateLimit blocks until the RateLimiter allows another delete
+// call, if it is set.
+func (po *PruneOptions) acceptRateLimit() {
+	if po.RateLimiter != nil {
+		po.RateLimiter.Accept()
+	}
+}
+
+// dryRunCallback calls the DryRunCallback with obj if it is set.
+func (po *PruneOptions) dryRunCallback(obj *unstructured.Unstructured) {
+	if po.DryRunCallback != nil {
+		po.DryRunCallback(obj)
+	}
+}
+
+// deleteOptions returns the options for deleting an object of the
+// provided kind, with the propagation policy for the kind from the
+// PropagationPolicyMap, or the PropagationPolicy otherwise.
+func (o Options) deleteOptions(gk schema.GroupKind) *metav1.DeleteOptions {
+	policy, found := o.PropagationPolicyMap[gk]
+	if !found {
+		policy = o.PropagationPolicy
+	}
+	return &metav1.DeleteOptions{
+		PropagationPolicy: &policy,
+	}
+}
+
+// isProtectedNamespace returns true for the namespaces created by
+// Kubernetes itself, which are never pruned as unused namespaces.
+func isProtectedNamespace(ns string) bool {
+	return ns == metav1.NamespaceDefault || strings.HasPrefix(ns, "kube-")
+}
+
+// namespaceGK is the GroupKind for the Namespace type.
+var namespaceGK = schema.GroupKind{Group: "", Kind: "Namespace"}
+
+// namespacesForInfos returns the set of namespaces used by the passed
+// objects. Namespace objects are included by their name.
+func namespacesForInfos(infos []*resource.Info) sets.String {
+	namespaces := sets.NewString()
+	for _, info := range infos {
+		if info == nil || info.Object == nil {
+			continue
+		}
+		if info.Object.GetObjectKind().GroupVersionKind().GroupKind() == namespaceGK {
+			namespaces.Insert(info.Name)
+			continue
+		}
+		namespaces.Insert(info.Namespace)
+	}
+	return namespaces
+}
+
+// LifecycleDirective defines what should happen to an object
+// when it is pruned.
+//
+//go:generate stringer -type=LifecycleDirective
+type LifecycleDirective int
+
+const (
+	// LifecycleDelete means the object should be deleted. This is
+	// the default if no lifecycle annotation is set.
+	LifecycleDelete LifecycleDirective = iota
+	// LifecycleKeep means the object should not be deleted.
+	LifecycleKeep
+	// LifecycleDetach means the object should not be deleted, and
+	// it is released from the inventory.
+	LifecycleDetach
+)
+
+// parseLifecycleAnnotation returns the lifecycle directive from the
+// "on-remove" annotation within the annotation map. Returns
+// LifecycleDelete if the annotation does not exist or has an
+// unknown value.
+func parseLifecycleAnnotation(annotations map[string]string) LifecycleDirective {
+	switch annotations[common.OnRemoveAnnotation] {
+	case common.OnRemoveKeep:
+		return LifecycleKeep
+	case common.OnRemoveDetach:
+		return LifecycleDetach
+	default:
+		return LifecycleDelete
+	}
+}
+
+// lifecycleDirective returns the lifecycle directive from the
+// annotation map, using the NoPruneAnnotationKey and
+// NoPruneAnnotationValue if either is set. The custom annotation can
+// only keep objects, so it returns either LifecycleKeep or
+// LifecycleDelete.
+func (o Options) lifecycleDirective(annotations map[string]string) LifecycleDirective {
+	if o.NoPruneAnnotationKey == "" && o.NoPruneAnnotationValue == "" {
+		return parseLifecycleAnnotation(annotations)
+	}
+	key := o.NoPruneAnnotationKey
+	if key == "" {
+		key = common.OnRemoveAnnotation
+	}
+	value := o.NoPruneAnnotationValue
+	if value == "" {
+		value = common.OnRemoveKeep
+	}
+	if v, found := annotations[key]; found && v == value {
+		return LifecycleKeep
+	}
+	return LifecycleDelete
+}
+
+// namespaceScopedSkipReason is the reason for skipping the pruning of
+// cluster-scoped resources with the NamespaceScoped option.
+const namespaceScopedSkipReason = "cluster-scoped resource excluded in namespace-scoped mode"
+
+// prePruneFilterSkipReason is the reason for skipping the pruning of
+// resources rejected by the PrePruneFilter.
+const prePruneFilterSkipReason = "filtered by PrePruneFilter"
+
+// protectedFinalizerSkipReason is the reason for skipping the pruning
+// of objects with one of the SkipFinalizers.
+const protectedFinalizerSkipReason = "protected finalizer"
+
+// hasSkipFinalizer returns true if any of the finalizers is one of the
+// SkipFinalizers.
+func (po *PruneOptions) hasSkipFinalizer(finalizers []string) bool {
+	for _, f := range finalizers {
+		for _, skip := range po.SkipFinalizers {
+			if f == skip {
 				return true
 			}
 		}
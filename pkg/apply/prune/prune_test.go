@@ -4,22 +4,37 @@
 package prune
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
 	"testing"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 var testNamespace = "test-inventory-namespace"
 var inventoryObjName = "test-inventory-obj"
+var currentInventoryObjName = "current-group"
 var pod1Name = "pod-1"
 var pod2Name = "pod-2"
 var pod3Name = "pod-3"
@@ -102,6 +117,7 @@ func createInventoryInfo(name string, children ...*resource.Info) *resource.Info
 		inventoryName = name
 	}
 	inventoryObjCopy := inventoryObj.DeepCopy()
+	inventoryObjCopy.SetName(inventoryName)
 	var inventoryInfo = &resource.Info{
 		Namespace: testNamespace,
 		Name:      inventoryName,
@@ -113,6 +129,72 @@ func createInventoryInfo(name string, children ...*resource.Info) *resource.Info
 	return inventoryInfo
 }
 
+// newTestPruneOptions returns PruneOptions which prune the passed
+// previously applied objects, with no objects currently applied. The
+// fake inventory client returns a previous inventory object storing
+// the past objects. The fake dynamic client, which is also returned,
+// contains the past objects, the previous inventory object and the
+// empty current inventory object named currentInventoryObjName.
+func newTestPruneOptions(t *testing.T, past ...*resource.Info) (*PruneOptions, *fake.FakeDynamicClient) {
+	po := NewPruneOptions(sets.NewString())
+	po.mapper = testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+	pastInventoryInfo := createInventoryInfo("", past...)
+	mapping, err := po.mapper.RESTMapping(inventoryObj.GroupVersionKind().GroupKind())
+	if err != nil {
+		t.Fatalf("Unexpected error getting inventory mapping: %#v", err)
+	}
+	pastInventoryInfo.Mapping = mapping
+	po.invClient = inventory.NewFakeInventoryClient([]*resource.Info{pastInventoryInfo})
+	objs := []runtime.Object{
+		pastInventoryInfo.Object.DeepCopyObject(),
+		createInventoryInfo(currentInventoryObjName).Object,
+	}
+	for _, info := range past {
+		objs = append(objs, info.Object)
+	}
+	client := fake.NewSimpleDynamicClient(scheme.Scheme, objs...)
+	po.client = client
+	return po, client
+}
+
+// isInventoryEvent returns true if the prune event is for an
+// inventory object.
+func isInventoryEvent(e event.Event) bool {
+	u, _ := e.PruneEvent.Object.(*unstructured.Unstructured)
+	return common.IsInventoryObject(u)
+}
+
+// inventoryObjNames returns the sorted names of the objects tracked by
+// the inventory object with the passed name in the cluster.
+func inventoryObjNames(t *testing.T, client dynamic.Interface, name string) []string {
+	inv, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).
+		Namespace(testNamespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting inventory object %s: %#v", name, err)
+	}
+	objs, err := inventory.WrapInventoryObj(&resource.Info{Object: inv}).Load()
+	if err != nil {
+		t.Fatalf("Unexpected error loading inventory: %#v", err)
+	}
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// assertInventoryDeleted fails the test if the inventory object with
+// the passed name still exists in the cluster.
+func assertInventoryDeleted(t *testing.T, client dynamic.Interface, name string) {
+	_, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).
+		Namespace(testNamespace).Get(name, metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("Expected inventory object %s to be deleted, got error: %#v", name, err)
+	}
+}
+
 // preventDelete object contains the "on-remove:keep" lifecycle directive.
 var preventDelete = unstructured.Unstructured{
 	Object: map[string]interface{}{
@@ -139,10 +221,11 @@ func TestPrune(t *testing.T) {
 	tests := map[string]struct {
 		// pastInfos/currentInfos do NOT contain the inventory object.
 		// Inventory object is generated from these past/current objects.
-		pastInfos    []*resource.Info
-		currentInfos []*resource.Info
-		prunedInfos  []*resource.Info
-		isError      bool
+		pastInfos           []*resource.Info
+		currentInfos        []*resource.Info
+		prunedInfos         []*resource.Info
+		skipInventoryUpdate bool
+		isError             bool
 	}{
 		"Past and current objects are empty; no pruned objects": {
 			pastInfos:    []*resource.Info{},
@@ -174,6 +257,13 @@ func TestPrune(t *testing.T) {
 			prunedInfos:  []*resource.Info{pod1Info},
 			isError:      false,
 		},
+		"Skip inventory update keeps previous inventory object": {
+			pastInfos:           []*resource.Info{pod1Info, pod2Info},
+			currentInfos:        []*resource.Info{pod2Info},
+			prunedInfos:         []*resource.Info{pod1Info},
+			skipInventoryUpdate: true,
+			isError:             false,
+		},
 		"Prevent delete lifecycle annotation stops pruning": {
 			pastInfos:    []*resource.Info{preventDeleteInfo, pod2Info},
 			currentInfos: []*resource.Info{pod2Info, pod3Info},
@@ -183,33 +273,33 @@ func TestPrune(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			po := NewPruneOptions(populateObjectIds(tc.currentInfos, t))
-			po.InventoryFactoryFunc = inventory.WrapInventoryObj
 			// Set up the previously applied objects.
-			pastInventoryInfo := createInventoryInfo("past-group", tc.pastInfos...)
-			po.invClient = inventory.NewFakeInventoryClient([]*resource.Info{pastInventoryInfo})
+			po, _ := newTestPruneOptions(t, tc.pastInfos...)
 			// Set up the currently applied objects.
-			currentInventoryInfo := createInventoryInfo("current-group", tc.currentInfos...)
+			po.currentUids = populateObjectIds(tc.currentInfos, t)
+			currentInventoryInfo := createInventoryInfo(currentInventoryObjName, tc.currentInfos...)
 			currentInfos := append(tc.currentInfos, currentInventoryInfo)
 			// The event channel can not block; make sure its bigger than all
 			// the events that can be put on it.
 			eventChannel := make(chan event.Event, len(tc.pastInfos)+1) // Add one for inventory object
 			defer close(eventChannel)
-			// Set up the fake dynamic client to recognize all objects, and the RESTMapper.
+			// Set up the fake dynamic client to recognize all objects.
 			po.client = fake.NewSimpleDynamicClient(scheme.Scheme,
 				pod1Info.Object, pod2Info.Object, pod3Info.Object)
-			po.mapper = testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
-				scheme.Scheme.PrioritizedVersionsAllGroups()...)
 			// Run the prune and validate.
 			err := po.Prune(currentInfos, eventChannel, Options{
-				DryRun: true,
+				DryRun:              true,
+				SkipInventoryUpdate: tc.skipInventoryUpdate,
 			})
 			if !tc.isError {
 				if err != nil {
 					t.Fatalf("Unexpected error during Prune(): %#v", err)
 				}
 				// Validate the prune events on the event channel.
-				expectedPruneEvents := len(tc.prunedInfos) + 1 // One extra for pruning inventory object
+				expectedPruneEvents := len(tc.prunedInfos)
+				if !tc.skipInventoryUpdate {
+					expectedPruneEvents++ // One extra for pruning inventory object
+				}
 				actualPruneEvents := len(eventChannel)
 				if expectedPruneEvents != actualPruneEvents {
 					t.Errorf("Expected (%d) prune events, got (%d)",
@@ -222,6 +312,998 @@ func TestPrune(t *testing.T) {
 	}
 }
 
+// TestPruneMissingInventory verifies that nothing is pruned if the
+// inventory object was deleted from the cluster. The inventory objects
+// are looked up by their label, so the inventory client finds no
+// previous inventory objects, and the past set is empty.
+func TestPruneMissingInventory(t *testing.T) {
+	po, _ := newTestPruneOptions(t)
+	po.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 2)
+	client := fake.NewSimpleDynamicClient(scheme.Scheme, pod1Info.Object)
+	po.client = client
+
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+	if len(eventChannel) != 0 {
+		t.Errorf("Expected no prune events, got (%d)", len(eventChannel))
+	}
+	pods := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).Namespace(testNamespace)
+	if _, err := pods.Get(pod1Name, metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected %s to exist: %#v", pod1Name, err)
+	}
+}
+
+func TestPruneContinueOnError(t *testing.T) {
+	tests := map[string]struct {
+		continueOnError bool
+		expectedPruned  []string
+	}{
+		"Prune stops at the first error by default": {
+			continueOnError: false,
+		},
+		"Prune continues after an error if requested": {
+			continueOnError: true,
+			expectedPruned:  []string{pod2Name},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			po, client := newTestPruneOptions(t, pod1Info, pod2Info)
+			currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+			eventChannel := make(chan event.Event, 3)
+			client.PrependReactor("delete", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.(clienttesting.DeleteAction).GetName() == pod1Name {
+					return true, nil, fmt.Errorf("delete failed")
+				}
+				return false, nil, nil
+			})
+
+			err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+				ContinueOnError: tc.continueOnError,
+			})
+			close(eventChannel)
+			if err == nil {
+				t.Fatalf("Expected error during Prune() but received none")
+			}
+
+			// The previous inventory object must not be pruned, since it
+			// still tracks the object that could not be deleted.
+			var pruned []string
+			for e := range eventChannel {
+				accessor, _ := meta.Accessor(e.PruneEvent.Object)
+				pruned = append(pruned, accessor.GetName())
+			}
+			if tc.continueOnError && !reflect.DeepEqual(tc.expectedPruned, pruned) {
+				t.Errorf("Expected pruned objects (%v), got (%v)", tc.expectedPruned, pruned)
+			}
+			for _, name := range pruned {
+				if name == pod1Name {
+					t.Errorf("Object (%s) should not have been pruned", pod1Name)
+				}
+			}
+		})
+	}
+}
+
+func TestPruneDryRunCallback(t *testing.T) {
+	po, client := newTestPruneOptions(t, pod1Info, pod2Info)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 3)
+	var wouldPrune []string
+	po.DryRunCallback = func(obj *unstructured.Unstructured) {
+		// The callback must be called before the event is sent.
+		if len(eventChannel) != len(wouldPrune) {
+			t.Errorf("DryRunCallback for (%s) called after its prune event", obj.GetName())
+		}
+		wouldPrune = append(wouldPrune, obj.GetName())
+	}
+
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+		DryRun: true,
+	})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+	sort.Strings(wouldPrune)
+	expected := []string{pod1Name, pod2Name}
+	if !reflect.DeepEqual(expected, wouldPrune) {
+		t.Errorf("Expected DryRunCallback objects (%v), got (%v)", expected, wouldPrune)
+	}
+	// The events are still sent, and nothing is deleted. Add one for
+	// the inventory object.
+	if len(eventChannel) != len(expected)+1 {
+		t.Errorf("Expected (%d) prune events, got (%d)", len(expected)+1, len(eventChannel))
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "delete" {
+			t.Errorf("Unexpected delete during dry-run: %v", action)
+		}
+	}
+}
+
+func labeledPodInfo(name, env string) *resource.Info {
+	return &resource.Info{
+		Namespace: testNamespace,
+		Name:      name,
+		Object: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": testNamespace,
+					"uid":       "uid-" + name,
+					"labels": map[string]interface{}{
+						"environment": env,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPruneLabelSelector(t *testing.T) {
+	stagingInfo := labeledPodInfo("staging-pod", "staging")
+	prodInfo := labeledPodInfo("prod-pod", "prod")
+
+	po, client := newTestPruneOptions(t, stagingInfo, prodInfo)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 3)
+
+	selector, err := labels.Parse("environment=staging")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing selector: %#v", err)
+	}
+	err = po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+		LabelSelector: selector,
+	})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+
+	var pruned []string
+	for e := range eventChannel {
+		if isInventoryEvent(e) {
+			continue
+		}
+		accessor, _ := meta.Accessor(e.PruneEvent.Object)
+		pruned = append(pruned, accessor.GetName())
+	}
+	if !reflect.DeepEqual([]string{"staging-pod"}, pruned) {
+		t.Errorf("Expected pruned objects (%v), got (%v)", []string{"staging-pod"}, pruned)
+	}
+
+	// The object not matching the selector must still exist and be
+	// the only object added to the current inventory.
+	pods := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).Namespace(testNamespace)
+	if _, err := pods.Get("prod-pod", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected prod-pod to exist: %#v", err)
+	}
+	if _, err := pods.Get("staging-pod", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected staging-pod to be deleted, got error: %#v", err)
+	}
+	if names := inventoryObjNames(t, client, currentInventoryObjName); !reflect.DeepEqual([]string{"prod-pod"}, names) {
+		t.Errorf("Expected only prod-pod in the current inventory, got (%v)", names)
+	}
+	assertInventoryDeleted(t, client, inventoryObjName)
+}
+
+func TestPrunePrePruneFilter(t *testing.T) {
+	stagingInfo := labeledPodInfo("staging-pod", "staging")
+	prodInfo := labeledPodInfo("prod-pod", "prod")
+
+	po, client := newTestPruneOptions(t, stagingInfo, prodInfo)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 3)
+	po.PrePruneFilter = func(info *resource.Info) bool {
+		accessor, _ := meta.Accessor(info.Object)
+		return accessor.GetLabels()["environment"] != "prod"
+	}
+
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+
+	var pruned, skipped []string
+	for e := range eventChannel {
+		if isInventoryEvent(e) {
+			continue
+		}
+		accessor, _ := meta.Accessor(e.PruneEvent.Object)
+		switch e.PruneEvent.Operation {
+		case event.Pruned:
+			pruned = append(pruned, accessor.GetName())
+		case event.PruneSkipped:
+			skipped = append(skipped, accessor.GetName())
+			if e.PruneEvent.Reason != prePruneFilterSkipReason {
+				t.Errorf("Expected skip reason %q, got %q", prePruneFilterSkipReason, e.PruneEvent.Reason)
+			}
+		}
+	}
+	if !reflect.DeepEqual([]string{"staging-pod"}, pruned) {
+		t.Errorf("Expected pruned objects (%v), got (%v)", []string{"staging-pod"}, pruned)
+	}
+	if !reflect.DeepEqual([]string{"prod-pod"}, skipped) {
+		t.Errorf("Expected skipped objects (%v), got (%v)", []string{"prod-pod"}, skipped)
+	}
+
+	// The filtered object must still exist and be the only object
+	// added to the current inventory.
+	pods := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).Namespace(testNamespace)
+	if _, err := pods.Get("prod-pod", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected prod-pod to exist: %#v", err)
+	}
+	if names := inventoryObjNames(t, client, currentInventoryObjName); !reflect.DeepEqual([]string{"prod-pod"}, names) {
+		t.Errorf("Expected only prod-pod in the current inventory, got (%v)", names)
+	}
+	assertInventoryDeleted(t, client, inventoryObjName)
+}
+
+func TestPruneSkipFinalizers(t *testing.T) {
+	protectedInfo := labeledPodInfo("protected-pod", "prod")
+	protectedInfo.Object.(*unstructured.Unstructured).SetFinalizers([]string{"example.com/protected"})
+	otherInfo := labeledPodInfo("other-pod", "prod")
+	otherInfo.Object.(*unstructured.Unstructured).SetFinalizers([]string{"example.com/other"})
+
+	po, client := newTestPruneOptions(t, protectedInfo, otherInfo)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 3)
+	po.SkipFinalizers = []string{"example.com/protected"}
+
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+
+	var pruned, skipped []string
+	for e := range eventChannel {
+		if isInventoryEvent(e) {
+			continue
+		}
+		accessor, _ := meta.Accessor(e.PruneEvent.Object)
+		switch e.PruneEvent.Operation {
+		case event.Pruned:
+			pruned = append(pruned, accessor.GetName())
+		case event.PruneSkipped:
+			skipped = append(skipped, accessor.GetName())
+			if e.PruneEvent.Reason != protectedFinalizerSkipReason {
+				t.Errorf("Expected skip reason %q, got %q", protectedFinalizerSkipReason, e.PruneEvent.Reason)
+			}
+		}
+	}
+	if !reflect.DeepEqual([]string{"other-pod"}, pruned) {
+		t.Errorf("Expected pruned objects (%v), got (%v)", []string{"other-pod"}, pruned)
+	}
+	if !reflect.DeepEqual([]string{"protected-pod"}, skipped) {
+		t.Errorf("Expected skipped objects (%v), got (%v)", []string{"protected-pod"}, skipped)
+	}
+
+	// The protected object must still exist and be the only object
+	// added to the current inventory for the next prune.
+	pods := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).Namespace(testNamespace)
+	if _, err := pods.Get("protected-pod", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected protected-pod to exist: %#v", err)
+	}
+	if names := inventoryObjNames(t, client, currentInventoryObjName); !reflect.DeepEqual([]string{"protected-pod"}, names) {
+		t.Errorf("Expected only protected-pod in the current inventory, got (%v)", names)
+	}
+	assertInventoryDeleted(t, client, inventoryObjName)
+}
+
+func TestPruneKeepObjects(t *testing.T) {
+	failedInfo := labeledPodInfo("failed-pod", "prod")
+	otherInfo := labeledPodInfo("other-pod", "prod")
+
+	po, client := newTestPruneOptions(t, failedInfo, otherInfo)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 3)
+
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+		KeepObjects: []object.ObjMetadata{object.InfoToObjMeta(failedInfo)},
+	})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+
+	var pruned []string
+	for e := range eventChannel {
+		if isInventoryEvent(e) {
+			continue
+		}
+		accessor, _ := meta.Accessor(e.PruneEvent.Object)
+		if e.PruneEvent.Operation == event.Pruned {
+			pruned = append(pruned, accessor.GetName())
+		}
+	}
+	if !reflect.DeepEqual([]string{"other-pod"}, pruned) {
+		t.Errorf("Expected pruned objects (%v), got (%v)", []string{"other-pod"}, pruned)
+	}
+	pods := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).Namespace(testNamespace)
+	if _, err := pods.Get("failed-pod", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected failed-pod to exist: %#v", err)
+	}
+	if names := inventoryObjNames(t, client, currentInventoryObjName); !reflect.DeepEqual([]string{"failed-pod"}, names) {
+		t.Errorf("Expected only failed-pod in the current inventory, got (%v)", names)
+	}
+	assertInventoryDeleted(t, client, inventoryObjName)
+}
+
+func TestPruneAppliedInventory(t *testing.T) {
+	tests := map[string]struct {
+		keepObjects  []string
+		expectedObjs []string
+	}{
+		"Applied inventory object is not pruned": {
+			expectedObjs: []string{"applied-pod"},
+		},
+		"Retained objects are added to the applied inventory object": {
+			keepObjects:  []string{"failed-pod"},
+			expectedObjs: []string{"applied-pod", "failed-pod"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			appliedInfo := labeledPodInfo("applied-pod", "prod")
+			failedInfo := labeledPodInfo("failed-pod", "prod")
+			// The inventory object keeps its name across applies, like the
+			// parent object of an ApplySet, so it was found as a previous
+			// inventory object before it was applied.
+			pastInventoryInfo := createInventoryInfo("", appliedInfo, failedInfo)
+			pastInventoryInfo.Object.(*unstructured.Unstructured).SetUID("inventory-uid")
+			currentInventoryInfo := createInventoryInfo("", appliedInfo)
+			currentInventoryInfo.Object.(*unstructured.Unstructured).SetUID("inventory-uid")
+
+			po := NewPruneOptions(sets.NewString("uid-applied-pod", "inventory-uid"))
+			po.invClient = inventory.NewFakeInventoryClient([]*resource.Info{pastInventoryInfo})
+			eventChannel := make(chan event.Event, 3)
+			client := fake.NewSimpleDynamicClient(scheme.Scheme,
+				appliedInfo.Object, failedInfo.Object, currentInventoryInfo.Object.DeepCopyObject())
+			po.client = client
+			po.mapper = testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+				scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+			var keepObjects []object.ObjMetadata
+			for _, keepName := range tc.keepObjects {
+				keepObjects = append(keepObjects, object.InfoToObjMeta(labeledPodInfo(keepName, "prod")))
+			}
+			err := po.Prune([]*resource.Info{currentInventoryInfo, appliedInfo}, eventChannel, Options{
+				KeepObjects: keepObjects,
+			})
+			close(eventChannel)
+			if err != nil {
+				t.Fatalf("Unexpected error during Prune(): %#v", err)
+			}
+			for e := range eventChannel {
+				if isInventoryEvent(e) {
+					t.Errorf("Unexpected prune event for the applied inventory object: %v", e.PruneEvent)
+				}
+			}
+
+			stored, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).
+				Namespace(testNamespace).Get(inventoryObjName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected applied inventory object to exist: %#v", err)
+			}
+			objs, err := inventory.WrapInventoryObj(&resource.Info{Object: stored}).Load()
+			if err != nil {
+				t.Fatalf("Unexpected error loading inventory: %#v", err)
+			}
+			var names []string
+			for _, obj := range objs {
+				names = append(names, obj.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(tc.expectedObjs, names) {
+				t.Errorf("Expected inventory objects (%v), got (%v)", tc.expectedObjs, names)
+			}
+		})
+	}
+}
+
+func TestPruneNoPruneAnnotation(t *testing.T) {
+	customInfo := labeledPodInfo("custom-pod", "prod")
+	customInfo.Object.(*unstructured.Unstructured).SetAnnotations(map[string]string{
+		"example.com/protect": "true",
+	})
+	defaultInfo := labeledPodInfo("default-pod", "prod")
+	defaultInfo.Object.(*unstructured.Unstructured).SetAnnotations(map[string]string{
+		common.OnRemoveAnnotation: common.OnRemoveKeep,
+	})
+
+	po, client := newTestPruneOptions(t, customInfo, defaultInfo)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 3)
+
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+		NoPruneAnnotationKey:   "example.com/protect",
+		NoPruneAnnotationValue: "true",
+	})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+
+	var pruned, skipped []string
+	for e := range eventChannel {
+		if isInventoryEvent(e) {
+			continue
+		}
+		accessor, _ := meta.Accessor(e.PruneEvent.Object)
+		switch e.PruneEvent.Operation {
+		case event.Pruned:
+			pruned = append(pruned, accessor.GetName())
+		case event.PruneSkipped:
+			skipped = append(skipped, accessor.GetName())
+		}
+	}
+	// The default annotation is not checked with a custom annotation.
+	if !reflect.DeepEqual([]string{"default-pod"}, pruned) {
+		t.Errorf("Expected pruned objects (%v), got (%v)", []string{"default-pod"}, pruned)
+	}
+	if !reflect.DeepEqual([]string{"custom-pod"}, skipped) {
+		t.Errorf("Expected skipped objects (%v), got (%v)", []string{"custom-pod"}, skipped)
+	}
+	pods := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).Namespace(testNamespace)
+	if _, err := pods.Get("custom-pod", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected custom-pod to exist: %#v", err)
+	}
+}
+
+func TestPruneLifecycleDetach(t *testing.T) {
+	detachedInfo := labeledPodInfo("detached-pod", "prod")
+	detachedInfo.Object.(*unstructured.Unstructured).SetAnnotations(map[string]string{
+		common.OnRemoveAnnotation: common.OnRemoveDetach,
+	})
+	keptInfo := labeledPodInfo("kept-pod", "prod")
+
+	po, client := newTestPruneOptions(t, detachedInfo, keptInfo)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 3)
+
+	// The kept object is added to the current inventory object, while
+	// the detached object is released from the inventory.
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+		KeepObjects: []object.ObjMetadata{object.InfoToObjMeta(keptInfo)},
+	})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+
+	var skipped []string
+	for e := range eventChannel {
+		accessor, _ := meta.Accessor(e.PruneEvent.Object)
+		if e.PruneEvent.Operation == event.PruneSkipped {
+			skipped = append(skipped, accessor.GetName())
+		}
+	}
+	if !reflect.DeepEqual([]string{"detached-pod"}, skipped) {
+		t.Errorf("Expected skipped objects (%v), got (%v)", []string{"detached-pod"}, skipped)
+	}
+	pods := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).Namespace(testNamespace)
+	if _, err := pods.Get("detached-pod", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected detached-pod to exist: %#v", err)
+	}
+	if names := inventoryObjNames(t, client, currentInventoryObjName); !reflect.DeepEqual([]string{"kept-pod"}, names) {
+		t.Errorf("Expected only kept-pod in the current inventory, got (%v)", names)
+	}
+	assertInventoryDeleted(t, client, inventoryObjName)
+}
+
+func TestPruneLifecycleKeep(t *testing.T) {
+	keptInfo := labeledPodInfo("kept-pod", "prod")
+	keptInfo.Object.(*unstructured.Unstructured).SetAnnotations(map[string]string{
+		common.OnRemoveAnnotation: common.OnRemoveKeep,
+	})
+	prunedInfo := labeledPodInfo("pruned-pod", "prod")
+
+	po, client := newTestPruneOptions(t, keptInfo, prunedInfo)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 3)
+
+	// The kept object is the only object that is skipped, so it alone
+	// must be added to the current inventory object.
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+
+	var pruned, skipped []string
+	for e := range eventChannel {
+		if isInventoryEvent(e) {
+			continue
+		}
+		accessor, _ := meta.Accessor(e.PruneEvent.Object)
+		switch e.PruneEvent.Operation {
+		case event.Pruned:
+			pruned = append(pruned, accessor.GetName())
+		case event.PruneSkipped:
+			skipped = append(skipped, accessor.GetName())
+		}
+	}
+	if !reflect.DeepEqual([]string{"pruned-pod"}, pruned) {
+		t.Errorf("Expected pruned objects (%v), got (%v)", []string{"pruned-pod"}, pruned)
+	}
+	if !reflect.DeepEqual([]string{"kept-pod"}, skipped) {
+		t.Errorf("Expected skipped objects (%v), got (%v)", []string{"kept-pod"}, skipped)
+	}
+	if names := inventoryObjNames(t, client, currentInventoryObjName); !reflect.DeepEqual([]string{"kept-pod"}, names) {
+		t.Errorf("Expected only kept-pod in the current inventory, got (%v)", names)
+	}
+	assertInventoryDeleted(t, client, inventoryObjName)
+}
+
+func TestPruneLifecycleKeepTwice(t *testing.T) {
+	keptInfo := labeledPodInfo("kept-pod", "prod")
+	keptInfo.Object.(*unstructured.Unstructured).SetAnnotations(map[string]string{
+		common.OnRemoveAnnotation: common.OnRemoveKeep,
+	})
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	po, client := newTestPruneOptions(t, keptInfo)
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 2)
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during first Prune(): %#v", err)
+	}
+
+	// The next apply has a new inventory object, and the inventory
+	// object of the first apply is the previous one.
+	previous, err := client.Resource(configMaps).Namespace(testNamespace).
+		Get(currentInventoryObjName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error getting inventory object: %#v", err)
+	}
+	previousInfo := &resource.Info{
+		Namespace: testNamespace,
+		Name:      currentInventoryObjName,
+		Object:    previous,
+	}
+	previousInfo.Mapping, err = po.mapper.RESTMapping(previous.GroupVersionKind().GroupKind())
+	if err != nil {
+		t.Fatalf("Unexpected error getting inventory mapping: %#v", err)
+	}
+	po.invClient = inventory.NewFakeInventoryClient([]*resource.Info{previousInfo})
+	nextInventoryInfo := createInventoryInfo("next-group")
+	_, err = client.Resource(configMaps).Namespace(testNamespace).
+		Create(nextInventoryInfo.Object.(*unstructured.Unstructured).DeepCopy(), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error creating inventory object: %#v", err)
+	}
+	eventChannel = make(chan event.Event, 2)
+	err = po.Prune([]*resource.Info{nextInventoryInfo}, eventChannel, Options{})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during second Prune(): %#v", err)
+	}
+
+	// Only the inventory object of the last apply is left, and it
+	// still tracks the kept object.
+	list, err := client.Resource(configMaps).Namespace(testNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error listing inventory objects: %#v", err)
+	}
+	var inventories []string
+	for _, item := range list.Items {
+		inventories = append(inventories, item.GetName())
+	}
+	if !reflect.DeepEqual([]string{"next-group"}, inventories) {
+		t.Errorf("Expected inventory objects (%v), got (%v)", []string{"next-group"}, inventories)
+	}
+	if names := inventoryObjNames(t, client, "next-group"); !reflect.DeepEqual([]string{"kept-pod"}, names) {
+		t.Errorf("Expected only kept-pod in the inventory, got (%v)", names)
+	}
+}
+
+func TestOptionsLifecycleDirective(t *testing.T) {
+	tests := map[string]struct {
+		options     Options
+		annotations map[string]string
+		expected    LifecycleDirective
+	}{
+		"Default annotation is used without custom annotation": {
+			annotations: map[string]string{common.OnRemoveAnnotation: common.OnRemoveDetach},
+			expected:    LifecycleDetach,
+		},
+		"Custom key and value keep the object": {
+			options:     Options{NoPruneAnnotationKey: "example.com/protect", NoPruneAnnotationValue: "true"},
+			annotations: map[string]string{"example.com/protect": "true"},
+			expected:    LifecycleKeep,
+		},
+		"Custom key with another value deletes the object": {
+			options:     Options{NoPruneAnnotationKey: "example.com/protect", NoPruneAnnotationValue: "true"},
+			annotations: map[string]string{"example.com/protect": "false"},
+			expected:    LifecycleDelete,
+		},
+		"Custom key defaults to the keep value": {
+			options:     Options{NoPruneAnnotationKey: "example.com/protect"},
+			annotations: map[string]string{"example.com/protect": common.OnRemoveKeep},
+			expected:    LifecycleKeep,
+		},
+		"Custom value uses the default key": {
+			options:     Options{NoPruneAnnotationValue: "never"},
+			annotations: map[string]string{common.OnRemoveAnnotation: "never"},
+			expected:    LifecycleKeep,
+		},
+		"Default annotation is ignored with custom annotation": {
+			options:     Options{NoPruneAnnotationKey: "example.com/protect"},
+			annotations: map[string]string{common.OnRemoveAnnotation: common.OnRemoveKeep},
+			expected:    LifecycleDelete,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := tc.options.lifecycleDirective(tc.annotations)
+			if tc.expected != actual {
+				t.Errorf("lifecycleDirective Expected (%s), got (%s)", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPruneForceDelete(t *testing.T) {
+	tests := map[string]struct {
+		forceDelete       bool
+		expectedOperation event.PruneEventOperation
+		expectDeleted     bool
+	}{
+		"Object with keep annotation is skipped by default": {
+			forceDelete:       false,
+			expectedOperation: event.PruneSkipped,
+			expectDeleted:     false,
+		},
+		"Object with keep annotation is deleted with force delete": {
+			forceDelete:       true,
+			expectedOperation: event.Pruned,
+			expectDeleted:     true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			po, client := newTestPruneOptions(t, preventDeleteInfo)
+			currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+			eventChannel := make(chan event.Event, 2)
+
+			err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+				ForceDelete: tc.forceDelete,
+			})
+			close(eventChannel)
+			if err != nil {
+				t.Fatalf("Unexpected error during Prune(): %#v", err)
+			}
+
+			e, ok := <-eventChannel
+			if !ok {
+				t.Fatalf("Expected a prune event, but got none")
+			}
+			if e.PruneEvent.Operation != tc.expectedOperation {
+				t.Errorf("Expected prune operation (%s), got (%s)", tc.expectedOperation, e.PruneEvent.Operation)
+			}
+			_, err = client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).
+				Namespace(testNamespace).Get(preventDeleteInfo.Name, metav1.GetOptions{})
+			if deleted := apierrors.IsNotFound(err); deleted != tc.expectDeleted {
+				t.Errorf("Expected object deleted (%t), got (%t)", tc.expectDeleted, deleted)
+			}
+		})
+	}
+}
+
+func TestPruneRateLimiter(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	interval := 100 * time.Millisecond
+	rateLimiter := &fakeRateLimiter{
+		clock:    fakeClock,
+		interval: interval,
+	}
+
+	po, client := newTestPruneOptions(t, pod1Info, pod2Info, pod3Info)
+	po.RateLimiter = rateLimiter
+	currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+	eventChannel := make(chan event.Event, 10)
+	var deleteTimes []time.Time
+	client.PrependReactor("delete", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		deleteTimes = append(deleteTimes, fakeClock.Now())
+		return false, nil, nil
+	})
+
+	err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{})
+	close(eventChannel)
+	if err != nil {
+		t.Fatalf("Unexpected error during Prune(): %#v", err)
+	}
+
+	if want, got := 3, len(deleteTimes); want != got {
+		t.Fatalf("Expected (%d) delete calls, got (%d)", want, got)
+	}
+	for i := 1; i < len(deleteTimes); i++ {
+		if d := deleteTimes[i].Sub(deleteTimes[i-1]); d < interval {
+			t.Errorf("Expected at least %s between delete calls, got %s", interval, d)
+		}
+	}
+}
+
+// fakeRateLimiter is a RateLimiter which allows one call per interval
+// of the fake clock. Accept steps the clock forward instead of
+// sleeping.
+type fakeRateLimiter struct {
+	flowcontrol.RateLimiter
+	clock    *clock.FakeClock
+	interval time.Duration
+	next     time.Time
+}
+
+func (f *fakeRateLimiter) Accept() {
+	now := f.clock.Now()
+	if now.Before(f.next) {
+		f.clock.SetTime(f.next)
+		now = f.next
+	}
+	f.next = now.Add(f.interval)
+}
+
+func TestPruneGarbageCollect(t *testing.T) {
+	// The Widget type is not known by the RESTMapper, as if the CRD
+	// had been removed from the cluster.
+	widgetInfo := &resource.Info{
+		Namespace: testNamespace,
+		Name:      "widget",
+		Object: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"metadata": map[string]interface{}{
+					"name":      "widget",
+					"namespace": testNamespace,
+				},
+			},
+		},
+	}
+	tests := map[string]struct {
+		garbageCollect bool
+		isError        bool
+	}{
+		"Unknown type fails prune by default": {
+			garbageCollect: false,
+			isError:        true,
+		},
+		"Unknown type is removed from inventory with garbage collection": {
+			garbageCollect: true,
+			isError:        false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			po, _ := newTestPruneOptions(t, widgetInfo)
+			currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+			eventChannel := make(chan event.Event, 2)
+
+			err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+				DryRun:         true,
+				GarbageCollect: tc.garbageCollect,
+			})
+			close(eventChannel)
+			if tc.isError {
+				if err == nil {
+					t.Fatalf("Expected error during Prune() but received none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error during Prune(): %#v", err)
+			}
+
+			// The garbage collected event is followed by the prune event
+			// for the previous inventory object, so the widget is only
+			// left out of the current inventory.
+			var eventTypes []event.Type
+			for e := range eventChannel {
+				eventTypes = append(eventTypes, e.Type)
+				if e.Type == event.GarbageCollectedType && e.GarbageCollectedEvent.Identifier.Name != "widget" {
+					t.Errorf("Expected widget to be garbage collected, got (%s)",
+						e.GarbageCollectedEvent.Identifier)
+				}
+			}
+			expected := []event.Type{event.GarbageCollectedType, event.PruneType}
+			if !reflect.DeepEqual(expected, eventTypes) {
+				t.Errorf("Expected events (%v), got (%v)", expected, eventTypes)
+			}
+		})
+	}
+}
+
+var clusterRole = unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata": map[string]interface{}{
+			"name": "test-cluster-role",
+			"uid":  "cluster-role",
+		},
+	},
+}
+
+var clusterRoleInfo = &resource.Info{
+	Name:   "test-cluster-role",
+	Object: &clusterRole,
+}
+
+func TestPruneNamespaceScoped(t *testing.T) {
+	tests := map[string]struct {
+		namespaceScoped   bool
+		expectedPruned    []string
+		expectedSkipped   []string
+		expectedInventory []string
+	}{
+		"Cluster-scoped object is pruned by default": {
+			namespaceScoped: false,
+			expectedPruned:  []string{pod1Name, "test-cluster-role", inventoryObjName},
+		},
+		"Cluster-scoped object is skipped in namespace-scoped mode": {
+			namespaceScoped:   true,
+			expectedPruned:    []string{pod1Name, inventoryObjName},
+			expectedSkipped:   []string{"test-cluster-role"},
+			expectedInventory: []string{"test-cluster-role"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			po, client := newTestPruneOptions(t, pod1Info, clusterRoleInfo)
+			currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+			eventChannel := make(chan event.Event, 4)
+
+			err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+				NamespaceScoped: tc.namespaceScoped,
+			})
+			close(eventChannel)
+			if err != nil {
+				t.Fatalf("Unexpected error during Prune(): %#v", err)
+			}
+
+			var pruned, skipped []string
+			for e := range eventChannel {
+				accessor, _ := meta.Accessor(e.PruneEvent.Object)
+				switch e.PruneEvent.Operation {
+				case event.Pruned:
+					pruned = append(pruned, accessor.GetName())
+				case event.PruneSkipped:
+					skipped = append(skipped, accessor.GetName())
+					if e.PruneEvent.Reason != namespaceScopedSkipReason {
+						t.Errorf("Expected skip reason %q, got %q",
+							namespaceScopedSkipReason, e.PruneEvent.Reason)
+					}
+				}
+			}
+			sort.Strings(pruned)
+			if !reflect.DeepEqual(tc.expectedPruned, pruned) {
+				t.Errorf("Expected pruned objects (%v), got (%v)", tc.expectedPruned, pruned)
+			}
+			if !reflect.DeepEqual(tc.expectedSkipped, skipped) {
+				t.Errorf("Expected skipped objects (%v), got (%v)", tc.expectedSkipped, skipped)
+			}
+
+			// The skipped objects must be tracked by the current
+			// inventory object, and the previous one is deleted.
+			names := inventoryObjNames(t, client, currentInventoryObjName)
+			if !reflect.DeepEqual(tc.expectedInventory, names) {
+				t.Errorf("Expected inventory objects (%v), got (%v)", tc.expectedInventory, names)
+			}
+			assertInventoryDeleted(t, client, inventoryObjName)
+		})
+	}
+}
+
+// deleteRecordingClient wraps a dynamic client, recording the
+// propagation policy of every delete by object name.
+type deleteRecordingClient struct {
+	dynamic.Interface
+	policies map[string]metav1.DeletionPropagation
+}
+
+func (c *deleteRecordingClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &deleteRecordingResource{
+		NamespaceableResourceInterface: c.Interface.Resource(resource),
+		policies:                       c.policies,
+	}
+}
+
+type deleteRecordingResource struct {
+	dynamic.NamespaceableResourceInterface
+	policies map[string]metav1.DeletionPropagation
+}
+
+func (r *deleteRecordingResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &deleteRecordingNamespacedResource{
+		ResourceInterface: r.NamespaceableResourceInterface.Namespace(ns),
+		policies:          r.policies,
+	}
+}
+
+func (r *deleteRecordingResource) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	recordPropagationPolicy(r.policies, name, options)
+	return r.NamespaceableResourceInterface.Delete(name, options, subresources...)
+}
+
+type deleteRecordingNamespacedResource struct {
+	dynamic.ResourceInterface
+	policies map[string]metav1.DeletionPropagation
+}
+
+func (r *deleteRecordingNamespacedResource) Delete(name string, options *metav1.DeleteOptions, subresources ...string) error {
+	recordPropagationPolicy(r.policies, name, options)
+	return r.ResourceInterface.Delete(name, options, subresources...)
+}
+
+func recordPropagationPolicy(policies map[string]metav1.DeletionPropagation, name string, options *metav1.DeleteOptions) {
+	if options != nil && options.PropagationPolicy != nil {
+		policies[name] = *options.PropagationPolicy
+	}
+}
+
+func TestPrunePropagationPolicyMap(t *testing.T) {
+	tests := map[string]struct {
+		policyMap map[schema.GroupKind]metav1.DeletionPropagation
+		expected  map[string]metav1.DeletionPropagation
+	}{
+		"Default policy is used without a map": {
+			expected: map[string]metav1.DeletionPropagation{
+				pod1Name:            metav1.DeletePropagationBackground,
+				"test-cluster-role": metav1.DeletePropagationBackground,
+			},
+		},
+		"Mapped kind uses its own policy": {
+			policyMap: map[schema.GroupKind]metav1.DeletionPropagation{
+				{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}: metav1.DeletePropagationForeground,
+			},
+			expected: map[string]metav1.DeletionPropagation{
+				pod1Name:            metav1.DeletePropagationBackground,
+				"test-cluster-role": metav1.DeletePropagationForeground,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			po, client := newTestPruneOptions(t, pod1Info, clusterRoleInfo)
+			currentInventoryInfo := createInventoryInfo(currentInventoryObjName)
+			eventChannel := make(chan event.Event, 3)
+			policies := make(map[string]metav1.DeletionPropagation)
+			po.client = &deleteRecordingClient{
+				Interface: client,
+				policies:  policies,
+			}
+
+			err := po.Prune([]*resource.Info{currentInventoryInfo}, eventChannel, Options{
+				PropagationPolicy:    metav1.DeletePropagationBackground,
+				PropagationPolicyMap: tc.policyMap,
+			})
+			close(eventChannel)
+			if err != nil {
+				t.Fatalf("Unexpected error during Prune(): %#v", err)
+			}
+
+			for objName, expected := range tc.expected {
+				if actual := policies[objName]; actual != expected {
+					t.Errorf("Expected propagation policy (%s) for (%s), got (%s)", expected, objName, actual)
+				}
+			}
+		})
+	}
+}
+
 // populateObjectIds returns a pointer to a set of strings containing
 // the UID's of the passed objects (infos).
 func populateObjectIds(infos []*resource.Info, t *testing.T) sets.String {
@@ -238,43 +1320,226 @@ func populateObjectIds(infos []*resource.Info, t *testing.T) sets.String {
 	return uids
 }
 
-func TestPreventDeleteAnnotation(t *testing.T) {
+var otherNamespace = "other-namespace"
+
+// namespaceObj is owned by the inventory of the tests.
+var namespaceObj = unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata": map[string]interface{}{
+			"name": otherNamespace,
+			"uid":  "uid-namespace",
+			"labels": map[string]interface{}{
+				common.InventoryLabel: testInventoryLabel,
+			},
+		},
+	},
+}
+
+var pod4 = unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      "pod-4",
+			"namespace": otherNamespace,
+			"uid":       "uid4",
+		},
+	},
+}
+
+var pod4Info = &resource.Info{
+	Namespace: otherNamespace,
+	Name:      "pod-4",
+	Object:    &pod4,
+}
+
+var pod5 = unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      "pod-5",
+			"namespace": otherNamespace,
+			"uid":       "uid5",
+		},
+	},
+}
+
+var pod5Info = &resource.Info{
+	Namespace: otherNamespace,
+	Name:      "pod-5",
+	Object:    &pod5,
+}
+
+func TestPruneUnusedNamespaces(t *testing.T) {
+	tests := map[string]struct {
+		pastInfos             []*resource.Info
+		currentInfos          []*resource.Info
+		pruneUnusedNamespaces bool
+		namespaceScoped       bool
+		namespaceLabels       map[string]string
+		expectedPruned        []string
+	}{
+		"Namespace is not pruned if option is not set": {
+			pastInfos:             []*resource.Info{pod4Info, pod5Info},
+			currentInfos:          []*resource.Info{},
+			pruneUnusedNamespaces: false,
+			expectedPruned:        []string{"pod-4", "pod-5"},
+		},
+		"Namespace is pruned when all tracked objects are pruned": {
+			pastInfos:             []*resource.Info{pod4Info, pod5Info},
+			currentInfos:          []*resource.Info{},
+			pruneUnusedNamespaces: true,
+			expectedPruned:        []string{otherNamespace, "pod-4", "pod-5"},
+		},
+		"Namespace is not pruned when a tracked object remains": {
+			pastInfos:             []*resource.Info{pod4Info, pod5Info},
+			currentInfos:          []*resource.Info{pod4Info},
+			pruneUnusedNamespaces: true,
+			expectedPruned:        []string{"pod-5"},
+		},
+		"Namespace is not pruned when pruning is namespace-scoped": {
+			pastInfos:             []*resource.Info{pod4Info, pod5Info},
+			currentInfos:          []*resource.Info{},
+			pruneUnusedNamespaces: true,
+			namespaceScoped:       true,
+			expectedPruned:        []string{"pod-4", "pod-5"},
+		},
+		"Namespace not owned by the inventory is not pruned": {
+			pastInfos:             []*resource.Info{pod4Info, pod5Info},
+			currentInfos:          []*resource.Info{},
+			pruneUnusedNamespaces: true,
+			namespaceLabels:       map[string]string{},
+			expectedPruned:        []string{"pod-4", "pod-5"},
+		},
+		"Namespace owned by another inventory is not pruned": {
+			pastInfos:             []*resource.Info{pod4Info, pod5Info},
+			currentInfos:          []*resource.Info{},
+			pruneUnusedNamespaces: true,
+			namespaceLabels:       map[string]string{common.InventoryLabel: "other-inventory"},
+			expectedPruned:        []string{"pod-4", "pod-5"},
+		},
+		"Namespace is not pruned when no objects were pruned from it": {
+			pastInfos:             []*resource.Info{pod1Info},
+			currentInfos:          []*resource.Info{pod4Info},
+			pruneUnusedNamespaces: true,
+			expectedPruned:        []string{pod1Name},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			po, _ := newTestPruneOptions(t, tc.pastInfos...)
+			po.currentUids = populateObjectIds(tc.currentInfos, t)
+			currentInventoryInfo := createInventoryInfo(currentInventoryObjName, tc.currentInfos...)
+			currentInfos := append(tc.currentInfos, currentInventoryInfo)
+			// Add one for the namespace and one for the inventory object.
+			eventChannel := make(chan event.Event, len(tc.pastInfos)+2)
+			namespace := namespaceObj.DeepCopy()
+			if tc.namespaceLabels != nil {
+				namespace.SetLabels(tc.namespaceLabels)
+			}
+			po.client = fake.NewSimpleDynamicClient(scheme.Scheme,
+				pod1Info.Object, pod4Info.Object, pod5Info.Object, namespace)
+			err := po.Prune(currentInfos, eventChannel, Options{
+				DryRun:                true,
+				PruneUnusedNamespaces: tc.pruneUnusedNamespaces,
+				NamespaceScoped:       tc.namespaceScoped,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error during Prune(): %#v", err)
+			}
+			close(eventChannel)
+			var pruned []string
+			for e := range eventChannel {
+				if e.PruneEvent.Operation != event.Pruned {
+					continue
+				}
+				accessor, _ := meta.Accessor(e.PruneEvent.Object)
+				if isInventoryEvent(e) {
+					continue
+				}
+				pruned = append(pruned, accessor.GetName())
+			}
+			sort.Strings(pruned)
+			if !reflect.DeepEqual(tc.expectedPruned, pruned) {
+				t.Errorf("Expected pruned objects %v, got %v", tc.expectedPruned, pruned)
+			}
+		})
+	}
+}
+
+func TestIsProtectedNamespace(t *testing.T) {
+	tests := map[string]struct {
+		namespace string
+		expected  bool
+	}{
+		"Default namespace is protected": {
+			namespace: "default",
+			expected:  true,
+		},
+		"System namespace is protected": {
+			namespace: "kube-system",
+			expected:  true,
+		},
+		"Other namespace is not protected": {
+			namespace: otherNamespace,
+			expected:  false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if actual := isProtectedNamespace(tc.namespace); tc.expected != actual {
+				t.Errorf("Expected protected (%t), got (%t)", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseLifecycleAnnotation(t *testing.T) {
 	tests := map[string]struct {
 		annotations map[string]string
-		expected    bool
+		expected    LifecycleDirective
 	}{
-		"Nil map returns false": {
+		"Nil map returns delete": {
 			annotations: nil,
-			expected:    false,
+			expected:    LifecycleDelete,
 		},
-		"Empty map returns false": {
+		"Empty map returns delete": {
 			annotations: map[string]string{},
-			expected:    false,
+			expected:    LifecycleDelete,
 		},
-		"Wrong annotation key/value is false": {
+		"Wrong annotation key returns delete": {
 			annotations: map[string]string{
-				"foo": "bar",
+				"foo": common.OnRemoveKeep,
 			},
-			expected: false,
+			expected: LifecycleDelete,
 		},
-		"Annotation key without value is false": {
+		"Unknown annotation value returns delete": {
 			annotations: map[string]string{
 				common.OnRemoveAnnotation: "bar",
 			},
-			expected: false,
+			expected: LifecycleDelete,
 		},
-		"Annotation key and value is true": {
+		"Keep annotation value returns keep": {
 			annotations: map[string]string{
 				common.OnRemoveAnnotation: common.OnRemoveKeep,
 			},
-			expected: true,
+			expected: LifecycleKeep,
+		},
+		"Detach annotation value returns detach": {
+			annotations: map[string]string{
+				common.OnRemoveAnnotation: common.OnRemoveDetach,
+			},
+			expected: LifecycleDetach,
 		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			actual := preventDeleteAnnotation(tc.annotations)
+			actual := parseLifecycleAnnotation(tc.annotations)
 			if tc.expected != actual {
-				t.Errorf("preventDeleteAnnotation Expected (%t), got (%t)", tc.expected, actual)
+				t.Errorf("parseLifecycleAnnotation Expected (%s), got (%s)", tc.expected, actual)
 			}
 		})
 	}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"fmt"
+
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+)
+
+// serverVersion returns the version of the API server, using the
+// discovery client from the factory.
+func (a *Applier) serverVersion() (*k8sversion.Info, error) {
+	dc, err := a.factory.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	return dc.ServerVersion()
+}
+
+// checkServerVersion returns an error if the version of the API
+// server is older than the minimum version, like "1.25" or "v1.25.3".
+func (a *Applier) checkServerVersion(minimum string) error {
+	minVersion, err := utilversion.ParseGeneric(minimum)
+	if err != nil {
+		return fmt.Errorf("invalid minimum server version %q: %v", minimum, err)
+	}
+	info, err := a.serverVersionFunc()
+	if err != nil {
+		return fmt.Errorf("error getting the server version: %w", err)
+	}
+	serverVersion, err := utilversion.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return fmt.Errorf("invalid server version %q: %v", info.GitVersion, err)
+	}
+	if !serverVersion.AtLeast(minVersion) {
+		return fmt.Errorf("the server version %s is older than the minimum version %s",
+			serverVersion, minVersion)
+	}
+	return nil
+}
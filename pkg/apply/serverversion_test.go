@@ -0,0 +1,73 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func TestCheckServerVersion(t *testing.T) {
+	testCases := map[string]struct {
+		serverVersion string
+		minimum       string
+
+		expectedError bool
+	}{
+		"newer server": {
+			serverVersion: "v1.26.0",
+			minimum:       "1.25",
+		},
+		"same minor version": {
+			serverVersion: "v1.25.0",
+			minimum:       "1.25",
+		},
+		"same patch version with build metadata": {
+			serverVersion: "v1.25.3-gke.100",
+			minimum:       "v1.25.3",
+		},
+		"older patch version": {
+			serverVersion: "v1.25.2",
+			minimum:       "1.25.3",
+			expectedError: true,
+		},
+		"older minor version": {
+			serverVersion: "v1.24.9",
+			minimum:       "1.25",
+			expectedError: true,
+		},
+		"invalid minimum version": {
+			serverVersion: "v1.25.0",
+			minimum:       "latest",
+			expectedError: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory()
+			defer tf.Cleanup()
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplier(tf, ioStreams)
+			discovery := &fakediscovery.FakeDiscovery{
+				Fake:               &clienttesting.Fake{},
+				FakedServerVersion: &k8sversion.Info{GitVersion: tc.serverVersion},
+			}
+			applier.serverVersionFunc = discovery.ServerVersion
+
+			err := applier.checkServerVersion(tc.minimum)
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -17,10 +17,13 @@ package solver
 import (
 	"time"
 
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/kubectl/pkg/cmd/apply"
@@ -29,6 +32,8 @@ import (
 	"sigs.k8s.io/cli-utils/pkg/apply/prune"
 	"sigs.k8s.io/cli-utils/pkg/apply/task"
 	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
 	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
@@ -38,6 +43,8 @@ type TaskQueueSolver struct {
 	PruneOptions *prune.PruneOptions
 	InfoHelper   info.InfoHelper
 	Mapper       meta.RESTMapper
+	// Logger is passed on to the tasks. Can be nil.
+	Logger logr.Logger
 }
 
 type Options struct {
@@ -46,8 +53,42 @@ type Options struct {
 	DryRun                 bool
 	PrunePropagationPolicy metav1.DeletionPropagation
 	PruneTimeout           time.Duration
+	PruneUnusedNamespaces  bool
+	PruneNamespaceScoped   bool
+	PruneContinueOnError   bool
+	PruneForceDelete       bool
+	NoPruneAnnotationKey   string
+	NoPruneAnnotationValue string
+	GarbageCollect         bool
+	ShowDiff               bool
+	SkipInventoryUpdate    bool
+	OwnerReferencePolicy   common.OwnerRefPolicy
+	IgnoreNotFound         bool
+	MaxRetries             int
+	ContinueOnError        bool
+	WaitForConditions      map[schema.GroupKind]taskrunner.StatusCondition
+	HealthPolicy           common.HealthPolicy
+	FetchPreviousObject    bool
+	WaitForJobs            bool
+	JobTimeout             time.Duration
+	LabelMutationPolicy    common.LabelMutationPolicy
+	ResourceStrategy       common.ResourceStrategy
+
+	// PrunePropagationPolicyMap overrides the PrunePropagationPolicy
+	// for the kinds in the map.
+	PrunePropagationPolicyMap map[schema.GroupKind]metav1.DeletionPropagation
+	// PostApplyStatusCheck waits briefly for the status of the applied
+	// resources to be observed if there is no ReconcileTimeout.
+	PostApplyStatusCheck bool
+	// ServerDryRun makes the ApplyTasks send the resources to the
+	// API server with dryRun=All during DryRun.
+	ServerDryRun bool
 }
 
+// postApplyStatusCheckTimeout is how long the post-apply status check
+// waits for the status of the applied resources.
+const postApplyStatusCheckTimeout = 10 * time.Second
+
 type resourceObjects interface {
 	InfosForApply() []*resource.Info
 	IdsForApply() []object.ObjMetadata
@@ -61,16 +102,33 @@ func (t *TaskQueueSolver) BuildTaskQueue(ro resourceObjects,
 	o Options) chan taskrunner.Task {
 	var tasks []taskrunner.Task
 	remainingInfos := ro.InfosForApply()
+	applyIds := ro.IdsForApply()
+	inventoryObj, _ := inventory.FindInventoryObj(remainingInfos)
+	if o.SkipInventoryUpdate {
+		remainingInfos = withoutInventoryObj(remainingInfos)
+		applyIds = object.InfosToObjMetas(remainingInfos)
+	}
 
 	crdSplitRes, hasCRDs := splitAfterCRDs(remainingInfos)
 	if hasCRDs {
 		tasks = append(tasks, &task.ApplyTask{
-			Objects:      append(crdSplitRes.before, crdSplitRes.crds...),
-			CRDs:         crdSplitRes.crds,
-			ApplyOptions: t.ApplyOptions,
-			DryRun:       o.DryRun,
-			InfoHelper:   t.InfoHelper,
-			Mapper:       t.Mapper,
+			Objects:              append(crdSplitRes.before, crdSplitRes.crds...),
+			CRDs:                 crdSplitRes.crds,
+			ApplyOptions:         t.ApplyOptions,
+			DryRun:               o.DryRun,
+			ShowDiff:             o.ShowDiff,
+			InfoHelper:           t.InfoHelper,
+			Mapper:               t.Mapper,
+			Logger:               t.Logger,
+			InventoryObject:      inventoryObj,
+			OwnerReferencePolicy: o.OwnerReferencePolicy,
+			IgnoreNotFound:       o.IgnoreNotFound,
+			MaxRetries:           o.MaxRetries,
+			ContinueOnError:      o.ContinueOnError,
+			FetchPreviousObject:  o.FetchPreviousObject,
+			LabelMutationPolicy:  o.LabelMutationPolicy,
+			ResourceStrategy:     o.ResourceStrategy,
+			ServerDryRun:         o.ServerDryRun,
 		})
 		if !o.DryRun {
 			tasks = append(tasks, taskrunner.NewWaitTask(
@@ -86,12 +144,23 @@ func (t *TaskQueueSolver) BuildTaskQueue(ro resourceObjects,
 
 	tasks = append(tasks,
 		&task.ApplyTask{
-			Objects:      remainingInfos,
-			CRDs:         crdSplitRes.crds,
-			ApplyOptions: t.ApplyOptions,
-			DryRun:       o.DryRun,
-			InfoHelper:   t.InfoHelper,
-			Mapper:       t.Mapper,
+			Objects:              remainingInfos,
+			CRDs:                 crdSplitRes.crds,
+			ApplyOptions:         t.ApplyOptions,
+			DryRun:               o.DryRun,
+			ShowDiff:             o.ShowDiff,
+			InfoHelper:           t.InfoHelper,
+			Mapper:               t.Mapper,
+			Logger:               t.Logger,
+			InventoryObject:      inventoryObj,
+			OwnerReferencePolicy: o.OwnerReferencePolicy,
+			IgnoreNotFound:       o.IgnoreNotFound,
+			MaxRetries:           o.MaxRetries,
+			ContinueOnError:      o.ContinueOnError,
+			FetchPreviousObject:  o.FetchPreviousObject,
+			LabelMutationPolicy:  o.LabelMutationPolicy,
+			ResourceStrategy:     o.ResourceStrategy,
+			ServerDryRun:         o.ServerDryRun,
 		},
 		&task.SendEventTask{
 			Event: event.Event{
@@ -103,12 +172,53 @@ func (t *TaskQueueSolver) BuildTaskQueue(ro resourceObjects,
 		},
 	)
 
+	// Jobs are waited on separately from the ReconcileTimeout when
+	// waiting for jobs, since they have their own deadlines.
+	var jobIds []object.ObjMetadata
+	if o.WaitForJobs {
+		applyIds, jobIds = splitJobIds(applyIds)
+	}
+	var waitTasks []taskrunner.Task
+	// A zero ReconcileTimeout means not waiting, and a negative one
+	// waiting until the context is cancelled, which the WaitTask does
+	// for negative timeouts.
 	if !o.DryRun && o.ReconcileTimeout != time.Duration(0) {
+		waitTask := taskrunner.NewWaitTask(
+			applyIds,
+			taskrunner.AllCurrent,
+			o.ReconcileTimeout)
+		waitTask.StatusConditions = o.WaitForConditions
+		if inventoryObj != nil {
+			// The inventory object has no status conditions.
+			waitTask.DefaultConditionExcluded = map[object.ObjMetadata]bool{
+				object.InfoToObjMeta(inventoryObj): true,
+			}
+		}
+		waitTask.Optional = optionalIds(ro.InfosForApply(), o.HealthPolicy)
+		waitTasks = append(waitTasks, waitTask)
+	} else if !o.DryRun && o.PostApplyStatusCheck {
+		// The status check never fails the apply, so all resources
+		// are optional.
+		statusCheckTask := taskrunner.NewWaitTask(
+			applyIds,
+			taskrunner.AllObserved,
+			postApplyStatusCheckTimeout)
+		statusCheckTask.Optional = make(map[object.ObjMetadata]bool, len(applyIds))
+		for _, id := range applyIds {
+			statusCheckTask.Optional[id] = true
+		}
+		waitTasks = append(waitTasks, statusCheckTask)
+	}
+	if !o.DryRun && len(jobIds) > 0 {
+		// A negative timeout means the Jobs are waited on until they
+		// complete or fail.
+		jobWaitTask := taskrunner.NewWaitTask(jobIds, taskrunner.AllCurrent, o.JobTimeout)
+		jobWaitTask.FailOnFailure = true
+		waitTasks = append(waitTasks, jobWaitTask)
+	}
+	if len(waitTasks) > 0 {
+		tasks = append(tasks, waitTasks...)
 		tasks = append(tasks,
-			taskrunner.NewWaitTask(
-				ro.IdsForApply(),
-				taskrunner.AllCurrent,
-				o.ReconcileTimeout),
 			&task.SendEventTask{
 				Event: event.Event{
 					Type: event.StatusType,
@@ -123,10 +233,19 @@ func (t *TaskQueueSolver) BuildTaskQueue(ro resourceObjects,
 	if o.Prune {
 		tasks = append(tasks,
 			&task.PruneTask{
-				Objects:           ro.InfosForApply(),
-				PruneOptions:      t.PruneOptions,
-				PropagationPolicy: o.PrunePropagationPolicy,
-				DryRun:            o.DryRun,
+				Objects:                ro.InfosForApply(),
+				PruneOptions:           t.PruneOptions,
+				PropagationPolicy:      o.PrunePropagationPolicy,
+				PropagationPolicyMap:   o.PrunePropagationPolicyMap,
+				DryRun:                 o.DryRun,
+				PruneUnusedNamespaces:  o.PruneUnusedNamespaces,
+				NamespaceScoped:        o.PruneNamespaceScoped,
+				ContinueOnError:        o.PruneContinueOnError,
+				GarbageCollect:         o.GarbageCollect,
+				ForceDelete:            o.PruneForceDelete,
+				SkipInventoryUpdate:    o.SkipInventoryUpdate,
+				NoPruneAnnotationKey:   o.NoPruneAnnotationKey,
+				NoPruneAnnotationValue: o.NoPruneAnnotationValue,
 			},
 			&task.SendEventTask{
 				Event: event.Event{
@@ -138,12 +257,18 @@ func (t *TaskQueueSolver) BuildTaskQueue(ro resourceObjects,
 			},
 		)
 
+		// A negative PruneTimeout means the wait task will wait
+		// without a timeout.
+		// Only the objects that are actually deleted are waited for,
+		// since the skipped objects never disappear.
 		if !o.DryRun && o.PruneTimeout != time.Duration(0) {
+			pruneWaitTask := taskrunner.NewWaitTask(
+				ro.IdsForPrune(),
+				taskrunner.AllNotFound,
+				o.PruneTimeout)
+			pruneWaitTask.PrunedOnly = true
 			tasks = append(tasks,
-				taskrunner.NewWaitTask(
-					ro.IdsForPrune(),
-					taskrunner.AllNotFound,
-					o.PruneTimeout),
+				pruneWaitTask,
 				&task.SendEventTask{
 					Event: event.Event{
 						Type: event.StatusType,
@@ -156,6 +281,12 @@ func (t *TaskQueueSolver) BuildTaskQueue(ro resourceObjects,
 		}
 	}
 
+	if o.ContinueOnError {
+		tasks = append(tasks, &task.SummaryTask{
+			Objects: object.InfosToObjMetas(withoutInventoryObj(ro.InfosForApply())),
+		})
+	}
+
 	return tasksToQueue(tasks)
 }
 
@@ -167,6 +298,53 @@ func tasksToQueue(tasks []taskrunner.Task) chan taskrunner.Task {
 	return taskQueue
 }
 
+// withoutInventoryObj returns the infos without the inventory object.
+func withoutInventoryObj(infos []*resource.Info) []*resource.Info {
+	var res []*resource.Info
+	for _, info := range infos {
+		if u, _ := info.Object.(*unstructured.Unstructured); !common.IsInventoryObject(u) {
+			res = append(res, info)
+		}
+	}
+	return res
+}
+
+// optionalIds returns the identifiers of the resources that have the
+// optional health policy, either from the health policy annotation or
+// from the passed default policy.
+func optionalIds(infos []*resource.Info, defaultPolicy common.HealthPolicy) map[object.ObjMetadata]bool {
+	optional := make(map[object.ObjMetadata]bool)
+	for _, info := range infos {
+		policy := defaultPolicy
+		if acc, err := meta.Accessor(info.Object); err == nil {
+			switch acc.GetAnnotations()[common.HealthPolicyAnnotation] {
+			case common.HealthPolicyRequiredValue:
+				policy = common.HealthPolicyRequired
+			case common.HealthPolicyOptionalValue:
+				policy = common.HealthPolicyOptional
+			}
+		}
+		if policy == common.HealthPolicyOptional {
+			optional[object.InfoToObjMeta(info)] = true
+		}
+	}
+	return optional
+}
+
+// splitJobIds splits the identifiers into the ones that are not Jobs
+// and the ones that are.
+func splitJobIds(ids []object.ObjMetadata) ([]object.ObjMetadata, []object.ObjMetadata) {
+	var others, jobs []object.ObjMetadata
+	for _, id := range ids {
+		if id.GroupKind == batchv1.SchemeGroupVersion.WithKind("Job").GroupKind() {
+			jobs = append(jobs, id)
+		} else {
+			others = append(others, id)
+		}
+	}
+	return others, jobs
+}
+
 type crdSplitResult struct {
 	before []*resource.Info
 	after  []*resource.Info
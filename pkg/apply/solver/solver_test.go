@@ -15,6 +15,7 @@ import (
 	"sigs.k8s.io/cli-utils/pkg/apply/prune"
 	"sigs.k8s.io/cli-utils/pkg/apply/task"
 	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/cli-utils/pkg/testutil"
 )
@@ -26,6 +27,8 @@ var (
 	depInfo    = createInfo("apps/v1", "Deployment", "foo", "bar")
 	customInfo = createInfo("custom.io/v1", "Custom", "Foo", "")
 	crdInfo    = createInfo("apiextensions.k8s.io/v1", "CustomResourceDefinition", "CRD", "")
+	invInfo    = createInventoryInfo("inventory", "bar")
+	jobInfo    = createInfo("batch/v1", "Job", "migrate", "bar")
 )
 
 func TestTaskQueueSolver_BuildTaskQueue(t *testing.T) {
@@ -81,6 +84,51 @@ func TestTaskQueueSolver_BuildTaskQueue(t *testing.T) {
 				&task.SendEventTask{},
 			},
 		},
+		"post-apply status check without wait": {
+			infos: []*resource.Info{
+				depInfo,
+			},
+			options: Options{
+				PostApplyStatusCheck: true,
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.ApplyTask{
+					Objects: []*resource.Info{
+						depInfo,
+					},
+				},
+				&task.SendEventTask{},
+				taskrunner.NewWaitTask(
+					[]object.ObjMetadata{
+						object.InfoToObjMeta(depInfo),
+					},
+					taskrunner.AllObserved, 1*time.Second),
+				&task.SendEventTask{},
+			},
+		},
+		"post-apply status check is replaced by the wait": {
+			infos: []*resource.Info{
+				depInfo,
+			},
+			options: Options{
+				ReconcileTimeout:     time.Minute,
+				PostApplyStatusCheck: true,
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.ApplyTask{
+					Objects: []*resource.Info{
+						depInfo,
+					},
+				},
+				&task.SendEventTask{},
+				taskrunner.NewWaitTask(
+					[]object.ObjMetadata{
+						object.InfoToObjMeta(depInfo),
+					},
+					taskrunner.AllCurrent, 1*time.Second),
+				&task.SendEventTask{},
+			},
+		},
 		"multiple resources with wait and prune": {
 			infos: []*resource.Info{
 				depInfo,
@@ -131,6 +179,33 @@ func TestTaskQueueSolver_BuildTaskQueue(t *testing.T) {
 				&task.SendEventTask{},
 			},
 		},
+		"inventory object is not applied with SkipInventoryUpdate": {
+			infos: []*resource.Info{
+				invInfo,
+				depInfo,
+			},
+			options: Options{
+				ReconcileTimeout:    time.Minute,
+				Prune:               true,
+				SkipInventoryUpdate: true,
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.ApplyTask{
+					Objects: []*resource.Info{
+						depInfo,
+					},
+				},
+				&task.SendEventTask{},
+				taskrunner.NewWaitTask(
+					[]object.ObjMetadata{
+						object.InfoToObjMeta(depInfo),
+					},
+					taskrunner.AllCurrent, 1*time.Second),
+				&task.SendEventTask{},
+				&task.PruneTask{},
+				&task.SendEventTask{},
+			},
+		},
 		"multiple resources including CRD": {
 			infos: []*resource.Info{
 				crdInfo,
@@ -166,6 +241,66 @@ func TestTaskQueueSolver_BuildTaskQueue(t *testing.T) {
 				&task.SendEventTask{},
 			},
 		},
+		"wait for jobs without reconcile timeout": {
+			infos: []*resource.Info{
+				depInfo,
+				jobInfo,
+			},
+			options: Options{
+				WaitForJobs: true,
+				JobTimeout:  time.Hour,
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.ApplyTask{
+					Objects: []*resource.Info{
+						depInfo,
+						jobInfo,
+					},
+				},
+				&task.SendEventTask{},
+				&taskrunner.WaitTask{
+					Identifiers: []object.ObjMetadata{
+						object.InfoToObjMeta(jobInfo),
+					},
+					Timeout:       time.Hour,
+					FailOnFailure: true,
+				},
+				&task.SendEventTask{},
+			},
+		},
+		"wait for jobs separately from other resources": {
+			infos: []*resource.Info{
+				depInfo,
+				jobInfo,
+			},
+			options: Options{
+				ReconcileTimeout: time.Minute,
+				WaitForJobs:      true,
+				JobTimeout:       time.Hour,
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.ApplyTask{
+					Objects: []*resource.Info{
+						depInfo,
+						jobInfo,
+					},
+				},
+				&task.SendEventTask{},
+				taskrunner.NewWaitTask(
+					[]object.ObjMetadata{
+						object.InfoToObjMeta(depInfo),
+					},
+					taskrunner.AllCurrent, 1*time.Second),
+				&taskrunner.WaitTask{
+					Identifiers: []object.ObjMetadata{
+						object.InfoToObjMeta(jobInfo),
+					},
+					Timeout:       time.Hour,
+					FailOnFailure: true,
+				},
+				&task.SendEventTask{},
+			},
+		},
 		"no wait with CRDs if it is a dryrun": {
 			infos: []*resource.Info{
 				crdInfo,
@@ -227,8 +362,113 @@ func TestTaskQueueSolver_BuildTaskQueue(t *testing.T) {
 						actID := actWaitTask.Identifiers[j]
 						assert.Equal(t, id, actID)
 					}
+					assert.Equal(t, expTsk.FailOnFailure, actWaitTask.FailOnFailure)
+					if expTsk.FailOnFailure {
+						assert.Equal(t, expTsk.Timeout, actWaitTask.Timeout)
+					}
+					assert.Equal(t, expTsk.Condition, actWaitTask.Condition)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskQueueSolver_ReconcileTimeout(t *testing.T) {
+	testCases := map[string]struct {
+		reconcileTimeout time.Duration
+
+		expectedWait    bool
+		expectedTimeout time.Duration
+	}{
+		// The values match apply.NoWaitForReconcile and
+		// apply.WaitForeverReconcile.
+		"no wait for reconcile": {
+			reconcileTimeout: time.Duration(0),
+			expectedWait:     false,
+		},
+		"wait forever for reconcile": {
+			reconcileTimeout: -1 * time.Second,
+			expectedWait:     true,
+			expectedTimeout:  -1 * time.Second,
+		},
+		"wait with timeout": {
+			reconcileTimeout: time.Minute,
+			expectedWait:     true,
+			expectedTimeout:  time.Minute,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tqs := TaskQueueSolver{
+				ApplyOptions: applyOptions,
+				PruneOptions: pruneOptions,
+				Mapper:       testutil.NewFakeRESTMapper(),
+			}
+
+			tq := tqs.BuildTaskQueue(&fakeResourceObjects{
+				infosForApply: []*resource.Info{depInfo},
+				idsForApply:   object.InfosToObjMetas([]*resource.Info{depInfo}),
+			}, Options{
+				ReconcileTimeout: tc.reconcileTimeout,
+			})
+
+			var waitTasks []*taskrunner.WaitTask
+			for _, tsk := range queueToSlice(tq) {
+				if wt, ok := tsk.(*taskrunner.WaitTask); ok {
+					waitTasks = append(waitTasks, wt)
 				}
 			}
+			if !tc.expectedWait {
+				assert.Equal(t, 0, len(waitTasks))
+				return
+			}
+			assert.Equal(t, 1, len(waitTasks))
+			assert.Equal(t, tc.expectedTimeout, waitTasks[0].Timeout)
+		})
+	}
+}
+
+func TestTaskQueueSolver_ContinueOnError(t *testing.T) {
+	testCases := map[string]struct {
+		continueOnError bool
+
+		expectedSummary bool
+	}{
+		"no summary by default": {
+			continueOnError: false,
+			expectedSummary: false,
+		},
+		"summary added when continuing on error": {
+			continueOnError: true,
+			expectedSummary: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tqs := TaskQueueSolver{
+				ApplyOptions: applyOptions,
+				PruneOptions: pruneOptions,
+				Mapper:       testutil.NewFakeRESTMapper(),
+			}
+
+			tq := tqs.BuildTaskQueue(&fakeResourceObjects{
+				infosForApply: []*resource.Info{depInfo},
+				idsForApply:   object.InfosToObjMetas([]*resource.Info{depInfo}),
+			}, Options{
+				ContinueOnError: tc.continueOnError,
+			})
+
+			tasks := queueToSlice(tq)
+			summaryTask, ok := tasks[len(tasks)-1].(*task.SummaryTask)
+			if !tc.expectedSummary {
+				assert.False(t, ok)
+				return
+			}
+			if assert.True(t, ok) {
+				assert.Equal(t, object.InfosToObjMetas([]*resource.Info{depInfo}), summaryTask.Objects)
+			}
 		})
 	}
 }
@@ -268,6 +508,14 @@ func createInfo(apiVersion, kind, name, namespace string) *resource.Info {
 	}
 }
 
+func createInventoryInfo(name, namespace string) *resource.Info {
+	info := createInfo("v1", "ConfigMap", name, namespace)
+	info.Object.(*unstructured.Unstructured).SetLabels(map[string]string{
+		common.InventoryLabel: "test-app-label",
+	})
+	return info
+}
+
 func queueToSlice(tq chan taskrunner.Task) []taskrunner.Task {
 	var tasks []taskrunner.Task
 	for {
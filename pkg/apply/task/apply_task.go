@@ -4,14 +4,27 @@
 package task
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog"
 	"k8s.io/kubectl/pkg/cmd/apply"
+	"k8s.io/kubectl/pkg/util"
 	"k8s.io/kubectl/pkg/util/slice"
+	"sigs.k8s.io/cli-utils/pkg/apply/diff"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/apply/info"
 	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
@@ -24,6 +37,50 @@ type ApplyTask struct {
 	Objects      []*resource.Info
 	CRDs         []*resource.Info
 	DryRun       bool
+	// ShowDiff enables computing the diff between the live state
+	// and the desired state for each resource during dry-run.
+	ShowDiff bool
+	// Logger is used for structured logging. Can be nil.
+	Logger logr.Logger
+	// InventoryObject is the inventory object for the apply. It is
+	// only used for setting owner references. Can be nil.
+	InventoryObject *resource.Info
+	// OwnerReferencePolicy defines whether an owner reference to the
+	// InventoryObject is added to the applied resources.
+	OwnerReferencePolicy common.OwnerRefPolicy
+	// IgnoreNotFound defines whether NotFound errors when applying a
+	// resource should be reported with a NotFound event instead of
+	// failing the task.
+	IgnoreNotFound bool
+	// MaxRetries is how many times applying a resource is retried if
+	// it fails with a transient error, like a server timeout, too many
+	// requests, a conflict or an internal server error. If it is more than
+	// zero, the resources are applied one at a time, and a
+	// taskrunner.RetriesExhaustedError is returned if all retries
+	// fail.
+	MaxRetries int
+	// ContinueOnError defines whether the task should continue with
+	// the other resources if applying a resource fails. If true, the
+	// resources are applied one at a time, and an ApplyFailed event is
+	// sent for each resource that failed instead of failing the task.
+	ContinueOnError bool
+	// FetchPreviousObject enables fetching the live state of each
+	// resource before it is applied, so it can be included in the
+	// apply events.
+	FetchPreviousObject bool
+	// LabelMutationPolicy defines how the labels of resources that
+	// already exist in the cluster may be changed by the apply.
+	LabelMutationPolicy common.LabelMutationPolicy
+	// ResourceStrategy defines whether resources are updated with a
+	// three-way merge patch through the ApplyOptions, or replaced.
+	// Replacing is not supported during dry-run, so dry-run always
+	// uses the ApplyOptions.
+	ResourceStrategy common.ResourceStrategy
+	// ServerDryRun defines whether the resources are sent to the API
+	// server with dryRun=All during DryRun, so they are validated by
+	// the API server without being persisted. Otherwise nothing is
+	// sent to the API server during DryRun.
+	ServerDryRun bool
 }
 
 // applyOptions defines the two key functions on the ApplyOptions
@@ -49,9 +106,6 @@ type applyOptions interface {
 // the desired state of a resource is changed.
 func (a *ApplyTask) Start(taskContext *taskrunner.TaskContext) {
 	go func() {
-		// Update the dry-run field on the Applier.
-		a.setApplyOptionsFields(taskContext.EventChannel())
-
 		objects := a.Objects
 
 		// If this is a dry run, we need to handle situations where
@@ -77,6 +131,7 @@ func (a *ApplyTask) Start(taskContext *taskrunner.TaskContext) {
 						Type:      event.ApplyEventResourceUpdate,
 						Operation: event.Created,
 						Object:    obj.Object,
+						Diff:      a.crDiff(obj),
 					},
 				}
 			}
@@ -99,16 +154,88 @@ func (a *ApplyTask) Start(taskContext *taskrunner.TaskContext) {
 			a.sendTaskResult(taskContext, err)
 			return
 		}
-		a.ApplyOptions.SetObjects(objects)
-		err = a.ApplyOptions.Run()
-		if err != nil {
-			a.sendTaskResult(taskContext, err)
-			return
+
+		var diffs map[object.ObjMetadata]string
+		if a.DryRun && a.ShowDiff {
+			diffs, err = a.computeDiffs(objects)
+			if err != nil {
+				a.sendTaskResult(taskContext, err)
+				return
+			}
+		}
+
+		var previous map[object.ObjMetadata]*unstructured.Unstructured
+		if a.FetchPreviousObject {
+			previous, err = a.fetchPreviousObjects(objects)
+			if err != nil {
+				a.sendTaskResult(taskContext, err)
+				return
+			}
+		}
+
+		if a.LabelMutationPolicy != common.LabelMutationAllowAll {
+			live := previous
+			if live == nil {
+				live, err = a.fetchPreviousObjects(objects)
+				if err != nil {
+					a.sendTaskResult(taskContext, err)
+					return
+				}
+			}
+			err = mutateLabels(objects, live, a.LabelMutationPolicy)
+			if err != nil {
+				a.sendTaskResult(taskContext, err)
+				return
+			}
+		}
+
+		// Update the dry-run field on the Applier.
+		adapter := a.setApplyOptionsFields(taskContext.EventChannel(), len(objects), diffs, previous)
+		logger := a.logger()
+		applyObjects := objects
+		if a.OwnerReferencePolicy == common.OwnerRefInventory && a.InventoryObject != nil {
+			// The inventory object must exist in the cluster before
+			// the other resources can reference it, so it is applied
+			// separately before the rest of the resources.
+			applyObjects = withoutInfo(objects, a.InventoryObject)
+			if len(applyObjects) < len(objects) {
+				a.ApplyOptions.SetObjects([]*resource.Info{a.InventoryObject})
+				err = a.ApplyOptions.Run()
+				if err != nil {
+					logger.Error(err, "Failed to apply inventory object")
+					a.sendTaskResult(taskContext, err)
+					return
+				}
+			}
+			err = a.setOwnerReferences(applyObjects)
+			if err != nil {
+				a.sendTaskResult(taskContext, err)
+				return
+			}
+		}
+		notApplied := make(map[*resource.Info]bool)
+		if len(applyObjects) > 0 {
+			if a.ResourceStrategy == common.StrategyReplace && !a.DryRun {
+				notApplied, err = a.replaceObjects(taskContext, applyObjects, adapter)
+			} else if a.IgnoreNotFound || a.MaxRetries > 0 || a.ContinueOnError {
+				notApplied, err = a.applyEach(taskContext, applyObjects)
+			} else {
+				a.ApplyOptions.SetObjects(applyObjects)
+				err = a.ApplyOptions.Run()
+			}
+			if err != nil {
+				logger.Error(err, "Failed to apply resources")
+				a.sendTaskResult(taskContext, err)
+				return
+			}
 		}
 		// Fetch the Generation from all Infos after they have been
 		// applied.
 		//TODO: This isn't really needed if we are doing dry-run.
 		for _, obj := range objects {
+			if notApplied[obj] {
+				continue
+			}
 			id := object.InfoToObjMeta(obj)
 			acc, _ := meta.Accessor(obj.Object)
 			gen := acc.GetGeneration()
@@ -118,22 +245,416 @@ func (a *ApplyTask) Start(taskContext *taskrunner.TaskContext) {
 	}()
 }
 
+// applyEach applies the objects one at a time, so errors can be
+// attributed to a single resource, and failed applies can be retried
+// without applying the other resources again. If NotFound errors are
+// ignored, a NotFound event is sent for each resource where the apply
+// failed with a NotFound error. If the task continues on errors, an
+// ApplyFailed event is sent for each resource where the apply failed
+// with any other error, and the resource is recorded as failed in the
+// task context. The set of resources which were not applied is
+// returned. Any other error is returned.
+func (a *ApplyTask) applyEach(taskContext *taskrunner.TaskContext,
+	objects []*resource.Info) (map[*resource.Info]bool, error) {
+	notApplied := make(map[*resource.Info]bool)
+	for _, obj := range objects {
+		a.ApplyOptions.SetObjects([]*resource.Info{obj})
+		err := a.runWithRetries(taskContext.Context(), a.ApplyOptions.Run)
+		if err == nil {
+			continue
+		}
+		switch {
+		case a.IgnoreNotFound && apierrors.IsNotFound(err):
+			taskContext.EventChannel() <- event.Event{
+				Type: event.NotFoundType,
+				NotFoundEvent: event.NotFoundEvent{
+					Object: obj.Object,
+					Err:    err,
+				},
+			}
+		case a.ContinueOnError:
+			a.resourceFailed(taskContext, obj, err)
+		default:
+			return notApplied, err
+		}
+		notApplied[obj] = true
+	}
+	return notApplied, nil
+}
+
+// resourceFailed records the resource as failed in the task context
+// and sends an ApplyFailed event for it.
+func (a *ApplyTask) resourceFailed(taskContext *taskrunner.TaskContext, obj *resource.Info, err error) {
+	a.logger().Error(err, "Failed to apply resource", "namespace", obj.Namespace, "name", obj.Name)
+	taskContext.ResourceFailed(object.InfoToObjMeta(obj))
+	taskContext.EventChannel() <- event.Event{
+		Type: event.ApplyFailedType,
+		ApplyFailedEvent: event.ApplyFailedEvent{
+			Object: obj.Object,
+			Err:    err,
+		},
+	}
+}
+
+// retryInterval is how long to wait before retrying a failed apply. It
+// is defined here so we can override it in unit tests.
+var retryInterval = time.Second
+
+// runWithRetries runs the apply, retrying up to MaxRetries times if it
+// fails with a transient error. Waiting for the next retry stops when
+// the context is done. If the apply still fails after being retried,
+// the error is returned wrapped in a taskrunner.RetriesExhaustedError.
+func (a *ApplyTask) runWithRetries(ctx context.Context, run func() error) error {
+	err := run()
+	retries := 0
+	for err != nil && retries < a.MaxRetries && isTransientError(err) {
+		select {
+		case <-ctx.Done():
+			a.logger().Info("Not retrying failed apply; cancelled", "error", err.Error())
+			return err
+		case <-time.After(retryInterval):
+		}
+		retries++
+		a.logger().Info("Retrying failed apply", "retry", retries, "maxRetries", a.MaxRetries, "error", err.Error())
+		err = run()
+	}
+	if err != nil && retries > 0 {
+		return taskrunner.RetriesExhaustedError{
+			Err:        err,
+			RetryCount: retries,
+		}
+	}
+	return err
+}
+
+// isTransientError returns true if the error returned by the API server
+// is likely to go away when the request is retried.
+func isTransientError(err error) bool {
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) || apierrors.IsConflict(err) {
+		return true
+	}
+	status, ok := err.(apierrors.APIStatus)
+	return ok && status.Status().Code >= 500
+}
+
+// replaceObjects replaces each of the objects in the cluster with an
+// update (PUT), or creates it if it doesn't exist, and sends an apply
+// event for each of them. The last-applied-configuration annotation is
+// set as with a regular apply, and the objects are marked as visited
+// so they are not pruned. The objects are logged and their events are
+// sent through the adapter, like the objects applied with the
+// ApplyOptions.
+// Transient errors are retried and other errors are handled like in
+// applyEach, and the set of resources which were not applied is
+// returned.
+func (a *ApplyTask) replaceObjects(taskContext *taskrunner.TaskContext, objects []*resource.Info,
+	adapter *KubectlPrinterAdapter) (map[*resource.Info]bool, error) {
+	notApplied := make(map[*resource.Info]bool)
+	for _, obj := range objects {
+		if err := util.CreateApplyAnnotation(obj.Object, unstructured.UnstructuredJSONScheme); err != nil {
+			return notApplied, err
+		}
+		acc, err := meta.Accessor(obj.Object)
+		if err != nil {
+			return notApplied, err
+		}
+		// Replace sets the resource version of the live object on the
+		// object, so it is reset before every attempt to make a retry
+		// after a conflict use the latest version.
+		resourceVersion := acc.GetResourceVersion()
+		helper := resource.NewHelper(obj.Client, obj.Mapping)
+		var operation event.ApplyEventOperation
+		var result runtime.Object
+		err = a.runWithRetries(taskContext.Context(), func() error {
+			var err error
+			acc.SetResourceVersion(resourceVersion)
+			operation = event.Configured
+			result, err = helper.Replace(obj.Namespace, obj.Name, true, obj.Object)
+			if apierrors.IsNotFound(err) {
+				operation = event.Created
+				result, err = helper.Create(obj.Namespace, true, obj.Object, &metav1.CreateOptions{})
+			}
+			return err
+		})
+		if err != nil {
+			switch {
+			case a.IgnoreNotFound && apierrors.IsNotFound(err):
+				taskContext.EventChannel() <- event.Event{
+					Type: event.NotFoundType,
+					NotFoundEvent: event.NotFoundEvent{
+						Object: obj.Object,
+						Err:    err,
+					},
+				}
+			case a.ContinueOnError:
+				a.resourceFailed(taskContext, obj, err)
+			default:
+				return notApplied, err
+			}
+			notApplied[obj] = true
+			continue
+		}
+		if err := obj.Refresh(result, true); err != nil {
+			return notApplied, err
+		}
+		if err := a.markVisited(obj); err != nil {
+			return notApplied, err
+		}
+		if err := adapter.printer(operation).PrintObj(obj.Object, nil); err != nil {
+			return notApplied, err
+		}
+	}
+	return notApplied, nil
+}
+
+// markVisited records the UID and namespace of an object that was
+// applied without the ApplyOptions in the visited sets of the
+// ApplyOptions, like the ApplyOptions does for the objects it applies.
+// The prune uses these sets to find the objects of the current apply.
+func (a *ApplyTask) markVisited(obj *resource.Info) error {
+	ao, ok := a.ApplyOptions.(*apply.ApplyOptions)
+	if !ok {
+		return nil
+	}
+	acc, err := meta.Accessor(obj.Object)
+	if err != nil {
+		return err
+	}
+	if obj.Namespaced() {
+		ao.VisitedNamespaces.Insert(obj.Namespace)
+	}
+	ao.VisitedUids.Insert(string(acc.GetUID()))
+	return nil
+}
+
+// logger returns the Logger of the task, or a no-op logger
+// if none has been set.
+func (a *ApplyTask) logger() logr.Logger {
+	if a.Logger == nil {
+		return common.NullLogger{}
+	}
+	return a.Logger
+}
+
+// setOwnerReferences adds an owner reference to the inventory object
+// to each of the provided resources that lives in the same namespace
+// as the inventory object. Owner references can not be set if the
+// inventory object doesn't have a UID yet, which is the case for a
+// new inventory object during dry-run.
+func (a *ApplyTask) setOwnerReferences(objects []*resource.Info) error {
+	inv, err := meta.Accessor(a.InventoryObject.Object)
+	if err != nil {
+		return err
+	}
+	if inv.GetUID() == "" {
+		klog.V(4).Infof("inventory object %s/%s has no UID; not setting owner references",
+			a.InventoryObject.Namespace, a.InventoryObject.Name)
+		return nil
+	}
+	gvk := a.InventoryObject.Object.GetObjectKind().GroupVersionKind()
+	ownerRef := metav1.OwnerReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Name:       inv.GetName(),
+		UID:        inv.GetUID(),
+	}
+	for _, obj := range objects {
+		// Owner references across namespaces are not allowed.
+		if obj.Namespace == "" || obj.Namespace != inv.GetNamespace() {
+			continue
+		}
+		acc, err := meta.Accessor(obj.Object)
+		if err != nil {
+			return err
+		}
+		hasRef := false
+		for _, ref := range acc.GetOwnerReferences() {
+			if ref.UID == ownerRef.UID {
+				hasRef = true
+				break
+			}
+		}
+		if !hasRef {
+			acc.SetOwnerReferences(append(acc.GetOwnerReferences(), ownerRef))
+		}
+	}
+	return nil
+}
+
+// mutateLabels changes the labels of the provided resources according
+// to the policy, based on the labels of the live resources. Resources
+// that don't exist in the cluster are left unchanged.
+//
+// Labels that only exist in the live resource are kept by the
+// three-way merge, so they are not copied into the resources, which
+// would add them to the last-applied-configuration annotation. Only
+// the labels the three-way merge would remove or change are set to
+// their live values: the labels in the previous
+// last-applied-configuration that are missing from the manifest, and
+// with LabelMutationNone the labels from the manifest.
+func mutateLabels(objects []*resource.Info, live map[object.ObjMetadata]*unstructured.Unstructured,
+	policy common.LabelMutationPolicy) error {
+	for _, obj := range objects {
+		liveObj, found := live[object.InfoToObjMeta(obj)]
+		if !found {
+			continue
+		}
+		acc, err := meta.Accessor(obj.Object)
+		if err != nil {
+			return err
+		}
+		applied, err := lastAppliedLabels(liveObj)
+		if err != nil {
+			return err
+		}
+		liveLabels := liveObj.GetLabels()
+		labels := make(map[string]string)
+		for k, v := range acc.GetLabels() {
+			if policy == common.LabelMutationPreserveExisting {
+				labels[k] = v
+			} else if liveValue, found := liveLabels[k]; found {
+				labels[k] = liveValue
+			}
+		}
+		for k := range applied {
+			if _, found := labels[k]; found {
+				continue
+			}
+			if liveValue, found := liveLabels[k]; found {
+				labels[k] = liveValue
+			}
+		}
+		if len(labels) == 0 {
+			labels = nil
+		}
+		acc.SetLabels(labels)
+	}
+	return nil
+}
+
+// lastAppliedLabels returns the labels in the last-applied-configuration
+// annotation of the live resource, or nil if it has no annotation.
+func lastAppliedLabels(liveObj *unstructured.Unstructured) (map[string]string, error) {
+	data, found := liveObj.GetAnnotations()[v1.LastAppliedConfigAnnotation]
+	if !found {
+		return nil, nil
+	}
+	lastApplied := &unstructured.Unstructured{}
+	if err := lastApplied.UnmarshalJSON([]byte(data)); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation on %s/%s: %w", v1.LastAppliedConfigAnnotation,
+			liveObj.GetNamespace(), liveObj.GetName(), err)
+	}
+	return lastApplied.GetLabels(), nil
+}
+
+// withoutInfo returns the infos except the provided one.
+func withoutInfo(infos []*resource.Info, exclude *resource.Info) []*resource.Info {
+	var res []*resource.Info
+	for _, info := range infos {
+		if info != exclude {
+			res = append(res, info)
+		}
+	}
+	return res
+}
+
 func (a *ApplyTask) sendTaskResult(taskContext *taskrunner.TaskContext, err error) {
 	taskContext.TaskChannel() <- taskrunner.TaskResult{
 		Err: err,
 	}
 }
 
-func (a *ApplyTask) setApplyOptionsFields(eventChannel chan event.Event) {
-	if ao, ok := a.ApplyOptions.(*apply.ApplyOptions); ok {
-		ao.DryRun = a.DryRun
-		adapter := &KubectlPrinterAdapter{
-			ch: eventChannel,
+// setApplyOptionsFields sets the dry-run fields of the ApplyOptions and
+// the printer that turns the applied resources into events, if the
+// ApplyOptions are the kubectl ApplyOptions. Returns the printer
+// adapter, which also sends the events for the resources applied
+// without the ApplyOptions.
+func (a *ApplyTask) setApplyOptionsFields(eventChannel chan event.Event, total int,
+	diffs map[object.ObjMetadata]string, previous map[object.ObjMetadata]*unstructured.Unstructured) *KubectlPrinterAdapter {
+	adapter := &KubectlPrinterAdapter{
+		ch:       eventChannel,
+		diffs:    diffs,
+		previous: previous,
+		last:     time.Now(),
+		logger:   a.logger(),
+		total:    total,
+	}
+	ao, ok := a.ApplyOptions.(*apply.ApplyOptions)
+	if !ok {
+		return adapter
+	}
+	ao.DryRun = a.DryRun && !a.ServerDryRun
+	ao.ServerDryRun = a.DryRun && a.ServerDryRun
+	// The adapter is used to intercept what is meant to be printing
+	// in the ApplyOptions, and instead turn those into events.
+	ao.ToPrinter = adapter.toPrinterFunc()
+	return adapter
+}
+
+// computeDiffs fetches the live state of each of the provided resources
+// and computes the diff against the desired state. Resources that
+// doesn't exist in the cluster are diffed against an empty object.
+func (a *ApplyTask) computeDiffs(objects []*resource.Info) (map[object.ObjMetadata]string, error) {
+	diffs := make(map[object.ObjMetadata]string)
+	for _, obj := range objects {
+		helper := resource.NewHelper(obj.Client, obj.Mapping)
+		live, err := helper.Get(obj.Namespace, obj.Name, false)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			live = nil
+		}
+		d, err := diff.Diff(live, obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		diffs[object.InfoToObjMeta(obj)] = d
+	}
+	return diffs, nil
+}
+
+// fetchPreviousObjects fetches the live state of each of the provided
+// resources. Resources that doesn't exist in the cluster are not
+// included in the result.
+func (a *ApplyTask) fetchPreviousObjects(objects []*resource.Info) (map[object.ObjMetadata]*unstructured.Unstructured, error) {
+	previous := make(map[object.ObjMetadata]*unstructured.Unstructured)
+	for _, obj := range objects {
+		helper := resource.NewHelper(obj.Client, obj.Mapping)
+		live, err := helper.Get(obj.Namespace, obj.Name, false)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		u, ok := live.(*unstructured.Unstructured)
+		if !ok {
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+			if err != nil {
+				return nil, err
+			}
+			u = &unstructured.Unstructured{Object: content}
 		}
-		// The adapter is used to intercept what is meant to be printing
-		// in the ApplyOptions, and instead turn those into events.
-		ao.ToPrinter = adapter.toPrinterFunc()
+		previous[object.InfoToObjMeta(obj)] = u
+	}
+	return previous, nil
+}
+
+// crDiff returns the diff for a CR whose CRD has not yet been applied,
+// which means the resource can not exist in the cluster. An empty
+// string is returned if diffs have not been requested.
+func (a *ApplyTask) crDiff(obj *resource.Info) string {
+	if !a.ShowDiff {
+		return ""
+	}
+	d, err := diff.Diff(nil, obj.Object)
+	if err != nil {
+		klog.V(4).Infof("unable to compute diff for %s: %v", obj.Name, err)
+		return ""
 	}
+	return d
 }
 
 // filterCRsWithCRDInSet loops through all the resources and filters out the
@@ -4,15 +4,30 @@
 package task
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"sync"
 	"testing"
+	"time"
 
 	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/cli-utils/pkg/testutil"
 )
@@ -264,6 +279,506 @@ func TestApplyTask_DryRun(t *testing.T) {
 	}
 }
 
+func TestApplyTask_OwnerReferences(t *testing.T) {
+	inv := toInfo(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "inventory",
+			"namespace": "default",
+			"uid":       "inventory-uid",
+		},
+	})
+	inv.Namespace = "default"
+	dep := toInfo(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "foo",
+			"namespace": "default",
+		},
+	})
+	dep.Namespace = "default"
+	crd := toInfo(map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name": "crd",
+		},
+	})
+
+	testCases := map[string]struct {
+		policy common.OwnerRefPolicy
+
+		expectedRuns       int
+		expectedOwnerRefs  int
+		expectedAppliedLen int
+	}{
+		"no owner references by default": {
+			policy:             common.OwnerRefNone,
+			expectedRuns:       1,
+			expectedOwnerRefs:  0,
+			expectedAppliedLen: 3,
+		},
+		"owner reference to inventory object": {
+			policy:             common.OwnerRefInventory,
+			expectedRuns:       2,
+			expectedOwnerRefs:  1,
+			expectedAppliedLen: 2,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			defer close(eventChannel)
+			taskContext := taskrunner.NewTaskContext(eventChannel)
+
+			objects := []*resource.Info{
+				{Namespace: inv.Namespace, Object: inv.Object.DeepCopyObject()},
+				{Namespace: dep.Namespace, Object: dep.Object.DeepCopyObject()},
+				{Namespace: crd.Namespace, Object: crd.Object.DeepCopyObject()},
+			}
+			applyOptions := &fakeApplyOptions{}
+
+			applyTask := &ApplyTask{
+				ApplyOptions:         applyOptions,
+				Objects:              objects,
+				InfoHelper:           &fakeInfoHelper{},
+				InventoryObject:      objects[0],
+				OwnerReferencePolicy: tc.policy,
+			}
+
+			applyTask.Start(taskContext)
+			res := <-taskContext.TaskChannel()
+			assert.NilError(t, res.Err)
+
+			assert.Equal(t, tc.expectedRuns, len(applyOptions.applied))
+			if tc.expectedRuns > 1 {
+				assert.Equal(t, 1, len(applyOptions.applied[0]))
+				assert.Equal(t, objects[0], applyOptions.applied[0][0])
+			}
+			lastApplied := applyOptions.applied[len(applyOptions.applied)-1]
+			assert.Equal(t, tc.expectedAppliedLen, len(lastApplied))
+
+			depRefs := objects[1].Object.(*unstructured.Unstructured).GetOwnerReferences()
+			assert.Equal(t, tc.expectedOwnerRefs, len(depRefs))
+			for _, ref := range depRefs {
+				assert.Equal(t, "ConfigMap", ref.Kind)
+				assert.Equal(t, "inventory", ref.Name)
+				assert.Equal(t, "inventory-uid", string(ref.UID))
+			}
+			// Cluster scoped resources can not be owned by the
+			// namespaced inventory object.
+			crdRefs := objects[2].Object.(*unstructured.Unstructured).GetOwnerReferences()
+			assert.Equal(t, 0, len(crdRefs))
+		})
+	}
+}
+
+func TestApplyTask_IgnoreNotFound(t *testing.T) {
+	notFoundErr := apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "missing")
+
+	testCases := map[string]struct {
+		ignoreNotFound     bool
+		expectError        bool
+		expectedEventTypes []event.Type
+	}{
+		"NotFound error fails the task by default": {
+			ignoreNotFound:     false,
+			expectError:        true,
+			expectedEventTypes: nil,
+		},
+		"NotFound error is reported as event if ignored": {
+			ignoreNotFound:     true,
+			expectError:        false,
+			expectedEventTypes: []event.Type{event.NotFoundType},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			taskContext := taskrunner.NewTaskContext(eventChannel)
+
+			objects := toInfos([]resourceInfo{
+				{
+					group:      "apps",
+					apiVersion: "apps/v1",
+					kind:       "Deployment",
+					name:       "missing",
+					namespace:  "default",
+					generation: 1,
+				},
+				{
+					group:      "apps",
+					apiVersion: "apps/v1",
+					kind:       "Deployment",
+					name:       "found",
+					namespace:  "default",
+					generation: 1,
+				},
+			})
+			for _, obj := range objects {
+				obj.Name = obj.Object.(*unstructured.Unstructured).GetName()
+			}
+			applyOptions := &fakeApplyOptions{
+				errs: map[string]error{
+					"missing": notFoundErr,
+				},
+			}
+
+			applyTask := &ApplyTask{
+				ApplyOptions:   applyOptions,
+				Objects:        objects,
+				InfoHelper:     &fakeInfoHelper{},
+				IgnoreNotFound: tc.ignoreNotFound,
+			}
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			applyTask.Start(taskContext)
+			res := <-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			if tc.expectError {
+				assert.Assert(t, apierrors.IsNotFound(res.Err))
+			} else {
+				assert.NilError(t, res.Err)
+			}
+			var eventTypes []event.Type
+			for _, e := range events {
+				eventTypes = append(eventTypes, e.Type)
+			}
+			assert.DeepEqual(t, tc.expectedEventTypes, eventTypes)
+			if tc.ignoreNotFound {
+				assert.Equal(t, objects[0].Object, events[0].NotFoundEvent.Object)
+				// The resources are applied one at a time, and the
+				// generation is only recorded for the applied resource.
+				assert.Equal(t, 2, len(applyOptions.applied))
+				missingID := object.InfoToObjMeta(objects[0])
+				foundID := object.InfoToObjMeta(objects[1])
+				assert.Equal(t, int64(0), taskContext.ResourceGeneration(missingID))
+				assert.Equal(t, int64(1), taskContext.ResourceGeneration(foundID))
+			}
+		})
+	}
+}
+
+func TestApplyTask_ResourceStrategy(t *testing.T) {
+	defer func(d time.Duration) { retryInterval = d }(retryInterval)
+	retryInterval = 0
+
+	testCases := map[string]struct {
+		strategy        common.ResourceStrategy
+		exists          bool
+		failing         bool
+		continueOnError bool
+		maxRetries      int
+
+		expectedRuns       int
+		expectedRequests   []string
+		expectedEventTypes []event.Type
+		expectedOperation  event.ApplyEventOperation
+	}{
+		"merge strategy patches through the ApplyOptions": {
+			strategy:     common.StrategyMerge,
+			exists:       true,
+			expectedRuns: 1,
+		},
+		"replace strategy updates existing resources": {
+			strategy:           common.StrategyReplace,
+			exists:             true,
+			expectedRequests:   []string{http.MethodGet, http.MethodPut},
+			expectedEventTypes: []event.Type{event.ApplyType},
+			expectedOperation:  event.Configured,
+		},
+		"replace strategy creates missing resources": {
+			strategy:           common.StrategyReplace,
+			exists:             false,
+			expectedRequests:   []string{http.MethodGet, http.MethodPut, http.MethodPost},
+			expectedEventTypes: []event.Type{event.ApplyType},
+			expectedOperation:  event.Created,
+		},
+		"replace strategy continues on errors": {
+			strategy:           common.StrategyReplace,
+			exists:             true,
+			failing:            true,
+			continueOnError:    true,
+			expectedRequests:   []string{http.MethodGet, http.MethodPut},
+			expectedEventTypes: []event.Type{event.ApplyFailedType},
+		},
+		"replace strategy retries transient errors": {
+			strategy:           common.StrategyReplace,
+			exists:             true,
+			failing:            true,
+			continueOnError:    true,
+			maxRetries:         1,
+			expectedRequests:   []string{http.MethodGet, http.MethodPut, http.MethodGet, http.MethodPut},
+			expectedEventTypes: []event.Type{event.ApplyFailedType},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			taskContext := taskrunner.NewTaskContext(eventChannel)
+
+			dep := toInfo(map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":      "foo",
+					"namespace": "default",
+				},
+			})
+			dep.Name = "foo"
+			dep.Namespace = "default"
+			live := dep.Object.(*unstructured.Unstructured).DeepCopy()
+			live.SetResourceVersion("1")
+			liveBytes, err := live.MarshalJSON()
+			assert.NilError(t, err)
+
+			var requests []string
+			dep.Mapping = &meta.RESTMapping{
+				Resource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+				Scope:    meta.RESTScopeNamespace,
+			}
+			dep.Client = &fake.RESTClient{
+				NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+				Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+					requests = append(requests, req.Method)
+					if tc.failing && req.Method == http.MethodPut {
+						return &http.Response{StatusCode: http.StatusInternalServerError, Header: cmdtesting.DefaultHeader(),
+							Body: cmdtesting.StringBody("")}, nil
+					}
+					if !tc.exists && req.Method != http.MethodPost {
+						return &http.Response{StatusCode: http.StatusNotFound, Header: cmdtesting.DefaultHeader(),
+							Body: cmdtesting.StringBody("")}, nil
+					}
+					return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(),
+						Body: ioutil.NopCloser(bytes.NewReader(liveBytes))}, nil
+				}),
+			}
+			applyOptions := &fakeApplyOptions{}
+
+			applyTask := &ApplyTask{
+				ApplyOptions:     applyOptions,
+				Objects:          []*resource.Info{dep},
+				InfoHelper:       &fakeInfoHelper{},
+				ResourceStrategy: tc.strategy,
+				ContinueOnError:  tc.continueOnError,
+				MaxRetries:       tc.maxRetries,
+			}
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			applyTask.Start(taskContext)
+			res := <-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			assert.NilError(t, res.Err)
+			assert.Equal(t, tc.expectedRuns, len(applyOptions.applied))
+			assert.DeepEqual(t, tc.expectedRequests, requests)
+			var eventTypes []event.Type
+			for _, e := range events {
+				eventTypes = append(eventTypes, e.Type)
+			}
+			assert.DeepEqual(t, tc.expectedEventTypes, eventTypes)
+			if len(events) > 0 {
+				assert.Equal(t, tc.expectedOperation, events[0].ApplyEvent.Operation)
+			}
+			for _, e := range events {
+				if e.Type == event.ApplyType {
+					// Replaced resources report their latency like
+					// the resources applied with the ApplyOptions.
+					assert.Assert(t, e.ApplyEvent.Duration > 0)
+					assert.Assert(t, e.ApplyEvent.LatencyBucket != "")
+				}
+			}
+		})
+	}
+}
+
+// TestApplyTask_ReplaceMarksVisited verifies that resources applied
+// with the replace strategy are recorded as visited, so a re-apply
+// doesn't prune the resources it just replaced.
+func TestApplyTask_ReplaceMarksVisited(t *testing.T) {
+	eventChannel := make(chan event.Event)
+	taskContext := taskrunner.NewTaskContext(eventChannel)
+
+	dep := toInfo(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "foo",
+			"namespace": "default",
+		},
+	})
+	dep.Name = "foo"
+	dep.Namespace = "default"
+	live := dep.Object.(*unstructured.Unstructured).DeepCopy()
+	live.SetUID("uid-foo")
+	live.SetResourceVersion("1")
+	liveBytes, err := live.MarshalJSON()
+	assert.NilError(t, err)
+
+	var putBody []byte
+	dep.Mapping = &meta.RESTMapping{
+		Resource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		Scope:    meta.RESTScopeNamespace,
+	}
+	dep.Client = &fake.RESTClient{
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPut {
+				body, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					return nil, err
+				}
+				putBody = body
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(),
+				Body: ioutil.NopCloser(bytes.NewReader(liveBytes))}, nil
+		}),
+	}
+	applyOptions := &apply.ApplyOptions{
+		VisitedUids:       sets.NewString(),
+		VisitedNamespaces: sets.NewString(),
+	}
+
+	applyTask := &ApplyTask{
+		ApplyOptions:     applyOptions,
+		Objects:          []*resource.Info{dep},
+		InfoHelper:       &fakeInfoHelper{},
+		ResourceStrategy: common.StrategyReplace,
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range eventChannel {
+		}
+	}()
+
+	applyTask.Start(taskContext)
+	res := <-taskContext.TaskChannel()
+	close(eventChannel)
+	wg.Wait()
+
+	assert.NilError(t, res.Err)
+	// The prune skips all objects whose UIDs are visited.
+	assert.DeepEqual(t, []string{"uid-foo"}, applyOptions.VisitedUids.List())
+	assert.DeepEqual(t, []string{"default"}, applyOptions.VisitedNamespaces.List())
+	assert.Assert(t, bytes.Contains(putBody, []byte("kubectl.kubernetes.io/last-applied-configuration")))
+}
+
+func TestMutateLabels(t *testing.T) {
+	testCases := map[string]struct {
+		policy            common.LabelMutationPolicy
+		liveLabels        map[string]string
+		lastAppliedLabels map[string]string
+		exists            bool
+		expectedLabels    map[string]string
+	}{
+		"preserve existing doesn't claim out-of-band label": {
+			policy:         common.LabelMutationPreserveExisting,
+			liveLabels:     map[string]string{"app": "old", "out-of-band": "true"},
+			exists:         true,
+			expectedLabels: map[string]string{"app": "foo", "tier": "web"},
+		},
+		"preserve existing keeps previously applied label": {
+			policy:            common.LabelMutationPreserveExisting,
+			liveLabels:        map[string]string{"app": "old", "removed": "true", "out-of-band": "true"},
+			lastAppliedLabels: map[string]string{"app": "old", "removed": "true"},
+			exists:            true,
+			expectedLabels: map[string]string{
+				"app":     "foo",
+				"tier":    "web",
+				"removed": "true",
+			},
+		},
+		"no mutation keeps the live values of the manifest labels": {
+			policy:         common.LabelMutationNone,
+			liveLabels:     map[string]string{"app": "old", "out-of-band": "true"},
+			exists:         true,
+			expectedLabels: map[string]string{"app": "old"},
+		},
+		"no mutation keeps previously applied label": {
+			policy:            common.LabelMutationNone,
+			liveLabels:        map[string]string{"app": "old", "removed": "true"},
+			lastAppliedLabels: map[string]string{"app": "old", "removed": "true"},
+			exists:            true,
+			expectedLabels:    map[string]string{"app": "old", "removed": "true"},
+		},
+		"no mutation without live labels": {
+			policy:         common.LabelMutationNone,
+			exists:         true,
+			expectedLabels: nil,
+		},
+		"new resources keep the manifest labels": {
+			policy:         common.LabelMutationNone,
+			exists:         false,
+			expectedLabels: map[string]string{"app": "foo", "tier": "web"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			dep := toInfo(map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":      "foo",
+					"namespace": "default",
+					"labels": map[string]interface{}{
+						"app":  "foo",
+						"tier": "web",
+					},
+				},
+			})
+			live := make(map[object.ObjMetadata]*unstructured.Unstructured)
+			if tc.exists {
+				liveObj := dep.Object.(*unstructured.Unstructured).DeepCopy()
+				liveObj.SetLabels(tc.liveLabels)
+				if tc.lastAppliedLabels != nil {
+					lastApplied := liveObj.DeepCopy()
+					lastApplied.SetLabels(tc.lastAppliedLabels)
+					data, err := lastApplied.MarshalJSON()
+					assert.NilError(t, err)
+					liveObj.SetAnnotations(map[string]string{
+						v1.LastAppliedConfigAnnotation: string(data),
+					})
+				}
+				live[object.InfoToObjMeta(dep)] = liveObj
+			}
+
+			err := mutateLabels([]*resource.Info{dep}, live, tc.policy)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tc.expectedLabels, dep.Object.(*unstructured.Unstructured).GetLabels())
+		})
+	}
+}
+
 func toInfo(obj map[string]interface{}) *resource.Info {
 	return &resource.Info{
 		Object: &unstructured.Unstructured{
@@ -295,9 +810,20 @@ func toInfos(rss []resourceInfo) []*resource.Info {
 
 type fakeApplyOptions struct {
 	objects []*resource.Info
+	// applied contains the objects for every invocation of Run.
+	applied [][]*resource.Info
+	// errs contains the errors returned by Run when applying
+	// objects with the given name.
+	errs map[string]error
 }
 
 func (f *fakeApplyOptions) Run() error {
+	f.applied = append(f.applied, f.objects)
+	for _, obj := range f.objects {
+		if err, found := f.errs[obj.Name]; found {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -310,3 +836,249 @@ type fakeInfoHelper struct{}
 func (f *fakeInfoHelper) UpdateInfos([]*resource.Info) error {
 	return nil
 }
+
+func TestApplyTask_MaxRetries(t *testing.T) {
+	defer func(d time.Duration) { retryInterval = d }(retryInterval)
+	retryInterval = 0
+
+	testCases := map[string]struct {
+		maxRetries int
+		err        error
+
+		expectedRuns       int
+		expectedRetried    bool
+		expectedRetryCount int
+	}{
+		"error is returned without retries": {
+			err:          fmt.Errorf("failed"),
+			expectedRuns: 1,
+		},
+		"error is returned after exhausting retries": {
+			maxRetries:         2,
+			err:                apierrors.NewTooManyRequests("slow down", 1),
+			expectedRuns:       3,
+			expectedRetried:    true,
+			expectedRetryCount: 2,
+		},
+		"internal server errors are retried": {
+			maxRetries:         1,
+			err:                apierrors.NewInternalError(fmt.Errorf("failed")),
+			expectedRuns:       2,
+			expectedRetried:    true,
+			expectedRetryCount: 1,
+		},
+		"non-transient errors are not retried": {
+			maxRetries:   2,
+			err:          fmt.Errorf("failed"),
+			expectedRuns: 1,
+		},
+		"invalid resources are not retried": {
+			maxRetries: 2,
+			err: apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "foo",
+				field.ErrorList{}),
+			expectedRuns: 1,
+		},
+		"NotFound errors are not retried": {
+			maxRetries:   2,
+			err:          apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "foo"),
+			expectedRuns: 1,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			defer close(eventChannel)
+			taskContext := taskrunner.NewTaskContext(eventChannel)
+
+			dep := toInfo(map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":      "foo",
+					"namespace": "default",
+				},
+			})
+			dep.Name = "foo"
+			applyOptions := &fakeApplyOptions{
+				errs: map[string]error{"foo": tc.err},
+			}
+			applyTask := &ApplyTask{
+				ApplyOptions: applyOptions,
+				Objects:      []*resource.Info{dep},
+				InfoHelper:   &fakeInfoHelper{},
+				Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				}),
+				MaxRetries: tc.maxRetries,
+			}
+
+			applyTask.Start(taskContext)
+			result := <-taskContext.TaskChannel()
+
+			assert.Equal(t, tc.expectedRuns, len(applyOptions.applied))
+			assert.Assert(t, result.Err != nil)
+			retryErr, retried := taskrunner.IsRetriesExhaustedError(result.Err)
+			assert.Equal(t, tc.expectedRetried, retried)
+			assert.Equal(t, tc.expectedRetryCount, retryErr.RetryCount)
+		})
+	}
+}
+
+func TestApplyTask_RetryCancelled(t *testing.T) {
+	defer func(d time.Duration) { retryInterval = d }(retryInterval)
+	retryInterval = time.Hour
+
+	eventChannel := make(chan event.Event)
+	defer close(eventChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	taskContext := taskrunner.NewTaskContextWithContext(ctx, eventChannel)
+
+	dep := toInfo(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "foo",
+			"namespace": "default",
+		},
+	})
+	dep.Name = "foo"
+	applyOptions := &fakeApplyOptions{
+		errs: map[string]error{"foo": apierrors.NewServerTimeout(
+			schema.GroupResource{Group: "apps", Resource: "deployments"}, "patch", 1)},
+	}
+	applyTask := &ApplyTask{
+		ApplyOptions: applyOptions,
+		Objects:      []*resource.Info{dep},
+		InfoHelper:   &fakeInfoHelper{},
+		Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+			Group:   "apps",
+			Version: "v1",
+			Kind:    "Deployment",
+		}),
+		MaxRetries: 2,
+	}
+
+	cancel()
+	applyTask.Start(taskContext)
+	select {
+	case result := <-taskContext.TaskChannel():
+		assert.Assert(t, apierrors.IsServerTimeout(result.Err))
+		assert.Equal(t, 1, len(applyOptions.applied))
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the retry to be cancelled")
+	}
+}
+
+func TestApplyTask_ContinueOnError(t *testing.T) {
+	defer func(d time.Duration) { retryInterval = d }(retryInterval)
+	retryInterval = 0
+
+	deployment := func(name string) *resource.Info {
+		info := toInfo(map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "default",
+			},
+		})
+		info.Name = name
+		return info
+	}
+	infos := []*resource.Info{deployment("good-1"), deployment("bad"), deployment("good-2")}
+
+	eventChannel := make(chan event.Event, 10)
+	taskContext := taskrunner.NewTaskContext(eventChannel)
+	applyOptions := &fakeApplyOptions{
+		errs: map[string]error{"bad": apierrors.NewInternalError(fmt.Errorf("invalid"))},
+	}
+	applyTask := &ApplyTask{
+		ApplyOptions: applyOptions,
+		Objects:      infos,
+		InfoHelper:   &fakeInfoHelper{},
+		Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+			Group:   "apps",
+			Version: "v1",
+			Kind:    "Deployment",
+		}),
+		ContinueOnError: true,
+		MaxRetries:      1,
+	}
+
+	applyTask.Start(taskContext)
+	result := <-taskContext.TaskChannel()
+	assert.NilError(t, result.Err)
+	// The failed resource is applied once more when it is retried.
+	assert.Equal(t, 4, len(applyOptions.applied))
+
+	summaryTask := &SummaryTask{
+		Objects: object.InfosToObjMetas(infos),
+	}
+	summaryTask.Start(taskContext)
+	result = <-taskContext.TaskChannel()
+	assert.NilError(t, result.Err)
+	close(eventChannel)
+
+	var failed []event.ApplyFailedEvent
+	var summaries []event.SummaryEvent
+	for e := range eventChannel {
+		switch e.Type {
+		case event.ApplyFailedType:
+			failed = append(failed, e.ApplyFailedEvent)
+		case event.SummaryType:
+			summaries = append(summaries, e.SummaryEvent)
+		}
+	}
+	assert.Equal(t, 1, len(failed))
+	retryErr, retried := taskrunner.IsRetriesExhaustedError(failed[0].Err)
+	assert.Assert(t, retried)
+	assert.Equal(t, 1, retryErr.RetryCount)
+	assert.Assert(t, taskContext.IsResourceFailed(object.InfoToObjMeta(infos[1])))
+
+	assert.Equal(t, 1, len(summaries))
+	assert.DeepEqual(t, []object.ObjMetadata{object.InfoToObjMeta(infos[0]), object.InfoToObjMeta(infos[2])},
+		summaries[0].Succeeded)
+	assert.DeepEqual(t, []object.ObjMetadata{object.InfoToObjMeta(infos[1])}, summaries[0].Failed)
+}
+
+func TestApplyTask_ServerDryRun(t *testing.T) {
+	testCases := map[string]struct {
+		dryRun       bool
+		serverDryRun bool
+
+		expectedDryRun       bool
+		expectedServerDryRun bool
+	}{
+		"no dry-run": {},
+		"client dry-run": {
+			dryRun:         true,
+			expectedDryRun: true,
+		},
+		"server dry-run": {
+			dryRun:               true,
+			serverDryRun:         true,
+			expectedServerDryRun: true,
+		},
+		"server dry-run is ignored without dry-run": {
+			serverDryRun: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ao := &apply.ApplyOptions{}
+			applyTask := &ApplyTask{
+				ApplyOptions: ao,
+				DryRun:       tc.dryRun,
+				ServerDryRun: tc.serverDryRun,
+			}
+			applyTask.setApplyOptionsFields(make(chan event.Event), 0, nil, nil)
+
+			assert.Equal(t, tc.expectedDryRun, ao.DryRun)
+			assert.Equal(t, tc.expectedServerDryRun, ao.ServerDryRun)
+		})
+	}
+}
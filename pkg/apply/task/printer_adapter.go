@@ -6,10 +6,15 @@ package task
 import (
 	"fmt"
 	"io"
+	"time"
 
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/printers"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 // KubectlPrinterAdapter is a workaround for capturing progress from
@@ -19,6 +24,57 @@ import (
 // printing the info, it emits it as an event on the provided channel.
 type KubectlPrinterAdapter struct {
 	ch chan<- event.Event
+	// diffs contains the diff for each resource that should be
+	// included in the apply events. Can be nil.
+	diffs map[object.ObjMetadata]string
+	// previous contains the live state of each resource before it
+	// was applied. Can be nil.
+	previous map[object.ObjMetadata]*unstructured.Unstructured
+	// last is when the previous resource was printed, or when the
+	// apply started if none has been printed yet. The duration of the
+	// apply events is not set if it is zero.
+	last time.Time
+	// now returns the current time. Defaults to time.Now. It is
+	// defined here so we can override it in unit tests.
+	now func() time.Time
+	// logger is used to log each resource as it is applied. Can be nil.
+	logger logr.Logger
+	// total is the number of resources being applied, and applied is
+	// the number of resources logged so far.
+	total   int
+	applied int
+}
+
+// elapsed returns the time since the previous call, or since the apply
+// started for the first call. ApplyOptions applies the resources one
+// at a time and prints each right after it has been applied, so this
+// is how long the resource took to apply. It returns zero if the
+// start of the apply is unknown.
+func (p *KubectlPrinterAdapter) elapsed() time.Duration {
+	if p.last.IsZero() {
+		return 0
+	}
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+	d := now.Sub(p.last)
+	p.last = now
+	return d
+}
+
+// logApplied logs that the passed resource is being applied, together
+// with its position among all the resources being applied. Like
+// elapsed, it relies on ApplyOptions printing each resource right after
+// it has been applied.
+func (p *KubectlPrinterAdapter) logApplied(obj runtime.Object) {
+	if p == nil || p.logger == nil {
+		return
+	}
+	p.applied++
+	id := objMetadata(obj)
+	p.logger.Info("Applying resource", "index", p.applied, "total", p.total,
+		"kind", id.GroupKind.Kind, "namespace", id.Namespace, "name", id.Name)
 }
 
 // resourcePrinterImpl implements the ResourcePrinter interface. But
@@ -26,22 +82,56 @@ type KubectlPrinterAdapter struct {
 type resourcePrinterImpl struct {
 	applyOperation event.ApplyEventOperation
 	ch             chan<- event.Event
+	diffs          map[object.ObjMetadata]string
+	previous       map[object.ObjMetadata]*unstructured.Unstructured
+	elapsed        func() time.Duration
+	logApplied     func(runtime.Object)
 }
 
 // PrintObj takes the provided object and operation and emits
 // it on the channel.
 func (r *resourcePrinterImpl) PrintObj(obj runtime.Object, _ io.Writer) error {
+	r.logApplied(obj)
+	id := objMetadata(obj)
+	var previous *unstructured.Unstructured
+	if r.applyOperation != event.Created {
+		previous = r.previous[id]
+	}
+	var bucket string
+	duration := r.elapsed()
+	if duration > 0 {
+		bucket = event.LatencyBucketFor(duration)
+	}
 	r.ch <- event.Event{
 		Type: event.ApplyType,
 		ApplyEvent: event.ApplyEvent{
-			Type:      event.ApplyEventResourceUpdate,
-			Operation: r.applyOperation,
-			Object:    obj,
+			Type:          event.ApplyEventResourceUpdate,
+			Operation:     r.applyOperation,
+			Object:        obj,
+			Diff:          r.diffs[id],
+			Previous:      previous,
+			Duration:      duration,
+			LatencyBucket: bucket,
 		},
 	}
 	return nil
 }
 
+// objMetadata returns the identifier for the passed object. An empty
+// ObjMetadata is returned if the object doesn't have the metadata
+// needed.
+func objMetadata(obj runtime.Object) object.ObjMetadata {
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		return object.ObjMetadata{}
+	}
+	return object.ObjMetadata{
+		GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
+		Name:      acc.GetName(),
+		Namespace: acc.GetNamespace(),
+	}
+}
+
 type toPrinterFunc func(string) (printers.ResourcePrinter, error)
 
 // toPrinterFunc returns a function of type toPrinterFunc. This
@@ -49,10 +139,21 @@ type toPrinterFunc func(string) (printers.ResourcePrinter, error)
 func (p *KubectlPrinterAdapter) toPrinterFunc() toPrinterFunc {
 	return func(operation string) (printers.ResourcePrinter, error) {
 		applyOperation, err := operationToApplyOperationConst(operation)
-		return &resourcePrinterImpl{
-			ch:             p.ch,
-			applyOperation: applyOperation,
-		}, err
+		return p.printer(applyOperation), err
+	}
+}
+
+// printer returns a printer that emits apply events with the provided
+// operation. It is also used for resources applied without the
+// ApplyOptions, so their events are the same.
+func (p *KubectlPrinterAdapter) printer(applyOperation event.ApplyEventOperation) *resourcePrinterImpl {
+	return &resourcePrinterImpl{
+		ch:             p.ch,
+		applyOperation: applyOperation,
+		diffs:          p.diffs,
+		previous:       p.previous,
+		elapsed:        p.elapsed,
+		logApplied:     p.logApplied,
 	}
 }
 
@@ -7,11 +7,15 @@ import (
 	"bytes"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 func TestKubectlPrinterAdapter(t *testing.T) {
@@ -53,3 +57,210 @@ func TestKubectlPrinterAdapter(t *testing.T) {
 	assert.Equal(t, event.ServersideApplied, msg.ApplyEvent.Operation)
 	assert.Equal(t, &deployment, msg.ApplyEvent.Object)
 }
+
+func TestKubectlPrinterAdapterPrevious(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "name",
+				"namespace": "namespace",
+			},
+		},
+	}
+	previous := deployment.DeepCopy()
+	previous.SetLabels(map[string]string{"version": "1"})
+	id := object.ObjMetadata{
+		GroupKind: deployment.GroupVersionKind().GroupKind(),
+		Name:      "name",
+		Namespace: "namespace",
+	}
+
+	testCases := map[string]struct {
+		operation        string
+		expectedPrevious *unstructured.Unstructured
+	}{
+		"updated resource has previous object": {
+			operation:        "configured",
+			expectedPrevious: previous,
+		},
+		"unchanged resource has previous object": {
+			operation:        "unchanged",
+			expectedPrevious: previous,
+		},
+		"created resource has no previous object": {
+			operation:        "created",
+			expectedPrevious: nil,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ch := make(chan event.Event, 1)
+			adapter := KubectlPrinterAdapter{
+				ch: ch,
+				previous: map[object.ObjMetadata]*unstructured.Unstructured{
+					id: previous,
+				},
+			}
+
+			resourcePrinter, err := adapter.toPrinterFunc()(tc.operation)
+			assert.NoError(t, err)
+			err = resourcePrinter.PrintObj(deployment, &bytes.Buffer{})
+			assert.NoError(t, err)
+
+			msg := <-ch
+			assert.Equal(t, tc.expectedPrevious, msg.ApplyEvent.Previous)
+		})
+	}
+}
+
+func TestKubectlPrinterAdapterDuration(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "name",
+				"namespace": "namespace",
+			},
+		},
+	}
+	start := time.Now()
+	times := []time.Time{
+		start.Add(50 * time.Millisecond),
+		start.Add(2 * time.Second),
+	}
+
+	ch := make(chan event.Event, len(times))
+	adapter := KubectlPrinterAdapter{
+		ch:   ch,
+		last: start,
+		now: func() time.Time {
+			now := times[0]
+			times = times[1:]
+			return now
+		},
+	}
+
+	resourcePrinter, err := adapter.toPrinterFunc()("configured")
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, resourcePrinter.PrintObj(deployment, &bytes.Buffer{}))
+	}
+
+	// The duration of each event is measured from the previous one.
+	msg := <-ch
+	assert.Equal(t, 50*time.Millisecond, msg.ApplyEvent.Duration)
+	assert.Equal(t, event.LatencyFast, msg.ApplyEvent.LatencyBucket)
+	msg = <-ch
+	assert.Equal(t, 1950*time.Millisecond, msg.ApplyEvent.Duration)
+	assert.Equal(t, event.LatencySlow, msg.ApplyEvent.LatencyBucket)
+}
+
+func TestKubectlPrinterAdapterLogApplied(t *testing.T) {
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "name",
+				"namespace": "namespace",
+			},
+		},
+	}
+
+	logger := &indexLogger{}
+	ch := make(chan event.Event, 2)
+	adapter := KubectlPrinterAdapter{
+		ch:     ch,
+		logger: logger,
+		total:  3,
+	}
+
+	resourcePrinter, err := adapter.toPrinterFunc()("created")
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, resourcePrinter.PrintObj(deployment, &bytes.Buffer{}))
+	}
+
+	// Each resource is logged when it has been applied.
+	assert.Equal(t, []interface{}{1, 2}, logger.indexes)
+	assert.Equal(t, []interface{}{3, 3}, logger.totals)
+
+	// Nothing is logged without a logger.
+	var nilAdapter *KubectlPrinterAdapter
+	nilAdapter.logApplied(deployment)
+}
+
+// indexLogger is a logr.Logger that records the index and total
+// values of the logged entries.
+type indexLogger struct {
+	indexes []interface{}
+	totals  []interface{}
+}
+
+var _ logr.Logger = &indexLogger{}
+
+func (l *indexLogger) Info(_ string, keysAndValues ...interface{}) {
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		switch keysAndValues[i] {
+		case "index":
+			l.indexes = append(l.indexes, keysAndValues[i+1])
+		case "total":
+			l.totals = append(l.totals, keysAndValues[i+1])
+		}
+	}
+}
+
+func (l *indexLogger) Enabled() bool { return true }
+
+func (l *indexLogger) Error(_ error, _ string, _ ...interface{}) {}
+
+func (l *indexLogger) V(_ int) logr.InfoLogger { return l }
+
+func (l *indexLogger) WithValues(_ ...interface{}) logr.Logger { return l }
+
+func (l *indexLogger) WithName(_ string) logr.Logger { return l }
+
+func TestOperationToApplyOperationConst(t *testing.T) {
+	testCases := map[string]struct {
+		operation         string
+		expectedOperation event.ApplyEventOperation
+		expectedErr       bool
+	}{
+		"new resource": {
+			operation:         "created",
+			expectedOperation: event.Created,
+		},
+		"existing resource updated": {
+			operation:         "configured",
+			expectedOperation: event.Configured,
+		},
+		"existing resource not changed": {
+			operation:         "unchanged",
+			expectedOperation: event.Unchanged,
+		},
+		"server-side apply": {
+			operation:         "serverside-applied",
+			expectedOperation: event.ServersideApplied,
+		},
+		"unknown operation": {
+			operation:   "patched",
+			expectedErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			op, err := operationToApplyOperationConst(tc.operation)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOperation, op)
+		})
+	}
+}
@@ -5,6 +5,7 @@ package task
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/resource"
 	"sigs.k8s.io/cli-utils/pkg/apply/prune"
 	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
@@ -12,12 +13,23 @@ import (
 
 // PruneTask prunes objects from the cluster
 // by using the PruneOptions. The provided Objects is the
-// set of resources that have just been applied.
+// set of resources that have just been applied. The objects deleted
+// from the cluster are recorded in the TaskContext, so a later
+// WaitTask only waits for them.
 type PruneTask struct {
-	PruneOptions      *prune.PruneOptions
-	Objects           []*resource.Info
-	DryRun            bool
-	PropagationPolicy metav1.DeletionPropagation
+	PruneOptions           *prune.PruneOptions
+	Objects                []*resource.Info
+	DryRun                 bool
+	PropagationPolicy      metav1.DeletionPropagation
+	PropagationPolicyMap   map[schema.GroupKind]metav1.DeletionPropagation
+	PruneUnusedNamespaces  bool
+	NamespaceScoped        bool
+	ContinueOnError        bool
+	GarbageCollect         bool
+	ForceDelete            bool
+	SkipInventoryUpdate    bool
+	NoPruneAnnotationKey   string
+	NoPruneAnnotationValue string
 }
 
 // Start creates a new goroutine that will invoke
@@ -28,8 +40,19 @@ func (p *PruneTask) Start(taskContext *taskrunner.TaskContext) {
 	go func() {
 		err := p.PruneOptions.Prune(p.Objects, taskContext.EventChannel(),
 			prune.Options{
-				DryRun:            p.DryRun,
-				PropagationPolicy: p.PropagationPolicy,
+				DryRun:                 p.DryRun,
+				PropagationPolicy:      p.PropagationPolicy,
+				PropagationPolicyMap:   p.PropagationPolicyMap,
+				PruneUnusedNamespaces:  p.PruneUnusedNamespaces,
+				NamespaceScoped:        p.NamespaceScoped,
+				ContinueOnError:        p.ContinueOnError,
+				GarbageCollect:         p.GarbageCollect,
+				ForceDelete:            p.ForceDelete,
+				SkipInventoryUpdate:    p.SkipInventoryUpdate,
+				NoPruneAnnotationKey:   p.NoPruneAnnotationKey,
+				NoPruneAnnotationValue: p.NoPruneAnnotationValue,
+				KeepObjects:            taskContext.FailedResources(),
+				DeleteCallback:         taskContext.ResourcePruned,
 			})
 		taskContext.TaskChannel() <- taskrunner.TaskResult{
 			Err: err,
@@ -0,0 +1,45 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// SummaryTask is an implementation of the Task interface that sends
+// a SummaryEvent with the Objects that were applied and the Objects
+// that failed to apply, as recorded in the task context by the
+// ApplyTasks.
+type SummaryTask struct {
+	Objects []object.ObjMetadata
+}
+
+// Start creates a new goroutine that will send the summary and then
+// push a TaskResult on the taskChannel to signal to the taskrunner
+// that the task is completed. Objects that were neither applied nor
+// failed, such as resources which were not found, are left out.
+func (s *SummaryTask) Start(taskContext *taskrunner.TaskContext) {
+	go func() {
+		var summary event.SummaryEvent
+		for _, id := range s.Objects {
+			switch {
+			case taskContext.IsResourceFailed(id):
+				summary.Failed = append(summary.Failed, id)
+			case taskContext.IsResourceApplied(id):
+				summary.Succeeded = append(summary.Succeeded, id)
+			}
+		}
+		taskContext.EventChannel() <- event.Event{
+			Type:         event.SummaryType,
+			SummaryEvent: summary,
+		}
+		taskContext.TaskChannel() <- taskrunner.TaskResult{}
+	}()
+}
+
+// ClearTimeout doesn't do anything as SummaryTask doesn't support
+// timeouts.
+func (s *SummaryTask) ClearTimeout() {}
@@ -37,6 +37,8 @@ type resourceStatus struct {
 	Identifier    object.ObjMetadata
 	CurrentStatus status.Status
 	Generation    int64
+	Conditions    []status.BasicCondition
+	Message       string
 }
 
 // resourceStatus updates the collector with the latest
@@ -45,6 +47,8 @@ func (a *resourceStatusCollector) resourceStatus(r *event.ResourceStatus) {
 	if ri, found := a.resourceMap[r.Identifier]; found {
 		ri.CurrentStatus = r.Status
 		ri.Generation = getGeneration(r)
+		ri.Conditions = r.Conditions
+		ri.Message = r.Message
 		a.resourceMap[r.Identifier] = ri
 	}
 }
@@ -64,7 +68,7 @@ func getGeneration(r *event.ResourceStatus) int64 {
 func (a *resourceStatusCollector) conditionMet(rwd []resourceWaitData, c Condition) bool {
 	switch c {
 	case AllCurrent:
-		return a.allMatchStatus(rwd, status.CurrentStatus)
+		return a.allCurrent(rwd)
 	case AllNotFound:
 		return a.allMatchStatus(rwd, status.NotFoundStatus)
 	default:
@@ -72,6 +76,31 @@ func (a *resourceStatusCollector) conditionMet(rwd []resourceWaitData, c Conditi
 	}
 }
 
+// allCurrent checks whether all resources given by the Identifiers
+// parameter has the Current status, or if the resource must have a
+// status condition, whether it has the condition.
+func (a *resourceStatusCollector) allCurrent(rwd []resourceWaitData) bool {
+	for _, wd := range rwd {
+		ri, found := a.resourceMap[wd.identifier]
+		if !found {
+			return false
+		}
+		if ri.Generation < wd.generation {
+			return false
+		}
+		if wd.condition != nil {
+			if !wd.condition.MetBy(ri.Conditions) {
+				return false
+			}
+			continue
+		}
+		if ri.CurrentStatus != status.CurrentStatus {
+			return false
+		}
+	}
+	return true
+}
+
 // allMatchStatus checks whether all resources given by the
 // Identifiers parameter has the provided status.
 func (a *resourceStatusCollector) allMatchStatus(rwd []resourceWaitData, s status.Status) bool {
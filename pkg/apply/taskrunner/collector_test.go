@@ -7,12 +7,14 @@ import (
 	"testing"
 
 	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 func TestCollector_ConditionMet(t *testing.T) {
+	readyCondition := StatusCondition{Type: "Ready", Status: corev1.ConditionTrue}
 	identifiers := map[string]object.ObjMetadata{
 		"dep": {
 			GroupKind: schema.GroupKind{
@@ -123,6 +125,66 @@ func TestCollector_ConditionMet(t *testing.T) {
 			condition:      AllCurrent,
 			expectedResult: false,
 		},
+		"single resource without the status condition": {
+			collectorState: map[object.ObjMetadata]resourceStatus{
+				identifiers["dep"]: {
+					Identifier:    identifiers["dep"],
+					CurrentStatus: status.CurrentStatus,
+					Generation:    int64(42),
+				},
+			},
+			waitTaskData: []resourceWaitData{
+				{
+					identifier: identifiers["dep"],
+					generation: int64(42),
+					condition:  &readyCondition,
+				},
+			},
+			condition:      AllCurrent,
+			expectedResult: false,
+		},
+		"single resource with the status condition": {
+			collectorState: map[object.ObjMetadata]resourceStatus{
+				identifiers["dep"]: {
+					Identifier:    identifiers["dep"],
+					CurrentStatus: status.InProgressStatus,
+					Generation:    int64(42),
+					Conditions: []status.BasicCondition{
+						{Type: "Ready", Status: corev1.ConditionTrue},
+					},
+				},
+			},
+			waitTaskData: []resourceWaitData{
+				{
+					identifier: identifiers["dep"],
+					generation: int64(42),
+					condition:  &readyCondition,
+				},
+			},
+			condition:      AllCurrent,
+			expectedResult: true,
+		},
+		"single resource with the status condition false": {
+			collectorState: map[object.ObjMetadata]resourceStatus{
+				identifiers["dep"]: {
+					Identifier:    identifiers["dep"],
+					CurrentStatus: status.CurrentStatus,
+					Generation:    int64(42),
+					Conditions: []status.BasicCondition{
+						{Type: "Ready", Status: corev1.ConditionFalse},
+					},
+				},
+			},
+			waitTaskData: []resourceWaitData{
+				{
+					identifier: identifiers["dep"],
+					generation: int64(42),
+					condition:  &readyCondition,
+				},
+			},
+			condition:      AllCurrent,
+			expectedResult: false,
+		},
 	}
 
 	for tn, tc := range testCases {
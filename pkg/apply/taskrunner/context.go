@@ -4,27 +4,50 @@
 package taskrunner
 
 import (
+	"context"
+
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 // NewTaskContext returns a new TaskContext
 func NewTaskContext(eventChannel chan event.Event) *TaskContext {
+	return NewTaskContextWithContext(context.Background(), eventChannel)
+}
+
+// NewTaskContextWithContext returns a new TaskContext for a run that
+// is cancelled with the passed context.
+func NewTaskContextWithContext(ctx context.Context, eventChannel chan event.Event) *TaskContext {
 	return &TaskContext{
+		ctx:              ctx,
 		taskChannel:      make(chan TaskResult),
 		eventChannel:     eventChannel,
 		appliedResources: make(map[object.ObjMetadata]applyInfo),
+		failedResources:  make(map[object.ObjMetadata]bool),
+		prunedResources:  make(map[object.ObjMetadata]bool),
 	}
 }
 
 // TaskContext defines a context that is passed between all
 // the tasks that is in a taskqueue.
 type TaskContext struct {
+	ctx context.Context
+
 	taskChannel chan TaskResult
 
 	eventChannel chan event.Event
 
 	appliedResources map[object.ObjMetadata]applyInfo
+
+	failedResources map[object.ObjMetadata]bool
+
+	prunedResources map[object.ObjMetadata]bool
+}
+
+// Context returns the context of the run, which is done when the run
+// is cancelled. Tasks that wait should stop waiting when it is done.
+func (tc *TaskContext) Context() context.Context {
+	return tc.ctx
 }
 
 func (tc *TaskContext) TaskChannel() chan TaskResult {
@@ -54,6 +77,48 @@ func (tc *TaskContext) ResourceGeneration(id object.ObjMetadata) int64 {
 	return ai.generation
 }
 
+// IsResourceApplied returns true if the resource identified by the
+// provided id has been applied.
+func (tc *TaskContext) IsResourceApplied(id object.ObjMetadata) bool {
+	_, found := tc.appliedResources[id]
+	return found
+}
+
+// ResourceFailed updates the context with the resource identified by
+// the provided id failing to apply. Later tasks don't wait for failed
+// resources, and don't prune them.
+func (tc *TaskContext) ResourceFailed(id object.ObjMetadata) {
+	tc.failedResources[id] = true
+}
+
+// IsResourceFailed returns true if the resource identified by the
+// provided id failed to apply.
+func (tc *TaskContext) IsResourceFailed(id object.ObjMetadata) bool {
+	return tc.failedResources[id]
+}
+
+// FailedResources returns the identifiers of all the resources that
+// failed to apply.
+func (tc *TaskContext) FailedResources() []object.ObjMetadata {
+	var ids []object.ObjMetadata
+	for id := range tc.failedResources {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// ResourcePruned updates the context with the resource identified by
+// the provided id being deleted from the cluster by pruning.
+func (tc *TaskContext) ResourcePruned(id object.ObjMetadata) {
+	tc.prunedResources[id] = true
+}
+
+// IsResourcePruned returns true if the resource identified by the
+// provided id has been deleted from the cluster by pruning.
+func (tc *TaskContext) IsResourcePruned(id object.ObjMetadata) bool {
+	return tc.prunedResources[id]
+}
+
 // applyInfo captures information about resources that have been
 // applied. This is captured in the TaskContext so other tasks
 // running later might use this information.
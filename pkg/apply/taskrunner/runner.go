@@ -5,6 +5,7 @@ package taskrunner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -38,9 +39,11 @@ type taskStatusRunner struct {
 // Options defines properties that is passed along to
 // the statusPoller.
 type Options struct {
-	PollInterval     time.Duration
-	UseCache         bool
-	EmitStatusEvents bool
+	PollInterval      time.Duration
+	UseCache          bool
+	EmitStatusEvents  bool
+	ContinueOnTimeout bool
+	WaitForJobs       bool
 }
 
 // Run starts the execution of the taskqueue. It will start the
@@ -52,10 +55,12 @@ func (tsr *taskStatusRunner) Run(ctx context.Context, taskQueue chan Task,
 	statusChannel := tsr.statusPoller.Poll(statusCtx, tsr.identifiers, polling.Options{
 		PollInterval: options.PollInterval,
 		UseCache:     options.UseCache,
+		WaitForJobs:  options.WaitForJobs,
 	})
 
 	o := baseOptions{
-		emitStatusEvents: options.EmitStatusEvents,
+		emitStatusEvents:  options.EmitStatusEvents,
+		continueOnTimeout: options.ContinueOnTimeout,
 	}
 	err := tsr.baseRunner.run(ctx, taskQueue, statusChannel, eventChannel, o)
 	// cancel the statusPoller by cancelling the context.
@@ -116,6 +121,12 @@ type baseRunner struct {
 
 type baseOptions struct {
 	emitStatusEvents bool
+
+	// continueOnTimeout defines whether the runner should move on
+	// to the next task when a wait task times out. If true, a
+	// TimeoutEvent is emitted for every resource that didn't meet
+	// the condition instead of returning a TimeoutError.
+	continueOnTimeout bool
 }
 
 // run is the main function that implements the processing of
@@ -127,13 +138,7 @@ func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
 	// taskContext is passed into all tasks when they are started. It
 	// provides access to the eventChannel and the taskChannel, and
 	// also provides a way to pass data between tasks.
-	taskContext := NewTaskContext(eventChannel)
-
-	// Find and start the first task in the queue.
-	currentTask, done := b.nextTask(taskQueue, taskContext)
-	if done {
-		return nil
-	}
+	taskContext := NewTaskContextWithContext(ctx, eventChannel)
 
 	// abort is used to signal that something has failed, and
 	// the task processing should end as soon as is possible. Only
@@ -143,6 +148,16 @@ func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
 	abort := false
 	var abortReason error
 
+	// Find and start the first task in the queue.
+	currentTask, done := b.nextTask(taskQueue, taskContext)
+	if done {
+		return nil
+	}
+	if err := b.failedOnStart(currentTask, taskContext); err != nil {
+		abort = true
+		abortReason = err
+	}
+
 	// We do this so we can set the doneCh to a nil channel after
 	// it has been closed. This is needed to avoid a busy loop.
 	doneCh := ctx.Done()
@@ -191,7 +206,16 @@ func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
 			b.collector.resourceStatus(statusEvent.Resource)
 			// If the current task is a wait task, we check whether
 			// the condition has been met. If so, we complete the task.
+			// If the task fails on failed resources and the resource
+			// has failed, we abort.
 			if wt, ok := currentTask.(*WaitTask); ok {
+				if wt.failed(statusEvent.Resource) {
+					abort = true
+					abortReason = statusFailed(eventChannel, statusEvent.Resource.Identifier,
+						statusEvent.Resource.Message)
+					completeIfWaitTask(currentTask, taskContext)
+					continue
+				}
 				if wt.checkCondition(taskContext, b.collector) {
 					completeIfWaitTask(currentTask, taskContext)
 				}
@@ -205,7 +229,15 @@ func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
 		case msg := <-taskContext.TaskChannel():
 			currentTask.ClearTimeout()
 			if msg.Err != nil {
-				return msg.Err
+				timeoutErr, ok := IsTimeoutError(msg.Err)
+				if !ok {
+					return msg.Err
+				}
+				timedOut := b.timedOutResources(currentTask, taskContext, timeoutErr)
+				if !o.continueOnTimeout && requiredTimedOut(currentTask, timedOut) {
+					return msg.Err
+				}
+				sendTimeoutEvents(eventChannel, timedOut, timeoutErr)
 			}
 			if abort {
 				return abortReason
@@ -216,6 +248,10 @@ func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
 			if done {
 				return nil
 			}
+			if err := b.failedOnStart(currentTask, taskContext); err != nil {
+				abort = true
+				abortReason = err
+			}
 		// The doneCh will be closed if the passed in context is cancelled.
 		// If so, we just set the abort flag and wait for the currently running
 		// task to complete before we exit.
@@ -227,6 +263,87 @@ func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
 	}
 }
 
+// failedOnStart checks whether the current task is a wait task that
+// fails on failed resources, and one of its resources has already
+// failed. If so, the task is completed, a StatusFailed event is sent,
+// and the error to abort with is returned.
+func (b *baseRunner) failedOnStart(currentTask Task, taskContext *TaskContext) error {
+	wt, ok := currentTask.(*WaitTask)
+	if !ok {
+		return nil
+	}
+	rs := wt.failedResource(taskContext, b.collector)
+	if rs == nil {
+		return nil
+	}
+	completeIfWaitTask(currentTask, taskContext)
+	return statusFailed(taskContext.EventChannel(), rs.Identifier, rs.Message)
+}
+
+// statusFailed emits a StatusFailed event for the resource and
+// returns the error for the failed resource.
+func statusFailed(eventChannel chan event.Event, id object.ObjMetadata, message string) error {
+	eventChannel <- event.Event{
+		Type: event.StatusFailedType,
+		StatusFailedEvent: event.StatusFailedEvent{
+			Identifier: id,
+			Message:    message,
+		},
+	}
+	return fmt.Errorf("%s %s/%s failed: %s", id.GroupKind.Kind, id.Namespace, id.Name, message)
+}
+
+// timedOutResources returns the resources in the TimeoutError that
+// don't meet the condition of the wait task. For a WaitTask, this is
+// checked the same way as the condition of the task, so resources that
+// only need a status condition meet it if they have the condition.
+func (b *baseRunner) timedOutResources(currentTask Task, taskContext *TaskContext,
+	timeoutErr TimeoutError) []object.ObjMetadata {
+	wt, isWaitTask := currentTask.(*WaitTask)
+	var timedOut []object.ObjMetadata
+	for _, id := range timeoutErr.Identifiers {
+		var met bool
+		if isWaitTask {
+			met = wt.resourceMet(taskContext, b.collector, id)
+		} else if rs, found := b.collector.resourceMap[id]; found {
+			met = timeoutErr.Condition.Meets(rs.CurrentStatus)
+		}
+		if !met {
+			timedOut = append(timedOut, id)
+		}
+	}
+	return timedOut
+}
+
+// sendTimeoutEvents emits a TimeoutEvent for each of the resources
+// that timed out.
+func sendTimeoutEvents(eventChannel chan event.Event, timedOut []object.ObjMetadata, timeoutErr TimeoutError) {
+	for _, id := range timedOut {
+		eventChannel <- event.Event{
+			Type: event.TimeoutType,
+			TimeoutEvent: event.TimeoutEvent{
+				Identifier: id,
+				Timeout:    timeoutErr.Timeout,
+			},
+		}
+	}
+}
+
+// requiredTimedOut returns true if any of the resources that timed
+// out isn't optional.
+func requiredTimedOut(currentTask Task, timedOut []object.ObjMetadata) bool {
+	wt, ok := currentTask.(*WaitTask)
+	if !ok {
+		return true
+	}
+	for _, id := range timedOut {
+		if !wt.Optional[id] {
+			return true
+		}
+	}
+	return false
+}
+
 // completeIfWaitTask checks if the current task is a wait task. If so,
 // we invoke the complete function to complete it.
 func completeIfWaitTask(currentTask Task, taskContext *TaskContext) {
@@ -302,3 +419,32 @@ func IsTimeoutError(err error) (TimeoutError, bool) {
 	}
 	return TimeoutError{}, false
 }
+
+// RetriesExhaustedError is used by tasks when an operation has
+// still failed after being retried.
+type RetriesExhaustedError struct {
+	// Err is the error from the last attempt.
+	Err error
+
+	// RetryCount is the number of retries, not including the
+	// first attempt.
+	RetryCount int
+}
+
+func (re RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("failed after %d retries: %v", re.RetryCount, re.Err)
+}
+
+func (re RetriesExhaustedError) Unwrap() error {
+	return re.Err
+}
+
+// IsRetriesExhaustedError checks whether a given error is, or
+// wraps, a RetriesExhaustedError.
+func IsRetriesExhaustedError(err error) (RetriesExhaustedError, bool) {
+	var e RetriesExhaustedError
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return RetriesExhaustedError{}, false
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
@@ -86,6 +87,46 @@ func TestBaseRunner(t *testing.T) {
 				event.PruneType,
 			},
 		},
+		"wait task with status condition runs until the condition is set": {
+			identifiers: []object.ObjMetadata{depID},
+			tasks: []Task{
+				newStatusConditionWaitTask([]object.ObjMetadata{depID},
+					map[schema.GroupKind]StatusCondition{
+						depID.GroupKind: {Type: "Ready", Status: corev1.ConditionTrue},
+					}, 10*time.Second),
+				&busyTask{
+					resultEvent: event.Event{
+						Type: event.PruneType,
+					},
+					duration: 1 * time.Second,
+				},
+			},
+			statusEventsDelay: 1 * time.Second,
+			statusEvents: []pollevent.Event{
+				{
+					EventType: pollevent.ResourceUpdateEvent,
+					Resource: &pollevent.ResourceStatus{
+						Identifier: depID,
+						Status:     status.CurrentStatus,
+					},
+				},
+				{
+					EventType: pollevent.ResourceUpdateEvent,
+					Resource: &pollevent.ResourceStatus{
+						Identifier: depID,
+						Status:     status.CurrentStatus,
+						Conditions: []status.BasicCondition{
+							{Type: "Ready", Status: corev1.ConditionTrue},
+						},
+					},
+				},
+			},
+			expectedEventTypes: []event.Type{
+				event.StatusType,
+				event.StatusType,
+				event.PruneType,
+			},
+		},
 		"tasks run in order": {
 			identifiers: []object.ObjMetadata{},
 			tasks: []Task{
@@ -230,6 +271,20 @@ func TestBaseRunnerCancellation(t *testing.T) {
 			contextTimeout:     2 * time.Second,
 			expectedEventTypes: []event.Type{},
 		},
+		"cancellation while wait task without timeout is running": {
+			identifiers: []object.ObjMetadata{depID},
+			tasks: []Task{
+				NewWaitTask([]object.ObjMetadata{depID}, AllNotFound, -1*time.Second),
+				&busyTask{
+					resultEvent: event.Event{
+						Type: event.PruneType,
+					},
+					duration: 2 * time.Second,
+				},
+			},
+			contextTimeout:     2 * time.Second,
+			expectedEventTypes: []event.Type{},
+		},
 		"error while custom task is running": {
 			identifiers: []object.ObjMetadata{depID},
 			tasks: []Task{
@@ -342,6 +397,305 @@ func TestBaseRunnerCancellation(t *testing.T) {
 	}
 }
 
+func TestBaseRunnerContinueOnTimeout(t *testing.T) {
+	runner := newBaseRunner(newResourceStatusCollector([]object.ObjMetadata{depID, cmID}))
+	eventChannel := make(chan event.Event)
+	tasks := []Task{
+		NewWaitTask([]object.ObjMetadata{depID, cmID}, AllCurrent, 2*time.Second),
+		&busyTask{
+			resultEvent: event.Event{
+				Type: event.PruneType,
+			},
+			duration: 1 * time.Second,
+		},
+	}
+	taskQueue := make(chan Task, len(tasks))
+	for _, tsk := range tasks {
+		taskQueue <- tsk
+	}
+
+	// Use a WaitGroup to make sure changes in the goroutines
+	// are visible to the main goroutine.
+	var wg sync.WaitGroup
+
+	statusChannel := make(chan pollevent.Event)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		statusChannel <- pollevent.Event{
+			EventType: pollevent.ResourceUpdateEvent,
+			Resource: &pollevent.ResourceStatus{
+				Identifier: cmID,
+				Status:     status.CurrentStatus,
+			},
+		}
+	}()
+
+	var events []event.Event
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for msg := range eventChannel {
+			events = append(events, msg)
+		}
+	}()
+
+	err := runner.run(context.Background(), taskQueue, statusChannel, eventChannel,
+		baseOptions{emitStatusEvents: false, continueOnTimeout: true})
+	close(statusChannel)
+	close(eventChannel)
+	wg.Wait()
+
+	if err != nil {
+		t.Errorf("expected no error, but got %v", err)
+	}
+
+	expectedEventTypes := []event.Type{
+		event.TimeoutType,
+		event.PruneType,
+	}
+	if want, got := len(expectedEventTypes), len(events); want != got {
+		t.Fatalf("expected %d events, but got %d", want, got)
+	}
+	for i, e := range events {
+		if want, got := expectedEventTypes[i], e.Type; want != got {
+			t.Errorf("expected event type %s, but got %s", want, got)
+		}
+	}
+	if want, got := depID, events[0].TimeoutEvent.Identifier; want != got {
+		t.Errorf("expected timeout event for %v, but got %v", want, got)
+	}
+}
+
+func TestBaseRunnerHealthPolicy(t *testing.T) {
+	testCases := map[string]struct {
+		optional           map[object.ObjMetadata]bool
+		statusConditions   map[schema.GroupKind]StatusCondition
+		cmStatus           status.Status
+		cmConditions       []status.BasicCondition
+		expectTimeoutError bool
+		expectedEventTypes []event.Type
+	}{
+		"required resource not current fails": {
+			optional:           map[object.ObjMetadata]bool{cmID: true},
+			expectTimeoutError: true,
+			expectedEventTypes: []event.Type{},
+		},
+		"optional resource not current emits timeout event": {
+			optional:           map[object.ObjMetadata]bool{depID: true},
+			expectTimeoutError: false,
+			expectedEventTypes: []event.Type{
+				event.TimeoutType,
+				event.PruneType,
+			},
+		},
+		"required resource with status condition met doesn't time out": {
+			optional: map[object.ObjMetadata]bool{depID: true},
+			statusConditions: map[schema.GroupKind]StatusCondition{
+				cmID.GroupKind: {Type: "Ready", Status: corev1.ConditionTrue},
+			},
+			cmStatus: status.InProgressStatus,
+			cmConditions: []status.BasicCondition{
+				{Type: "Ready", Status: corev1.ConditionTrue},
+			},
+			expectTimeoutError: false,
+			expectedEventTypes: []event.Type{
+				event.TimeoutType,
+				event.PruneType,
+			},
+		},
+		"required resource without status condition times out": {
+			optional: map[object.ObjMetadata]bool{depID: true},
+			statusConditions: map[schema.GroupKind]StatusCondition{
+				cmID.GroupKind: {Type: "Ready", Status: corev1.ConditionTrue},
+			},
+			expectTimeoutError: true,
+			expectedEventTypes: []event.Type{},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			runner := newBaseRunner(newResourceStatusCollector([]object.ObjMetadata{depID, cmID}))
+			eventChannel := make(chan event.Event)
+			waitTask := NewWaitTask([]object.ObjMetadata{depID, cmID}, AllCurrent, 1*time.Second)
+			waitTask.Optional = tc.optional
+			waitTask.StatusConditions = tc.statusConditions
+			cmStatus := tc.cmStatus
+			if cmStatus == "" {
+				cmStatus = status.CurrentStatus
+			}
+			tasks := []Task{
+				waitTask,
+				&busyTask{
+					resultEvent: event.Event{
+						Type: event.PruneType,
+					},
+					duration: 1 * time.Second,
+				},
+			}
+			taskQueue := make(chan Task, len(tasks))
+			for _, tsk := range tasks {
+				taskQueue <- tsk
+			}
+
+			var wg sync.WaitGroup
+
+			statusChannel := make(chan pollevent.Event)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				statusChannel <- pollevent.Event{
+					EventType: pollevent.ResourceUpdateEvent,
+					Resource: &pollevent.ResourceStatus{
+						Identifier: cmID,
+						Status:     cmStatus,
+						Conditions: tc.cmConditions,
+					},
+				}
+			}()
+
+			events := []event.Event{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			err := runner.run(context.Background(), taskQueue, statusChannel, eventChannel,
+				baseOptions{emitStatusEvents: false})
+			close(statusChannel)
+			close(eventChannel)
+			wg.Wait()
+
+			if tc.expectTimeoutError {
+				if _, ok := IsTimeoutError(err); !ok {
+					t.Errorf("expected timeout error, but got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+
+			if want, got := len(tc.expectedEventTypes), len(events); want != got {
+				t.Fatalf("expected %d events, but got %d", want, got)
+			}
+			for i, e := range events {
+				if want, got := tc.expectedEventTypes[i], e.Type; want != got {
+					t.Errorf("expected event type %s, but got %s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBaseRunnerFailOnFailure(t *testing.T) {
+	jobID := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "batch", Kind: "Job"},
+		Namespace: "default",
+		Name:      "migrate",
+	}
+
+	testCases := map[string]struct {
+		initialStatus      status.Status
+		statuses           []status.Status
+		expectError        bool
+		expectedEventTypes []event.Type
+	}{
+		"job has already failed when the task starts": {
+			initialStatus: status.FailedStatus,
+			expectError:   true,
+			expectedEventTypes: []event.Type{
+				event.StatusFailedType,
+			},
+		},
+		"job progresses from running to succeeded": {
+			statuses:           []status.Status{status.InProgressStatus, status.InProgressStatus, status.CurrentStatus},
+			expectError:        false,
+			expectedEventTypes: []event.Type{},
+		},
+		"job fails": {
+			statuses:    []status.Status{status.InProgressStatus, status.FailedStatus},
+			expectError: true,
+			expectedEventTypes: []event.Type{
+				event.StatusFailedType,
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			runner := newBaseRunner(newResourceStatusCollector([]object.ObjMetadata{jobID}))
+			if tc.initialStatus != "" {
+				runner.collector.resourceStatus(&pollevent.ResourceStatus{
+					Identifier: jobID,
+					Status:     tc.initialStatus,
+				})
+			}
+			eventChannel := make(chan event.Event)
+			waitTask := NewWaitTask([]object.ObjMetadata{jobID}, AllCurrent, -1)
+			waitTask.FailOnFailure = true
+			taskQueue := make(chan Task, 1)
+			taskQueue <- waitTask
+
+			var wg sync.WaitGroup
+
+			statusChannel := make(chan pollevent.Event)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for _, s := range tc.statuses {
+					statusChannel <- pollevent.Event{
+						EventType: pollevent.ResourceUpdateEvent,
+						Resource: &pollevent.ResourceStatus{
+							Identifier: jobID,
+							Status:     s,
+						},
+					}
+				}
+			}()
+
+			events := []event.Event{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			err := runner.run(context.Background(), taskQueue, statusChannel, eventChannel,
+				baseOptions{emitStatusEvents: false})
+			close(statusChannel)
+			close(eventChannel)
+			wg.Wait()
+
+			if tc.expectError && err == nil {
+				t.Errorf("expected error, but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+
+			if want, got := len(tc.expectedEventTypes), len(events); want != got {
+				t.Fatalf("expected %d events, but got %d", want, got)
+			}
+			for i, e := range events {
+				if want, got := tc.expectedEventTypes[i], e.Type; want != got {
+					t.Errorf("expected event type %s, but got %s", want, got)
+				}
+			}
+		})
+	}
+}
+
 type busyTask struct {
 	resultEvent event.Event
 	duration    time.Duration
@@ -359,3 +713,12 @@ func (b *busyTask) Start(taskContext *TaskContext) {
 }
 
 func (b *busyTask) ClearTimeout() {}
+
+// newStatusConditionWaitTask returns a wait task for the AllCurrent
+// condition where resources must have the provided status conditions.
+func newStatusConditionWaitTask(ids []object.ObjMetadata, conditions map[schema.GroupKind]StatusCondition,
+	timeout time.Duration) *WaitTask {
+	task := NewWaitTask(ids, AllCurrent, timeout)
+	task.StatusConditions = conditions
+	return task
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package taskrunner
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+// StatusCondition is a condition in the status of a resource that a
+// WaitTask can wait for, identified by its type, together with the
+// status it must have.
+type StatusCondition struct {
+	Type   string
+	Status corev1.ConditionStatus
+}
+
+// ParseStatusCondition parses a condition expression of the form
+// "type=Ready,status=True". The status can be left out, in which case
+// it defaults to True.
+func ParseStatusCondition(expr string) (StatusCondition, error) {
+	sc := StatusCondition{
+		Status: corev1.ConditionTrue,
+	}
+	for _, part := range strings.Split(expr, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return StatusCondition{}, fmt.Errorf("invalid condition expression %q: expected key=value pairs", expr)
+		}
+		switch strings.ToLower(kv[0]) {
+		case "type":
+			sc.Type = kv[1]
+		case "status":
+			sc.Status = corev1.ConditionStatus(kv[1])
+		default:
+			return StatusCondition{}, fmt.Errorf("invalid condition expression %q: unknown key %q", expr, kv[0])
+		}
+	}
+	if sc.Type == "" {
+		return StatusCondition{}, fmt.Errorf("invalid condition expression %q: type is required", expr)
+	}
+	return sc, nil
+}
+
+// MetBy returns true if the conditions contain a condition with the
+// type and status of the StatusCondition.
+func (sc StatusCondition) MetBy(conditions []status.BasicCondition) bool {
+	for _, c := range conditions {
+		if c.Type == sc.Type {
+			return c.Status == sc.Status
+		}
+	}
+	return false
+}
+
+// String returns the condition expression for the StatusCondition.
+func (sc StatusCondition) String() string {
+	return fmt.Sprintf("type=%s,status=%s", sc.Type, sc.Status)
+}
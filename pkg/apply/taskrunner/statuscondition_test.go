@@ -0,0 +1,52 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package taskrunner
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseStatusCondition(t *testing.T) {
+	testCases := map[string]struct {
+		expr          string
+		expected      StatusCondition
+		expectedError bool
+	}{
+		"type and status": {
+			expr:     "type=Ready,status=False",
+			expected: StatusCondition{Type: "Ready", Status: corev1.ConditionFalse},
+		},
+		"status defaults to True": {
+			expr:     "type=Available",
+			expected: StatusCondition{Type: "Available", Status: corev1.ConditionTrue},
+		},
+		"missing type": {
+			expr:          "status=True",
+			expectedError: true,
+		},
+		"unknown key": {
+			expr:          "type=Ready,reason=Done",
+			expectedError: true,
+		},
+		"not a key value pair": {
+			expr:          "Ready",
+			expectedError: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			sc, err := ParseStatusCondition(tc.expr)
+			if tc.expectedError {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tc.expected, sc)
+		})
+	}
+}
@@ -6,6 +6,8 @@ package taskrunner
 import (
 	"time"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
@@ -46,8 +48,33 @@ type WaitTask struct {
 	// Condition defines the status we want all resources to reach
 	Condition Condition
 	// Timeout defines how long we are willing to wait for the condition
-	// to be met.
+	// to be met. A negative value means the task will wait until the
+	// condition is met or the task is cancelled.
 	Timeout time.Duration
+	// StatusConditions maps resource kinds to the status condition
+	// resources of that kind must have to meet the AllCurrent
+	// condition, instead of having the Current status. The condition
+	// for the empty GroupKind is used for all kinds that don't have
+	// their own entry.
+	StatusConditions map[schema.GroupKind]StatusCondition
+	// DefaultConditionExcluded contains the resources which the
+	// condition for the empty GroupKind in StatusConditions doesn't
+	// apply to, like the inventory object which never has any status
+	// conditions. They must still have the Current status.
+	DefaultConditionExcluded map[object.ObjMetadata]bool
+	// Optional contains the resources that are allowed to not meet
+	// the condition when the task times out. If only optional
+	// resources don't meet the condition, the timeout doesn't fail
+	// the task runner.
+	Optional map[object.ObjMetadata]bool
+	// FailOnFailure defines whether the task should fail as soon as
+	// one of the resources has the Failed status, instead of waiting
+	// for the condition to be met or the timeout.
+	FailOnFailure bool
+	// PrunedOnly defines whether only the resources in Identifiers that
+	// were deleted by pruning should be waited for. Resources that the
+	// pruner skipped are never deleted, so they are not waited for.
+	PrunedOnly bool
 
 	// cancelFunc is a function that will cancel the timeout timer
 	// on the task.
@@ -72,6 +99,12 @@ func (w *WaitTask) Start(taskContext *TaskContext) {
 // the WaitTask struct. Once the timer expires, it will send
 // a message on the TaskChannel provided in the taskContext.
 func (w *WaitTask) setTimer(taskContext *TaskContext) {
+	// A negative timeout means we wait indefinitely, so there
+	// is no timer.
+	if w.Timeout < 0 {
+		w.cancelFunc = func() {}
+		return
+	}
 	timer := time.NewTimer(w.Timeout)
 	go func() {
 		//TODO(mortent): See if there is a better way to do this. This
@@ -84,7 +117,7 @@ func (w *WaitTask) setTimer(taskContext *TaskContext) {
 		case <-w.token:
 			taskContext.TaskChannel() <- TaskResult{
 				Err: TimeoutError{
-					Identifiers: w.Identifiers,
+					Identifiers: w.identifiers(taskContext),
 					Timeout:     w.Timeout,
 					Condition:   w.Condition,
 				},
@@ -105,21 +138,104 @@ func (w *WaitTask) checkCondition(taskContext *TaskContext, coll *resourceStatus
 	return coll.conditionMet(rwd, w.Condition)
 }
 
+// resourceMet returns true if the resource meets the condition of the
+// task, checked the same way as in checkCondition. Resources that
+// failed to apply are not waited for, so they meet the condition.
+func (w *WaitTask) resourceMet(taskContext *TaskContext, coll *resourceStatusCollector, id object.ObjMetadata) bool {
+	if taskContext.IsResourceFailed(id) {
+		return true
+	}
+	return coll.conditionMet([]resourceWaitData{{
+		identifier: id,
+		generation: taskContext.ResourceGeneration(id),
+		condition:  w.statusCondition(id),
+	}}, w.Condition)
+}
+
 // computeResourceWaitData creates a slice of resourceWaitData for
 // the resources that is relevant to this wait task. The objective is
 // to match each resource with the generation seen after the resource
-// was applied.
+// was applied. Resources that failed to apply are not waited for.
 func (w *WaitTask) computeResourceWaitData(taskContext *TaskContext) []resourceWaitData {
 	var rwd []resourceWaitData
-	for _, id := range w.Identifiers {
+	for _, id := range w.identifiers(taskContext) {
+		if taskContext.IsResourceFailed(id) {
+			continue
+		}
 		rwd = append(rwd, resourceWaitData{
 			identifier: id,
 			generation: taskContext.ResourceGeneration(id),
+			condition:  w.statusCondition(id),
 		})
 	}
 	return rwd
 }
 
+// identifiers returns the identifiers of the resources the task waits
+// for. If PrunedOnly is set, these are only the resources that have
+// been pruned.
+func (w *WaitTask) identifiers(taskContext *TaskContext) []object.ObjMetadata {
+	if !w.PrunedOnly {
+		return w.Identifiers
+	}
+	var ids []object.ObjMetadata
+	for _, id := range w.Identifiers {
+		if taskContext.IsResourcePruned(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// failed returns true if the task fails on failed resources and the
+// resource is one of the resources of the task and has the Failed
+// status.
+func (w *WaitTask) failed(rs *pollevent.ResourceStatus) bool {
+	if !w.FailOnFailure || rs == nil || rs.Status != status.FailedStatus {
+		return false
+	}
+	for _, id := range w.Identifiers {
+		if id == rs.Identifier {
+			return true
+		}
+	}
+	return false
+}
+
+// failedResource returns the latest status of a resource of the task
+// which has the Failed status in the collector, or nil if there is no
+// such resource or the task doesn't fail on failed resources. It is
+// checked when the task is started, since there might not be any more
+// status events for resources which have already failed.
+func (w *WaitTask) failedResource(taskContext *TaskContext, coll *resourceStatusCollector) *resourceStatus {
+	if !w.FailOnFailure {
+		return nil
+	}
+	for _, id := range w.Identifiers {
+		rs, found := coll.resourceMap[id]
+		if found && rs.CurrentStatus == status.FailedStatus &&
+			rs.Generation >= taskContext.ResourceGeneration(id) {
+			return &rs
+		}
+	}
+	return nil
+}
+
+// statusCondition returns the status condition that the provided
+// resource must have, or nil if it must be Current.
+func (w *WaitTask) statusCondition(id object.ObjMetadata) *StatusCondition {
+	if sc, found := w.StatusConditions[id.GroupKind]; found {
+		return &sc
+	}
+	if w.DefaultConditionExcluded[id] {
+		return nil
+	}
+	if sc, found := w.StatusConditions[schema.GroupKind{}]; found {
+		return &sc
+	}
+	return nil
+}
+
 // startAndComplete is invoked when the condition is already
 // met when the task should be started. In this case there is no
 // need to start a timer. So it just sets the cancelFunc and then
@@ -152,6 +268,9 @@ func (w *WaitTask) ClearTimeout() {
 type resourceWaitData struct {
 	identifier object.ObjMetadata
 	generation int64
+	// condition is the status condition the resource must have to
+	// meet the AllCurrent condition. Nil means it must be Current.
+	condition *StatusCondition
 }
 
 // Condition is a type that defines the types of conditions
@@ -167,6 +286,11 @@ const (
 	// has reached the NotFound status, i.e. they are all deleted
 	// from the cluster.
 	AllNotFound Condition = "AllNotFound"
+
+	// AllObserved Condition means the status of all the provided
+	// resources has been computed after they were applied, whatever
+	// the status is.
+	AllObserved Condition = "AllObserved"
 )
 
 // Meets returns true if the provided status meets the condition and
@@ -177,6 +301,8 @@ func (c Condition) Meets(s status.Status) bool {
 		return s == status.CurrentStatus
 	case AllNotFound:
 		return s == status.NotFoundStatus
+	case AllObserved:
+		return s != status.UnknownStatus
 	default:
 		return false
 	}
@@ -8,7 +8,9 @@ import (
 	"testing"
 	"time"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
@@ -53,6 +55,25 @@ func TestWaitTask_TimeoutCancelled(t *testing.T) {
 	}
 }
 
+func TestWaitTask_NoTimeout(t *testing.T) {
+	task := NewWaitTask([]object.ObjMetadata{}, AllCurrent, -1*time.Second)
+
+	eventChannel := make(chan event.Event)
+	taskContext := NewTaskContext(eventChannel)
+	defer close(eventChannel)
+
+	task.Start(taskContext)
+	timer := time.NewTimer(2 * time.Second)
+
+	select {
+	case res := <-taskContext.TaskChannel():
+		t.Errorf("didn't expect the task to complete, but got %v", res.Err)
+	case <-timer.C:
+		task.ClearTimeout()
+		return
+	}
+}
+
 func TestWaitTask_SingleTaskResult(t *testing.T) {
 	task := NewWaitTask([]object.ObjMetadata{}, AllCurrent, 2*time.Second)
 
@@ -83,3 +104,108 @@ func TestWaitTask_SingleTaskResult(t *testing.T) {
 		return
 	}
 }
+
+func TestWaitTask_StatusCondition(t *testing.T) {
+	deployment := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Name:      "foo",
+		Namespace: "default",
+	}
+	inventoryObj := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+		Name:      "inventory",
+		Namespace: "default",
+	}
+	ready := StatusCondition{Type: "Ready", Status: "True"}
+	available := StatusCondition{Type: "Available", Status: "True"}
+
+	testCases := map[string]struct {
+		conditions map[schema.GroupKind]StatusCondition
+		excluded   map[object.ObjMetadata]bool
+		id         object.ObjMetadata
+
+		expected *StatusCondition
+	}{
+		"no conditions": {
+			id: deployment,
+		},
+		"default condition": {
+			conditions: map[schema.GroupKind]StatusCondition{
+				{}: ready,
+			},
+			id:       deployment,
+			expected: &ready,
+		},
+		"kind condition takes precedence": {
+			conditions: map[schema.GroupKind]StatusCondition{
+				{}:                   ready,
+				deployment.GroupKind: available,
+			},
+			id:       deployment,
+			expected: &available,
+		},
+		"default condition doesn't apply to excluded resources": {
+			conditions: map[schema.GroupKind]StatusCondition{
+				{}: ready,
+			},
+			excluded: map[object.ObjMetadata]bool{
+				inventoryObj: true,
+			},
+			id: inventoryObj,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			task := NewWaitTask([]object.ObjMetadata{tc.id}, AllCurrent, -1)
+			task.StatusConditions = tc.conditions
+			task.DefaultConditionExcluded = tc.excluded
+
+			sc := task.statusCondition(tc.id)
+			if tc.expected == nil {
+				if sc != nil {
+					t.Errorf("expected no condition, but got %s", sc)
+				}
+				return
+			}
+			if sc == nil || *sc != *tc.expected {
+				t.Errorf("expected condition %s, but got %v", tc.expected, sc)
+			}
+		})
+	}
+}
+
+func TestWaitTask_PrunedOnly(t *testing.T) {
+	pruned := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Name:      "pruned",
+		Namespace: "default",
+	}
+	skipped := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Name:      "skipped",
+		Namespace: "default",
+	}
+	ids := []object.ObjMetadata{pruned, skipped}
+
+	eventChannel := make(chan event.Event)
+	taskContext := NewTaskContext(eventChannel)
+	defer close(eventChannel)
+	taskContext.ResourcePruned(pruned)
+
+	coll := newResourceStatusCollector(ids)
+	coll.resourceMap[pruned] = resourceStatus{
+		Identifier:    pruned,
+		CurrentStatus: status.NotFoundStatus,
+	}
+
+	task := NewWaitTask(ids, AllNotFound, -1)
+	if task.checkCondition(taskContext, coll) {
+		t.Errorf("expected the condition not to be met while waiting for the skipped resource")
+	}
+
+	task.PrunedOnly = true
+	if !task.checkCondition(taskContext, coll) {
+		t.Errorf("expected the condition to be met when only waiting for the pruned resource")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+)
+
+// TrackedResource is a resource tracked by an inventory object in
+// the cluster, with its live state.
+type TrackedResource struct {
+	*resource.Info
+
+	// Missing is true if the resource no longer exists in the
+	// cluster. The Object of the Info is nil in that case.
+	Missing bool
+}
+
+// ListTrackedResources returns the resources tracked by the inventory
+// objects in the cluster for the provided inventory object, with the
+// live state of each resource fetched from the cluster. The resources
+// are sorted by their identifier. The Applier must have been
+// initialized.
+func (a *Applier) ListTrackedResources(ctx context.Context, inventoryObject *resource.Info) ([]*TrackedResource, error) {
+	if a.invClient == nil {
+		return nil, fmt.Errorf("applier has not been initialized")
+	}
+	if inventoryObject == nil {
+		return nil, inventory.NoInventoryObjError{}
+	}
+	if u, _ := inventoryObject.Object.(*unstructured.Unstructured); !common.IsInventoryObject(u) {
+		return nil, inventory.NoInventoryObjError{}
+	}
+	// The inventory client caches the inventory objects it has read,
+	// so a new one is needed to read the current inventory.
+	invClient, err := a.InventoryClientFactoryFunc(a.factory)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := invClient.GetStoredObjRefs(inventoryObject)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].String() < objs[j].String()
+	})
+	dynamicClient, err := a.factory.DynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	mapper, err := a.factory.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := make([]*TrackedResource, 0, len(objs))
+	for _, obj := range objs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		mapping, err := mapper.RESTMapping(obj.GroupKind)
+		if err != nil {
+			return nil, err
+		}
+		tr := &TrackedResource{
+			Info: &resource.Info{
+				Mapping:   mapping,
+				Namespace: obj.Namespace,
+				Name:      obj.Name,
+			},
+		}
+		live, err := dynamicClient.Resource(mapping.Resource).Namespace(obj.Namespace).
+			Get(obj.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			tr.Missing = true
+		case err != nil:
+			return nil, err
+		default:
+			tr.Object = live
+		}
+		tracked = append(tracked, tr)
+	}
+	return tracked, nil
+}
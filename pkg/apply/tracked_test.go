@@ -0,0 +1,92 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+)
+
+func TestListTrackedResources(t *testing.T) {
+	testCases := map[string]struct {
+		clusterObjs     []runtime.Object
+		expectedMissing map[string]bool
+	}{
+		"all tracked resources exist": {
+			clusterObjs: []runtime.Object{obj1Info.Object, obj2Info.Object},
+			expectedMissing: map[string]bool{
+				"obj1": false,
+				"obj2": false,
+			},
+		},
+		"some tracked resources are missing": {
+			clusterObjs: []runtime.Object{obj2Info.Object},
+			expectedMissing: map[string]bool{
+				"obj1": true,
+				"obj2": false,
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+			tf.FakeDynamicClient = dynamicfake.NewSimpleDynamicClient(scheme.Scheme, tc.clusterObjs...)
+
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplier(tf, ioStreams)
+			inv := *inventoryObjInfo
+			inv.Object = inventoryObjInfo.Object.DeepCopyObject()
+			pastInventory, err := inventory.CreateInventoryObj(inventory.WrapInventoryObj(&inv),
+				[]*resource.Info{obj1Info, obj2Info})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			// The cached inventory client of the Applier is stale, so
+			// the inventory must be read with a new client.
+			applier.invClient = inventory.NewFakeInventoryClient([]*resource.Info{})
+			applier.InventoryClientFactoryFunc = func(cmdutil.Factory) (inventory.InventoryClient, error) {
+				return inventory.NewFakeInventoryClient([]*resource.Info{pastInventory}), nil
+			}
+
+			tracked, err := applier.ListTrackedResources(context.Background(), inventoryObjInfo)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			missing := make(map[string]bool)
+			for _, tr := range tracked {
+				missing[tr.Name] = tr.Missing
+				if tr.Missing {
+					assert.Nil(t, tr.Object)
+				} else {
+					assert.Equal(t, tr.Name, getName(tr.Object))
+				}
+			}
+			assert.Equal(t, tc.expectedMissing, missing)
+		})
+	}
+}
+
+func TestListTrackedResourcesRequiresInitialize(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+	defer tf.Cleanup()
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+
+	_, err := applier.ListTrackedResources(context.Background(), inventoryObjInfo)
+	assert.Error(t, err)
+}
@@ -0,0 +1,15 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+// version is the version of cli-utils. It is set at build time with
+// -ldflags "-X sigs.k8s.io/cli-utils/pkg/apply.version=<version>".
+var version = "unknown"
+
+// GetApplierVersion returns the version of cli-utils the Applier was
+// built from. It is recorded on the inventory object during every
+// apply, to help debugging compatibility issues between versions.
+func (a *Applier) GetApplierVersion() string {
+	return version
+}
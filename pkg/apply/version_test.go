@@ -0,0 +1,76 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/info"
+	"sigs.k8s.io/cli-utils/pkg/common"
+)
+
+func TestApplierVersionAnnotation(t *testing.T) {
+	defer func(v string) { version = v }(version)
+	version = "v0.0.0-test"
+
+	infos, err := createInfos([]resourceInfo{
+		resources["deployment"],
+		resources["inventoryObject"],
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+
+	invHandler := &inventoryObjectHandler{}
+	tf.FakeDynamicClient = newFakeDynamicClient(t, infos)
+	tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+		&nsHandler{},
+		invHandler,
+		&genericHandler{
+			resourceInfo: resources["deployment"],
+			namespace:    "default",
+		},
+	})
+
+	ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+	applier := NewApplier(tf, ioStreams)
+	assert.Equal(t, "v0.0.0-test", applier.GetApplierVersion())
+
+	cmd := &cobra.Command{}
+	_ = applier.SetFlags(cmd)
+	var notUsedFlag bool
+	// This flag needs to be set as there is a dependency on it.
+	cmd.Flags().BoolVar(&notUsedFlag, "dry-run", notUsedFlag, "")
+	cmdutil.AddValidateFlags(cmd)
+	cmdutil.AddServerSideApplyFlags(cmd)
+	if !assert.NoError(t, applier.Initialize(cmd)) {
+		return
+	}
+	applier.infoHelperFactoryFunc = func() info.InfoHelper {
+		return &fakeInfoHelper{
+			factory: tf,
+		}
+	}
+
+	err = applier.RunWithCallback(context.Background(), infos, Options{
+		NoPrune: true,
+	}, func(e event.Event) {})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if assert.NotNil(t, invHandler.inventoryObj, "expected the inventory object to be created") {
+		assert.Equal(t, "v0.0.0-test", invHandler.inventoryObj.Annotations[common.ApplierVersionAnnotation])
+	}
+}
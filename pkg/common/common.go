@@ -10,6 +10,10 @@ const (
 	// objects applied at the same time as the inventory object.
 	// This inventory object is used for pruning and deletion.
 	InventoryLabel = "cli-utils.sigs.k8s.io/inventory-id"
+	// InventoryHistoryLabel is the label stored on the ConfigMaps
+	// with snapshots of previous applies. The value is the inventory
+	// id of the inventory object the snapshots belong to.
+	InventoryHistoryLabel = "cli-utils.sigs.k8s.io/inventory-history"
 	// InventoryHash defines an annotation which stores the hash of
 	// the set of objects applied at the same time as the inventory
 	// object. This annotation is set on the inventory object at the
@@ -18,8 +22,98 @@ const (
 	// used as a suffix of the inventory object name. Example:
 	//   inventory-1e5824fb
 	InventoryHash = "cli-utils.sigs.k8s.io/inventory-hash"
+	// LastApplyTimeAnnotation defines an annotation which stores the
+	// time of the last successful apply in RFC 3339 format. It is set
+	// on the inventory object at the end of every apply.
+	LastApplyTimeAnnotation = "cli-utils.sigs.k8s.io/last-apply-time"
+	// InventoryFormatAnnotation defines an annotation which stores the
+	// format version of the data of the inventory object. Inventory
+	// objects without the annotation use the original v1 format.
+	InventoryFormatAnnotation = "cli-utils.sigs.k8s.io/inventory-format"
+	// ApplierVersionAnnotation defines an annotation which stores the
+	// version of cli-utils which last applied the inventory object.
+	ApplierVersionAnnotation = "cli-utils.sigs.k8s.io/applier-version"
+	// GenerateNameAnnotation defines an annotation which stores the
+	// generateName a resource's name was generated from by the
+	// applier. Only resources with the annotation are reused when
+	// the same generateName is applied again.
+	GenerateNameAnnotation = "cli-utils.sigs.k8s.io/generate-name"
 	// Resource lifecycle annotation key for "on-remove" operations.
 	OnRemoveAnnotation = "cli-utils.sigs.k8s.io/on-remove"
 	// Resource lifecycle annotation value to prevent deletion.
 	OnRemoveKeep = "keep"
+	// Resource lifecycle annotation value to prevent deletion, and
+	// release the object from the inventory.
+	OnRemoveDetach = "detach"
+	// HealthPolicyAnnotation defines an annotation which overrides
+	// the health policy for a single resource. The value is either
+	// HealthPolicyRequiredValue or HealthPolicyOptionalValue.
+	HealthPolicyAnnotation = "cli-utils.sigs.k8s.io/health-policy"
+	// Health policy annotation value for resources that must reach
+	// the desired status.
+	HealthPolicyRequiredValue = "required"
+	// Health policy annotation value for resources that are allowed
+	// to not reach the desired status.
+	HealthPolicyOptionalValue = "optional"
+)
+
+// OwnerRefPolicy defines whether owner references should be added
+// to the applied resources.
+type OwnerRefPolicy int
+
+const (
+	// OwnerRefNone means no owner references are added to the
+	// applied resources.
+	OwnerRefNone OwnerRefPolicy = iota
+	// OwnerRefInventory means an owner reference pointing to the
+	// inventory object is added to every applied resource in the
+	// same namespace as the inventory object, so deleting the
+	// inventory object cascades to the resources.
+	OwnerRefInventory
+)
+
+// HealthPolicy defines whether resources must reach the desired
+// status before waiting for them times out.
+type HealthPolicy int
+
+const (
+	// HealthPolicyRequired means waiting fails if the resources
+	// haven't reached the desired status when it times out.
+	HealthPolicyRequired HealthPolicy = iota
+	// HealthPolicyOptional means the resources are allowed to not
+	// have reached the desired status when waiting times out. A
+	// TimeoutEvent is emitted for them instead of failing.
+	HealthPolicyOptional
+)
+
+// LabelMutationPolicy defines how the applier may change the labels
+// of resources that already exist in the cluster.
+type LabelMutationPolicy int
+
+const (
+	// LabelMutationAllowAll means the labels are set to the labels in
+	// the manifests, so the apply can add, change and remove labels.
+	LabelMutationAllowAll LabelMutationPolicy = iota
+	// LabelMutationPreserveExisting means labels in the cluster are
+	// never removed, but labels from the manifests are added or
+	// changed.
+	LabelMutationPreserveExisting
+	// LabelMutationNone means the labels of resources in the cluster
+	// are not changed at all. Labels from the manifests are only used
+	// when creating resources.
+	LabelMutationNone
+)
+
+// ResourceStrategy defines how the applier updates resources that
+// already exist in the cluster.
+type ResourceStrategy int
+
+const (
+	// StrategyMerge means resources are updated with a three-way
+	// strategic merge patch, like kubectl apply does.
+	StrategyMerge ResourceStrategy = iota
+	// StrategyReplace means resources are replaced with the state in
+	// the manifests using an update (PUT). It is simpler, but drops any
+	// fields set in the cluster that are not in the manifests.
+	StrategyReplace
 )
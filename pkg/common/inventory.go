@@ -0,0 +1,41 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// IsInventoryObject returns true if the passed object has the
+// InventoryLabel with a non-empty value.
+func IsInventoryObject(obj *unstructured.Unstructured) bool {
+	return obj != nil && HasInventoryLabel(obj.GetLabels())
+}
+
+// MustBeInventoryObject returns an error describing why the passed
+// object is not an inventory object, or nil if it is one.
+func MustBeInventoryObject(obj *unstructured.Unstructured) error {
+	if obj == nil {
+		return fmt.Errorf("inventory object is nil")
+	}
+	labels := obj.GetLabels()
+	if _, found := labels[InventoryLabel]; !found {
+		return fmt.Errorf("%s %s/%s is not an inventory object: missing label %s",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), InventoryLabel)
+	}
+	if !HasInventoryLabel(labels) {
+		return fmt.Errorf("%s %s/%s is not an inventory object: label %s is empty",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(), InventoryLabel)
+	}
+	return nil
+}
+
+// HasInventoryLabel returns true if the labels contain the
+// InventoryLabel with a non-empty value.
+func HasInventoryLabel(labels map[string]string) bool {
+	return strings.TrimSpace(labels[InventoryLabel]) != ""
+}
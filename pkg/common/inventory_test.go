@@ -0,0 +1,57 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIsInventoryObject(t *testing.T) {
+	tests := map[string]struct {
+		labels      map[string]string
+		nilObject   bool
+		isInventory bool
+	}{
+		"nil object": {
+			nilObject:   true,
+			isInventory: false,
+		},
+		"no labels": {
+			isInventory: false,
+		},
+		"empty inventory label": {
+			labels:      map[string]string{InventoryLabel: " "},
+			isInventory: false,
+		},
+		"inventory label": {
+			labels:      map[string]string{InventoryLabel: "test-id"},
+			isInventory: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var obj *unstructured.Unstructured
+			if !tc.nilObject {
+				obj = &unstructured.Unstructured{}
+				obj.SetAPIVersion("v1")
+				obj.SetKind("ConfigMap")
+				obj.SetName("inventory")
+				obj.SetLabels(tc.labels)
+			}
+			if actual := IsInventoryObject(obj); actual != tc.isInventory {
+				t.Errorf("Expected IsInventoryObject (%t), got (%t)", tc.isInventory, actual)
+			}
+			err := MustBeInventoryObject(obj)
+			if tc.isInventory && err != nil {
+				t.Errorf("Unexpected error: %s", err)
+			}
+			if !tc.isInventory && err == nil {
+				t.Errorf("Expected error, but got none")
+			}
+		})
+	}
+}
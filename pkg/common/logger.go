@@ -0,0 +1,38 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import "github.com/go-logr/logr"
+
+// NullLogger is a logr.Logger that discards all messages. It is used
+// when no logger has been set.
+type NullLogger struct{}
+
+var _ logr.Logger = NullLogger{}
+
+// Info implements logr.InfoLogger.
+func (NullLogger) Info(_ string, _ ...interface{}) {}
+
+// Enabled implements logr.InfoLogger.
+func (NullLogger) Enabled() bool {
+	return false
+}
+
+// Error implements logr.Logger.
+func (NullLogger) Error(_ error, _ string, _ ...interface{}) {}
+
+// V implements logr.Logger.
+func (l NullLogger) V(_ int) logr.InfoLogger {
+	return l
+}
+
+// WithName implements logr.Logger.
+func (l NullLogger) WithName(_ string) logr.Logger {
+	return l
+}
+
+// WithValues implements logr.Logger.
+func (l NullLogger) WithValues(_ ...interface{}) logr.Logger {
+	return l
+}
@@ -42,11 +42,14 @@ func processPaths(paths []string) genericclioptions.FileNameFlags {
 // DemandOneDirectoryOrStdin processes "paths" to ensure the
 // single argument in the array is a directory. Returns FileNameFlags
 // populated with the directory (recursive flag set), or
-// the StdIn dash. An empty array gets treated as StdIn
-// (adding dash to the array). Returns an error if more than
-// one element in the array or the filepath is not a directory.
+// the StdIn dash. An empty array or a single dash argument gets
+// treated as StdIn (adding dash to the array). Returns an error if more
+// than one element in the array or the filepath is not a directory.
 func DemandOneDirectory(paths []string) (genericclioptions.FileNameFlags, error) {
 	result := genericclioptions.FileNameFlags{}
+	if len(paths) == 1 && paths[0] == stdinDash {
+		return processPaths([]string{}), nil
+	}
 	if len(paths) == 1 {
 		dirPath := paths[0]
 		if !isPathADirectory(dirPath) {
@@ -122,8 +125,7 @@ func FilterInputFile(in io.Reader, tmpDir string) error {
 			continue
 		}
 		// If object has inventory label, skip it.
-		labels := meta.Labels
-		if _, exists := labels[InventoryLabel]; !exists {
+		if !HasInventoryLabel(meta.Labels) {
 			filteredNodes = append(filteredNodes, node)
 		}
 	}
@@ -152,8 +154,7 @@ func expandDir(dir string) ([]string, error) {
 			continue
 		}
 		// If object has inventory label, skip it.
-		labels := meta.Labels
-		if _, exists := labels[InventoryLabel]; exists {
+		if HasInventoryLabel(meta.Labels) {
 			continue
 		}
 		path := meta.Annotations[kioutil.PathAnnotation]
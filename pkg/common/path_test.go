@@ -109,6 +109,12 @@ func TestProcessPaths(t *testing.T) {
 				Filenames: &[]string{"-"},
 			},
 		},
+		"dash means reading from StdIn": {
+			paths: []string{"-"},
+			expectedFileNameFlags: genericclioptions.FileNameFlags{
+				Filenames: &[]string{"-"},
+			},
+		},
 		"single file in slice is error; must be directory": {
 			paths: []string{podAFilePath},
 			expectedFileNameFlags: genericclioptions.FileNameFlags{
@@ -0,0 +1,253 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Introduces the ApplySetInventoryClient, which uses the parent object
+// of a KEP-2886 ApplySet as the inventory object instead of an inventory
+// object template provided in the manifests. The parent object is a
+// ConfigMap with a name derived from the ApplySet id, which is kept
+// across applies, and the applied objects are labeled as part of the
+// ApplySet, so other tools implementing the KEP recognize them.
+
+package inventory
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+const (
+	// ApplySetParentIDLabel is the label on the ApplySet parent object
+	// which holds the id of the ApplySet as defined by KEP-2886.
+	ApplySetParentIDLabel = "applyset.kubernetes.io/id"
+	// ApplySetPartOfLabel is the label on the members of an ApplySet
+	// which holds the id of the ApplySet they belong to.
+	ApplySetPartOfLabel = "applyset.kubernetes.io/part-of"
+	// ApplySetToolingAnnotation identifies the tool managing
+	// the ApplySet.
+	ApplySetToolingAnnotation = "applyset.kubernetes.io/tooling"
+	// ApplySetGKsAnnotation lists the group kinds of the members of
+	// the ApplySet, so they can be found without listing every type.
+	ApplySetGKsAnnotation = "applyset.kubernetes.io/contains-group-kinds"
+	// ApplySetAdditionalNamespacesAnnotation lists the namespaces of the
+	// members of the ApplySet other than the namespace of the parent.
+	ApplySetAdditionalNamespacesAnnotation = "applyset.kubernetes.io/additional-namespaces"
+	// applySetTooling is the value of the ApplySetToolingAnnotation.
+	applySetTooling = "kapply/v1"
+	// applySetNamePrefix is the prefix of the name of the
+	// inventory object for an ApplySet.
+	applySetNamePrefix = "applyset-"
+	// applySetIDFormat is the format of the ApplySet id of the parent
+	// object, the hash being computed by applySetParentID.
+	applySetIDFormat = "applyset-%s-v1"
+)
+
+// ApplySetName returns the name of the inventory object for the ApplySet
+// with the passed id. The name is derived from a hash of the id, and the
+// inventory object keeps it across applies.
+func ApplySetName(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if len(id) == 0 {
+		return "", fmt.Errorf("empty ApplySet id")
+	}
+	h, err := calcInventoryHash([]string{id})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%08x", applySetNamePrefix, h), nil
+}
+
+// applySetParentID returns the KEP-2886 id of the ApplySet with a
+// ConfigMap parent object with the passed name and namespace.
+func applySetParentID(name, namespace string) string {
+	// <name>.<namespace>.<kind>.<group>, ConfigMaps are in the core group.
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s.%s.ConfigMap.", name, namespace)))
+	return fmt.Sprintf(applySetIDFormat, base64.RawURLEncoding.EncodeToString(hash[:]))
+}
+
+// IsApplySetParent returns true if the passed inventory object is
+// the parent object of an ApplySet.
+func IsApplySetParent(obj runtime.Object) bool {
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	_, found := acc.GetLabels()[ApplySetParentIDLabel]
+	return found
+}
+
+// ApplySetInventoryClient is an implementation of the InventoryClient
+// interface for inventory objects managed through an ApplySet. The
+// previous inventory objects are looked up in the cluster the same way
+// as for the ClusterInventoryClient, but the current inventory object
+// must belong to the ApplySet of the client.
+type ApplySetInventoryClient struct {
+	*ClusterInventoryClient
+	id string
+}
+
+var _ InventoryClient = &ApplySetInventoryClient{}
+
+// NewApplySetInventoryClient returns an ApplySetInventoryClient for
+// the ApplySet with the passed id, or an error.
+func NewApplySetInventoryClient(factory util.Factory, id string) (*ApplySetInventoryClient, error) {
+	id = strings.TrimSpace(id)
+	if errs := validation.IsValidLabelValue(id); len(id) == 0 || len(errs) > 0 {
+		return nil, fmt.Errorf("invalid ApplySet id %q: %s", id, strings.Join(errs, ", "))
+	}
+	cic, err := NewInventoryClient(factory)
+	if err != nil {
+		return nil, err
+	}
+	return &ApplySetInventoryClient{
+		ClusterInventoryClient: cic,
+		id:                     id,
+	}, nil
+}
+
+// InventoryObject returns the inventory object template for the ApplySet
+// in the passed namespace, which is the ApplySet parent object. It is
+// used in place of an inventory object template in the manifests.
+func (ac *ApplySetInventoryClient) InventoryObject(namespace string) (*resource.Info, error) {
+	name, err := ApplySetName(ac.id)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(map[string]string{
+		common.InventoryLabel: ac.id,
+		ApplySetParentIDLabel: applySetParentID(name, namespace),
+	})
+	obj.SetAnnotations(map[string]string{
+		ApplySetToolingAnnotation: applySetTooling,
+	})
+	return &resource.Info{
+		Source:    "generated",
+		Name:      name,
+		Namespace: namespace,
+		Object:    obj,
+	}, nil
+}
+
+// SetMembers labels the passed objects as part of the ApplySet with the
+// parent object inv, and records their group kinds and namespaces in
+// the annotations of the parent. The group kinds of the objects
+// previously applied as part of the ApplySet are recorded as well,
+// since they might still have to be pruned.
+func (ac *ApplySetInventoryClient) SetMembers(inv *resource.Info, members []*resource.Info) error {
+	if err := ac.validateInventory(inv); err != nil {
+		return err
+	}
+	parent, err := meta.Accessor(inv.Object)
+	if err != nil {
+		return err
+	}
+	id, found := parent.GetLabels()[ApplySetParentIDLabel]
+	if !found {
+		return fmt.Errorf("inventory object %s is not an ApplySet parent", inv.Name)
+	}
+	pastObjs, err := ac.GetStoredObjRefs(inv)
+	if err != nil {
+		return err
+	}
+
+	gks := sets.NewString()
+	namespaces := sets.NewString()
+	for _, obj := range pastObjs {
+		gks.Insert(formatGroupKind(obj.GroupKind.Kind, obj.GroupKind.Group))
+	}
+	for _, member := range members {
+		acc, err := meta.Accessor(member.Object)
+		if err != nil {
+			return err
+		}
+		labels := acc.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[ApplySetPartOfLabel] = id
+		acc.SetLabels(labels)
+
+		gk := member.Object.GetObjectKind().GroupVersionKind().GroupKind()
+		gks.Insert(formatGroupKind(gk.Kind, gk.Group))
+		if member.Namespace != "" && member.Namespace != inv.Namespace {
+			namespaces.Insert(member.Namespace)
+		}
+	}
+
+	annotations := parent.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ApplySetGKsAnnotation] = strings.Join(gks.List(), ",")
+	if namespaces.Len() > 0 {
+		annotations[ApplySetAdditionalNamespacesAnnotation] = strings.Join(namespaces.List(), ",")
+	}
+	parent.SetAnnotations(annotations)
+	return nil
+}
+
+// formatGroupKind formats a group kind as <kind>.<group> the way it is
+// listed in the ApplySetGKsAnnotation.
+func formatGroupKind(kind, group string) string {
+	return fmt.Sprintf("%s.%s", kind, group)
+}
+
+// GetPreviousInventoryObjects returns the inventory objects for the
+// ApplySet stored in the cluster. Since the parent object keeps its
+// name, it is not removed from the returned objects like the current
+// inventory object is by the ClusterInventoryClient. The inventory
+// objects are only read once, before the parent object is updated by
+// the apply. Returns an error if the current inventory object doesn't
+// belong to the ApplySet.
+func (ac *ApplySetInventoryClient) GetPreviousInventoryObjects(currentInv *resource.Info) ([]*resource.Info, error) {
+	if err := ac.validateInventory(currentInv); err != nil {
+		return nil, err
+	}
+	current, err := infoToObjMetadata(currentInv)
+	if err != nil {
+		return nil, err
+	}
+	return ac.retrievePreviousInventoryObjects(current, ac.id)
+}
+
+// GetStoredObjRefs returns the set of objects previously applied as
+// part of the ApplySet as ObjMetadata, or an error if one occurred.
+func (ac *ApplySetInventoryClient) GetStoredObjRefs(currentInv *resource.Info) ([]object.ObjMetadata, error) {
+	prevInventories, err := ac.GetPreviousInventoryObjects(currentInv)
+	if err != nil {
+		return nil, err
+	}
+	return UnionPastObjs(prevInventories)
+}
+
+// validateInventory returns an error if the passed inventory object
+// doesn't belong to the ApplySet of the client.
+func (ac *ApplySetInventoryClient) validateInventory(inv *resource.Info) error {
+	if inv == nil {
+		return fmt.Errorf("inventory object is nil")
+	}
+	label, err := retrieveInventoryLabel(inv.Object)
+	if err != nil {
+		return err
+	}
+	if label != ac.id {
+		return fmt.Errorf("inventory object %s does not belong to ApplySet %s", inv.Name, ac.id)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/common"
+)
+
+func TestApplySetName(t *testing.T) {
+	name, err := ApplySetName("my-app")
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile("^applyset-[0-9a-f]{8}$"), name)
+
+	// The name must be stable for the same id.
+	again, err := ApplySetName("my-app")
+	assert.NoError(t, err)
+	assert.Equal(t, name, again)
+
+	other, err := ApplySetName("other-app")
+	assert.NoError(t, err)
+	assert.NotEqual(t, name, other)
+
+	_, err = ApplySetName("  ")
+	assert.Error(t, err)
+}
+
+func TestApplySetInventoryObject(t *testing.T) {
+	ac := &ApplySetInventoryClient{id: "my-app"}
+	inv, err := ac.InventoryObject(testNamespace)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	expectedName, err := ApplySetName("my-app")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedName, inv.Name)
+	assert.Equal(t, testNamespace, inv.Namespace)
+	assert.True(t, common.IsInventoryObject(inv.Object.(*unstructured.Unstructured)))
+
+	label, err := retrieveInventoryLabel(inv.Object)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-app", label)
+	assert.NoError(t, ac.validateInventory(inv))
+
+	// The inventory object is the parent object of the ApplySet.
+	u := inv.Object.(*unstructured.Unstructured)
+	assert.True(t, IsApplySetParent(u))
+	assert.Regexp(t, regexp.MustCompile("^applyset-[A-Za-z0-9_-]{43}-v1$"), u.GetLabels()[ApplySetParentIDLabel])
+	assert.Equal(t, applySetParentID(expectedName, testNamespace), u.GetLabels()[ApplySetParentIDLabel])
+	assert.NotEqual(t, applySetParentID(expectedName, "other"), u.GetLabels()[ApplySetParentIDLabel])
+	assert.Equal(t, "kapply/v1", u.GetAnnotations()[ApplySetToolingAnnotation])
+
+	// The parent object keeps its name when the objects are stored.
+	applied, err := CreateInventoryObj(WrapInventoryObj(inv), []*resource.Info{pod1Info})
+	if assert.NoError(t, err) {
+		assert.Equal(t, expectedName, applied.Name)
+		assert.Equal(t, expectedName, applied.Object.(*unstructured.Unstructured).GetName())
+	}
+}
+
+func TestApplySetSetMembers(t *testing.T) {
+	// The previous inventory object has the same name as the current
+	// one, and tracks an object which is no longer applied.
+	ac := &ApplySetInventoryClient{id: "my-app"}
+	inv, err := ac.InventoryObject(testNamespace)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetName("deployment")
+	deployment.SetNamespace(testNamespace)
+	past, err := CreateInventoryObj(WrapInventoryObj(inv), []*resource.Info{{
+		Namespace: testNamespace,
+		Name:      "deployment",
+		Object:    deployment,
+	}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ac.ClusterInventoryClient = &ClusterInventoryClient{
+		pastInventoryObjects:      []*resource.Info{past},
+		retrievedInventoryObjects: true,
+	}
+
+	prevInventories, err := ac.GetPreviousInventoryObjects(inv)
+	assert.NoError(t, err)
+	assert.Equal(t, []*resource.Info{past}, prevInventories)
+
+	members := []*resource.Info{
+		{Namespace: testNamespace, Name: pod1Name, Object: pod1.DeepCopy()},
+		{Namespace: "other", Name: pod2Name, Object: pod2.DeepCopy()},
+	}
+	members[0].Object.(*unstructured.Unstructured).SetLabels(map[string]string{"app": "my-app"})
+	err = ac.SetMembers(inv, members)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	u := inv.Object.(*unstructured.Unstructured)
+	id := u.GetLabels()[ApplySetParentIDLabel]
+	for _, member := range members {
+		labels := member.Object.(*unstructured.Unstructured).GetLabels()
+		assert.Equal(t, id, labels[ApplySetPartOfLabel])
+	}
+	assert.Equal(t, "my-app", members[0].Object.(*unstructured.Unstructured).GetLabels()["app"])
+	assert.Equal(t, "Deployment.apps,Pod.", u.GetAnnotations()[ApplySetGKsAnnotation])
+	assert.Equal(t, "other", u.GetAnnotations()[ApplySetAdditionalNamespacesAnnotation])
+
+	// Only inventory objects of the ApplySet can be the parent.
+	assert.Error(t, ac.SetMembers(copyInventoryInfo(), members))
+}
+
+func TestApplySetValidateInventory(t *testing.T) {
+	ac := &ApplySetInventoryClient{id: "my-app"}
+
+	// The test inventory object has a different inventory label.
+	inv := copyInventoryInfo()
+	assert.Error(t, ac.validateInventory(inv))
+
+	inv.Object.(*unstructured.Unstructured).SetLabels(map[string]string{
+		common.InventoryLabel: "my-app",
+	})
+	assert.NoError(t, ac.validateInventory(inv))
+
+	assert.Error(t, ac.validateInventory(nil))
+}
@@ -0,0 +1,182 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// CleanStaleInventoryEntries removes the references to objects that no
+// longer exist in the cluster from the inventory objects stored in the
+// cluster. The inventory objects are found through the invClient using
+// the current inventory object, and the ones with stale entries are
+// updated in the cluster. The passed inventory infos are updated in
+// place. Returns the references that were removed.
+func CleanStaleInventoryEntries(ctx context.Context, invClient InventoryClient, currentInv *resource.Info,
+	dynamicClient dynamic.Interface, mapper meta.RESTMapper) ([]object.ObjMetadata, error) {
+	inventories, err := invClient.GetPreviousInventoryObjects(currentInv)
+	if err != nil {
+		return nil, err
+	}
+	// Keep track of the objects that have already been looked up, since
+	// the same object can be referenced by several inventory objects.
+	exists := make(map[object.ObjMetadata]bool)
+	removed := []object.ObjMetadata{}
+	for _, inv := range inventories {
+		objs, err := WrapInventoryObj(inv).Load()
+		if err != nil {
+			return removed, err
+		}
+		kept := make([]object.ObjMetadata, 0, len(objs))
+		for _, obj := range objs {
+			found, checked := exists[obj]
+			if !checked {
+				if err := ctx.Err(); err != nil {
+					return removed, err
+				}
+				found, err = objectExists(dynamicClient, mapper, obj)
+				if err != nil {
+					return removed, err
+				}
+				exists[obj] = found
+				if !found {
+					klog.V(4).Infof("removing stale inventory entry: %s", obj)
+					removed = append(removed, obj)
+				}
+			}
+			if found {
+				kept = append(kept, obj)
+			}
+		}
+		if len(kept) == len(objs) {
+			continue
+		}
+		if err := updateInventoryObjs(dynamicClient, mapper, inv, kept); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// objectExists returns true if the object identified by obj exists
+// in the cluster.
+func objectExists(dynamicClient dynamic.Interface, mapper meta.RESTMapper, obj object.ObjMetadata) (bool, error) {
+	mapping, err := mapper.RESTMapping(obj.GroupKind)
+	if err != nil {
+		return false, err
+	}
+	_, err = dynamicClient.Resource(mapping.Resource).Namespace(obj.Namespace).
+		Get(obj.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// updateInventoryObjs stores objs in the inventory object inv, and
+// updates it in the cluster.
+func updateInventoryObjs(dynamicClient dynamic.Interface, mapper meta.RESTMapper,
+	inv *resource.Info, objs []object.ObjMetadata) error {
+	invObj, ok := inv.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("inventory object is not an Unstructured: %#v", inv.Object)
+	}
+	invCopy := invObj.DeepCopy()
+	if err := setInventoryObjs(invCopy, objs); err != nil {
+		return err
+	}
+	mapping, err := mapper.RESTMapping(invCopy.GroupVersionKind().GroupKind())
+	if err != nil {
+		return err
+	}
+	updated, err := dynamicClient.Resource(mapping.Resource).Namespace(invCopy.GetNamespace()).
+		Update(invCopy, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	inv.Object = updated
+	return nil
+}
+
+// RemoveInventoryEntries removes the references to the passed objects
+// from the inventory objects, and updates the inventory objects that
+// changed in the cluster. The passed inventory infos are updated in
+// place.
+func RemoveInventoryEntries(dynamicClient dynamic.Interface, mapper meta.RESTMapper,
+	inventories []*resource.Info, removed []object.ObjMetadata) error {
+	removedSet := make(map[object.ObjMetadata]bool, len(removed))
+	for _, obj := range removed {
+		removedSet[obj] = true
+	}
+	for _, inv := range inventories {
+		objs, err := WrapInventoryObj(inv).Load()
+		if err != nil {
+			return err
+		}
+		kept := make([]object.ObjMetadata, 0, len(objs))
+		for _, obj := range objs {
+			if !removedSet[obj] {
+				kept = append(kept, obj)
+			}
+		}
+		if len(kept) == len(objs) {
+			continue
+		}
+		if err := updateInventoryObjs(dynamicClient, mapper, inv, kept); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddInventoryEntries adds references to the passed objects to the
+// inventory object inv, and updates it in the cluster. The inventory
+// object is read from the cluster first, since it might have been
+// updated after inv was read. The passed inventory info is updated
+// in place.
+func AddInventoryEntries(dynamicClient dynamic.Interface, mapper meta.RESTMapper,
+	inv *resource.Info, added []object.ObjMetadata) error {
+	mapping, err := mapper.RESTMapping(inv.Object.GetObjectKind().GroupVersionKind().GroupKind())
+	if err != nil {
+		return err
+	}
+	current, err := dynamicClient.Resource(mapping.Resource).Namespace(inv.Namespace).
+		Get(inv.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	inv.Object = current
+	objs, err := WrapInventoryObj(inv).Load()
+	if err != nil {
+		return err
+	}
+	found := make(map[object.ObjMetadata]bool, len(objs))
+	for _, obj := range objs {
+		found[obj] = true
+	}
+	count := len(objs)
+	for _, obj := range added {
+		if !found[obj] {
+			found[obj] = true
+			objs = append(objs, obj)
+		}
+	}
+	if len(objs) == count {
+		return nil
+	}
+	return updateInventoryObjs(dynamicClient, mapper, inv, objs)
+}
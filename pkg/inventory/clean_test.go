@@ -0,0 +1,137 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestCleanStaleInventoryEntries(t *testing.T) {
+	tests := map[string]struct {
+		inventoried     []*resource.Info
+		clusterObjs     []runtime.Object
+		expectedRemoved []object.ObjMetadata
+		expectedKept    []object.ObjMetadata
+	}{
+		"No stale entries leaves inventory unchanged": {
+			inventoried:     []*resource.Info{pod1Info, pod2Info},
+			clusterObjs:     []runtime.Object{&pod1, &pod2},
+			expectedRemoved: []object.ObjMetadata{},
+			expectedKept:    []object.ObjMetadata{*pod1Metadata, *pod2Metadata},
+		},
+		"Objects missing from the cluster are removed": {
+			inventoried:     []*resource.Info{pod1Info, pod2Info, pod3Info},
+			clusterObjs:     []runtime.Object{&pod2},
+			expectedRemoved: []object.ObjMetadata{*pod1Metadata, *pod3Metadata},
+			expectedKept:    []object.ObjMetadata{*pod2Metadata},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inv := createInventoryInfo("", tc.inventoried...)
+			invClient := NewFakeInventoryClient([]*resource.Info{inv})
+			clusterObjs := append([]runtime.Object{inv.Object.DeepCopyObject()}, tc.clusterObjs...)
+			dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme, clusterObjs...)
+			mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+				scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+			removed, err := CleanStaleInventoryEntries(context.Background(), invClient,
+				copyInventoryInfo(), dynamicClient, mapper)
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			if len(tc.expectedRemoved) != len(removed) {
+				t.Fatalf("Expected (%d) removed objects, got (%d)\n", len(tc.expectedRemoved), len(removed))
+			}
+			for _, expectedObj := range tc.expectedRemoved {
+				if !objInArray(expectedObj, removed) {
+					t.Errorf("Expected removed object (%s), but not found\n", expectedObj)
+				}
+			}
+
+			// Verify the inventory object stored in the cluster.
+			stored, err := dynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).
+				Namespace(testNamespace).Get(inventoryObjName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			for _, info := range []*resource.Info{inv, {Object: stored}} {
+				kept, err := WrapInventoryObj(info).Load()
+				if err != nil {
+					t.Fatalf("Unexpected error received: %s\n", err)
+				}
+				if len(tc.expectedKept) != len(kept) {
+					t.Fatalf("Expected (%d) objects in inventory, got (%d)\n", len(tc.expectedKept), len(kept))
+				}
+				for _, expectedObj := range tc.expectedKept {
+					if !objInArray(expectedObj, kept) {
+						t.Errorf("Expected object (%s) in inventory, but not found\n", expectedObj)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCleanStaleInventoryEntriesCancelled(t *testing.T) {
+	inv := createInventoryInfo("", pod1Info)
+	invClient := NewFakeInventoryClient([]*resource.Info{inv})
+	dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme, inv.Object.DeepCopyObject())
+	mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := CleanStaleInventoryEntries(ctx, invClient, copyInventoryInfo(), dynamicClient, mapper)
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled error, got %v", err)
+	}
+}
+
+func TestAddInventoryEntries(t *testing.T) {
+	// The inventory object in the cluster was updated after the
+	// inventory info was read.
+	stored := createInventoryInfo("", pod1Info, pod2Info)
+	inv := createInventoryInfo("", pod1Info)
+	dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme, stored.Object.DeepCopyObject())
+	mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+	err := AddInventoryEntries(dynamicClient, mapper, inv, []object.ObjMetadata{*pod2Metadata, *pod3Metadata})
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+
+	updated, err := dynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).
+		Namespace(testNamespace).Get(inventoryObjName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	expected := []object.ObjMetadata{*pod1Metadata, *pod2Metadata, *pod3Metadata}
+	for _, info := range []*resource.Info{inv, {Object: updated}} {
+		objs, err := WrapInventoryObj(info).Load()
+		if err != nil {
+			t.Fatalf("Unexpected error received: %s\n", err)
+		}
+		if len(expected) != len(objs) {
+			t.Fatalf("Expected (%d) objects in inventory, got (%d)\n", len(expected), len(objs))
+		}
+		for _, expectedObj := range expected {
+			if !objInArray(expectedObj, objs) {
+				t.Errorf("Expected object (%s) in inventory, but not found\n", expectedObj)
+			}
+		}
+	}
+}
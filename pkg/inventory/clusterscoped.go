@@ -0,0 +1,64 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Introduces cluster-wide inventory objects, which are stored in the
+// kube-system namespace instead of the namespace of the applied
+// resources. Since the inventory object is named after the inventory
+// label, there is a single inventory object for the label in the
+// whole cluster. Applying and pruning with a cluster-wide inventory
+// object requires permissions to create, update and delete ConfigMaps
+// in the kube-system namespace, which is usually only granted to
+// cluster-admin.
+
+package inventory
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/common"
+)
+
+const (
+	// ClusterInventoryNamespace is the namespace where cluster-wide
+	// inventory objects are stored.
+	ClusterInventoryNamespace = "kube-system"
+	// clusterInventoryNamePrefix is the prefix of the name of
+	// cluster-wide inventory objects.
+	clusterInventoryNamePrefix = "cli-utils-"
+)
+
+// SetClusterScoped turns the passed inventory object template into
+// a cluster-wide inventory object by moving it to the
+// ClusterInventoryNamespace and naming it after its inventory label.
+// The inventory object is modified in place. Returns an error if the
+// passed object is not an inventory object, or if the inventory label
+// can't be used in the name of the inventory object.
+func SetClusterScoped(info *resource.Info) error {
+	if info == nil {
+		return fmt.Errorf("not an inventory object")
+	}
+	obj, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("inventory object is not an Unstructured: %#v", info.Object)
+	}
+	if err := common.MustBeInventoryObject(obj); err != nil {
+		return err
+	}
+	label, err := retrieveInventoryLabel(obj)
+	if err != nil {
+		return err
+	}
+	name := clusterInventoryNamePrefix + label
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid cluster-wide inventory object name %q: %s", name, strings.Join(errs, ", "))
+	}
+	obj.SetName(name)
+	obj.SetNamespace(ClusterInventoryNamespace)
+	info.Name = name
+	info.Namespace = ClusterInventoryNamespace
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+var clusterRole = unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata": map[string]interface{}{
+			"name": "test-cluster-role",
+		},
+	},
+}
+
+var clusterRoleInfo = &resource.Info{
+	Name:   "test-cluster-role",
+	Object: &clusterRole,
+}
+
+func TestSetClusterScoped(t *testing.T) {
+	tests := map[string]struct {
+		info         *resource.Info
+		expectedName string
+		isError      bool
+	}{
+		"Nil info is an error": {
+			info:    nil,
+			isError: true,
+		},
+		"Non-inventory object is an error": {
+			info:    pod1Info,
+			isError: true,
+		},
+		"Inventory label not valid in name is an error": {
+			info:    inventoryInfoWithLabel("Not_A_Valid_Name"),
+			isError: true,
+		},
+		"Inventory object is moved to kube-system": {
+			info:         copyInventoryInfo(),
+			expectedName: "cli-utils-" + testInventoryLabel,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := SetClusterScoped(tc.info)
+			if tc.isError {
+				if err == nil {
+					t.Fatalf("Expected error but received none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			obj := tc.info.Object.(*unstructured.Unstructured)
+			if tc.expectedName != tc.info.Name || tc.expectedName != obj.GetName() {
+				t.Errorf("Expected name (%s), got (%s) and (%s)", tc.expectedName, tc.info.Name, obj.GetName())
+			}
+			if ClusterInventoryNamespace != tc.info.Namespace || ClusterInventoryNamespace != obj.GetNamespace() {
+				t.Errorf("Expected namespace (%s), got (%s) and (%s)",
+					ClusterInventoryNamespace, tc.info.Namespace, obj.GetNamespace())
+			}
+		})
+	}
+}
+
+func TestClusterScopedInventoryTracksAllResources(t *testing.T) {
+	inv := copyInventoryInfo()
+	if err := SetClusterScoped(inv); err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	if err := AddObjsToInventory([]*resource.Info{inv, pod1Info, clusterRoleInfo}); err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+
+	objs, err := WrapInventoryObj(inv).Load()
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	clusterRoleMetadata := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+		Name:      "test-cluster-role",
+	}
+	expected := []object.ObjMetadata{*pod1Metadata, clusterRoleMetadata}
+	if len(expected) != len(objs) {
+		t.Fatalf("Expected (%d) objects, got (%d)\n", len(expected), len(objs))
+	}
+	for _, expectedObj := range expected {
+		if !objInArray(expectedObj, objs) {
+			t.Errorf("Expected object (%s), but not found\n", expectedObj)
+		}
+	}
+}
+
+func inventoryInfoWithLabel(label string) *resource.Info {
+	info := copyInventoryInfo()
+	info.Object.(*unstructured.Unstructured).SetLabels(map[string]string{
+		common.InventoryLabel: label,
+	})
+	return info
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// InventoryFormat is the format version of the data stored in an
+// inventory object.
+type InventoryFormat string
+
+const (
+	// InventoryFormatV1 stores the object metadata of every inventory
+	// entry as a data key with an empty value.
+	InventoryFormatV1 InventoryFormat = "v1"
+	// InventoryFormatV2 additionally stores the resource version of
+	// every entry, as of the last apply, as a JSON-encoded data value.
+	InventoryFormatV2 InventoryFormat = "v2"
+)
+
+// ParseInventoryFormat returns the InventoryFormat for the passed
+// string, or an error if it is not a known format.
+func ParseInventoryFormat(s string) (InventoryFormat, error) {
+	switch f := InventoryFormat(s); f {
+	case InventoryFormatV1, InventoryFormatV2:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown inventory format %q, must be one of %q or %q",
+			s, InventoryFormatV1, InventoryFormatV2)
+	}
+}
+
+// GetInventoryFormat detects the format of the passed inventory object
+// from its format annotation. Inventory objects without the annotation
+// are in the v1 format.
+func GetInventoryFormat(inv *unstructured.Unstructured) InventoryFormat {
+	if f, ok := inv.GetAnnotations()[common.InventoryFormatAnnotation]; ok {
+		return InventoryFormat(f)
+	}
+	return InventoryFormatV1
+}
+
+// SetInventoryFormat sets the format annotation on the passed inventory
+// object. The annotation is removed for the v1 format, so the object
+// stays readable by older versions.
+func SetInventoryFormat(inv *unstructured.Unstructured, format InventoryFormat) {
+	annotations := inv.GetAnnotations()
+	if format == InventoryFormatV1 {
+		if _, ok := annotations[common.InventoryFormatAnnotation]; !ok {
+			return
+		}
+		delete(annotations, common.InventoryFormatAnnotation)
+	} else {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[common.InventoryFormatAnnotation] = string(format)
+	}
+	inv.SetAnnotations(annotations)
+}
+
+// inventoryEntry is the JSON-encoded value of an entry in the data of
+// an inventory object in the v2 format.
+type inventoryEntry struct {
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// GetResourceVersions returns the resource versions stored in the
+// passed inventory object, keyed by the object metadata of the entries.
+// It returns an empty map for inventory objects in the v1 format.
+// Entries with a malformed or empty value are skipped.
+func GetResourceVersions(inv *unstructured.Unstructured) map[object.ObjMetadata]string {
+	versions := make(map[object.ObjMetadata]string)
+	if GetInventoryFormat(inv) != InventoryFormatV2 {
+		return versions
+	}
+	data, _, _ := unstructured.NestedStringMap(inv.Object, "data")
+	for key, value := range data {
+		obj, err := object.ParseObjMetadata(key)
+		if err != nil {
+			continue
+		}
+		var entry inventoryEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil || entry.ResourceVersion == "" {
+			continue
+		}
+		versions[*obj] = entry.ResourceVersion
+	}
+	return versions
+}
+
+// setResourceVersions stores the passed resource versions as the values
+// of the matching entries of the inventory object. Entries without a
+// resource version get an empty value, like in the v1 format.
+func setResourceVersions(inv *unstructured.Unstructured, versions map[object.ObjMetadata]string) error {
+	data, _, err := unstructured.NestedStringMap(inv.Object, "data")
+	if err != nil {
+		return err
+	}
+	for key := range data {
+		data[key] = ""
+		obj, err := object.ParseObjMetadata(key)
+		if err != nil {
+			continue
+		}
+		rv, found := versions[*obj]
+		if !found || rv == "" {
+			continue
+		}
+		value, err := json.Marshal(inventoryEntry{ResourceVersion: rv})
+		if err != nil {
+			return err
+		}
+		data[key] = string(value)
+	}
+	return unstructured.SetNestedStringMap(inv.Object, data, "data")
+}
+
+// RecordResourceVersions stores the resource versions of the passed
+// applied objects in the inventory object inv in the cluster, if it
+// is in the v2 format. The resource versions are taken from the
+// objects as returned by the API server when they were applied.
+func RecordResourceVersions(dynamicClient dynamic.Interface, mapper meta.RESTMapper,
+	inv *resource.Info, applied []*resource.Info) error {
+	versions := make(map[object.ObjMetadata]string, len(applied))
+	for _, info := range applied {
+		acc, err := meta.Accessor(info.Object)
+		if err != nil {
+			return err
+		}
+		versions[object.InfoToObjMeta(info)] = acc.GetResourceVersion()
+	}
+	return updateInventoryObj(dynamicClient, mapper, inv, func(obj *unstructured.Unstructured) error {
+		if GetInventoryFormat(obj) != InventoryFormatV2 {
+			return nil
+		}
+		return setResourceVersions(obj, versions)
+	})
+}
+
+// MigrateFormat converts the inventory object inv in the cluster to
+// the passed format. When migrating to the v2 format the current
+// resource versions of the inventory entries are looked up in the
+// cluster. Entries for objects that no longer exist are kept without
+// a resource version.
+func MigrateFormat(dynamicClient dynamic.Interface, mapper meta.RESTMapper,
+	inv *resource.Info, to InventoryFormat) error {
+	objs, err := WrapInventoryObj(inv).Load()
+	if err != nil {
+		return err
+	}
+	versions := make(map[object.ObjMetadata]string, len(objs))
+	if to == InventoryFormatV2 {
+		for _, obj := range objs {
+			rv, err := liveResourceVersion(dynamicClient, mapper, obj)
+			if err != nil {
+				return err
+			}
+			versions[obj] = rv
+		}
+	}
+	return updateInventoryObj(dynamicClient, mapper, inv, func(obj *unstructured.Unstructured) error {
+		SetInventoryFormat(obj, to)
+		return setResourceVersions(obj, versions)
+	})
+}
+
+// liveResourceVersion returns the resource version of the object
+// identified by obj in the cluster, or an empty string if it does
+// not exist.
+func liveResourceVersion(dynamicClient dynamic.Interface, mapper meta.RESTMapper,
+	obj object.ObjMetadata) (string, error) {
+	mapping, err := mapper.RESTMapping(obj.GroupKind)
+	if err != nil {
+		return "", err
+	}
+	u, err := dynamicClient.Resource(mapping.Resource).Namespace(obj.Namespace).
+		Get(obj.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return u.GetResourceVersion(), nil
+}
+
+// updateInventoryObj fetches the inventory object inv from the cluster,
+// applies mutate to it, and updates it in the cluster. The passed
+// inventory info is updated in place.
+func updateInventoryObj(dynamicClient dynamic.Interface, mapper meta.RESTMapper,
+	inv *resource.Info, mutate func(*unstructured.Unstructured) error) error {
+	mapping, err := mapper.RESTMapping(inv.Object.GetObjectKind().GroupVersionKind().GroupKind())
+	if err != nil {
+		return err
+	}
+	client := dynamicClient.Resource(mapping.Resource).Namespace(inv.Namespace)
+	obj, err := client.Get(inv.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := mutate(obj); err != nil {
+		return err
+	}
+	updated, err := client.Update(obj, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	inv.Object = updated
+	return nil
+}
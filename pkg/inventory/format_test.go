@@ -0,0 +1,184 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestParseInventoryFormat(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected InventoryFormat
+		isError  bool
+	}{
+		"v1 format": {
+			value:    "v1",
+			expected: InventoryFormatV1,
+		},
+		"v2 format": {
+			value:    "v2",
+			expected: InventoryFormatV2,
+		},
+		"Unknown format is an error": {
+			value:   "v3",
+			isError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ParseInventoryFormat(tc.value)
+			if tc.isError {
+				if err == nil {
+					t.Fatalf("Expected error, but received none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			if tc.expected != actual {
+				t.Errorf("Expected format (%s), got (%s)\n", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetResourceVersions(t *testing.T) {
+	tests := map[string]struct {
+		annotations map[string]string
+		data        map[string]string
+		expected    map[object.ObjMetadata]string
+	}{
+		"v1 format has no resource versions": {
+			data: map[string]string{
+				pod1Metadata.String(): "",
+			},
+			expected: map[object.ObjMetadata]string{},
+		},
+		"v2 format returns the stored resource versions": {
+			annotations: map[string]string{
+				common.InventoryFormatAnnotation: "v2",
+			},
+			data: map[string]string{
+				pod1Metadata.String(): `{"resourceVersion":"11"}`,
+				pod2Metadata.String(): `{"resourceVersion":"12"}`,
+			},
+			expected: map[object.ObjMetadata]string{
+				*pod1Metadata: "11",
+				*pod2Metadata: "12",
+			},
+		},
+		"v2 format skips empty and malformed values": {
+			annotations: map[string]string{
+				common.InventoryFormatAnnotation: "v2",
+			},
+			data: map[string]string{
+				pod1Metadata.String(): `{"resourceVersion":"11"}`,
+				pod2Metadata.String(): "",
+				pod3Metadata.String(): "not-json",
+				"malformed":           `{"resourceVersion":"13"}`,
+			},
+			expected: map[object.ObjMetadata]string{
+				*pod1Metadata: "11",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inv := inventoryObj.DeepCopy()
+			inv.SetAnnotations(tc.annotations)
+			if err := unstructured.SetNestedStringMap(inv.Object, tc.data, "data"); err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			actual := GetResourceVersions(inv)
+			if len(tc.expected) != len(actual) {
+				t.Fatalf("Expected (%d) resource versions, got (%d)\n", len(tc.expected), len(actual))
+			}
+			for id, rv := range tc.expected {
+				if actual[id] != rv {
+					t.Errorf("Expected resource version (%s) for (%s), got (%s)\n", rv, id, actual[id])
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateFormat(t *testing.T) {
+	livePod1 := pod1.DeepCopy()
+	livePod1.SetResourceVersion("11")
+	livePod2 := pod2.DeepCopy()
+	livePod2.SetResourceVersion("12")
+
+	inv := createInventoryInfo("", pod1Info, pod2Info, pod3Info)
+	dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme,
+		inv.Object.DeepCopyObject(), livePod1, livePod2)
+	mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+	configMaps := dynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).
+		Namespace(testNamespace)
+
+	if err := MigrateFormat(dynamicClient, mapper, inv, InventoryFormatV2); err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	stored, err := configMaps.Get(inventoryObjName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	if format := GetInventoryFormat(stored); format != InventoryFormatV2 {
+		t.Errorf("Expected format (%s), got (%s)\n", InventoryFormatV2, format)
+	}
+	// The entry for pod3, which doesn't exist, is kept without a
+	// resource version.
+	objs, err := WrapInventoryObj(&resource.Info{Object: stored}).Load()
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	if len(objs) != 3 {
+		t.Errorf("Expected (3) objects in inventory, got (%d)\n", len(objs))
+	}
+	versions := GetResourceVersions(stored)
+	expected := map[object.ObjMetadata]string{
+		*pod1Metadata: "11",
+		*pod2Metadata: "12",
+	}
+	if len(expected) != len(versions) {
+		t.Fatalf("Expected (%d) resource versions, got (%d)\n", len(expected), len(versions))
+	}
+	for id, rv := range expected {
+		if versions[id] != rv {
+			t.Errorf("Expected resource version (%s) for (%s), got (%s)\n", rv, id, versions[id])
+		}
+	}
+
+	// Migrating back removes the annotation and the resource versions.
+	if err := MigrateFormat(dynamicClient, mapper, inv, InventoryFormatV1); err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	stored, err = configMaps.Get(inventoryObjName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	if _, found := stored.GetAnnotations()[common.InventoryFormatAnnotation]; found {
+		t.Errorf("Expected no format annotation after migrating to v1\n")
+	}
+	data, _, _ := unstructured.NestedStringMap(stored.Object, "data")
+	for key, value := range data {
+		if value != "" {
+			t.Errorf("Expected empty value for (%s), got (%s)\n", key, value)
+		}
+	}
+}
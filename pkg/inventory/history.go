@@ -0,0 +1,157 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog"
+	"sigs.k8s.io/cli-utils/pkg/common"
+	"sigs.k8s.io/yaml"
+)
+
+// MaxRevisionHistoryLimit is the maximum number of history objects
+// that can be kept for an inventory.
+const MaxRevisionHistoryLimit = 10
+
+const (
+	// historySequenceAnnotation stores the sequence number of a
+	// history object.
+	historySequenceAnnotation = "cli-utils.sigs.k8s.io/history-sequence"
+	// historyManifestsKey is the key in the data of a history object
+	// that contains the applied manifests as a YAML stream.
+	historyManifestsKey = "manifests"
+)
+
+// History is a snapshot of the resources applied together with an
+// inventory object, stored in a history ConfigMap.
+type History struct {
+	// Name is the name of the history ConfigMap.
+	Name string
+	// Sequence is the sequence number of the snapshot. Snapshots of
+	// later applies have higher sequence numbers.
+	Sequence int
+	// Manifests contains the applied resources as a YAML stream.
+	Manifests string
+}
+
+// SaveHistory stores a snapshot of the applied resources in a new
+// history ConfigMap named <inventory-name>-history-<sequence>, where
+// inv is the inventory object template. History objects beyond the
+// most recent limit ones are deleted. Returns an error if the limit
+// is not between 1 and MaxRevisionHistoryLimit.
+func SaveHistory(client dynamic.Interface, inv *resource.Info, infos []*resource.Info, limit int) error {
+	if limit < 1 || limit > MaxRevisionHistoryLimit {
+		return fmt.Errorf("revision history limit must be between 1 and %d, got %d",
+			MaxRevisionHistoryLimit, limit)
+	}
+	label, err := retrieveInventoryLabel(inv.Object)
+	if err != nil {
+		return err
+	}
+	history, err := ListHistory(client, inv)
+	if err != nil {
+		return err
+	}
+	manifests, err := historyManifests(infos)
+	if err != nil {
+		return err
+	}
+
+	sequence := 1
+	if len(history) > 0 {
+		sequence = history[len(history)-1].Sequence + 1
+	}
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetName(fmt.Sprintf("%s-history-%d", inv.Name, sequence))
+	cm.SetNamespace(inv.Namespace)
+	cm.SetLabels(map[string]string{
+		common.InventoryHistoryLabel: label,
+	})
+	cm.SetAnnotations(map[string]string{
+		historySequenceAnnotation: strconv.Itoa(sequence),
+	})
+	err = unstructured.SetNestedStringMap(cm.Object, map[string]string{
+		historyManifestsKey: manifests,
+	}, "data")
+	if err != nil {
+		return err
+	}
+	klog.V(4).Infof("creating inventory history object: %s/%s", cm.GetNamespace(), cm.GetName())
+	_, err = client.Resource(configMapGVR).Namespace(inv.Namespace).Create(cm, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	// The new history object is not part of the list, so it counts
+	// towards the limit.
+	for len(history) > limit-1 {
+		old := history[0]
+		history = history[1:]
+		klog.V(4).Infof("deleting inventory history object: %s/%s", inv.Namespace, old.Name)
+		err = client.Resource(configMapGVR).Namespace(inv.Namespace).Delete(old.Name, &metav1.DeleteOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListHistory returns the history snapshots for the inventory object
+// template inv, sorted by ascending sequence number.
+func ListHistory(client dynamic.Interface, inv *resource.Info) ([]History, error) {
+	label, err := retrieveInventoryLabel(inv.Object)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.Resource(configMapGVR).Namespace(inv.Namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", common.InventoryHistoryLabel, label),
+	})
+	if err != nil {
+		return nil, err
+	}
+	history := make([]History, 0, len(list.Items))
+	for _, item := range list.Items {
+		sequence, err := strconv.Atoi(item.GetAnnotations()[historySequenceAnnotation])
+		if err != nil {
+			return nil, fmt.Errorf("invalid sequence for inventory history object %s: %w", item.GetName(), err)
+		}
+		manifests, _, err := unstructured.NestedString(item.Object, "data", historyManifestsKey)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, History{
+			Name:      item.GetName(),
+			Sequence:  sequence,
+			Manifests: manifests,
+		})
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Sequence < history[j].Sequence
+	})
+	return history, nil
+}
+
+// historyManifests serializes the objects of the infos into a
+// YAML stream.
+func historyManifests(infos []*resource.Info) (string, error) {
+	docs := make([]string, 0, len(infos))
+	for _, info := range infos {
+		data, err := yaml.Marshal(info.Object)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(data))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/common"
+)
+
+func TestSaveHistory(t *testing.T) {
+	inv := &resource.Info{
+		Namespace: testNamespace,
+		Name:      inventoryObjName,
+		Object:    inventoryObj.DeepCopy(),
+	}
+	client := fake.NewSimpleDynamicClient(scheme.Scheme)
+
+	for i := 0; i < 5; i++ {
+		if err := SaveHistory(client, inv, []*resource.Info{pod1Info, pod2Info}, 3); err != nil {
+			t.Fatalf("unexpected error saving history: %s", err)
+		}
+	}
+
+	history, err := ListHistory(client, inv)
+	if err != nil {
+		t.Fatalf("unexpected error listing history: %s", err)
+	}
+	var names []string
+	for _, h := range history {
+		names = append(names, h.Name)
+	}
+	expected := []string{
+		inventoryObjName + "-history-3",
+		inventoryObjName + "-history-4",
+		inventoryObjName + "-history-5",
+	}
+	if !reflect.DeepEqual(expected, names) {
+		t.Errorf("expected history objects %v, got %v", expected, names)
+	}
+	for _, h := range history {
+		if !strings.Contains(h.Manifests, pod1Name) || !strings.Contains(h.Manifests, pod2Name) {
+			t.Errorf("expected history object %s to contain both pods, got:\n%s", h.Name, h.Manifests)
+		}
+	}
+
+	// History objects must not be picked up as inventory objects.
+	list, err := client.Resource(configMapGVR).Namespace(testNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error listing ConfigMaps: %s", err)
+	}
+	for i := range list.Items {
+		if common.IsInventoryObject(&list.Items[i]) {
+			t.Errorf("history object %s has the inventory label", list.Items[i].GetName())
+		}
+	}
+}
+
+func TestSaveHistoryInvalidLimit(t *testing.T) {
+	inv := &resource.Info{
+		Namespace: testNamespace,
+		Name:      inventoryObjName,
+		Object:    inventoryObj.DeepCopy(),
+	}
+	client := fake.NewSimpleDynamicClient(scheme.Scheme)
+	for _, limit := range []int{0, MaxRevisionHistoryLimit + 1} {
+		if err := SaveHistory(client, inv, []*resource.Info{pod1Info}, limit); err == nil {
+			t.Errorf("expected error for limit %d, got none", limit)
+		}
+	}
+}
@@ -36,46 +36,59 @@ type Inventory interface {
 	Store(objs []object.ObjMetadata) error
 	// GetObject returns the object that stores the inventory
 	GetObject() (*resource.Info, error)
+	// SetAnnotations merges the passed annotations with the existing
+	// annotations of the inventory object
+	SetAnnotations(annotations map[string]string)
+	// GetAnnotations returns the annotations of the inventory object
+	GetAnnotations() map[string]string
 }
 
 // retrieveInventoryLabel returns the string value of the InventoryLabel
 // for the passed object. Returns error if the passed object is nil or
 // is not a inventory object.
 func retrieveInventoryLabel(obj runtime.Object) (string, error) {
-	var inventoryLabel string
 	if obj == nil {
 		return "", fmt.Errorf("inventory object is nil")
 	}
-	accessor, err := meta.Accessor(obj)
-	if err != nil {
-		return "", err
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return "", fmt.Errorf("inventory object is not an Unstructured: %#v", obj)
 	}
-	labels := accessor.GetLabels()
-	inventoryLabel, exists := labels[common.InventoryLabel]
-	if !exists {
-		return "", fmt.Errorf("inventory label does not exist for inventory object: %s", common.InventoryLabel)
+	if err := common.MustBeInventoryObject(u); err != nil {
+		return "", err
 	}
-	return strings.TrimSpace(inventoryLabel), nil
+	return strings.TrimSpace(u.GetLabels()[common.InventoryLabel]), nil
 }
 
-// IsInventoryObject returns true if the passed object has the
-// inventory label.
-func IsInventoryObject(obj runtime.Object) bool {
-	if obj == nil {
-		return false
+// NewInventoryObjectTemplate returns an inventory object template
+// with the passed name and namespace. The name is also used as the
+// value of the inventory label, so it must be a valid label value.
+// It is used in place of an inventory object template in the manifests.
+func NewInventoryObjectTemplate(name, namespace string) *resource.Info {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(map[string]string{
+		common.InventoryLabel: name,
+	})
+	return &resource.Info{
+		Source:    "generated",
+		Name:      name,
+		Namespace: namespace,
+		Object:    obj,
 	}
-	inventoryLabel, err := retrieveInventoryLabel(obj)
-	if err == nil && len(inventoryLabel) > 0 {
-		return true
-	}
-	return false
 }
 
 // FindInventoryObj returns the "Inventory" object (ConfigMap with
 // inventory label) if it exists, and a boolean describing if it was found.
 func FindInventoryObj(infos []*resource.Info) (*resource.Info, bool) {
 	for _, info := range infos {
-		if info != nil && IsInventoryObject(info.Object) {
+		if info == nil {
+			continue
+		}
+		if u, _ := info.Object.(*unstructured.Unstructured); common.IsInventoryObject(u) {
 			return info, true
 		}
 	}
@@ -93,16 +106,12 @@ func AddObjsToInventory(infos []*resource.Info) error {
 	objMap := map[string]string{}
 	for _, info := range infos {
 		obj := info.Object
-		if IsInventoryObject(obj) {
+		if u, _ := obj.(*unstructured.Unstructured); common.IsInventoryObject(u) {
 			// If we have more than one inventory object--error.
 			if inventoryObj != nil {
 				return fmt.Errorf("error--applying more than one inventory object")
 			}
-			var ok bool
-			inventoryObj, ok = obj.(*unstructured.Unstructured)
-			if !ok {
-				return fmt.Errorf("inventory object is not an Unstructured: %#v", inventoryObj)
-			}
+			inventoryObj = u
 			inventoryInfo = info
 		} else {
 			if obj == nil {
@@ -191,11 +200,11 @@ func buildObjMetadata(infos []*resource.Info) ([]object.ObjMetadata, error) {
 
 // RetrieveObjsFromInventoryObj returns a slice of pointers to the
 // object metadata. This function finds the inventory object, then
-// parses the stored resource metadata into ObjMetadata structs. Returns
-// an error if there is a problem parsing the data into ObjMetadata
-// structs, or if the inventory object is not in Unstructured format; nil
-// otherwise. If a inventory object does not exist, or it does not have a
-// "data" map, then returns an empty slice and no error.
+// parses the stored resource metadata into ObjMetadata structs, skipping
+// malformed entries. Returns an error if the inventory object is not in
+// Unstructured format; nil otherwise. If a inventory object does not
+// exist, or it does not have a "data" map, then returns an empty slice
+// and no error.
 func RetrieveObjsFromInventory(infos []*resource.Info) ([]*object.ObjMetadata, error) {
 	objs := []*object.ObjMetadata{}
 	inventoryInfo, exists := FindInventoryObj(infos)
@@ -211,12 +220,10 @@ func RetrieveObjsFromInventory(infos []*resource.Info) ([]*object.ObjMetadata, e
 			return objs, err
 		}
 		if exists {
-			for objStr := range objMap {
-				obj, err := object.ParseObjMetadata(objStr)
-				if err != nil {
-					return objs, err
-				}
-				objs = append(objs, obj)
+			parsed, errs := ParseInventoryData(objMap)
+			logParseErrors(inventoryInfo, errs)
+			for i := range parsed {
+				objs = append(objs, &parsed[i])
 			}
 		}
 	}
@@ -247,6 +254,69 @@ func UnionPastObjs(pastInvs []*resource.Info) ([]object.ObjMetadata, error) {
 	return pastObjs, nil
 }
 
+// MergeInventories returns a copy of the inventory object a where the
+// stored object metadata is the union of the objects referenced by
+// the inventory objects a and b. Objects referenced by both inventory
+// objects are only stored once. The metadata (name, namespace, labels)
+// of the returned inventory object is taken from a. Returns an error
+// if either of the passed objects is not an inventory object.
+func MergeInventories(a, b *resource.Info) (*resource.Info, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("nil inventory object")
+	}
+	for _, inv := range []*resource.Info{a, b} {
+		if u, _ := inv.Object.(*unstructured.Unstructured); !common.IsInventoryObject(u) {
+			return nil, fmt.Errorf("%s/%s is not an inventory object", inv.Namespace, inv.Name)
+		}
+	}
+	objs, err := UnionPastObjs([]*resource.Info{a, b})
+	if err != nil {
+		return nil, err
+	}
+	invCopy := a.Object.(*unstructured.Unstructured).DeepCopy()
+	if err := setInventoryObjs(invCopy, objs); err != nil {
+		return nil, err
+	}
+
+	return &resource.Info{
+		Client:    a.Client,
+		Mapping:   a.Mapping,
+		Source:    a.Source,
+		Name:      a.Name,
+		Namespace: a.Namespace,
+		Object:    invCopy,
+	}, nil
+}
+
+// setInventoryObjs replaces the object metadata stored in the passed
+// inventory object with objMetas, and updates the inventory hash
+// annotation to match.
+func setInventoryObjs(inv *unstructured.Unstructured, objMetas []object.ObjMetadata) error {
+	objMap := buildObjMap(objMetas)
+	// Keep the values of the remaining entries, since they hold the
+	// resource versions in the v2 inventory format.
+	data, _, _ := unstructured.NestedStringMap(inv.Object, "data")
+	for key := range objMap {
+		objMap[key] = data[key]
+	}
+	invHashStr, err := computeInventoryHash(objMap)
+	if err != nil {
+		return err
+	}
+	err = unstructured.SetNestedStringMap(inv.UnstructuredContent(),
+		objMap, "data")
+	if err != nil {
+		return err
+	}
+	annotations := inv.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[common.InventoryHash] = invHashStr
+	inv.SetAnnotations(annotations)
+	return nil
+}
+
 // ClearInventoryObj finds the inventory object in the list of objects,
 // and sets an empty inventory. Returns error if the inventory object
 // is not Unstructured, the inventory object does not exist, or if
@@ -256,13 +326,8 @@ func ClearInventoryObj(infos []*resource.Info) error {
 	// Initially, find the inventory object ConfigMap (in Unstructured format).
 	var inventoryObj *unstructured.Unstructured
 	for _, info := range infos {
-		obj := info.Object
-		if IsInventoryObject(obj) {
-			var ok bool
-			inventoryObj, ok = obj.(*unstructured.Unstructured)
-			if !ok {
-				return fmt.Errorf("inventory object is not an Unstructured: %#v", inventoryObj)
-			}
+		if u, _ := info.Object.(*unstructured.Unstructured); common.IsInventoryObject(u) {
+			inventoryObj = u
 			break
 		}
 	}
@@ -204,36 +204,6 @@ func TestRetrieveInventoryLabel(t *testing.T) {
 	}
 }
 
-func TestIsInventoryObject(t *testing.T) {
-	tests := []struct {
-		obj         runtime.Object
-		isInventory bool
-	}{
-		{
-			obj:         nil,
-			isInventory: false,
-		},
-		{
-			obj:         &inventoryObj,
-			isInventory: true,
-		},
-		{
-			obj:         &pod2,
-			isInventory: false,
-		},
-	}
-
-	for _, test := range tests {
-		inventory := IsInventoryObject(test.obj)
-		if test.isInventory && !inventory {
-			t.Errorf("Inventory object not identified: %#v", test.obj)
-		}
-		if !test.isInventory && inventory {
-			t.Errorf("Non-inventory object identifed as inventory obj: %#v", test.obj)
-		}
-	}
-}
-
 func TestCreateInventoryObject(t *testing.T) {
 	testCases := map[string]struct {
 		inventoryObjectTemplate *resource.Info
@@ -605,6 +575,80 @@ func TestAddRetrieveObjsToFromInventory(t *testing.T) {
 	}
 }
 
+func TestInventoryConfigMapRoundTrip(t *testing.T) {
+	objs := []object.ObjMetadata{*pod1Metadata, *pod2Metadata, *pod3Metadata}
+	inv := WrapInventoryObj(copyInventoryInfo())
+	if err := inv.Store(objs); err != nil {
+		t.Fatalf("Unexpected error storing inventory: %s\n", err)
+	}
+	stored, err := inv.GetObject()
+	if err != nil {
+		t.Fatalf("Unexpected error getting inventory object: %s\n", err)
+	}
+	loaded, err := WrapInventoryObj(stored).Load()
+	if err != nil {
+		t.Fatalf("Unexpected error loading inventory: %s\n", err)
+	}
+	if len(objs) != len(loaded) {
+		t.Fatalf("Expected (%d) objects, got (%d)\n", len(objs), len(loaded))
+	}
+	for _, obj := range objs {
+		if !objInArray(obj, loaded) {
+			t.Errorf("Expected object (%s) in loaded inventory, but not found\n", obj)
+		}
+	}
+}
+
+func TestInventoryConfigMapAnnotations(t *testing.T) {
+	template := copyInventoryInfo()
+	template.Object.(*unstructured.Unstructured).SetAnnotations(map[string]string{
+		"existing":  "template",
+		"overwrite": "template",
+	})
+	inv := WrapInventoryObj(template)
+	inv.SetAnnotations(map[string]string{
+		"overwrite":          "set",
+		"last-apply-commit":  "abc123",
+		common.InventoryHash: "ignored",
+	})
+	inv.SetAnnotations(map[string]string{"applied-by": "user@example.com"})
+
+	expected := map[string]string{
+		"existing":          "template",
+		"overwrite":         "set",
+		"last-apply-commit": "abc123",
+		"applied-by":        "user@example.com",
+	}
+	actual := inv.GetAnnotations()
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Errorf("Expected annotation %s=%s, got (%s)\n", k, v, actual[k])
+		}
+	}
+
+	if err := inv.Store([]object.ObjMetadata{*pod1Metadata}); err != nil {
+		t.Fatalf("Unexpected error storing inventory: %s\n", err)
+	}
+	stored, err := inv.GetObject()
+	if err != nil {
+		t.Fatalf("Unexpected error getting inventory object: %s\n", err)
+	}
+	annotations := stored.Object.(*unstructured.Unstructured).GetAnnotations()
+	for k, v := range expected {
+		if annotations[k] != v {
+			t.Errorf("Expected annotation %s=%s on inventory object, got (%s)\n", k, v, annotations[k])
+		}
+	}
+	// The inventory hash can not be overwritten.
+	if annotations[common.InventoryHash] == "ignored" {
+		t.Errorf("Expected the inventory hash annotation to be computed\n")
+	}
+	// The template is left unchanged.
+	if _, found := template.Object.(*unstructured.Unstructured).GetAnnotations()["applied-by"]; found {
+		t.Errorf("Expected the inventory template to be unchanged\n")
+	}
+}
+
 func TestUnionPastObjs(t *testing.T) {
 	tests := map[string]struct {
 		prevInventories []*resource.Info
@@ -662,6 +706,75 @@ func TestUnionPastObjs(t *testing.T) {
 	}
 }
 
+func TestMergeInventories(t *testing.T) {
+	tests := map[string]struct {
+		a        *resource.Info
+		b        *resource.Info
+		expected []object.ObjMetadata
+		isError  bool
+	}{
+		"Empty inventory objects = empty inventory": {
+			a:        createInventoryInfo("test-1"),
+			b:        createInventoryInfo("test-2"),
+			expected: []object.ObjMetadata{},
+		},
+		"Empty second inventory object returns first inventory": {
+			a:        createInventoryInfo("test-1", pod1Info, pod2Info),
+			b:        createInventoryInfo("test-2"),
+			expected: []object.ObjMetadata{*pod1Metadata, *pod2Metadata},
+		},
+		"Disjoint inventory objects returns union": {
+			a:        createInventoryInfo("test-1", pod1Info),
+			b:        createInventoryInfo("test-2", pod2Info, pod3Info),
+			expected: []object.ObjMetadata{*pod1Metadata, *pod2Metadata, *pod3Metadata},
+		},
+		"Overlapping inventory objects only stores objects once": {
+			a:        createInventoryInfo("test-1", pod1Info, pod2Info),
+			b:        createInventoryInfo("test-2", pod2Info, pod3Info),
+			expected: []object.ObjMetadata{*pod1Metadata, *pod2Metadata, *pod3Metadata},
+		},
+		"Non-inventory object is an error": {
+			a:       createInventoryInfo("test-1", pod1Info),
+			b:       pod2Info,
+			isError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			merged, err := MergeInventories(tc.a, tc.b)
+			if tc.isError {
+				if err == nil {
+					t.Fatalf("Expected error but received none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			if tc.a.Name != merged.Name || tc.a.Namespace != merged.Namespace {
+				t.Errorf("Expected merged inventory (%s/%s), got (%s/%s)",
+					tc.a.Namespace, tc.a.Name, merged.Namespace, merged.Name)
+			}
+			if len(retrieveInventoryHash(merged)) == 0 {
+				t.Errorf("Merged inventory object missing inventory hash")
+			}
+			actual, err := WrapInventoryObj(merged).Load()
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			if len(tc.expected) != len(actual) {
+				t.Fatalf("Expected (%d) objects, got (%d)\n", len(tc.expected), len(actual))
+			}
+			for _, expectedObj := range tc.expected {
+				if !objInArray(expectedObj, actual) {
+					t.Fatalf("Expected object (%s), but not found\n", expectedObj)
+				}
+			}
+		})
+	}
+}
+
 func TestAddSuffixToName(t *testing.T) {
 	tests := []struct {
 		info     *resource.Info
@@ -15,6 +15,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
@@ -30,12 +31,14 @@ func WrapInventoryObj(info *resource.Info) Inventory {
 // the Inventory interface. This wrapper loads and stores the
 // object metadata (inventory) to and from the wrapped ConfigMap.
 type InventoryConfigMap struct {
-	inv      *resource.Info
-	objMetas []object.ObjMetadata
+	inv         *resource.Info
+	objMetas    []object.ObjMetadata
+	annotations map[string]string
 }
 
 // Load is an Inventory interface function returning the set of
-// object metadata from the wrapped ConfigMap, or an error.
+// object metadata from the wrapped ConfigMap, or an error. Malformed
+// entries are logged and skipped.
 func (icm *InventoryConfigMap) Load() ([]object.ObjMetadata, error) {
 	objs := []object.ObjMetadata{}
 	inventoryObj, ok := icm.inv.Object.(*unstructured.Unstructured)
@@ -49,17 +52,22 @@ func (icm *InventoryConfigMap) Load() ([]object.ObjMetadata, error) {
 		return objs, err
 	}
 	if exists {
-		for objStr := range objMap {
-			obj, err := object.ParseObjMetadata(objStr)
-			if err != nil {
-				return objs, err
-			}
-			objs = append(objs, *obj)
-		}
+		var errs []error
+		objs, errs = ParseInventoryData(objMap)
+		logParseErrors(icm.inv, errs)
 	}
 	return objs, nil
 }
 
+// logParseErrors logs the errors from parsing the data of the
+// inventory object.
+func logParseErrors(inv *resource.Info, errs []error) {
+	for _, err := range errs {
+		klog.Warningf("skipping malformed entry in inventory object %s/%s: %s",
+			inv.Namespace, inv.Name, err)
+	}
+}
+
 // Store is an Inventory interface function implemented to store
 // the object metadata in the wrapped ConfigMap. Actual storing
 // happens in "GetObject".
@@ -68,6 +76,35 @@ func (icm *InventoryConfigMap) Store(objMetas []object.ObjMetadata) error {
 	return nil
 }
 
+// SetAnnotations is an Inventory interface function which merges
+// the passed annotations with the annotations of the wrapped
+// ConfigMap. Like the object metadata, they are only added to the
+// object returned by "GetObject".
+func (icm *InventoryConfigMap) SetAnnotations(annotations map[string]string) {
+	if icm.annotations == nil {
+		icm.annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		icm.annotations[k] = v
+	}
+}
+
+// GetAnnotations is an Inventory interface function returning the
+// annotations of the wrapped ConfigMap merged with the annotations
+// set with "SetAnnotations".
+func (icm *InventoryConfigMap) GetAnnotations() map[string]string {
+	annotations := map[string]string{}
+	if u, ok := icm.inv.Object.(*unstructured.Unstructured); ok {
+		for k, v := range u.GetAnnotations() {
+			annotations[k] = v
+		}
+	}
+	for k, v := range icm.annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
 // GetObject returns the wrapped object (ConfigMap) as a resource.Info
 // or an error if one occurs.
 func (icm *InventoryConfigMap) GetObject() (*resource.Info, error) {
@@ -89,7 +126,12 @@ func (icm *InventoryConfigMap) GetObject() (*resource.Info, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The parent object of an ApplySet keeps its name, so other tools
+	// can find it by the ApplySet id.
 	name := fmt.Sprintf("%s-%s", icm.inv.Name, invHashStr)
+	if IsApplySetParent(iot) {
+		name = icm.inv.Name
+	}
 
 	// Create the inventory object by copying the template.
 	invCopy := iot.DeepCopy()
@@ -104,6 +146,9 @@ func (icm *InventoryConfigMap) GetObject() (*resource.Info, error) {
 	if annotations == nil {
 		annotations = map[string]string{}
 	}
+	for k, v := range icm.annotations {
+		annotations[k] = v
+	}
 	annotations[common.InventoryHash] = invHashStr
 	invCopy.SetAnnotations(annotations)
 
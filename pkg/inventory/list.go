@@ -0,0 +1,42 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/cli-utils/pkg/common"
+)
+
+// configMapGVR is the resource of the ConfigMaps used as
+// inventory objects.
+var configMapGVR = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "configmaps",
+}
+
+// ListAllInventories returns all inventory objects in the provided
+// namespace, which are the ConfigMaps that have the inventory label
+// set. If the namespace is empty, the inventory objects in all
+// namespaces are returned.
+func ListAllInventories(ctx context.Context, client dynamic.Interface, namespace string) ([]*unstructured.Unstructured, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	list, err := client.Resource(configMapGVR).Namespace(namespace).List(metav1.ListOptions{
+		LabelSelector: common.InventoryLabel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	inventories := make([]*unstructured.Unstructured, 0, len(list.Items))
+	for i := range list.Items {
+		inventories = append(inventories, &list.Items[i])
+	}
+	return inventories, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/common"
+)
+
+func TestListAllInventories(t *testing.T) {
+	objs := []runtime.Object{
+		configMap("inv-1", testNamespace, true),
+		configMap("inv-2", "other-namespace", true),
+		configMap("not-an-inventory", testNamespace, false),
+	}
+
+	tests := map[string]struct {
+		namespace string
+		expected  []string
+	}{
+		"Inventories in all namespaces": {
+			namespace: "",
+			expected:  []string{"inv-1", "inv-2"},
+		},
+		"Inventories in a single namespace": {
+			namespace: testNamespace,
+			expected:  []string{"inv-1"},
+		},
+		"No inventories in namespace": {
+			namespace: "empty-namespace",
+			expected:  []string{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClient(scheme.Scheme, objs...)
+			inventories, err := ListAllInventories(context.Background(), client, tc.namespace)
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			actual := []string{}
+			for _, inv := range inventories {
+				actual = append(actual, inv.GetName())
+			}
+			sort.Strings(actual)
+			if len(tc.expected) != len(actual) {
+				t.Fatalf("Expected (%d) inventories, got (%d)\n", len(tc.expected), len(actual))
+			}
+			for i := range tc.expected {
+				if tc.expected[i] != actual[i] {
+					t.Errorf("Expected inventory (%s), got (%s)\n", tc.expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
+
+func configMap(name, namespace string, inventory bool) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+		},
+	}
+	if inventory {
+		obj.SetLabels(map[string]string{
+			common.InventoryLabel: testInventoryLabel,
+		})
+	}
+	return obj
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"sort"
+
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// ParseInventoryData parses the object metadata stored as the keys of
+// the data of an inventory object. Malformed entries, for example from
+// manual edits of the inventory object, are skipped instead of failing
+// the whole parse. It returns the valid entries and an error for each
+// malformed one, both in the sorted order of the keys.
+func ParseInventoryData(data map[string]string) ([]object.ObjMetadata, []error) {
+	objs := []object.ObjMetadata{}
+	var errs []error
+	keys := mapKeysToSlice(data)
+	sort.Strings(keys)
+	for _, objStr := range keys {
+		obj, err := object.ParseObjMetadata(objStr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		objs = append(objs, *obj)
+	}
+	return objs, errs
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestParseInventoryData(t *testing.T) {
+	data := map[string]string{}
+	for i := 0; i < 9; i++ {
+		obj := object.ObjMetadata{
+			Namespace: testNamespace,
+			Name:      fmt.Sprintf("pod-%d", i),
+			GroupKind: schema.GroupKind{Kind: "Pod"},
+		}
+		data[obj.String()] = ""
+	}
+	data["not-an-object"] = ""
+
+	objs, errs := ParseInventoryData(data)
+	if len(objs) != 9 {
+		t.Errorf("expected 9 objects, got %d", len(objs))
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(errs))
+	}
+	for i, obj := range objs {
+		if expected := fmt.Sprintf("pod-%d", i); obj.Name != expected {
+			t.Errorf("expected object %s, got %s", expected, obj.Name)
+		}
+	}
+}
+
+func TestInventoryConfigMapLoadSkipsMalformedEntries(t *testing.T) {
+	inv := copyInventoryInfo()
+	wrapped := WrapInventoryObj(inv)
+	if err := wrapped.Store([]object.ObjMetadata{object.InfoToObjMeta(pod1Info)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	inv, err := wrapped.GetObject()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	u := inv.Object.(*unstructured.Unstructured)
+	data := u.Object["data"].(map[string]interface{})
+	data["not-an-object"] = ""
+
+	objs, err := WrapInventoryObj(inv).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(objs) != 1 || objs[0].Name != pod1Name {
+		t.Errorf("expected only %s, got %v", pod1Name, objs)
+	}
+}
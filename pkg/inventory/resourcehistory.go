@@ -0,0 +1,216 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// resourceHistoryDataKey is the key in the binaryData of the
+	// inventory ConfigMap which stores the resource history as JSON.
+	// The history is not stored in the data, which only holds the
+	// inventory entries.
+	resourceHistoryDataKey = "resource-history.json"
+	// MaxResourceHistorySize is the maximum size in bytes of the
+	// resource history stored on an inventory object. ConfigMaps are
+	// limited to 1MiB, so this leaves room for the inventory itself.
+	// The oldest revisions are dropped to stay below it.
+	MaxResourceHistorySize = 512 * 1024
+)
+
+// ResourceRevision is a manifest of a resource as it was applied,
+// stored in the resource history of an inventory object.
+type ResourceRevision struct {
+	// Revision is the sequence number of the revision. Later applies
+	// of the resource have higher revision numbers.
+	Revision int `json:"revision"`
+	// Manifest is the applied resource as YAML, without the fields
+	// set by the server.
+	Manifest string `json:"manifest"`
+}
+
+// ResourceHistoryKey returns the key of the resource identified by id
+// in the resource history, in the format <group>/<kind>/<namespace>/<name>.
+// The group is empty for the core group and the namespace is empty
+// for cluster-scoped resources.
+func ResourceHistoryKey(id object.ObjMetadata) string {
+	return strings.Join([]string{id.GroupKind.Group, id.GroupKind.Kind, id.Namespace, id.Name}, "/")
+}
+
+// ParseResourceHistoryKey returns the ObjMetadata for a resource
+// history key, or an error if it is not in the format
+// <group>/<kind>/<namespace>/<name>.
+func ParseResourceHistoryKey(key string) (object.ObjMetadata, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 4 || parts[1] == "" || parts[3] == "" {
+		return object.ObjMetadata{}, fmt.Errorf("invalid resource %q, must be <group>/<kind>/<namespace>/<name>", key)
+	}
+	return object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: parts[0], Kind: parts[1]},
+		Namespace: parts[2],
+		Name:      parts[3],
+	}, nil
+}
+
+// GetResourceHistory returns the resource history stored in the passed
+// inventory object, keyed by ResourceHistoryKey. The revisions of each
+// resource are sorted by ascending revision number.
+func GetResourceHistory(inv *unstructured.Unstructured) (map[string][]ResourceRevision, error) {
+	history := make(map[string][]ResourceRevision)
+	encoded, found, err := unstructured.NestedString(inv.Object, "binaryData", resourceHistoryDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource history on inventory object %s: %w", inv.GetName(), err)
+	}
+	if !found {
+		return history, nil
+	}
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource history on inventory object %s: %w", inv.GetName(), err)
+	}
+	if err := json.Unmarshal(value, &history); err != nil {
+		return nil, fmt.Errorf("invalid resource history on inventory object %s: %w", inv.GetName(), err)
+	}
+	return history, nil
+}
+
+// RecordResourceHistory adds the passed applied resources as new
+// revisions to the resource history of the inventory object inv in
+// the cluster, keeping the most recent limit revisions of each. The
+// history of the previous inventory objects is carried over, since
+// the inventory object is replaced when the set of applied resources
+// changes. The history of resources that are no longer applied is
+// dropped, and so are the oldest revisions if the history would exceed
+// MaxResourceHistorySize. The history is stored in the binaryData of
+// the inventory ConfigMap. Returns an error if the limit is not between
+// 1 and MaxRevisionHistoryLimit, or if the latest revisions alone
+// exceed MaxResourceHistorySize.
+func RecordResourceHistory(dynamicClient dynamic.Interface, mapper meta.RESTMapper,
+	inv *resource.Info, previous, applied []*resource.Info, limit int) error {
+	if limit < 1 || limit > MaxRevisionHistoryLimit {
+		return fmt.Errorf("resource history limit must be between 1 and %d, got %d",
+			MaxRevisionHistoryLimit, limit)
+	}
+	manifests := make(map[string]string, len(applied))
+	for _, info := range applied {
+		manifest, err := resourceHistoryManifest(info)
+		if err != nil {
+			return err
+		}
+		manifests[ResourceHistoryKey(object.InfoToObjMeta(info))] = manifest
+	}
+	return updateInventoryObj(dynamicClient, mapper, inv, func(obj *unstructured.Unstructured) error {
+		history, err := GetResourceHistory(obj)
+		if err != nil {
+			return err
+		}
+		for _, p := range previous {
+			u, ok := p.Object.(*unstructured.Unstructured)
+			if !ok || u.GetName() == obj.GetName() {
+				continue
+			}
+			previousHistory, err := GetResourceHistory(u)
+			if err != nil {
+				return err
+			}
+			for key, revisions := range previousHistory {
+				if _, found := history[key]; !found {
+					history[key] = revisions
+				}
+			}
+		}
+
+		updated := make(map[string][]ResourceRevision, len(manifests))
+		for key, manifest := range manifests {
+			revisions := history[key]
+			revision := 1
+			if len(revisions) > 0 {
+				revision = revisions[len(revisions)-1].Revision + 1
+			}
+			revisions = append(revisions, ResourceRevision{
+				Revision: revision,
+				Manifest: manifest,
+			})
+			if len(revisions) > limit {
+				revisions = revisions[len(revisions)-limit:]
+			}
+			updated[key] = revisions
+		}
+		value, err := limitResourceHistory(updated)
+		if err != nil {
+			return fmt.Errorf("resource history of inventory object %s: %w", obj.GetName(), err)
+		}
+		return unstructured.SetNestedField(obj.Object, base64.StdEncoding.EncodeToString(value),
+			"binaryData", resourceHistoryDataKey)
+	})
+}
+
+// limitResourceHistory returns the history as JSON, after dropping the
+// oldest revisions until it fits in MaxResourceHistorySize. Revisions
+// are dropped from the resources with the most revisions first, and
+// the latest revision of every resource is always kept.
+func limitResourceHistory(history map[string][]ResourceRevision) ([]byte, error) {
+	keys := make([]string, 0, len(history))
+	for key := range history {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for {
+		value, err := json.Marshal(history)
+		if err != nil {
+			return nil, err
+		}
+		if len(value) <= MaxResourceHistorySize {
+			return value, nil
+		}
+		longest := ""
+		for _, key := range keys {
+			if len(history[key]) > 1 && (longest == "" || len(history[key]) > len(history[longest])) {
+				longest = key
+			}
+		}
+		if longest == "" {
+			return nil, fmt.Errorf("latest revisions need %d bytes, more than the maximum of %d",
+				len(value), MaxResourceHistorySize)
+		}
+		history[longest] = history[longest][1:]
+	}
+}
+
+// resourceHistoryManifest serializes the object of the info into YAML,
+// without the status and the metadata fields set by the server, so the
+// manifest can be applied again. The last applied configuration
+// annotation is removed too, since it duplicates the manifest.
+func resourceHistoryManifest(info *resource.Info) (string, error) {
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return "", fmt.Errorf("resource %s is not in Unstructured format", info.Name)
+	}
+	u = u.DeepCopy()
+	unstructured.RemoveNestedField(u.Object, "status")
+	for _, field := range []string{"resourceVersion", "uid", "selfLink", "generation",
+		"creationTimestamp", "managedFields"} {
+		unstructured.RemoveNestedField(u.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(u.Object, "metadata", "annotations", v1.LastAppliedConfigAnnotation)
+	data, err := yaml.Marshal(u.Object)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
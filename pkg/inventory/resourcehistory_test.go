@@ -0,0 +1,233 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestParseResourceHistoryKey(t *testing.T) {
+	tests := map[string]struct {
+		key      string
+		expected object.ObjMetadata
+		isError  bool
+	}{
+		"Namespaced resource": {
+			key: "apps/Deployment/default/app",
+			expected: object.ObjMetadata{
+				GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+				Namespace: "default",
+				Name:      "app",
+			},
+		},
+		"Core group resource": {
+			key: "/ConfigMap/default/config",
+			expected: object.ObjMetadata{
+				GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+				Namespace: "default",
+				Name:      "config",
+			},
+		},
+		"Cluster-scoped resource": {
+			key: "rbac.authorization.k8s.io/ClusterRole//admin",
+			expected: object.ObjMetadata{
+				GroupKind: schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+				Name:      "admin",
+			},
+		},
+		"Missing name is an error": {
+			key:     "apps/Deployment/default",
+			isError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := ParseResourceHistoryKey(tc.key)
+			if tc.isError {
+				if err == nil {
+					t.Fatalf("Expected error, but received none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			if tc.expected != actual {
+				t.Errorf("Expected (%v), got (%v)\n", tc.expected, actual)
+			}
+			if key := ResourceHistoryKey(actual); key != tc.key {
+				t.Errorf("Expected key (%s), got (%s)\n", tc.key, key)
+			}
+		})
+	}
+}
+
+func TestRecordResourceHistory(t *testing.T) {
+	inv := createInventoryInfo("", pod1Info)
+	dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme, inv.Object.DeepCopyObject())
+	mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+	for i := 0; i < 5; i++ {
+		// The applied object contains fields set by the server.
+		applied := pod1.DeepCopy()
+		applied.SetResourceVersion("1")
+		applied.SetLabels(map[string]string{"apply": string(rune('a' + i))})
+		err := RecordResourceHistory(dynamicClient, mapper, inv, nil,
+			[]*resource.Info{{Name: pod1Name, Namespace: testNamespace, Object: applied}}, 3)
+		if err != nil {
+			t.Fatalf("Unexpected error received: %s\n", err)
+		}
+	}
+
+	stored := getStoredInventory(t, dynamicClient, inventoryObjName)
+	history, err := GetResourceHistory(stored)
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	revisions := history[ResourceHistoryKey(*pod1Metadata)]
+	var numbers []int
+	for _, r := range revisions {
+		numbers = append(numbers, r.Revision)
+		if strings.Contains(r.Manifest, "resourceVersion") || strings.Contains(r.Manifest, "uid") {
+			t.Errorf("Expected manifest without server fields, got:\n%s", r.Manifest)
+		}
+	}
+	if !reflect.DeepEqual([]int{3, 4, 5}, numbers) {
+		t.Errorf("Expected revisions (%v), got (%v)\n", []int{3, 4, 5}, numbers)
+	}
+	if len(revisions) == 3 && !strings.Contains(revisions[2].Manifest, "apply: e") {
+		t.Errorf("Expected the last revision to be the last apply, got:\n%s", revisions[2].Manifest)
+	}
+	if _, found, _ := unstructured.NestedString(stored.Object, "binaryData", resourceHistoryDataKey); !found {
+		t.Errorf("Expected the resource history in the binaryData of the inventory object")
+	}
+}
+
+func TestRecordResourceHistorySize(t *testing.T) {
+	inv := createInventoryInfo("", pod1Info)
+	dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme, inv.Object.DeepCopyObject())
+	mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+	// Each revision is about a fifth of the maximum size, so only the
+	// most recent revisions fit.
+	for i := 0; i < 8; i++ {
+		applied := pod1.DeepCopy()
+		applied.SetAnnotations(map[string]string{
+			"large": strings.Repeat(string(rune('a'+i)), MaxResourceHistorySize/5),
+		})
+		err := RecordResourceHistory(dynamicClient, mapper, inv, nil,
+			[]*resource.Info{{Name: pod1Name, Namespace: testNamespace, Object: applied}}, MaxRevisionHistoryLimit)
+		if err != nil {
+			t.Fatalf("Unexpected error received: %s\n", err)
+		}
+	}
+	history, err := GetResourceHistory(getStoredInventory(t, dynamicClient, inventoryObjName))
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	revisions := history[ResourceHistoryKey(*pod1Metadata)]
+	if len(revisions) == 0 || len(revisions) >= 8 || revisions[len(revisions)-1].Revision != 8 {
+		t.Errorf("Expected the most recent revisions up to 8, got (%d) revisions", len(revisions))
+	}
+	value, err := json.Marshal(history)
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	if len(value) > MaxResourceHistorySize {
+		t.Errorf("Expected at most (%d) bytes of history, got (%d)", MaxResourceHistorySize, len(value))
+	}
+
+	// A single revision larger than the maximum can't be stored.
+	applied := pod1.DeepCopy()
+	applied.SetAnnotations(map[string]string{"large": strings.Repeat("z", MaxResourceHistorySize)})
+	err = RecordResourceHistory(dynamicClient, mapper, inv, nil,
+		[]*resource.Info{{Name: pod1Name, Namespace: testNamespace, Object: applied}}, MaxRevisionHistoryLimit)
+	if err == nil {
+		t.Errorf("Expected error for a revision larger than the maximum size, but received none")
+	}
+}
+
+func TestRecordResourceHistoryPreviousInventory(t *testing.T) {
+	previous := createInventoryInfo("previous-inventory", pod1Info, pod2Info)
+	previousObj := previous.Object.(*unstructured.Unstructured)
+	previousObj.SetName("previous-inventory")
+	value, err := json.Marshal(map[string][]ResourceRevision{
+		ResourceHistoryKey(*pod1Metadata): {{Revision: 7, Manifest: "pod1"}},
+		ResourceHistoryKey(*pod2Metadata): {{Revision: 2, Manifest: "pod2"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	err = unstructured.SetNestedField(previousObj.Object, base64.StdEncoding.EncodeToString(value),
+		"binaryData", resourceHistoryDataKey)
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+
+	inv := createInventoryInfo("", pod1Info)
+	dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme, inv.Object.DeepCopyObject())
+	mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+	err = RecordResourceHistory(dynamicClient, mapper, inv, []*resource.Info{previous},
+		[]*resource.Info{pod1Info}, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+
+	// The history of pod1 continues from the previous inventory, while
+	// pod2, which is no longer applied, is dropped.
+	history, err := GetResourceHistory(getStoredInventory(t, dynamicClient, inventoryObjName))
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("Expected history for (1) resource, got (%d)\n", len(history))
+	}
+	revisions := history[ResourceHistoryKey(*pod1Metadata)]
+	if len(revisions) != 2 || revisions[0].Revision != 7 || revisions[1].Revision != 8 {
+		t.Errorf("Expected revisions 7 and 8 of pod1, got (%v)\n", revisions)
+	}
+}
+
+func TestRecordResourceHistoryLimit(t *testing.T) {
+	inv := createInventoryInfo("", pod1Info)
+	dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme, inv.Object.DeepCopyObject())
+	mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+	for _, limit := range []int{0, MaxRevisionHistoryLimit + 1} {
+		err := RecordResourceHistory(dynamicClient, mapper, inv, nil, []*resource.Info{pod1Info}, limit)
+		if err == nil {
+			t.Errorf("Expected error for limit (%d), but received none", limit)
+		}
+	}
+}
+
+// getStoredInventory returns the inventory ConfigMap with the passed
+// name from the fake dynamic client.
+func getStoredInventory(t *testing.T, dynamicClient *fake.FakeDynamicClient, name string) *unstructured.Unstructured {
+	stored, err := dynamicClient.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).
+		Namespace(testNamespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	return stored
+}
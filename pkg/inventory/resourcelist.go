@@ -0,0 +1,56 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+)
+
+// InventoryToResourceList returns a resource.Info for each object
+// referenced by the inventory. Only the Namespace, Name and Mapping
+// fields of the returned infos are set; use
+// InventoryToResourceListWithObjects if the objects are needed.
+func InventoryToResourceList(inv Inventory, mapper meta.RESTMapper) ([]*resource.Info, error) {
+	objs, err := inv.Load()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*resource.Info, 0, len(objs))
+	for _, obj := range objs {
+		mapping, err := mapper.RESTMapping(obj.GroupKind)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, &resource.Info{
+			Namespace: obj.Namespace,
+			Name:      obj.Name,
+			Mapping:   mapping,
+		})
+	}
+	return infos, nil
+}
+
+// InventoryToResourceListWithObjects is like InventoryToResourceList,
+// but it also fetches each referenced object from the cluster and
+// sets it as the Object of the info. Returns an error if any of the
+// objects can not be fetched, including when it no longer exists.
+func InventoryToResourceListWithObjects(inv Inventory, mapper meta.RESTMapper,
+	dynamicClient dynamic.Interface) ([]*resource.Info, error) {
+	infos, err := InventoryToResourceList(inv, mapper)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		obj, err := dynamicClient.Resource(info.Mapping.Resource).Namespace(info.Namespace).
+			Get(info.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		info.Object = obj
+	}
+	return infos, nil
+}
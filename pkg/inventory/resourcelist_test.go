@@ -0,0 +1,81 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/kubectl/pkg/scheme"
+)
+
+func TestInventoryToResourceList(t *testing.T) {
+	tests := map[string]struct {
+		inventoried []*resource.Info
+	}{
+		"Empty inventory": {
+			inventoried: []*resource.Info{},
+		},
+		"Inventory with objects": {
+			inventoried: []*resource.Info{pod1Info, pod2Info, pod3Info},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inv := createInventoryInfo("", tc.inventoried...)
+			mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+				scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+			infos, err := InventoryToResourceList(WrapInventoryObj(inv), mapper)
+			if err != nil {
+				t.Fatalf("Unexpected error received: %s\n", err)
+			}
+			if len(tc.inventoried) != len(infos) {
+				t.Fatalf("Expected (%d) infos, got (%d)\n", len(tc.inventoried), len(infos))
+			}
+			for _, info := range infos {
+				if info.Mapping == nil {
+					t.Errorf("Expected mapping for info (%s/%s), got nil\n", info.Namespace, info.Name)
+				}
+				if info.Object != nil {
+					t.Errorf("Expected no object for info (%s/%s)\n", info.Namespace, info.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestInventoryToResourceListWithObjects(t *testing.T) {
+	inv := createInventoryInfo("", pod1Info, pod2Info)
+	dynamicClient := fake.NewSimpleDynamicClient(scheme.Scheme, &pod1, &pod2)
+	mapper := testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+		scheme.Scheme.PrioritizedVersionsAllGroups()...)
+
+	infos, err := InventoryToResourceListWithObjects(WrapInventoryObj(inv), mapper, dynamicClient)
+	if err != nil {
+		t.Fatalf("Unexpected error received: %s\n", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("Expected (2) infos, got (%d)\n", len(infos))
+	}
+	for _, info := range infos {
+		if info.Object == nil {
+			t.Fatalf("Expected object for info (%s/%s), got nil\n", info.Namespace, info.Name)
+		}
+		u := info.Object.(*unstructured.Unstructured)
+		if u.GetName() != info.Name {
+			t.Errorf("Expected object (%s), got (%s)\n", info.Name, u.GetName())
+		}
+	}
+
+	// Objects missing from the cluster are an error.
+	inv = createInventoryInfo("", pod1Info, pod3Info)
+	if _, err := InventoryToResourceListWithObjects(WrapInventoryObj(inv), mapper, dynamicClient); err == nil {
+		t.Errorf("Expected error for object missing from the cluster, got nil\n")
+	}
+}
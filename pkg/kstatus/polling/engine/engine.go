@@ -10,8 +10,10 @@ import (
 
 	"github.com/go-errors/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/cli-utils/pkg/object"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,6 +32,9 @@ type StatusReadersFactoryFunc func(reader ClusterReader, mapper meta.RESTMapper)
 type PollerEngine struct {
 	Reader client.Reader
 	Mapper meta.RESTMapper
+	// StatusScorer is used to compute the HealthScore of every
+	// polled resource. Can be nil, in which case no score is computed.
+	StatusScorer StatusScorer
 }
 
 // Poll will create a new statusPollerRunner that will poll all the resources provided and report their status
@@ -72,6 +77,7 @@ func (s *PollerEngine) Poll(ctx context.Context, identifiers []object.ObjMetadat
 			previousResourceStatuses: make(map[object.ObjMetadata]*event.ResourceStatus),
 			eventChannel:             eventChannel,
 			pollingInterval:          options.PollInterval,
+			statusScorer:             s.StatusScorer,
 		}
 		runner.Run()
 	}()
@@ -180,6 +186,9 @@ type statusPollerRunner struct {
 	// pollingInterval determines how often we should poll the cluster for
 	// the latest state of resources.
 	pollingInterval time.Duration
+
+	// statusScorer computes the health score for each resource. Can be nil.
+	statusScorer StatusScorer
 }
 
 // Run starts the polling loop of the statusReaders.
@@ -246,6 +255,12 @@ func (r *statusPollerRunner) pollStatusForAllResources() {
 		gk := id.GroupKind
 		statusReader := r.statusReaderForGroupKind(gk)
 		resourceStatus := statusReader.ReadStatus(r.ctx, id)
+		if r.statusScorer != nil && resourceStatus.Resource != nil {
+			resourceStatus.HealthScore = r.statusScorer.Score(resourceStatus.Resource)
+		}
+		if resourceStatus.Resource != nil {
+			resourceStatus.Conditions = resourceConditions(resourceStatus.Resource)
+		}
 		if r.isUpdatedResourceStatus(resourceStatus) {
 			r.previousResourceStatuses[id] = resourceStatus
 			r.eventChannel <- event.Event{
@@ -256,6 +271,17 @@ func (r *statusPollerRunner) pollStatusForAllResources() {
 	}
 }
 
+// resourceConditions returns the conditions from the status of the
+// resource, or nil if the resource doesn't have any or they can't
+// be parsed.
+func resourceConditions(u *unstructured.Unstructured) []status.BasicCondition {
+	obj, err := status.GetObjectWithConditions(u.Object)
+	if err != nil {
+		return nil
+	}
+	return obj.Status.Conditions
+}
+
 func (r *statusPollerRunner) statusReaderForGroupKind(gk schema.GroupKind) StatusReader {
 	statusReader, ok := r.statusReaders[gk]
 	if !ok {
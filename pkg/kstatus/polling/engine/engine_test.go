@@ -141,6 +141,81 @@ func TestStatusPollerRunner(t *testing.T) {
 	}
 }
 
+func TestStatusPollerRunnerConditions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	identifier := object.ObjMetadata{
+		GroupKind: schema.GroupKind{
+			Group: "apps",
+			Kind:  "Deployment",
+		},
+		Name:      "foo",
+		Namespace: "default",
+	}
+	deployment := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":   "Available",
+						"status": "True",
+						"reason": "MinimumReplicasAvailable",
+					},
+					map[string]interface{}{
+						"type":    "Progressing",
+						"status":  "True",
+						"reason":  "NewReplicaSetAvailable",
+						"message": "ReplicaSet has successfully progressed.",
+					},
+				},
+			},
+		},
+	}
+
+	engine := PollerEngine{
+		Mapper: fakemapper.NewFakeRESTMapper(appsv1.SchemeGroupVersion.WithKind("Deployment")),
+	}
+	options := Options{
+		PollInterval: 2 * time.Second,
+		ClusterReaderFactoryFunc: func(_ client.Reader, _ meta.RESTMapper, _ []object.ObjMetadata) (
+			ClusterReader, error) {
+			return testutil.NewNoopClusterReader(), nil
+		},
+		StatusReadersFactoryFunc: func(_ ClusterReader, _ meta.RESTMapper) (
+			statusReaders map[schema.GroupKind]StatusReader, defaultStatusReader StatusReader) {
+			return make(map[schema.GroupKind]StatusReader), &resourceStatusReader{resource: deployment}
+		},
+	}
+
+	eventChannel := engine.Poll(ctx, []object.ObjMetadata{identifier}, options)
+	e := <-eventChannel
+	cancel()
+	for range eventChannel {
+	}
+
+	assert.Equal(t, event.ResourceUpdateEvent, e.EventType)
+	assert.DeepEqual(t, []status.BasicCondition{
+		{
+			Type:   "Available",
+			Status: v1.ConditionTrue,
+			Reason: "MinimumReplicasAvailable",
+		},
+		{
+			Type:    "Progressing",
+			Status:  v1.ConditionTrue,
+			Reason:  "NewReplicaSetAvailable",
+			Message: "ReplicaSet has successfully progressed.",
+		},
+	}, e.Resource.Conditions)
+}
+
 func TestNewStatusPollerRunnerCancellation(t *testing.T) {
 	identifiers := make([]object.ObjMetadata, 0)
 
@@ -252,6 +327,24 @@ func (f *fakeStatusReader) ReadStatus(_ context.Context, identifier object.ObjMe
 	}
 }
 
+// resourceStatusReader is a StatusReader that always returns the
+// Current status for the resource.
+type resourceStatusReader struct {
+	resource *unstructured.Unstructured
+}
+
+func (r *resourceStatusReader) ReadStatus(_ context.Context, identifier object.ObjMetadata) *event.ResourceStatus {
+	return &event.ResourceStatus{
+		Identifier: identifier,
+		Status:     status.CurrentStatus,
+		Resource:   r.resource,
+	}
+}
+
+func (r *resourceStatusReader) ReadStatusForObject(_ context.Context, _ *unstructured.Unstructured) *event.ResourceStatus {
+	return nil
+}
+
 func (f *fakeStatusReader) ReadStatusForObject(_ context.Context, _ *unstructured.Unstructured) *event.ResourceStatus {
 	return nil
 }
@@ -28,3 +28,10 @@ type StatusReader interface {
 	// resource based on an identifier, it will use the passed-in resource.
 	ReadStatusForObject(ctx context.Context, object *unstructured.Unstructured) *event.ResourceStatus
 }
+
+// StatusScorer computes a numeric health score for a resource. The
+// score must be in the range [0.0, 1.0], where 1.0 means the resource
+// is fully healthy and 0.0 means it is completely unhealthy.
+type StatusScorer interface {
+	Score(obj *unstructured.Unstructured) float64
+}
@@ -65,6 +65,16 @@ type ResourceStatus struct {
 	// Message is text describing the status of the resource.
 	Message string
 
+	// Conditions contains the raw conditions from the
+	// .status.conditions field of the resource, in the order they
+	// appear in the resource.
+	Conditions []status.BasicCondition
+
+	// HealthScore is a number in the range [0.0, 1.0] describing the
+	// health of the resource, where 1.0 means fully healthy. It is
+	// only set if the StatusPoller has a StatusScorer.
+	HealthScore float64
+
 	// GeneratedResources is a slice of ResourceStatus that
 	// contains information and status for any generated resources
 	// of the current resource.
@@ -107,7 +117,8 @@ func (g ResourceStatuses) Swap(i, j int) {
 func ResourceStatusEqual(or1, or2 *ResourceStatus) bool {
 	if or1.Identifier != or2.Identifier ||
 		or1.Status != or2.Status ||
-		or1.Message != or2.Message {
+		or1.Message != or2.Message ||
+		or1.HealthScore != or2.HealthScore {
 		return false
 	}
 
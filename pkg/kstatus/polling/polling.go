@@ -8,6 +8,7 @@ import (
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/clusterreader"
@@ -23,8 +24,9 @@ import (
 func NewStatusPoller(reader client.Reader, mapper meta.RESTMapper) *StatusPoller {
 	return &StatusPoller{
 		engine: &engine.PollerEngine{
-			Reader: reader,
-			Mapper: mapper,
+			Reader:       reader,
+			Mapper:       mapper,
+			StatusScorer: &statusreaders.DefaultStatusScorer{},
 		},
 	}
 }
@@ -32,6 +34,22 @@ func NewStatusPoller(reader client.Reader, mapper meta.RESTMapper) *StatusPoller
 // StatusPoller provides functionality for polling a cluster for status for a set of resources.
 type StatusPoller struct {
 	engine *engine.PollerEngine
+
+	jsonPathExtractors map[schema.GroupKind]statusreaders.JSONPathExtractor
+}
+
+// SetStatusScorer replaces the StatusScorer used for computing the
+// HealthScore of the polled resources. Passing nil disables computing
+// the score.
+func (s *StatusPoller) SetStatusScorer(scorer engine.StatusScorer) {
+	s.engine.StatusScorer = scorer
+}
+
+// SetJSONPathExtractors sets JSONPath extractors used to compute the
+// status of resources of the given GroupKinds. Resources with an
+// extractor don't use the built-in status logic.
+func (s *StatusPoller) SetJSONPathExtractors(extractors map[schema.GroupKind]statusreaders.JSONPathExtractor) {
+	s.jsonPathExtractors = extractors
 }
 
 // Poll will create a new statusPollerRunner that will poll all the resources provided and report their status
@@ -41,10 +59,29 @@ func (s *StatusPoller) Poll(ctx context.Context, identifiers []object.ObjMetadat
 	return s.engine.Poll(ctx, identifiers, engine.Options{
 		PollInterval:             options.PollInterval,
 		ClusterReaderFactoryFunc: clusterReaderFactoryFunc(options.UseCache),
-		StatusReadersFactoryFunc: createStatusReaders,
+		StatusReadersFactoryFunc: s.statusReadersFactoryFunc(options.WaitForJobs),
 	})
 }
 
+// statusReadersFactoryFunc returns a factory function that creates the
+// default statusreaders, with the ones for GroupKinds that have a
+// JSONPath extractor replaced. If waitForJobs is true, Jobs are only
+// Current once they have completed.
+func (s *StatusPoller) statusReadersFactoryFunc(waitForJobs bool) engine.StatusReadersFactoryFunc {
+	extractors := s.jsonPathExtractors
+	return func(reader engine.ClusterReader, mapper meta.RESTMapper) (map[schema.GroupKind]engine.StatusReader, engine.StatusReader) {
+		statusReaders, defaultStatusReader := createStatusReaders(reader, mapper)
+		if waitForJobs {
+			statusReaders[batchv1.SchemeGroupVersion.WithKind("Job").GroupKind()] =
+				statusreaders.NewJobStatusReader(reader, mapper)
+		}
+		for gk, extractor := range extractors {
+			statusReaders[gk] = statusreaders.NewJSONPathStatusReader(reader, mapper, extractor)
+		}
+		return statusReaders, defaultStatusReader
+	}
+}
+
 // Options defines the levers available for tuning the behavior of the
 // StatusPoller.
 type Options struct {
@@ -56,6 +93,11 @@ type Options struct {
 	// all needed resources before each polling cycle. If this is set to false,
 	// then each resource will be fetched when needed with GET calls.
 	UseCache bool
+
+	// WaitForJobs defines whether Jobs should only have the Current
+	// status once they have completed successfully, instead of as
+	// soon as they have started.
+	WaitForJobs bool
 }
 
 // createStatusReaders creates an instance of all the statusreaders. This includes a set of statusreaders for
@@ -0,0 +1,25 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package statusreaders
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/engine"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+// NewJobStatusReader returns a StatusReader for Jobs that computes
+// the status with status.JobCompletionStatus, so Jobs are only Current
+// once they have completed successfully.
+func NewJobStatusReader(reader engine.ClusterReader, mapper meta.RESTMapper) engine.StatusReader {
+	return &baseStatusReader{
+		reader: reader,
+		mapper: mapper,
+		resourceStatusReader: &genericStatusReader{
+			reader:     reader,
+			mapper:     mapper,
+			statusFunc: status.JobCompletionStatus,
+		},
+	}
+}
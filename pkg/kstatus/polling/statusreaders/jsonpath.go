@@ -0,0 +1,132 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package statusreaders
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/engine"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+// JSONPathExtractor defines how to compute the status of a resource
+// from two fields, given as JSONPath expressions like
+// ".status.readyReplicas". The resource is Current when the fields
+// have the same value, and InProgress otherwise.
+type JSONPathExtractor struct {
+	// Current is the path of the field with the current state.
+	Current string
+	// Desired is the path of the field with the desired state.
+	Desired string
+}
+
+// ParseJSONPathExtractor parses an expression of the form
+// "<current>=<desired>", like ".status.readyReplicas=.spec.replicas".
+func ParseJSONPathExtractor(expr string) (JSONPathExtractor, error) {
+	parts := strings.SplitN(expr, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return JSONPathExtractor{}, fmt.Errorf("invalid status fields %q: expected <current>=<desired>", expr)
+	}
+	e := JSONPathExtractor{
+		Current: strings.TrimSpace(parts[0]),
+		Desired: strings.TrimSpace(parts[1]),
+	}
+	for _, path := range []string{e.Current, e.Desired} {
+		if _, err := parseJSONPath(path); err != nil {
+			return JSONPathExtractor{}, fmt.Errorf("invalid status fields %q: %w", expr, err)
+		}
+	}
+	return e, nil
+}
+
+// NewJSONPathStatusReader returns a StatusReader that computes the
+// status of resources with the provided extractor instead of the
+// status library.
+func NewJSONPathStatusReader(reader engine.ClusterReader, mapper meta.RESTMapper,
+	extractor JSONPathExtractor) engine.StatusReader {
+	return &baseStatusReader{
+		reader: reader,
+		mapper: mapper,
+		resourceStatusReader: &genericStatusReader{
+			reader:     reader,
+			mapper:     mapper,
+			statusFunc: extractor.compute,
+		},
+	}
+}
+
+// compute computes the status of the resource by comparing the values
+// of the current and desired fields.
+func (e JSONPathExtractor) compute(u *unstructured.Unstructured) (*status.Result, error) {
+	current, found, err := jsonPathValue(u, e.Current)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &status.Result{
+			Status:  status.InProgressStatus,
+			Message: fmt.Sprintf("%s not set", e.Current),
+		}, nil
+	}
+	desired, found, err := jsonPathValue(u, e.Desired)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return &status.Result{
+			Status:  status.InProgressStatus,
+			Message: fmt.Sprintf("%s not set", e.Desired),
+		}, nil
+	}
+	if current != desired {
+		return &status.Result{
+			Status:  status.InProgressStatus,
+			Message: fmt.Sprintf("%s: %s, %s: %s", e.Current, current, e.Desired, desired),
+		}, nil
+	}
+	return &status.Result{
+		Status:  status.CurrentStatus,
+		Message: fmt.Sprintf("%s: %s", e.Current, current),
+	}, nil
+}
+
+// jsonPathValue returns the value of the field at the path in the
+// resource, formatted as a string. The second return value is false
+// if the field doesn't exist.
+func jsonPathValue(u *unstructured.Unstructured, path string) (string, bool, error) {
+	jp, err := parseJSONPath(path)
+	if err != nil {
+		return "", false, err
+	}
+	results, err := jp.FindResults(u.Object)
+	if err != nil {
+		return "", false, err
+	}
+	if len(results) == 0 || len(results[0]) == 0 {
+		return "", false, nil
+	}
+	var buf bytes.Buffer
+	if err := jp.PrintResults(&buf, results[0]); err != nil {
+		return "", false, err
+	}
+	return buf.String(), true, nil
+}
+
+// parseJSONPath parses a JSONPath expression, which can be given
+// with or without the surrounding braces.
+func parseJSONPath(path string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(path, "{") {
+		path = fmt.Sprintf("{%s}", path)
+	}
+	jp := jsonpath.New("status").AllowMissingKeys(true)
+	if err := jp.Parse(path); err != nil {
+		return nil, err
+	}
+	return jp, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package statusreaders
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/testutil"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	fakemapper "sigs.k8s.io/cli-utils/pkg/testutil"
+)
+
+func TestJSONPathStatusReader(t *testing.T) {
+	extractor, err := ParseJSONPathExtractor(".status.readyReplicas=.spec.replicas")
+	assert.NilError(t, err)
+
+	testCases := map[string]struct {
+		spec           map[string]interface{}
+		status         map[string]interface{}
+		expectedStatus status.Status
+	}{
+		"current field not set": {
+			spec: map[string]interface{}{
+				"replicas": int64(3),
+			},
+			expectedStatus: status.InProgressStatus,
+		},
+		"fields do not match": {
+			spec: map[string]interface{}{
+				"replicas": int64(3),
+			},
+			status: map[string]interface{}{
+				"readyReplicas": int64(1),
+			},
+			expectedStatus: status.InProgressStatus,
+		},
+		"fields match": {
+			spec: map[string]interface{}{
+				"replicas": int64(3),
+			},
+			status: map[string]interface{}{
+				"readyReplicas": int64(3),
+			},
+			expectedStatus: status.CurrentStatus,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			statusReader := NewJSONPathStatusReader(testutil.NewNoopClusterReader(),
+				fakemapper.NewFakeRESTMapper(), extractor)
+
+			o := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": tc.spec,
+				},
+			}
+			if tc.status != nil {
+				o.Object["status"] = tc.status
+			}
+			o.SetGroupVersionKind(customGVK)
+			o.SetName(name)
+			o.SetNamespace(namespace)
+
+			resourceStatus := statusReader.ReadStatusForObject(context.Background(), o)
+
+			assert.NilError(t, resourceStatus.Error)
+			assert.Equal(t, tc.expectedStatus, resourceStatus.Status)
+		})
+	}
+}
+
+func TestParseJSONPathExtractor(t *testing.T) {
+	testCases := map[string]struct {
+		expr          string
+		expected      JSONPathExtractor
+		expectedError bool
+	}{
+		"valid expression": {
+			expr: ".status.readyReplicas=.spec.replicas",
+			expected: JSONPathExtractor{
+				Current: ".status.readyReplicas",
+				Desired: ".spec.replicas",
+			},
+		},
+		"missing desired path": {
+			expr:          ".status.readyReplicas",
+			expectedError: true,
+		},
+		"invalid path": {
+			expr:          ".status[=.spec.replicas",
+			expectedError: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			e, err := ParseJSONPathExtractor(tc.expr)
+			if tc.expectedError {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tc.expected, e)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package statusreaders
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/engine"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+// DefaultStatusScorer is the StatusScorer used by the StatusPoller unless
+// another one is provided. Workloads are scored by the fraction of the
+// desired replicas that are ready and Pods by their phase. All other
+// resources are scored based on their computed status.
+type DefaultStatusScorer struct{}
+
+var _ engine.StatusScorer = &DefaultStatusScorer{}
+
+// Score returns the health score for the provided resource.
+func (d *DefaultStatusScorer) Score(obj *unstructured.Unstructured) float64 {
+	gk := obj.GroupVersionKind().GroupKind()
+	switch {
+	case gk.Group == "apps" && (gk.Kind == "Deployment" || gk.Kind == "StatefulSet" || gk.Kind == "ReplicaSet"):
+		return replicasScore(obj)
+	case gk.Group == "" && gk.Kind == "Pod":
+		return podScore(obj)
+	default:
+		res, err := status.Compute(obj)
+		if err != nil {
+			return 0.0
+		}
+		return statusScore(res.Status)
+	}
+}
+
+// replicasScore returns the fraction of the desired replicas
+// that are ready.
+func replicasScore(obj *unstructured.Unstructured) float64 {
+	desired, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return 0.0
+	}
+	if !found {
+		desired = 1
+	}
+	if desired == 0 {
+		return 1.0
+	}
+	ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	if ready >= desired {
+		return 1.0
+	}
+	return float64(ready) / float64(desired)
+}
+
+// podScore scores a Pod based on its phase and whether it is ready.
+func podScore(obj *unstructured.Unstructured) float64 {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	switch phase {
+	case "Succeeded":
+		return 1.0
+	case "Running":
+		if podReady(obj) {
+			return 1.0
+		}
+		return 0.5
+	case "Pending":
+		return 0.5
+	default:
+		return 0.0
+	}
+}
+
+// podReady returns true if the Ready condition of the Pod is True.
+func podReady(obj *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if cond["type"] == "Ready" {
+			return cond["status"] == "True"
+		}
+	}
+	return false
+}
+
+// statusScore maps the computed status of a resource to a score.
+func statusScore(s status.Status) float64 {
+	switch s {
+	case status.CurrentStatus:
+		return 1.0
+	case status.InProgressStatus, status.TerminatingStatus:
+		return 0.5
+	default:
+		return 0.0
+	}
+}
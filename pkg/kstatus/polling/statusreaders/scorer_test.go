@@ -0,0 +1,112 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package statusreaders
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/testutil"
+)
+
+func TestDefaultStatusScorer(t *testing.T) {
+	testCases := map[string]struct {
+		manifest      string
+		expectedScore float64
+	}{
+		"deployment with some ready replicas": {
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 4
+status:
+  readyReplicas: 1
+`,
+			expectedScore: 0.25,
+		},
+		"deployment with all replicas ready": {
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: 2
+status:
+  readyReplicas: 2
+`,
+			expectedScore: 1.0,
+		},
+		"statefulset scaled to zero": {
+			manifest: `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: foo
+spec:
+  replicas: 0
+`,
+			expectedScore: 1.0,
+		},
+		"running pod that is not ready": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: foo
+status:
+  phase: Running
+  conditions:
+  - type: Ready
+    status: "False"
+`,
+			expectedScore: 0.5,
+		},
+		"running pod that is ready": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: foo
+status:
+  phase: Running
+  conditions:
+  - type: Ready
+    status: "True"
+`,
+			expectedScore: 1.0,
+		},
+		"failed pod": {
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: foo
+status:
+  phase: Failed
+`,
+			expectedScore: 0.0,
+		},
+		"current configmap": {
+			manifest: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo
+`,
+			expectedScore: 1.0,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			obj := testutil.YamlToUnstructured(t, tc.manifest)
+			score := (&DefaultStatusScorer{}).Score(obj)
+			assert.Equal(t, tc.expectedScore, score)
+		})
+	}
+}
@@ -535,6 +535,48 @@ func jobConditions(u *unstructured.Unstructured) (*Result, error) {
 	}, nil
 }
 
+// JobCompletionStatus computes the status of a Job based on whether
+// it has run to completion, unlike the default status for Jobs where
+// a Job that has started is Current. The Job is Current when
+// .status.succeeded has reached .spec.completions, and Failed if it
+// has the Failed condition or .status.failed exceeds
+// .spec.backoffLimit. The Job controller sets the Failed condition
+// when .spec.activeDeadlineSeconds is exceeded.
+func JobCompletionStatus(u *unstructured.Unstructured) (*Result, error) {
+	obj := u.UnstructuredContent()
+
+	completions := GetIntField(obj, ".spec.completions", 1)
+	backoffLimit := GetIntField(obj, ".spec.backoffLimit", 6)
+	succeeded := GetIntField(obj, ".status.succeeded", 0)
+	active := GetIntField(obj, ".status.active", 0)
+	failed := GetIntField(obj, ".status.failed", 0)
+
+	objc, err := GetObjectWithConditions(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range objc.Status.Conditions {
+		if c.Type == "Failed" && c.Status == corev1.ConditionTrue {
+			return newFailedStatus("JobFailed",
+				fmt.Sprintf("Job Failed. %s: %s", c.Reason, c.Message)), nil
+		}
+	}
+	if failed > backoffLimit {
+		return newFailedStatus("BackoffLimitExceeded",
+			fmt.Sprintf("Job Failed. failed: %d, backoffLimit: %d", failed, backoffLimit)), nil
+	}
+	if succeeded >= completions {
+		return &Result{
+			Status:     CurrentStatus,
+			Message:    fmt.Sprintf("Job Completed. succeeded: %d/%d", succeeded, completions),
+			Conditions: []Condition{},
+		}, nil
+	}
+	return newInProgressStatus("JobInProgress",
+		fmt.Sprintf("Job in progress. succeeded: %d/%d, active: %d, failed: %d",
+			succeeded, completions, active, failed)), nil
+}
+
 // serviceConditions return standardized Conditions for Service
 func serviceConditions(u *unstructured.Unstructured) (*Result, error) {
 	obj := u.UnstructuredContent()
@@ -5,6 +5,7 @@ package status
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -19,6 +20,14 @@ const (
 	ConditionStalled     ConditionType = "Stalled"
 	ConditionReconciling ConditionType = "Reconciling"
 
+	// StatusConditionAnnotation lists the conditions, in the format
+	// Type=Status and separated by commas, that must be met for a resource
+	// to be considered Current. Example:
+	//   cli-utils.sigs.k8s.io/status-condition: Ready=True,Synced=True
+	// This allows computing status for custom resources that use
+	// conditions other than the ones understood by this package.
+	StatusConditionAnnotation = "cli-utils.sigs.k8s.io/status-condition"
+
 	// The set of status conditions which can be assigned to resources.
 	InProgressStatus  Status = "InProgress"
 	FailedStatus      Status = "Failed"
@@ -111,6 +120,13 @@ func Compute(u *unstructured.Unstructured) (*Result, error) {
 		return res, nil
 	}
 
+	// If the resource specifies the conditions that determine its
+	// status, those take precedence over the type-specific rules.
+	res, err = checkAnnotatedConditions(u)
+	if res != nil || err != nil {
+		return res, err
+	}
+
 	fn := GetLegacyConditionsFn(u)
 	if fn != nil {
 		return fn(u)
@@ -181,6 +197,84 @@ func checkReadyCondition(u *unstructured.Unstructured) (*Result, error) {
 	return nil, nil
 }
 
+// checkAnnotatedConditions checks if a resource has the
+// StatusConditionAnnotation, and if so, it will use the conditions
+// listed in the annotation to determine the status. The resource is
+// Current when all the listed conditions have the required status,
+// otherwise it is InProgress.
+func checkAnnotatedConditions(u *unstructured.Unstructured) (*Result, error) {
+	value, found := u.GetAnnotations()[StatusConditionAnnotation]
+	if !found {
+		return nil, nil
+	}
+	required, err := parseStatusConditions(value)
+	if err != nil {
+		return nil, err
+	}
+
+	objWithConditions, err := GetObjectWithConditions(u.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, req := range required {
+		var cond *BasicCondition
+		for i := range objWithConditions.Status.Conditions {
+			if objWithConditions.Status.Conditions[i].Type == req.Type.String() {
+				cond = &objWithConditions.Status.Conditions[i]
+				break
+			}
+		}
+		if cond == nil {
+			message := fmt.Sprintf("Condition %s not found", req.Type)
+			return newInProgressStatus("ConditionNotFound", message), nil
+		}
+		if cond.Status != req.Status {
+			message := fmt.Sprintf("Condition %s is %s, expected %s", req.Type, cond.Status, req.Status)
+			if cond.Message != "" {
+				message = fmt.Sprintf("%s: %s", message, cond.Message)
+			}
+			return newInProgressStatus("ConditionNotMet", message), nil
+		}
+	}
+	return &Result{
+		Status:     CurrentStatus,
+		Message:    "Resource has all required conditions",
+		Conditions: []Condition{},
+	}, nil
+}
+
+// parseStatusConditions parses the value of the StatusConditionAnnotation
+// into a list of conditions.
+func parseStatusConditions(value string) ([]Condition, error) {
+	var conditions []Condition
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.Errorf("invalid condition %q in annotation %s", entry, StatusConditionAnnotation)
+		}
+		status := corev1.ConditionStatus(strings.TrimSpace(parts[1]))
+		switch status {
+		case corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown:
+		default:
+			return nil, errors.Errorf("invalid status %q for condition %s in annotation %s",
+				status, parts[0], StatusConditionAnnotation)
+		}
+		conditions = append(conditions, Condition{
+			Type:   ConditionType(strings.TrimSpace(parts[0])),
+			Status: status,
+		})
+	}
+	if len(conditions) == 0 {
+		return nil, errors.Errorf("no conditions in annotation %s", StatusConditionAnnotation)
+	}
+	return conditions, nil
+}
+
 // Augment takes a resource and augments the resource with the
 // standard status conditions.
 func Augment(u *unstructured.Unstructured) error {
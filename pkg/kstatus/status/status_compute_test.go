@@ -1242,6 +1242,81 @@ func TestJobStatus(t *testing.T) {
 	}
 }
 
+var jobBackoffLimitExceeded = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+   name: test
+   namespace: qual
+   generation: 1
+spec:
+   backoffLimit: 2
+status:
+   startTime: "2019-06-04T01:17:13Z"
+   failed: 3
+`
+
+func TestJobCompletionStatus(t *testing.T) {
+	testCases := map[string]struct {
+		spec           string
+		expectedStatus Status
+	}{
+		"jobNoStatus": {
+			spec:           jobNoStatus,
+			expectedStatus: InProgressStatus,
+		},
+		"jobComplete": {
+			spec:           jobComplete,
+			expectedStatus: CurrentStatus,
+		},
+		"jobFailed": {
+			spec:           jobFailed,
+			expectedStatus: FailedStatus,
+		},
+		"jobInProgress": {
+			spec:           jobInProgress,
+			expectedStatus: InProgressStatus,
+		},
+		"jobBackoffLimitExceeded": {
+			spec:           jobBackoffLimitExceeded,
+			expectedStatus: FailedStatus,
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			res, err := JobCompletionStatus(y2u(t, tc.spec))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, res.Status)
+		})
+	}
+}
+
+func TestJobCompletionStatusProgression(t *testing.T) {
+	u := y2u(t, jobNoStatus)
+	assert.NoError(t, unstructured.SetNestedField(u.Object, int64(2), "spec", "completions"))
+
+	steps := []struct {
+		active         int64
+		succeeded      int64
+		expectedStatus Status
+	}{
+		{active: 2, succeeded: 0, expectedStatus: InProgressStatus},
+		{active: 1, succeeded: 1, expectedStatus: InProgressStatus},
+		{active: 0, succeeded: 2, expectedStatus: CurrentStatus},
+	}
+	for i, step := range steps {
+		assert.NoError(t, unstructured.SetNestedField(u.Object, "2019-06-04T01:17:13Z", "status", "startTime"))
+		assert.NoError(t, unstructured.SetNestedField(u.Object, step.active, "status", "active"))
+		assert.NoError(t, unstructured.SetNestedField(u.Object, step.succeeded, "status", "succeeded"))
+
+		res, err := JobCompletionStatus(u)
+		assert.NoError(t, err)
+		assert.Equal(t, step.expectedStatus, res.Status, "step %d", i)
+	}
+}
+
 var cronjobNoStatus = `
 apiVersion: batch/v1
 kind: CronJob
@@ -1495,3 +1570,162 @@ func TestCRDStatus(t *testing.T) {
 		})
 	}
 }
+
+var crAnnotatedNoStatus = `
+apiVersion: example.com/v1
+kind: Database
+metadata:
+   generation: 1
+   name: test
+   namespace: qual
+   annotations:
+      cli-utils.sigs.k8s.io/status-condition: Synced=True,Healthy=True
+`
+
+var crAnnotatedPartial = `
+apiVersion: example.com/v1
+kind: Database
+metadata:
+   generation: 1
+   name: test
+   namespace: qual
+   annotations:
+      cli-utils.sigs.k8s.io/status-condition: Synced=True,Healthy=True
+status:
+   conditions:
+    - type: Synced
+      status: "True"
+    - type: Healthy
+      status: "False"
+      reason: Starting
+      message: database is starting
+`
+
+var crAnnotatedAllMet = `
+apiVersion: example.com/v1
+kind: Database
+metadata:
+   generation: 1
+   name: test
+   namespace: qual
+   annotations:
+      cli-utils.sigs.k8s.io/status-condition: Synced=True,Healthy=True
+status:
+   conditions:
+    - type: Synced
+      status: "True"
+    - type: Healthy
+      status: "True"
+`
+
+var crAnnotatedReadyFalse = `
+apiVersion: example.com/v1
+kind: Database
+metadata:
+   generation: 1
+   name: test
+   namespace: qual
+   annotations:
+      cli-utils.sigs.k8s.io/status-condition: Ready=False
+status:
+   conditions:
+    - type: Ready
+      status: "False"
+`
+
+func TestStatusConditionAnnotation(t *testing.T) {
+	testCases := map[string]testSpec{
+		"no conditions reported yet": {
+			spec:           crAnnotatedNoStatus,
+			expectedStatus: InProgressStatus,
+			expectedConditions: []Condition{
+				{
+					Type:   ConditionReconciling,
+					Status: corev1.ConditionTrue,
+					Reason: "ConditionNotFound",
+				},
+			},
+		},
+		"some conditions not met": {
+			spec:           crAnnotatedPartial,
+			expectedStatus: InProgressStatus,
+			expectedConditions: []Condition{
+				{
+					Type:   ConditionReconciling,
+					Status: corev1.ConditionTrue,
+					Reason: "ConditionNotMet",
+				},
+			},
+		},
+		"all conditions met": {
+			spec:           crAnnotatedAllMet,
+			expectedStatus: CurrentStatus,
+			absentConditionTypes: []ConditionType{
+				ConditionReconciling,
+				ConditionStalled,
+			},
+		},
+		"annotation overrides the Ready condition": {
+			spec:           crAnnotatedReadyFalse,
+			expectedStatus: CurrentStatus,
+			absentConditionTypes: []ConditionType{
+				ConditionReconciling,
+				ConditionStalled,
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			runStatusTest(t, tc)
+		})
+	}
+}
+
+func TestParseStatusConditions(t *testing.T) {
+	testCases := map[string]struct {
+		value       string
+		expected    []Condition
+		expectedErr bool
+	}{
+		"single condition": {
+			value: "Ready=True",
+			expected: []Condition{
+				{Type: "Ready", Status: corev1.ConditionTrue},
+			},
+		},
+		"multiple conditions with whitespace": {
+			value: "Synced=True, Degraded=False",
+			expected: []Condition{
+				{Type: "Synced", Status: corev1.ConditionTrue},
+				{Type: "Degraded", Status: corev1.ConditionFalse},
+			},
+		},
+		"missing status": {
+			value:       "Ready",
+			expectedErr: true,
+		},
+		"invalid status": {
+			value:       "Ready=Yes",
+			expectedErr: true,
+		},
+		"empty": {
+			value:       "",
+			expectedErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			conditions, err := parseStatusConditions(tc.value)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, conditions)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// AnnotationTransformer is a Transformer that sets the provided
+// annotations on all resources. Existing annotations with other keys
+// are preserved.
+type AnnotationTransformer struct {
+	Annotations map[string]string
+}
+
+var _ Transformer = &AnnotationTransformer{}
+
+// NewAnnotationTransformer returns an AnnotationTransformer for the
+// provided annotations in the format <key>=<value>, or an error if
+// any of them is not in that format.
+func NewAnnotationTransformer(annotations []string) (*AnnotationTransformer, error) {
+	m := make(map[string]string)
+	for _, a := range annotations {
+		parts := strings.SplitN(a, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid annotation %q, must be <key>=<value>", a)
+		}
+		m[parts[0]] = parts[1]
+	}
+	return &AnnotationTransformer{
+		Annotations: m,
+	}, nil
+}
+
+// Transform sets the annotations on every info.
+func (a *AnnotationTransformer) Transform(infos []*resource.Info) ([]*resource.Info, error) {
+	for _, info := range infos {
+		acc, err := meta.Accessor(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		annotations := acc.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		for k, v := range a.Annotations {
+			annotations[k] = v
+		}
+		acc.SetAnnotations(annotations)
+	}
+	return infos, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+var annotatedCMManifest = `
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: baz
+  annotations:
+    owner: team-a
+    git-commit: abc
+`
+
+func TestAnnotationTransformer(t *testing.T) {
+	testCases := map[string]struct {
+		annotations []string
+
+		expectedAnnotations map[string]map[string]string
+		expectedErr         bool
+	}{
+		"annotations are set on all resources": {
+			annotations: []string{"git-commit=1234", "deployer=ci-pipeline"},
+			expectedAnnotations: map[string]map[string]string{
+				"foo": {
+					"git-commit": "1234",
+					"deployer":   "ci-pipeline",
+				},
+				"baz": {
+					"owner":      "team-a",
+					"git-commit": "1234",
+					"deployer":   "ci-pipeline",
+				},
+			},
+		},
+		"annotation with empty value is allowed": {
+			annotations: []string{"deployer="},
+			expectedAnnotations: map[string]map[string]string{
+				"foo": {
+					"deployer": "",
+				},
+				"baz": {
+					"owner":      "team-a",
+					"git-commit": "abc",
+					"deployer":   "",
+				},
+			},
+		},
+		"annotation without key is an error": {
+			annotations: []string{"=foo"},
+			expectedErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			annotationTransformer, err := NewAnnotationTransformer(tc.annotations)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			infos, err := (&StreamManifestReader{
+				ReaderName: "testReader",
+				Reader:     strings.NewReader(depManifest + "---" + annotatedCMManifest),
+				ReaderOptions: ReaderOptions{
+					Factory:      tf,
+					Namespace:    "test-ns",
+					Transformers: []Transformer{annotationTransformer},
+				},
+			}).Read()
+			if !assert.NoError(t, err) || !assert.Equal(t, 2, len(infos)) {
+				return
+			}
+
+			for _, info := range infos {
+				u := info.Object.(*unstructured.Unstructured)
+				assert.Equal(t, tc.expectedAnnotations[u.GetName()], u.GetAnnotations())
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// AnnotationTrimTransformer is a Transformer that removes the
+// annotations with the provided keys from all resources, for
+// annotations that tools add to exported manifests but that should
+// not be part of the desired state.
+type AnnotationTrimTransformer struct {
+	Annotations []string
+}
+
+var _ Transformer = &AnnotationTrimTransformer{}
+
+// Transform removes the annotations from every info.
+func (a *AnnotationTrimTransformer) Transform(infos []*resource.Info) ([]*resource.Info, error) {
+	for _, info := range infos {
+		acc, err := meta.Accessor(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		annotations := acc.GetAnnotations()
+		if len(annotations) == 0 {
+			continue
+		}
+		for _, key := range a.Annotations {
+			delete(annotations, key)
+		}
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		acc.SetAnnotations(annotations)
+	}
+	return infos, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func TestAnnotationTrimTransformer(t *testing.T) {
+	testCases := map[string]struct {
+		annotations []string
+
+		expectedAnnotations map[string]map[string]string
+	}{
+		"listed annotations are removed": {
+			annotations: []string{"git-commit", "unknown"},
+			expectedAnnotations: map[string]map[string]string{
+				"baz": {
+					"owner": "team-a",
+				},
+			},
+		},
+		"removing all annotations leaves none": {
+			annotations:         []string{"git-commit", "owner"},
+			expectedAnnotations: map[string]map[string]string{},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			infos, err := (&StreamManifestReader{
+				ReaderName: "testReader",
+				Reader:     strings.NewReader(depManifest + "---" + annotatedCMManifest),
+				ReaderOptions: ReaderOptions{
+					Factory:   tf,
+					Namespace: "test-ns",
+					Transformers: []Transformer{&AnnotationTrimTransformer{
+						Annotations: tc.annotations,
+					}},
+				},
+			}).Read()
+			if !assert.NoError(t, err) || !assert.Equal(t, 2, len(infos)) {
+				return
+			}
+
+			for _, info := range infos {
+				u := info.Object.(*unstructured.Unstructured)
+				assert.Equal(t, tc.expectedAnnotations[u.GetName()], u.GetAnnotations())
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// DefaultAPIVersionOverrides contains the migrations from deprecated
+// API versions of common resources to the versions supported by newer
+// clusters.
+var DefaultAPIVersionOverrides = map[schema.GroupKind]string{
+	{Group: "extensions", Kind: "Ingress"}:           "networking.k8s.io/v1",
+	{Group: "networking.k8s.io", Kind: "Ingress"}:    "networking.k8s.io/v1",
+	{Group: "extensions", Kind: "NetworkPolicy"}:     "networking.k8s.io/v1",
+	{Group: "extensions", Kind: "PodSecurityPolicy"}: "policy/v1beta1",
+	{Group: "extensions", Kind: "Deployment"}:        "apps/v1",
+	{Group: "extensions", Kind: "DaemonSet"}:         "apps/v1",
+	{Group: "extensions", Kind: "ReplicaSet"}:        "apps/v1",
+	{Group: "apps", Kind: "Deployment"}:              "apps/v1",
+	{Group: "apps", Kind: "DaemonSet"}:               "apps/v1",
+	{Group: "apps", Kind: "ReplicaSet"}:              "apps/v1",
+	{Group: "apps", Kind: "StatefulSet"}:             "apps/v1",
+}
+
+// apiVersionConverters update the structure of an object whose
+// apiVersion has been rewritten to the version in the key. They must
+// leave objects that already have the new structure unchanged.
+var apiVersionConverters = map[schema.GroupVersionKind]func(u *unstructured.Unstructured) error{
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}: convertIngressV1,
+	{Group: "apps", Version: "v1", Kind: "Deployment"}:           setSelectorFromTemplate,
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"}:            setSelectorFromTemplate,
+	{Group: "apps", Version: "v1", Kind: "ReplicaSet"}:           setSelectorFromTemplate,
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"}:          setSelectorFromTemplate,
+}
+
+// overrideAPIVersions rewrites the apiVersion of every info whose
+// GroupKind is found in overrides to the mapped apiVersion, and
+// updates the structure of the object if needed.
+func overrideAPIVersions(infos []*resource.Info, overrides map[schema.GroupKind]string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	for _, info := range infos {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		gvk := u.GroupVersionKind()
+		apiVersion, found := overrides[gvk.GroupKind()]
+		if !found || apiVersion == u.GetAPIVersion() {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return fmt.Errorf("invalid apiVersion override %q for %s: %v",
+				apiVersion, gvk.GroupKind(), err)
+		}
+		newGVK := gv.WithKind(gvk.Kind)
+		u.SetGroupVersionKind(newGVK)
+		if convert, found := apiVersionConverters[newGVK]; found {
+			if err := convert(u); err != nil {
+				return fmt.Errorf("error converting %s %s from %s to %s: %v",
+					gvk.Kind, info.Name, gvk.GroupVersion(), apiVersion, err)
+			}
+		}
+	}
+	return nil
+}
+
+// convertIngressV1 converts the spec of an extensions/v1beta1 or
+// networking.k8s.io/v1beta1 Ingress to networking.k8s.io/v1. The
+// default backend is renamed, service backends are nested in a service
+// field and paths without a pathType get ImplementationSpecific, which
+// matches the behavior of the old versions.
+func convertIngressV1(u *unstructured.Unstructured) error {
+	if backend, found, err := unstructured.NestedMap(u.Object, "spec", "backend"); err != nil {
+		return err
+	} else if found {
+		unstructured.RemoveNestedField(u.Object, "spec", "backend")
+		if err := unstructured.SetNestedMap(u.Object, convertIngressBackend(backend), "spec", "defaultBackend"); err != nil {
+			return err
+		}
+	}
+
+	rules, found, err := unstructured.NestedSlice(u.Object, "spec", "rules")
+	if err != nil || !found {
+		return err
+	}
+	for i := range rules {
+		rule, ok := rules[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths, found, err := unstructured.NestedSlice(rule, "http", "paths")
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		for j := range paths {
+			path, ok := paths[j].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, found := path["pathType"]; !found {
+				path["pathType"] = "ImplementationSpecific"
+			}
+			if backend, ok := path["backend"].(map[string]interface{}); ok {
+				path["backend"] = convertIngressBackend(backend)
+			}
+		}
+		if err := unstructured.SetNestedSlice(rule, paths, "http", "paths"); err != nil {
+			return err
+		}
+	}
+	return unstructured.SetNestedSlice(u.Object, rules, "spec", "rules")
+}
+
+// convertIngressBackend converts a backend with serviceName and
+// servicePort to a networking.k8s.io/v1 service backend. Other
+// backends are returned unchanged.
+func convertIngressBackend(backend map[string]interface{}) map[string]interface{} {
+	serviceName, found := backend["serviceName"]
+	if !found {
+		return backend
+	}
+	port := map[string]interface{}{}
+	switch p := backend["servicePort"].(type) {
+	case string:
+		port["name"] = p
+	case nil:
+	default:
+		port["number"] = p
+	}
+	delete(backend, "serviceName")
+	delete(backend, "servicePort")
+	backend["service"] = map[string]interface{}{
+		"name": serviceName,
+		"port": port,
+	}
+	return backend
+}
+
+// setSelectorFromTemplate sets the selector to the labels of the pod
+// template if it is not set, since the selector is required in apps/v1
+// while older versions defaulted it.
+func setSelectorFromTemplate(u *unstructured.Unstructured) error {
+	if _, found, err := unstructured.NestedMap(u.Object, "spec", "selector"); err != nil || found {
+		return err
+	}
+	labels, found, err := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return err
+	}
+	if !found || len(labels) == 0 {
+		return fmt.Errorf("spec.selector is required and can not be derived from the pod template labels")
+	}
+	matchLabels := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		matchLabels[k] = v
+	}
+	return unstructured.SetNestedMap(u.Object, matchLabels, "spec", "selector", "matchLabels")
+}
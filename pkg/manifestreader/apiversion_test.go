@@ -0,0 +1,209 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+var (
+	ingressV1beta1Manifest = `
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+spec:
+  backend:
+    serviceName: default
+    servicePort: 80
+  rules:
+  - host: example.com
+    http:
+      paths:
+      - path: /
+        backend:
+          serviceName: web
+          servicePort: http
+`
+)
+
+func TestStreamManifestReader_APIVersionOverrides(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+	defer tf.Cleanup()
+
+	infos, err := (&StreamManifestReader{
+		ReaderName: "testReader",
+		Reader:     strings.NewReader(ingressV1beta1Manifest),
+		ReaderOptions: ReaderOptions{
+			Factory:             tf,
+			Namespace:           "foo",
+			APIVersionOverrides: DefaultAPIVersionOverrides,
+		},
+	}).Read()
+	if !assert.NoError(t, err) || !assert.Len(t, infos, 1) {
+		return
+	}
+
+	u := infos[0].Object.(*unstructured.Unstructured)
+	assert.Equal(t, "networking.k8s.io/v1", u.GetAPIVersion())
+	assert.Equal(t, "foo", infos[0].Namespace)
+
+	expectedSpec := map[string]interface{}{
+		"defaultBackend": map[string]interface{}{
+			"service": map[string]interface{}{
+				"name": "default",
+				"port": map[string]interface{}{
+					"number": int64(80),
+				},
+			},
+		},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"host": "example.com",
+				"http": map[string]interface{}{
+					"paths": []interface{}{
+						map[string]interface{}{
+							"path":     "/",
+							"pathType": "ImplementationSpecific",
+							"backend": map[string]interface{}{
+								"service": map[string]interface{}{
+									"name": "web",
+									"port": map[string]interface{}{
+										"name": "http",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expectedSpec, u.Object["spec"])
+}
+
+func TestOverrideAPIVersions(t *testing.T) {
+	testCases := map[string]struct {
+		object    map[string]interface{}
+		overrides map[schema.GroupKind]string
+
+		expectedObject map[string]interface{}
+		expectedError  bool
+	}{
+		"objects without override are unchanged": {
+			object: map[string]interface{}{
+				"apiVersion": "extensions/v1beta1",
+				"kind":       "Ingress",
+			},
+			overrides: map[schema.GroupKind]string{
+				{Group: "apps", Kind: "Deployment"}: "apps/v1",
+			},
+			expectedObject: map[string]interface{}{
+				"apiVersion": "extensions/v1beta1",
+				"kind":       "Ingress",
+			},
+		},
+		"deployment selector is set from template labels": {
+			object: map[string]interface{}{
+				"apiVersion": "extensions/v1beta1",
+				"kind":       "Deployment",
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"labels": map[string]interface{}{
+								"app": "web",
+							},
+						},
+					},
+				},
+			},
+			overrides: DefaultAPIVersionOverrides,
+			expectedObject: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{
+						"matchLabels": map[string]interface{}{
+							"app": "web",
+						},
+					},
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"labels": map[string]interface{}{
+								"app": "web",
+							},
+						},
+					},
+				},
+			},
+		},
+		"existing selector is kept": {
+			object: map[string]interface{}{
+				"apiVersion": "apps/v1beta2",
+				"kind":       "StatefulSet",
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{
+						"matchLabels": map[string]interface{}{
+							"app": "db",
+						},
+					},
+				},
+			},
+			overrides: DefaultAPIVersionOverrides,
+			expectedObject: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "StatefulSet",
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{
+						"matchLabels": map[string]interface{}{
+							"app": "db",
+						},
+					},
+				},
+			},
+		},
+		"deployment without selector and labels is an error": {
+			object: map[string]interface{}{
+				"apiVersion": "apps/v1beta1",
+				"kind":       "Deployment",
+			},
+			overrides:     DefaultAPIVersionOverrides,
+			expectedError: true,
+		},
+		"invalid apiVersion override is an error": {
+			object: map[string]interface{}{
+				"apiVersion": "extensions/v1beta1",
+				"kind":       "Ingress",
+			},
+			overrides: map[schema.GroupKind]string{
+				{Group: "extensions", Kind: "Ingress"}: "networking.k8s.io/v1/extra",
+			},
+			expectedError: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			u := &unstructured.Unstructured{Object: tc.object}
+			infos := []*resource.Info{{Name: "test", Object: u}}
+
+			err := overrideAPIVersions(infos, tc.overrides)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expectedObject, u.Object)
+		})
+	}
+}
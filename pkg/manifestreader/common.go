@@ -5,12 +5,18 @@ package manifestreader
 
 import (
 	"fmt"
+	"io"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/validation"
 	"sigs.k8s.io/cli-utils/pkg/apply/solver"
+	"sigs.k8s.io/cli-utils/pkg/common"
 )
 
 // ManifestReader defines the interface for reading a set
@@ -22,10 +28,95 @@ type ManifestReader interface {
 // ReaderOptions defines the shared inputs for the different
 // implementations of the ManifestReader interface.
 type ReaderOptions struct {
-	Factory          util.Factory
+	Factory util.Factory
+	// Validate enables validating the manifests against the schema of
+	// the resources. The manifests are validated after the
+	// Transformers, so placeholders like the ones substituted by the
+	// EnvVarTransformer don't fail the validation.
 	Validate         bool
 	Namespace        string
 	EnforceNamespace bool
+	// StrictNamespaceValidation makes the reader return an error if
+	// any of the resources are cluster-scoped when a Namespace is set.
+	StrictNamespaceValidation bool
+	// NoRecursive makes the PathManifestReader only read the manifests
+	// in the top-level directory of the path. By default manifests are
+	// read from all subdirectories as well, following symlinks.
+	NoRecursive bool
+	// AllowDuplicates makes the manifest readers keep the last
+	// occurrence of resources found more than once with different
+	// content, instead of returning a DuplicateResourcesError.
+	AllowDuplicates bool
+	// APIVersionOverrides maps resource types to the apiVersion they
+	// are rewritten to before the namespaces are set, for example to
+	// migrate from deprecated API versions. The structure of the
+	// objects is updated where the versions differ. See
+	// DefaultAPIVersionOverrides for common migrations.
+	APIVersionOverrides map[schema.GroupKind]string
+	// Transformers are applied in order to the manifests after
+	// they have been read and the namespaces have been set. The
+	// inventory object is not transformed.
+	Transformers []Transformer
+	// Stdin is the reader the PathManifestReader reads the manifests
+	// from if the path is "-". Commands usually set it to the input
+	// stream of the command.
+	Stdin io.Reader
+}
+
+// Transformer defines the interface for modifying the manifests
+// read by a ManifestReader before they are returned.
+type Transformer interface {
+	Transform(infos []*resource.Info) ([]*resource.Info, error)
+}
+
+// transform runs the infos through each of the provided
+// transformers in order. Inventory objects are not passed to the
+// transformers, since changing them could break the tracking of the
+// applied resources. They are returned after the transformed infos.
+func transform(infos []*resource.Info, transformers []Transformer) ([]*resource.Info, error) {
+	if len(transformers) == 0 {
+		return infos, nil
+	}
+	var invInfos, objInfos []*resource.Info
+	for _, info := range infos {
+		if u, _ := info.Object.(*unstructured.Unstructured); common.IsInventoryObject(u) {
+			invInfos = append(invInfos, info)
+		} else {
+			objInfos = append(objInfos, info)
+		}
+	}
+	var err error
+	for _, t := range transformers {
+		objInfos, err = t.Transform(objInfos)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(objInfos, invInfos...), nil
+}
+
+// transformAndValidate runs the infos through the transformers, and
+// then validates the transformed infos against the schema.
+func transformAndValidate(infos []*resource.Info, transformers []Transformer,
+	validator validation.Schema) ([]*resource.Info, error) {
+	infos, err := transform(infos, transformers)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, info := range infos {
+		data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, info.Object)
+		if err != nil {
+			return nil, err
+		}
+		if err := validator.ValidateBytes(data); err != nil {
+			errs = append(errs, fmt.Errorf("error validating %q: %v", info.Source, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return infos, nil
 }
 
 // setNamespaces verifies that every namespaced resource has the namespace
@@ -35,8 +126,10 @@ type ReaderOptions struct {
 // the namespace set) on whether it is namespace or cluster scoped. It does
 // this by first checking the RESTMapper, and it there is not match there,
 // it will look for CRDs in the provided Infos.
+// If strictNamespace is true, an error is returned for any resource
+// without namespace that turns out to be cluster-scoped.
 func setNamespaces(factory util.Factory, infos []*resource.Info,
-	defaultNamespace string, enforceNamespace bool) error {
+	defaultNamespace string, enforceNamespace, strictNamespace bool) error {
 	mapper, err := factory.ToRESTMapper()
 	if err != nil {
 		return err
@@ -80,6 +173,10 @@ func setNamespaces(factory util.Factory, infos []*resource.Info,
 				// to the provided default value.
 				inf.Namespace = defaultNamespace
 				accessor.SetNamespace(defaultNamespace)
+				continue
+			}
+			if strictNamespace && defaultNamespace != "" {
+				return clusterScopedError(gk, accessor.GetName(), defaultNamespace)
 			}
 			continue
 		}
@@ -105,6 +202,9 @@ func setNamespaces(factory util.Factory, infos []*resource.Info,
 		case "":
 			return fmt.Errorf("can't find scope for resource %s %s", gk.String(), accessor.GetName())
 		case "Cluster":
+			if strictNamespace && defaultNamespace != "" {
+				return clusterScopedError(gk, accessor.GetName(), defaultNamespace)
+			}
 			continue
 		case "Namespaced":
 			inf.Namespace = defaultNamespace
@@ -114,3 +214,8 @@ func setNamespaces(factory util.Factory, infos []*resource.Info,
 
 	return nil
 }
+
+func clusterScopedError(gk schema.GroupKind, name, namespace string) error {
+	return fmt.Errorf("resource %s %s is cluster-scoped, which is not allowed "+
+		"when reading manifests for namespace %q", gk.String(), name, namespace)
+}
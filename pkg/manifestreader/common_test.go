@@ -25,6 +25,7 @@ func TestSetNamespaces(t *testing.T) {
 		infos            []*resource.Info
 		defaultNamspace  string
 		enforceNamespace bool
+		strictNamespace  bool
 
 		expectedNamespaces []string
 		expectedErrText    string
@@ -105,6 +106,50 @@ func TestSetNamespaces(t *testing.T) {
 			enforceNamespace:   true,
 			expectedNamespaces: []string{"", ""},
 		},
+		"cluster-scoped resource with strict namespace validation": {
+			infos: []*resource.Info{
+				toInfo(schema.GroupVersionKind{
+					Group:   "rbac.authorization.k8s.io",
+					Version: "v1",
+					Kind:    "ClusterRole",
+				}, ""),
+			},
+			defaultNamspace: "bar",
+			strictNamespace: true,
+			expectedErrText: "is cluster-scoped",
+		},
+		"cluster-scoped resource without strict namespace validation": {
+			infos: []*resource.Info{
+				toInfo(schema.GroupVersionKind{
+					Group:   "rbac.authorization.k8s.io",
+					Version: "v1",
+					Kind:    "ClusterRole",
+				}, ""),
+			},
+			defaultNamspace:    "bar",
+			strictNamespace:    false,
+			expectedNamespaces: []string{""},
+		},
+		"cluster-scoped CR with CRD and strict namespace validation": {
+			infos: []*resource.Info{
+				toInfo(schema.GroupVersionKind{
+					Group:   "custom.io",
+					Version: "v1",
+					Kind:    "Custom",
+				}, ""),
+				toCRDInfo(schema.GroupVersionKind{
+					Group:   "apiextensions.k8s.io",
+					Version: "v1",
+					Kind:    "CustomResourceDefinition",
+				}, schema.GroupKind{
+					Group: "custom.io",
+					Kind:  "Custom",
+				}, "Cluster"),
+			},
+			defaultNamspace: "bar",
+			strictNamespace: true,
+			expectedErrText: "is cluster-scoped",
+		},
 		"namespace-scoped CR with CRD": {
 			infos: []*resource.Info{
 				toCRDInfo(schema.GroupVersionKind{
@@ -132,7 +177,8 @@ func TestSetNamespaces(t *testing.T) {
 			tf := cmdtesting.NewTestFactory().WithNamespace("namespace")
 			defer tf.Cleanup()
 
-			err := setNamespaces(tf, tc.infos, tc.defaultNamspace, tc.enforceNamespace)
+			err := setNamespaces(tf, tc.infos, tc.defaultNamspace, tc.enforceNamespace,
+				tc.strictNamespace)
 
 			if tc.expectedErrText != "" {
 				if err == nil {
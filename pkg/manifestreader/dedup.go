@@ -0,0 +1,99 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// DuplicateConflict records a resource which is found more than once
+// in the manifests with different content.
+type DuplicateConflict struct {
+	ID object.ObjMetadata
+	// FirstSource and SecondSource are the sources, usually the
+	// file paths, of the two conflicting manifests.
+	FirstSource  string
+	SecondSource string
+}
+
+func (d DuplicateConflict) String() string {
+	return fmt.Sprintf("%s in %s and %s", d.ID, d.FirstSource, d.SecondSource)
+}
+
+// DuplicateResourcesError is returned by the manifest readers if
+// the same resource is found more than once with different content.
+type DuplicateResourcesError struct {
+	Conflicts []DuplicateConflict
+}
+
+func (d DuplicateResourcesError) Error() string {
+	conflicts := make([]string, 0, len(d.Conflicts))
+	for _, c := range d.Conflicts {
+		conflicts = append(conflicts, c.String())
+	}
+	return fmt.Sprintf("found %d conflicting duplicate resources: %s",
+		len(d.Conflicts), strings.Join(conflicts, "; "))
+}
+
+// DeduplicateInfos removes the resources found more than once, by
+// group, kind, namespace and name, from the infos. The last occurrence
+// of each resource is kept, in the position of the first one. A
+// DuplicateConflict is returned for every duplicate whose content
+// differs from the previous occurrence; identical duplicates are
+// dropped silently. Resources without a name that use generateName
+// are distinct resources, so they are never deduplicated.
+func DeduplicateInfos(infos []*resource.Info) ([]*resource.Info, []DuplicateConflict) {
+	var result []*resource.Info
+	var conflicts []DuplicateConflict
+	index := make(map[object.ObjMetadata]int)
+	for _, info := range infos {
+		if usesGenerateName(info) {
+			result = append(result, info)
+			continue
+		}
+		id := object.InfoToObjMeta(info)
+		i, found := index[id]
+		if !found {
+			index[id] = len(result)
+			result = append(result, info)
+			continue
+		}
+		if !reflect.DeepEqual(result[i].Object, info.Object) {
+			conflicts = append(conflicts, DuplicateConflict{
+				ID:           id,
+				FirstSource:  result[i].Source,
+				SecondSource: info.Source,
+			})
+		}
+		result[i] = info
+	}
+	return result, conflicts
+}
+
+// deduplicate removes the duplicate resources from the infos, and
+// returns a DuplicateResourcesError if any of them conflict, unless
+// duplicates are allowed.
+func deduplicate(infos []*resource.Info, allowDuplicates bool) ([]*resource.Info, error) {
+	infos, conflicts := DeduplicateInfos(infos)
+	if len(conflicts) > 0 && !allowDuplicates {
+		return nil, DuplicateResourcesError{Conflicts: conflicts}
+	}
+	return infos, nil
+}
+
+// usesGenerateName returns true if the resource has no name and its
+// name is generated by the API server from the generateName.
+func usesGenerateName(info *resource.Info) bool {
+	acc, err := meta.Accessor(info.Object)
+	if err != nil {
+		return false
+	}
+	return acc.GetName() == "" && acc.GetGenerateName() != ""
+}
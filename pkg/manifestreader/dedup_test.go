@@ -0,0 +1,180 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestDeduplicateInfos(t *testing.T) {
+	cm := func(name, value, source string) *resource.Info {
+		return &resource.Info{
+			Source: source,
+			Object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name":      name,
+						"namespace": "default",
+					},
+					"data": map[string]interface{}{
+						"key": value,
+					},
+				},
+			},
+		}
+	}
+	generated := func(source string) *resource.Info {
+		return &resource.Info{
+			Source: source,
+			Object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"generateName": "gen-",
+						"namespace":    "default",
+					},
+					"data": map[string]interface{}{
+						"key": source,
+					},
+				},
+			},
+		}
+	}
+	id := func(name string) object.ObjMetadata {
+		return object.ObjMetadata{
+			GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+			Name:      name,
+			Namespace: "default",
+		}
+	}
+
+	testCases := map[string]struct {
+		infos []*resource.Info
+
+		expectedSources   []string
+		expectedConflicts []DuplicateConflict
+	}{
+		"no duplicates": {
+			infos: []*resource.Info{
+				cm("a", "1", "a.yaml"),
+				cm("b", "1", "b.yaml"),
+			},
+			expectedSources: []string{"a.yaml", "b.yaml"},
+		},
+		"identical duplicates": {
+			infos: []*resource.Info{
+				cm("a", "1", "a.yaml"),
+				cm("b", "1", "b.yaml"),
+				cm("a", "1", "c.yaml"),
+			},
+			expectedSources: []string{"c.yaml", "b.yaml"},
+		},
+		"conflicting duplicates": {
+			infos: []*resource.Info{
+				cm("a", "1", "a.yaml"),
+				cm("b", "1", "b.yaml"),
+				cm("a", "2", "c.yaml"),
+			},
+			expectedSources: []string{"c.yaml", "b.yaml"},
+			expectedConflicts: []DuplicateConflict{
+				{
+					ID:           id("a"),
+					FirstSource:  "a.yaml",
+					SecondSource: "c.yaml",
+				},
+			},
+		},
+		"resources with generateName are not duplicates": {
+			infos: []*resource.Info{
+				generated("a.yaml"),
+				generated("b.yaml"),
+			},
+			expectedSources: []string{"a.yaml", "b.yaml"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			infos, conflicts := DeduplicateInfos(tc.infos)
+
+			var sources []string
+			for _, info := range infos {
+				sources = append(sources, info.Source)
+			}
+			assert.Equal(t, tc.expectedSources, sources)
+			assert.Equal(t, tc.expectedConflicts, conflicts)
+		})
+	}
+}
+
+func TestPathManifestReader_ReadDuplicates(t *testing.T) {
+	testCases := map[string]struct {
+		allowDuplicates bool
+
+		expectedError    bool
+		expectedReplicas int64
+	}{
+		"conflicting duplicates are an error": {
+			expectedError: true,
+		},
+		"the last occurrence is kept if duplicates are allowed": {
+			allowDuplicates:  true,
+			expectedReplicas: 2,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			dir, err := ioutil.TempDir("", "path-reader-test")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.yaml"), []byte(depManifest), 0600))
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.yaml"),
+				[]byte(strings.Replace(depManifest, "replicas: 1", "replicas: 2", 1)), 0600))
+
+			infos, err := (&PathManifestReader{
+				Path: dir,
+				ReaderOptions: ReaderOptions{
+					Factory:         tf,
+					Namespace:       "foo",
+					AllowDuplicates: tc.allowDuplicates,
+				},
+			}).Read()
+
+			if tc.expectedError {
+				if assert.IsType(t, DuplicateResourcesError{}, err) {
+					conflicts := err.(DuplicateResourcesError).Conflicts
+					if assert.Len(t, conflicts, 1) {
+						assert.Equal(t, filepath.Join(dir, "a.yaml"), conflicts[0].FirstSource)
+						assert.Equal(t, filepath.Join(dir, "b.yaml"), conflicts[0].SecondSource)
+					}
+				}
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, infos, 1) {
+				replicas, _, _ := unstructured.NestedInt64(
+					infos[0].Object.(*unstructured.Unstructured).Object, "spec", "replicas")
+				assert.Equal(t, tc.expectedReplicas, replicas)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// EnvVarTransformer is a Transformer that replaces ${VAR_NAME}
+// placeholders in all string values of the manifests with the value
+// of the corresponding environment variable. Placeholders for
+// variables that are not set result in an error, unless
+// AllowUndefined is true in which case they are left as-is.
+type EnvVarTransformer struct {
+	AllowUndefined bool
+
+	// lookupEnv is used for looking up the environment variables.
+	// Defaults to os.LookupEnv if not set.
+	lookupEnv func(string) (string, bool)
+}
+
+var _ Transformer = &EnvVarTransformer{}
+
+// NewEnvVarTransformer returns an EnvVarTransformer that looks up
+// the variables in the environment of the current process.
+func NewEnvVarTransformer(allowUndefined bool) *EnvVarTransformer {
+	return &EnvVarTransformer{
+		AllowUndefined: allowUndefined,
+	}
+}
+
+// Transform substitutes the placeholders in every info.
+func (e *EnvVarTransformer) Transform(infos []*resource.Info) ([]*resource.Info, error) {
+	for _, info := range infos {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("resource %s is not an Unstructured", info.Name)
+		}
+		content, err := e.substitute(u.Object)
+		if err != nil {
+			return nil, fmt.Errorf("error substituting variables in %s %s: %v",
+				u.GetKind(), info.Name, err)
+		}
+		u.Object = content.(map[string]interface{})
+		info.Name = u.GetName()
+		info.Namespace = u.GetNamespace()
+	}
+	return infos, nil
+}
+
+// substitute walks the value recursively and returns it with the
+// placeholders in all strings replaced.
+func (e *EnvVarTransformer) substitute(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			res, err := e.substitute(val)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = res
+		}
+		return v, nil
+	case []interface{}:
+		for i, val := range v {
+			res, err := e.substitute(val)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = res
+		}
+		return v, nil
+	case string:
+		return e.substituteString(v)
+	default:
+		return v, nil
+	}
+}
+
+func (e *EnvVarTransformer) substituteString(s string) (string, error) {
+	lookupEnv := e.lookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	var err error
+	res := envVarPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := envVarPattern.FindStringSubmatch(match)[1]
+		val, found := lookupEnv(name)
+		if !found {
+			if !e.AllowUndefined && err == nil {
+				err = fmt.Errorf("environment variable %q is not set", name)
+			}
+			return match
+		}
+		return val
+	})
+	return res, err
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+var envVarManifest = `
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: ${APP_NAME}
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx:${IMAGE_TAG}
+`
+
+func TestEnvVarTransformer(t *testing.T) {
+	testCases := map[string]struct {
+		env            map[string]string
+		allowUndefined bool
+
+		expectedName  string
+		expectedImage string
+		expectedErr   string
+	}{
+		"all variables are substituted": {
+			env: map[string]string{
+				"APP_NAME":  "foo",
+				"IMAGE_TAG": "1.19",
+			},
+			expectedName:  "foo",
+			expectedImage: "nginx:1.19",
+		},
+		"undefined variable is an error": {
+			env: map[string]string{
+				"APP_NAME": "foo",
+			},
+			expectedErr: `environment variable "IMAGE_TAG" is not set`,
+		},
+		"undefined variable is left as-is if allowed": {
+			env: map[string]string{
+				"APP_NAME": "foo",
+			},
+			allowUndefined: true,
+			expectedName:   "foo",
+			expectedImage:  "nginx:${IMAGE_TAG}",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			for _, name := range []string{"APP_NAME", "IMAGE_TAG"} {
+				value, found := tc.env[name]
+				if found {
+					assert.NoError(t, os.Setenv(name, value))
+				} else {
+					assert.NoError(t, os.Unsetenv(name))
+				}
+				defer os.Unsetenv(name)
+			}
+
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			infos, err := (&StreamManifestReader{
+				ReaderName: "testReader",
+				Reader:     strings.NewReader(envVarManifest),
+				ReaderOptions: ReaderOptions{
+					Factory:      tf,
+					Namespace:    "test-ns",
+					Transformers: []Transformer{NewEnvVarTransformer(tc.allowUndefined)},
+				},
+			}).Read()
+			if tc.expectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectedErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) || !assert.Equal(t, 1, len(infos)) {
+				return
+			}
+
+			u := infos[0].Object.(*unstructured.Unstructured)
+			assert.Equal(t, tc.expectedName, u.GetName())
+			assert.Equal(t, tc.expectedName, infos[0].Name)
+			containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+			if assert.Equal(t, 1, len(containers)) {
+				image, _, _ := unstructured.NestedString(containers[0].(map[string]interface{}), "image")
+				assert.Equal(t, tc.expectedImage, image)
+			}
+		})
+	}
+}
+
+// placeholderSchema is a validation.Schema that rejects manifests
+// with variable placeholders.
+type placeholderSchema struct{}
+
+func (placeholderSchema) ValidateBytes(data []byte) error {
+	if strings.Contains(string(data), "${") {
+		return fmt.Errorf("invalid value with placeholder")
+	}
+	return nil
+}
+
+func TestEnvVarTransformerBeforeValidation(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+	defer tf.Cleanup()
+
+	infos, err := (&StreamManifestReader{
+		ReaderName: "testReader",
+		Reader:     strings.NewReader(envVarManifest),
+		ReaderOptions: ReaderOptions{
+			Factory:   tf,
+			Namespace: "test-ns",
+		},
+	}).Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	env := map[string]string{"APP_NAME": "foo", "IMAGE_TAG": "1.19"}
+	transformer := &EnvVarTransformer{
+		lookupEnv: func(name string) (string, bool) {
+			value, found := env[name]
+			return value, found
+		},
+	}
+	// The placeholders are substituted before the manifests are
+	// validated, so the validation sees the actual values.
+	infos, err = transformAndValidate(infos, []Transformer{transformer}, placeholderSchema{})
+	if !assert.NoError(t, err) || !assert.Equal(t, 1, len(infos)) {
+		return
+	}
+	assert.Equal(t, "foo", infos[0].Name)
+
+	// Placeholders left in the manifests still fail the validation.
+	infos[0].Object.(*unstructured.Unstructured).SetName("${APP_NAME}")
+	_, err = transformAndValidate(infos, nil, placeholderSchema{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// namespaceField is the path of a field referencing a namespace. If
+// list is set, it is the path of a list and field is the path of the
+// namespace field in each of its elements. Otherwise field is the path
+// from the root of the resource.
+type namespaceField struct {
+	list  []string
+	field []string
+}
+
+// namespaceReferenceFields are the fields referencing namespaces, in
+// addition to metadata.namespace, that are rewritten by the
+// NamespaceMapTransformer for resources of known kinds.
+var namespaceReferenceFields = map[schema.GroupKind][]namespaceField{
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}: {
+		{list: []string{"subjects"}, field: []string{"namespace"}},
+	},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}: {
+		{list: []string{"subjects"}, field: []string{"namespace"}},
+	},
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: {
+		{list: []string{"webhooks"}, field: []string{"clientConfig", "service", "namespace"}},
+	},
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}: {
+		{list: []string{"webhooks"}, field: []string{"clientConfig", "service", "namespace"}},
+	},
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}: {
+		{field: []string{"spec", "service", "namespace"}},
+	},
+}
+
+var namespaceGK = schema.GroupKind{Group: "", Kind: "Namespace"}
+
+// NamespaceMapTransformer is a Transformer that rewrites namespaces
+// in all resources, for applying the same manifests to differently
+// named namespaces. Namespaces maps the namespace in the manifests to
+// the namespace it is replaced with. Besides metadata.namespace, the
+// names of Namespace resources and the namespace references in the
+// fields listed in namespaceReferenceFields are rewritten.
+type NamespaceMapTransformer struct {
+	Namespaces map[string]string
+}
+
+var _ Transformer = &NamespaceMapTransformer{}
+
+// NewNamespaceMapTransformer returns a NamespaceMapTransformer for the
+// provided namespace mappings in the format <src>=<dst>, or an error
+// if any of them is not in that format.
+func NewNamespaceMapTransformer(mappings []string) (*NamespaceMapTransformer, error) {
+	namespaces := make(map[string]string)
+	for _, m := range mappings {
+		parts := strings.SplitN(m, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid namespace mapping %q, must be <src>=<dst>", m)
+		}
+		namespaces[parts[0]] = parts[1]
+	}
+	return &NamespaceMapTransformer{
+		Namespaces: namespaces,
+	}, nil
+}
+
+// Transform rewrites the namespaces of every info.
+func (n *NamespaceMapTransformer) Transform(infos []*resource.Info) ([]*resource.Info, error) {
+	for _, info := range infos {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if ns, found := n.Namespaces[u.GetNamespace()]; found {
+			u.SetNamespace(ns)
+			info.Namespace = ns
+		}
+		gk := u.GroupVersionKind().GroupKind()
+		if gk == namespaceGK {
+			if ns, found := n.Namespaces[u.GetName()]; found {
+				u.SetName(ns)
+				info.Name = ns
+			}
+		}
+		for _, f := range namespaceReferenceFields[gk] {
+			if err := n.rewrite(u, f); err != nil {
+				return nil, fmt.Errorf("error rewriting namespaces in %s %s: %v",
+					u.GetKind(), info.Name, err)
+			}
+		}
+	}
+	return infos, nil
+}
+
+// rewrite rewrites the namespace references in the provided field.
+func (n *NamespaceMapTransformer) rewrite(u *unstructured.Unstructured, f namespaceField) error {
+	if len(f.list) == 0 {
+		n.rewriteField(u.Object, f.field)
+		return nil
+	}
+	items, found, err := unstructured.NestedSlice(u.Object, f.list...)
+	if err != nil || !found {
+		return err
+	}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			n.rewriteField(m, f.field)
+		}
+	}
+	return unstructured.SetNestedSlice(u.Object, items, f.list...)
+}
+
+// rewriteField replaces the namespace at the provided path in obj, if
+// it is set and mapped to another namespace.
+func (n *NamespaceMapTransformer) rewriteField(obj map[string]interface{}, fields []string) {
+	value, found, err := unstructured.NestedString(obj, fields...)
+	if err != nil || !found {
+		return
+	}
+	if ns, found := n.Namespaces[value]; found {
+		_ = unstructured.SetNestedField(obj, ns, fields...)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+var namespaceMapManifest = `
+kind: Namespace
+apiVersion: v1
+metadata:
+  name: staging
+---
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: cm
+  namespace: staging
+---
+kind: RoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: rb
+  namespace: staging
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: role
+subjects:
+- kind: ServiceAccount
+  name: app
+  namespace: staging
+- kind: ServiceAccount
+  name: monitoring
+  namespace: other
+- kind: User
+  apiGroup: rbac.authorization.k8s.io
+  name: jane
+`
+
+func TestNamespaceMapTransformer(t *testing.T) {
+	testCases := map[string]struct {
+		mappings []string
+
+		expectedNamespaces        map[string]string
+		expectedSubjectNamespaces []interface{}
+		expectedErr               bool
+	}{
+		"mapped namespaces are rewritten": {
+			mappings: []string{"staging=production"},
+			expectedNamespaces: map[string]string{
+				"Namespace/production": "",
+				"ConfigMap/cm":         "production",
+				"RoleBinding/rb":       "production",
+			},
+			expectedSubjectNamespaces: []interface{}{"production", "other", nil},
+		},
+		"unmapped namespaces are unchanged": {
+			mappings: []string{"dev=test"},
+			expectedNamespaces: map[string]string{
+				"Namespace/staging": "",
+				"ConfigMap/cm":      "staging",
+				"RoleBinding/rb":    "staging",
+			},
+			expectedSubjectNamespaces: []interface{}{"staging", "other", nil},
+		},
+		"mapping without destination is an error": {
+			mappings:    []string{"staging="},
+			expectedErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			namespaceMapTransformer, err := NewNamespaceMapTransformer(tc.mappings)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			infos, err := (&StreamManifestReader{
+				ReaderName: "testReader",
+				Reader:     strings.NewReader(namespaceMapManifest),
+				ReaderOptions: ReaderOptions{
+					Factory:      tf,
+					Namespace:    "test-ns",
+					Transformers: []Transformer{namespaceMapTransformer},
+				},
+			}).Read()
+			if !assert.NoError(t, err) || !assert.Equal(t, 3, len(infos)) {
+				return
+			}
+
+			namespaces := make(map[string]string)
+			for _, info := range infos {
+				u := info.Object.(*unstructured.Unstructured)
+				assert.Equal(t, u.GetNamespace(), info.Namespace)
+				assert.Equal(t, u.GetName(), info.Name)
+				namespaces[u.GetKind()+"/"+u.GetName()] = u.GetNamespace()
+				if u.GetKind() != "RoleBinding" {
+					continue
+				}
+				subjects, _, err := unstructured.NestedSlice(u.Object, "subjects")
+				assert.NoError(t, err)
+				var subjectNamespaces []interface{}
+				for _, s := range subjects {
+					subjectNamespaces = append(subjectNamespaces, s.(map[string]interface{})["namespace"])
+				}
+				assert.Equal(t, tc.expectedSubjectNamespaces, subjectNamespaces)
+			}
+			assert.Equal(t, tc.expectedNamespaces, namespaces)
+		})
+	}
+}
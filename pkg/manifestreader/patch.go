@@ -0,0 +1,77 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// JSONPatchTransformer is a Transformer that applies a RFC 6902
+// JSON Patch to the manifests. If Kinds is not empty, the patch is only
+// applied to resources of the given kinds.
+type JSONPatchTransformer struct {
+	Patch jsonpatch.Patch
+	Kinds []string
+}
+
+var _ Transformer = &JSONPatchTransformer{}
+
+// NewJSONPatchTransformer returns a JSONPatchTransformer for the provided
+// JSON Patch document, or an error if the document is not a valid
+// JSON Patch.
+func NewJSONPatchTransformer(patch string, kinds []string) (*JSONPatchTransformer, error) {
+	p, err := jsonpatch.DecodePatch([]byte(patch))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %v", err)
+	}
+	return &JSONPatchTransformer{
+		Patch: p,
+		Kinds: kinds,
+	}, nil
+}
+
+// Transform applies the patch to every info of a matching kind.
+func (j *JSONPatchTransformer) Transform(infos []*resource.Info) ([]*resource.Info, error) {
+	for _, info := range infos {
+		kind := info.Object.GetObjectKind().GroupVersionKind().Kind
+		if !j.matchesKind(kind) {
+			continue
+		}
+		doc, err := json.Marshal(info.Object)
+		if err != nil {
+			return nil, err
+		}
+		patched, err := j.Patch.Apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("error patching %s %s: %v", kind, info.Name, err)
+		}
+		u := &unstructured.Unstructured{}
+		if err := u.UnmarshalJSON(patched); err != nil {
+			return nil, err
+		}
+		info.Object = u
+		info.Name = u.GetName()
+		info.Namespace = u.GetNamespace()
+	}
+	return infos, nil
+}
+
+// matchesKind returns true if the patch should be applied to
+// resources of the given kind.
+func (j *JSONPatchTransformer) matchesKind(kind string) bool {
+	if len(j.Kinds) == 0 {
+		return true
+	}
+	for _, k := range j.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"sigs.k8s.io/cli-utils/pkg/common"
+)
+
+var cmManifest = `
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: bar
+data:
+  replicas: "1"
+`
+
+func TestJSONPatchTransformer(t *testing.T) {
+	testCases := map[string]struct {
+		patch string
+		kinds []string
+
+		expectedReplicas int64
+		expectedCMData   string
+		expectedErr      bool
+	}{
+		"patch limited to kind only changes that kind": {
+			patch:            `[{"op": "replace", "path": "/spec/replicas", "value": 3}]`,
+			kinds:            []string{"Deployment"},
+			expectedReplicas: 3,
+			expectedCMData:   "1",
+		},
+		"patch failing on other kinds returns error": {
+			patch:       `[{"op": "replace", "path": "/spec/replicas", "value": 3}]`,
+			expectedErr: true,
+		},
+		"patch applies to all kinds without filter": {
+			patch:            `[{"op": "add", "path": "/metadata/labels", "value": {"app": "foo"}}]`,
+			expectedReplicas: 1,
+			expectedCMData:   "1",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			patchTransformer, err := NewJSONPatchTransformer(tc.patch, tc.kinds)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			infos, err := (&StreamManifestReader{
+				ReaderName: "testReader",
+				Reader:     strings.NewReader(depManifest + "---" + cmManifest),
+				ReaderOptions: ReaderOptions{
+					Factory:      tf,
+					Namespace:    "test-ns",
+					Transformers: []Transformer{patchTransformer},
+				},
+			}).Read()
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) || !assert.Equal(t, 2, len(infos)) {
+				return
+			}
+
+			for _, info := range infos {
+				u := info.Object.(*unstructured.Unstructured)
+				switch u.GetKind() {
+				case "Deployment":
+					replicas, _, _ := unstructured.NestedInt64(u.Object, "spec", "replicas")
+					assert.Equal(t, tc.expectedReplicas, replicas)
+				case "ConfigMap":
+					data, _, _ := unstructured.NestedString(u.Object, "data", "replicas")
+					assert.Equal(t, tc.expectedCMData, data)
+				}
+				assert.Equal(t, "test-ns", info.Namespace)
+			}
+		})
+	}
+}
+
+func TestNewJSONPatchTransformerInvalidPatch(t *testing.T) {
+	_, err := NewJSONPatchTransformer(`{"op": "replace"}`, nil)
+	assert.Error(t, err)
+}
+
+var inventoryManifest = `
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: inventory
+  labels:
+    cli-utils.sigs.k8s.io/inventory-id: test-id
+`
+
+func TestJSONPatchTransformerSkipsInventory(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+	defer tf.Cleanup()
+
+	// The patch replaces all the labels, which would remove the
+	// inventory label if it was applied to the inventory object.
+	patchTransformer, err := NewJSONPatchTransformer(
+		`[{"op": "add", "path": "/metadata/labels", "value": {"app": "foo"}}]`, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	infos, err := (&StreamManifestReader{
+		ReaderName: "testReader",
+		Reader:     strings.NewReader(depManifest + "---" + inventoryManifest),
+		ReaderOptions: ReaderOptions{
+			Factory:      tf,
+			Namespace:    "test-ns",
+			Transformers: []Transformer{patchTransformer},
+		},
+	}).Read()
+	if !assert.NoError(t, err) || !assert.Equal(t, 2, len(infos)) {
+		return
+	}
+
+	for _, info := range infos {
+		u := info.Object.(*unstructured.Unstructured)
+		switch u.GetKind() {
+		case "Deployment":
+			assert.Equal(t, map[string]string{"app": "foo"}, u.GetLabels())
+		case "ConfigMap":
+			assert.Equal(t, map[string]string{common.InventoryLabel: "test-id"}, u.GetLabels())
+		}
+	}
+}
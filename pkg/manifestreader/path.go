@@ -4,12 +4,23 @@
 package manifestreader
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	"k8s.io/cli-runtime/pkg/resource"
 )
 
+// stdinPath is the path that means the manifests should
+// be read from stdin.
+const stdinPath = "-"
+
 // PathManifestReader reads manifests from the provided path
 // and returns them as Info objects. The returned Infos will not have
-// client or mapping set.
+// client or mapping set. If the path is "-", the manifests are
+// read from the Stdin of the ReaderOptions.
 type PathManifestReader struct {
 	Path string
 
@@ -18,37 +29,153 @@ type PathManifestReader struct {
 
 // Read reads the manifests and returns them as Info objects.
 func (p *PathManifestReader) Read() ([]*resource.Info, error) {
+	return p.ReadWithContext(context.Background())
+}
+
+// ReadWithContext reads the manifests and returns them as Info objects.
+// The context is checked before each file is read, and if it has been
+// cancelled, reading stops and the error from the context is returned
+// wrapped.
+func (p *PathManifestReader) ReadWithContext(ctx context.Context) ([]*resource.Info, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("reading manifests from %s: %w", p.Path, err)
+	}
+	if p.Path == stdinPath {
+		if p.Stdin == nil {
+			return nil, fmt.Errorf("reading manifests from stdin requires ReaderOptions.Stdin to be set")
+		}
+		return (&StreamManifestReader{
+			ReaderName:    "stdin",
+			Reader:        p.Stdin,
+			ReaderOptions: p.ReaderOptions,
+		}).Read()
+	}
+
 	validator, err := p.Factory.Validator(p.Validate)
 	if err != nil {
 		return nil, err
 	}
 
-	fileNameOptions := &resource.FilenameOptions{
-		Filenames: []string{p.Path},
-		Recursive: true,
+	paths, err := manifestPaths(p.Path, !p.NoRecursive)
+	if err != nil {
+		return nil, err
 	}
 
-	enforceNamespace := false
-	result := p.Factory.NewBuilder().
-		Local().
-		Unstructured().
-		Schema(validator).
-		ContinueOnError().
-		FilenameParam(enforceNamespace, fileNameOptions).
-		Flatten().
-		Do()
+	var infos []*resource.Info
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("reading manifests from %s: %w", p.Path, err)
+		}
+		fileNameOptions := &resource.FilenameOptions{
+			Filenames: []string{path},
+		}
 
-	if err := result.Err(); err != nil {
+		enforceNamespace := false
+		result := p.Factory.NewBuilder().
+			Local().
+			Unstructured().
+			ContinueOnError().
+			FilenameParam(enforceNamespace, fileNameOptions).
+			Flatten().
+			Do()
+
+		if err := result.Err(); err != nil {
+			return nil, err
+		}
+		fileInfos, err := result.Infos()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, fileInfos...)
+	}
+
+	if err := overrideAPIVersions(infos, p.APIVersionOverrides); err != nil {
 		return nil, err
 	}
-	infos, err := result.Infos()
+
+	err = setNamespaces(p.Factory, infos, p.Namespace, p.EnforceNamespace,
+		p.StrictNamespaceValidation)
 	if err != nil {
 		return nil, err
 	}
 
-	err = setNamespaces(p.Factory, infos, p.Namespace, p.EnforceNamespace)
+	infos, err = deduplicate(infos, p.AllowDuplicates)
 	if err != nil {
 		return nil, err
 	}
-	return infos, nil
+	return transformAndValidate(infos, p.Transformers, validator)
+}
+
+// manifestPaths returns the paths of all the manifest files found
+// in the provided path. If the path is a file, it is returned
+// regardless of its extension, otherwise only files with one of the
+// extensions in resource.FileExtensions are included. Subdirectories
+// are only read if recursive is true.
+func manifestPaths(root string, recursive bool) ([]string, error) {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{root}, nil
+	}
+	var paths []string
+	err = walkManifestDir(root, recursive, map[string]bool{}, &paths)
+	return paths, err
+}
+
+// walkManifestDir adds the paths of the manifest files in dir to paths.
+// Symlinks are followed, and ancestors holds the resolved paths of the
+// directories currently being walked so a symlink cycle results in an
+// error rather than an endless walk.
+func walkManifestDir(dir string, recursive bool, ancestors map[string]bool, paths *[]string) error {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return err
+	}
+	if ancestors[resolved] {
+		return fmt.Errorf("symlink cycle detected at %s", dir)
+	}
+	ancestors[resolved] = true
+	defer delete(ancestors, resolved)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		// Stat follows symlinks, unlike the FileInfo from ReadDir.
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if !recursive {
+				continue
+			}
+			if err := walkManifestDir(path, recursive, ancestors, paths); err != nil {
+				return err
+			}
+			continue
+		}
+		if hasManifestExtension(path) {
+			*paths = append(*paths, path)
+		}
+	}
+	return nil
+}
+
+func hasManifestExtension(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range resource.FileExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
 }
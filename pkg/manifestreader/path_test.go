@@ -4,9 +4,15 @@
 package manifestreader
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
@@ -66,3 +72,153 @@ func TestPathManifestReader_Read(t *testing.T) {
 		})
 	}
 }
+
+func TestPathManifestReader_ReadStdin(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+	defer tf.Cleanup()
+
+	infos, err := (&PathManifestReader{
+		Path: "-",
+		ReaderOptions: ReaderOptions{
+			Factory:   tf,
+			Namespace: "foo",
+			Stdin:     strings.NewReader(depManifest),
+		},
+	}).Read()
+
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(infos)) {
+		assert.Equal(t, "foo", infos[0].Namespace)
+		assert.Equal(t, "foo", infos[0].Name)
+	}
+}
+
+func TestPathManifestReader_ReadStdinNotSet(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+	defer tf.Cleanup()
+
+	_, err := (&PathManifestReader{
+		Path: "-",
+		ReaderOptions: ReaderOptions{
+			Factory: tf,
+		},
+	}).Read()
+
+	assert.Error(t, err)
+}
+
+func TestPathManifestReader_ReadWithContextCancelled(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+	defer tf.Cleanup()
+
+	dir, err := ioutil.TempDir("", "path-reader-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for i := 0; i < 100; i++ {
+		p := filepath.Join(dir, fmt.Sprintf("dep-%d.yaml", i))
+		err := ioutil.WriteFile(p, []byte(depManifest), 0600)
+		assert.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	_, err = (&PathManifestReader{
+		Path: dir,
+		ReaderOptions: ReaderOptions{
+			Factory:   tf,
+			Namespace: "foo",
+		},
+	}).ReadWithContext(ctx)
+
+	assert.True(t, errors.Is(err, context.Canceled), "expected context.Canceled, got %v", err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestPathManifestReader_ReadRecursive(t *testing.T) {
+	testCases := map[string]struct {
+		noRecursive bool
+		symlink     bool
+
+		infosCount int
+	}{
+		"subdirectories are read by default": {
+			infosCount: 3,
+		},
+		"symlinked directories are followed": {
+			symlink:    true,
+			infosCount: 4,
+		},
+		"only the top-level directory is read when not recursive": {
+			noRecursive: true,
+			infosCount:  1,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			dir, err := ioutil.TempDir("", "path-reader-test")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+			linked, err := ioutil.TempDir("", "path-reader-test-linked")
+			assert.NoError(t, err)
+			defer os.RemoveAll(linked)
+
+			assert.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0700))
+			for i, p := range []string{
+				filepath.Join(dir, "dep.yaml"),
+				filepath.Join(dir, "a", "dep.yaml"),
+				filepath.Join(dir, "a", "b", "dep.yaml"),
+				filepath.Join(linked, "dep.yaml"),
+			} {
+				// Every manifest has a different name, so they are not
+				// duplicates of each other.
+				manifest := strings.Replace(depManifest, "name: foo", fmt.Sprintf("name: foo-%d", i), 1)
+				assert.NoError(t, ioutil.WriteFile(p, []byte(manifest), 0600))
+			}
+			if tc.symlink {
+				assert.NoError(t, os.Symlink(linked, filepath.Join(dir, "a", "linked")))
+			}
+
+			infos, err := (&PathManifestReader{
+				Path: dir,
+				ReaderOptions: ReaderOptions{
+					Factory:     tf,
+					Namespace:   "foo",
+					NoRecursive: tc.noRecursive,
+				},
+			}).Read()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.infosCount, len(infos))
+		})
+	}
+}
+
+func TestPathManifestReader_ReadRecursiveSymlinkCycle(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+	defer tf.Cleanup()
+
+	dir, err := ioutil.TempDir("", "path-reader-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "a"), 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "dep.yaml"), []byte(depManifest), 0600))
+	assert.NoError(t, os.Symlink(dir, filepath.Join(dir, "a", "loop")))
+
+	_, err = (&PathManifestReader{
+		Path: dir,
+		ReaderOptions: ReaderOptions{
+			Factory:   tf,
+			Namespace: "foo",
+		},
+	}).Read()
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "symlink cycle")
+	}
+}
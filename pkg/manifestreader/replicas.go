@@ -0,0 +1,71 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// replicaKinds are the kinds of resources whose replicas are
+// updated by the ReplicaTransformer.
+var replicaKinds = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+}
+
+// ReplicaTransformer is a Transformer that overrides the number of
+// replicas of Deployments and StatefulSets. Replicas maps resource
+// names to the new number of replicas.
+type ReplicaTransformer struct {
+	Replicas map[string]int64
+}
+
+var _ Transformer = &ReplicaTransformer{}
+
+// NewReplicaTransformer returns a ReplicaTransformer for the provided
+// replica overrides in the format <name>=<count>, or an error if any
+// of them is not in that format.
+func NewReplicaTransformer(overrides []string) (*ReplicaTransformer, error) {
+	replicas := make(map[string]int64)
+	for _, o := range overrides {
+		parts := strings.SplitN(o, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid replica override %q, must be <name>=<count>", o)
+		}
+		count, err := strconv.ParseInt(parts[1], 10, 32)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid replica override %q, count must be a non-negative number", o)
+		}
+		replicas[parts[0]] = count
+	}
+	return &ReplicaTransformer{
+		Replicas: replicas,
+	}, nil
+}
+
+// Transform sets the replicas of every Deployment and StatefulSet
+// info with a matching name.
+func (r *ReplicaTransformer) Transform(infos []*resource.Info) ([]*resource.Info, error) {
+	for _, info := range infos {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok || !replicaKinds[u.GroupVersionKind().GroupKind()] {
+			continue
+		}
+		count, found := r.Replicas[u.GetName()]
+		if !found {
+			continue
+		}
+		if err := unstructured.SetNestedField(u.Object, count, "spec", "replicas"); err != nil {
+			return nil, fmt.Errorf("error setting replicas in %s %s: %v",
+				u.GetKind(), info.Name, err)
+		}
+	}
+	return infos, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+var statefulSetManifest = `
+kind: StatefulSet
+apiVersion: apps/v1
+metadata:
+  name: db
+spec:
+  replicas: 1
+`
+
+func TestReplicaTransformer(t *testing.T) {
+	testCases := map[string]struct {
+		overrides []string
+
+		expectedReplicas map[string]int64
+		expectedErr      bool
+	}{
+		"replicas of all matching resources are updated": {
+			overrides: []string{"foo=3", "db=5"},
+			expectedReplicas: map[string]int64{
+				"foo": 3,
+				"db":  5,
+			},
+		},
+		"override for unknown name does nothing": {
+			overrides: []string{"unknown=3"},
+			expectedReplicas: map[string]int64{
+				"foo": 1,
+				"db":  1,
+			},
+		},
+		"override for a ConfigMap name does nothing": {
+			overrides: []string{"bar=3"},
+			expectedReplicas: map[string]int64{
+				"foo": 1,
+				"db":  1,
+			},
+		},
+		"override without count is an error": {
+			overrides:   []string{"foo="},
+			expectedErr: true,
+		},
+		"negative count is an error": {
+			overrides:   []string{"foo=-1"},
+			expectedErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			replicaTransformer, err := NewReplicaTransformer(tc.overrides)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			infos, err := (&StreamManifestReader{
+				ReaderName: "testReader",
+				Reader: strings.NewReader(twoContainerDepManifest + "---" + statefulSetManifest +
+					"---" + cmManifest),
+				ReaderOptions: ReaderOptions{
+					Factory:      tf,
+					Namespace:    "test-ns",
+					Transformers: []Transformer{replicaTransformer},
+				},
+			}).Read()
+			if !assert.NoError(t, err) || !assert.Equal(t, 3, len(infos)) {
+				return
+			}
+
+			replicas := make(map[string]int64)
+			for _, info := range infos {
+				u := info.Object.(*unstructured.Unstructured)
+				if u.GetKind() == "ConfigMap" {
+					data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+					assert.Equal(t, map[string]string{"replicas": "1"}, data)
+					continue
+				}
+				count, _, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+				assert.NoError(t, err)
+				replicas[u.GetName()] = count
+			}
+			assert.Equal(t, tc.expectedReplicas, replicas)
+		})
+	}
+}
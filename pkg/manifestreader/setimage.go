@@ -0,0 +1,85 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// workloadKinds are the kinds of resources whose containers are
+// updated by the SetImageTransformer.
+var workloadKinds = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+	{Group: "apps", Kind: "DaemonSet"}:   true,
+}
+
+// SetImageTransformer is a Transformer that overrides the image of
+// containers in the pod templates of all Deployments, StatefulSets and
+// DaemonSets. Images maps container names to the new image.
+type SetImageTransformer struct {
+	Images map[string]string
+}
+
+var _ Transformer = &SetImageTransformer{}
+
+// NewSetImageTransformer returns a SetImageTransformer for the provided
+// image overrides in the format <container>=<image>, or an error if
+// any of them is not in that format.
+func NewSetImageTransformer(overrides []string) (*SetImageTransformer, error) {
+	images := make(map[string]string)
+	for _, o := range overrides {
+		parts := strings.SplitN(o, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid image override %q, must be <container>=<image>", o)
+		}
+		images[parts[0]] = parts[1]
+	}
+	return &SetImageTransformer{
+		Images: images,
+	}, nil
+}
+
+// Transform updates the images of matching containers in every
+// workload info.
+func (s *SetImageTransformer) Transform(infos []*resource.Info) ([]*resource.Info, error) {
+	for _, info := range infos {
+		u, ok := info.Object.(*unstructured.Unstructured)
+		if !ok || !workloadKinds[u.GroupVersionKind().GroupKind()] {
+			continue
+		}
+		for _, field := range []string{"containers", "initContainers"} {
+			if err := s.setImages(u, "spec", "template", "spec", field); err != nil {
+				return nil, fmt.Errorf("error setting images in %s %s: %v",
+					u.GetKind(), info.Name, err)
+			}
+		}
+	}
+	return infos, nil
+}
+
+// setImages updates the images of the containers in the list
+// found at the provided path.
+func (s *SetImageTransformer) setImages(u *unstructured.Unstructured, fields ...string) error {
+	containers, found, err := unstructured.NestedSlice(u.Object, fields...)
+	if err != nil || !found {
+		return err
+	}
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(container, "name")
+		if image, found := s.Images[name]; found {
+			container["image"] = image
+		}
+	}
+	return unstructured.SetNestedSlice(u.Object, containers, fields...)
+}
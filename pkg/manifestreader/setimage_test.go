@@ -0,0 +1,105 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package manifestreader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+var twoContainerDepManifest = `
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: foo
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: myimage:v1
+      - name: sidecar
+        image: proxy:1.0
+      - name: other
+        image: other:1.0
+`
+
+func TestSetImageTransformer(t *testing.T) {
+	testCases := map[string]struct {
+		overrides []string
+
+		expectedImages map[string]string
+		expectedErr    bool
+	}{
+		"images of all matching containers are updated": {
+			overrides: []string{"app=myimage:v2", "sidecar=proxy:1.1"},
+			expectedImages: map[string]string{
+				"app":     "myimage:v2",
+				"sidecar": "proxy:1.1",
+				"other":   "other:1.0",
+			},
+		},
+		"override for unknown container does nothing": {
+			overrides: []string{"unknown=foo:1.0"},
+			expectedImages: map[string]string{
+				"app":     "myimage:v1",
+				"sidecar": "proxy:1.0",
+				"other":   "other:1.0",
+			},
+		},
+		"override without image is an error": {
+			overrides:   []string{"app="},
+			expectedErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("test-ns")
+			defer tf.Cleanup()
+
+			setImageTransformer, err := NewSetImageTransformer(tc.overrides)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			infos, err := (&StreamManifestReader{
+				ReaderName: "testReader",
+				Reader:     strings.NewReader(twoContainerDepManifest + "---" + cmManifest),
+				ReaderOptions: ReaderOptions{
+					Factory:      tf,
+					Namespace:    "test-ns",
+					Transformers: []Transformer{setImageTransformer},
+				},
+			}).Read()
+			if !assert.NoError(t, err) || !assert.Equal(t, 2, len(infos)) {
+				return
+			}
+
+			for _, info := range infos {
+				u := info.Object.(*unstructured.Unstructured)
+				if u.GetKind() != "Deployment" {
+					continue
+				}
+				containers, _, err := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+				assert.NoError(t, err)
+				images := make(map[string]string)
+				for _, c := range containers {
+					container := c.(map[string]interface{})
+					images[container["name"].(string)] = container["image"].(string)
+				}
+				assert.Equal(t, tc.expectedImages, images)
+			}
+		})
+	}
+}
@@ -29,7 +29,6 @@ func (r *StreamManifestReader) Read() ([]*resource.Info, error) {
 	result := r.Factory.NewBuilder().
 		Local().
 		Unstructured().
-		Schema(validator).
 		ContinueOnError().
 		Stream(r.Reader, r.ReaderName).
 		Flatten().
@@ -43,9 +42,19 @@ func (r *StreamManifestReader) Read() ([]*resource.Info, error) {
 		return nil, err
 	}
 
-	err = setNamespaces(r.Factory, infos, r.Namespace, r.EnforceNamespace)
+	if err := overrideAPIVersions(infos, r.APIVersionOverrides); err != nil {
+		return nil, err
+	}
+
+	err = setNamespaces(r.Factory, infos, r.Namespace, r.EnforceNamespace,
+		r.StrictNamespaceValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err = deduplicate(infos, r.AllowDuplicates)
 	if err != nil {
 		return nil, err
 	}
-	return infos, nil
+	return transformAndValidate(infos, r.Transformers, validator)
 }
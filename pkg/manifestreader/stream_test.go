@@ -37,6 +37,14 @@ func TestStreamManifestReader_Read(t *testing.T) {
 			namespace:        "foo",
 			enforceNamespace: true,
 
+			infosCount: 1,
+			namespaces: []string{"foo"},
+		},
+		"identical duplicates are removed": {
+			manifests:        depManifest + "\n---\n" + depManifest,
+			namespace:        "foo",
+			enforceNamespace: true,
+
 			infosCount: 1,
 			namespaces: []string{"foo"},
 		},
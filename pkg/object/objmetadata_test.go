@@ -208,3 +208,45 @@ func TestParseObjMetadata(t *testing.T) {
 		}
 	}
 }
+
+// TestObjMetadataRoundTrip verifies that the string stored in the
+// inventory object for an ObjMetadata is parsed back to the same
+// ObjMetadata.
+func TestObjMetadataRoundTrip(t *testing.T) {
+	tests := map[string]ObjMetadata{
+		"namespaced object": {
+			Namespace: "test-namespace",
+			Name:      "test-name",
+			GroupKind: schema.GroupKind{
+				Group: "apps",
+				Kind:  "Deployment",
+			},
+		},
+		"cluster-scoped object": {
+			Name: "test-name",
+			GroupKind: schema.GroupKind{
+				Group: "rbac.authorization.k8s.io",
+				Kind:  "ClusterRole",
+			},
+		},
+		"object in the core group": {
+			Namespace: "test-namespace",
+			Name:      "test-name",
+			GroupKind: schema.GroupKind{
+				Kind: "ConfigMap",
+			},
+		},
+	}
+
+	for name, obj := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := ParseObjMetadata(obj.String())
+			if err != nil {
+				t.Fatalf("Unexpected error parsing (%s): %s", obj.String(), err)
+			}
+			if !obj.Equals(parsed) {
+				t.Errorf("Expected (%s), got (%s)", obj, parsed)
+			}
+		})
+	}
+}
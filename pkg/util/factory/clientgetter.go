@@ -41,3 +41,39 @@ func (c *CachingRESTClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
 func (c *CachingRESTClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
 	return c.Delegate.ToRawKubeConfigLoader()
 }
+
+// ThrottledRESTClientGetter sets the QPS and Burst of the rate limiter
+// on the rest config returned by the Delegate. Zero values leave the
+// settings of the Delegate unchanged.
+type ThrottledRESTClientGetter struct {
+	Delegate genericclioptions.RESTClientGetter
+
+	QPS   float32
+	Burst int
+}
+
+func (t *ThrottledRESTClientGetter) ToRESTConfig() (*rest.Config, error) {
+	config, err := t.Delegate.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	if t.QPS > 0 {
+		config.QPS = t.QPS
+	}
+	if t.Burst > 0 {
+		config.Burst = t.Burst
+	}
+	return config, nil
+}
+
+func (t *ThrottledRESTClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	return t.Delegate.ToDiscoveryClient()
+}
+
+func (t *ThrottledRESTClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	return t.Delegate.ToRESTMapper()
+}
+
+func (t *ThrottledRESTClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return t.Delegate.ToRawKubeConfigLoader()
+}
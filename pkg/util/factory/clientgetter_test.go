@@ -0,0 +1,53 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package factory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func TestThrottledRESTClientGetter(t *testing.T) {
+	testCases := map[string]struct {
+		qps   float32
+		burst int
+
+		expectedQPS   float32
+		expectedBurst int
+	}{
+		"values are set on the rest config": {
+			qps:           50,
+			burst:         100,
+			expectedQPS:   50,
+			expectedBurst: 100,
+		},
+		"zero values keep the delegate config": {
+			expectedQPS:   0,
+			expectedBurst: 0,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory()
+			defer tf.Cleanup()
+
+			f := cmdutil.NewFactory(&ThrottledRESTClientGetter{
+				Delegate: tf,
+				QPS:      tc.qps,
+				Burst:    tc.burst,
+			})
+
+			config, err := f.ToRESTConfig()
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expectedQPS, config.QPS)
+			assert.Equal(t, tc.expectedBurst, config.Burst)
+		})
+	}
+}
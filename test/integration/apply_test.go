@@ -0,0 +1,178 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// +build integration
+
+package integration
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestApply(t *testing.T) {
+	f, cleanup := newFactory(t)
+	defer cleanup()
+	namespace := newNamespace(t, f)
+
+	infos := readManifests(t, f, namespace, inventoryTemplate("apply"), configMap("cm-a"))
+	events := runApply(t, newApplier(t, f, false), infos, apply.Options{NoPrune: true})
+
+	var created []string
+	for _, e := range events {
+		if e.Type == event.ApplyType && e.ApplyEvent.Type == event.ApplyEventResourceUpdate {
+			assert.Equal(t, event.Created, e.ApplyEvent.Operation)
+			created = append(created, objectName(t, e.ApplyEvent.Object))
+		}
+	}
+	assert.Equal(t, []string{"cm-a"}, created)
+	assert.True(t, configMapExists(t, f, namespace, "cm-a"))
+
+	// Applying the same manifests again leaves the object unchanged.
+	events = runApply(t, newApplier(t, f, false), infos, apply.Options{NoPrune: true})
+	for _, e := range events {
+		if e.Type == event.ApplyType && e.ApplyEvent.Type == event.ApplyEventResourceUpdate {
+			assert.Equal(t, event.Unchanged, e.ApplyEvent.Operation)
+		}
+	}
+}
+
+func TestPruneRemovedResources(t *testing.T) {
+	f, cleanup := newFactory(t)
+	defer cleanup()
+	namespace := newNamespace(t, f)
+
+	infos := readManifests(t, f, namespace, inventoryTemplate("prune"), configMap("cm-a"), configMap("cm-b"))
+	runApply(t, newApplier(t, f, false), infos, apply.Options{})
+	assert.True(t, configMapExists(t, f, namespace, "cm-b"))
+
+	infos = readManifests(t, f, namespace, inventoryTemplate("prune"), configMap("cm-a"))
+	events := runApply(t, newApplier(t, f, false), infos, apply.Options{})
+
+	var pruned []string
+	for _, e := range events {
+		if e.Type == event.PruneType && e.PruneEvent.Type == event.PruneEventResourceUpdate {
+			assert.Equal(t, event.Pruned, e.PruneEvent.Operation)
+			pruned = append(pruned, objectName(t, e.PruneEvent.Object))
+		}
+	}
+	assert.Equal(t, []string{"cm-b"}, pruned)
+	assert.True(t, configMapExists(t, f, namespace, "cm-a"))
+	assert.False(t, configMapExists(t, f, namespace, "cm-b"))
+}
+
+func TestServerSideApply(t *testing.T) {
+	f, cleanup := newFactory(t)
+	defer cleanup()
+	namespace := newNamespace(t, f)
+
+	infos := readManifests(t, f, namespace, inventoryTemplate("server-side"), configMap("cm-a"))
+	events := runApply(t, newApplier(t, f, true), infos, apply.Options{NoPrune: true})
+
+	var applied []string
+	for _, e := range events {
+		if e.Type == event.ApplyType && e.ApplyEvent.Type == event.ApplyEventResourceUpdate {
+			assert.Equal(t, event.ServersideApplied, e.ApplyEvent.Operation)
+			applied = append(applied, objectName(t, e.ApplyEvent.Object))
+		}
+	}
+	assert.Equal(t, []string{"cm-a"}, applied)
+	assert.True(t, configMapExists(t, f, namespace, "cm-a"))
+}
+
+func TestInventoryUpdate(t *testing.T) {
+	f, cleanup := newFactory(t)
+	defer cleanup()
+	namespace := newNamespace(t, f)
+
+	infos := readManifests(t, f, namespace, inventoryTemplate("inventory"), configMap("cm-a"), configMap("cm-b"))
+	runApply(t, newApplier(t, f, false), infos, apply.Options{})
+	template, _ := inventory.FindInventoryObj(infos)
+	assertInventory(t, inventoryObjects(t, f, template),
+		configMapID(namespace, "cm-a"), configMapID(namespace, "cm-b"))
+
+	infos = readManifests(t, f, namespace, inventoryTemplate("inventory"), configMap("cm-b"), configMap("cm-c"))
+	runApply(t, newApplier(t, f, false), infos, apply.Options{})
+	template, _ = inventory.FindInventoryObj(infos)
+	assertInventory(t, inventoryObjects(t, f, template),
+		configMapID(namespace, "cm-b"), configMapID(namespace, "cm-c"))
+}
+
+func TestInventoryAnnotations(t *testing.T) {
+	f, cleanup := newFactory(t)
+	defer cleanup()
+	namespace := newNamespace(t, f)
+
+	infos := readManifests(t, f, namespace, inventoryTemplate("annotations"), configMap("cm-a"))
+	runApply(t, newApplier(t, f, false), infos, apply.Options{
+		NoPrune: true,
+		InventoryAnnotations: map[string]string{
+			"last-apply-commit": "abc123",
+			"applied-by":        "user@example.com",
+		},
+	})
+
+	template, _ := inventory.FindInventoryObj(infos)
+	invClient, err := inventory.NewInventoryClient(f)
+	if !assert.NoError(t, err) {
+		return
+	}
+	invs, err := invClient.GetPreviousInventoryObjects(template)
+	if !assert.NoError(t, err) || !assert.Len(t, invs, 1) {
+		return
+	}
+	annotations := inventory.WrapInventoryObj(invs[0]).GetAnnotations()
+	assert.Equal(t, "abc123", annotations["last-apply-commit"])
+	assert.Equal(t, "user@example.com", annotations["applied-by"])
+}
+
+func TestStatusWaiting(t *testing.T) {
+	f, cleanup := newFactory(t)
+	defer cleanup()
+	namespace := newNamespace(t, f)
+
+	infos := readManifests(t, f, namespace, inventoryTemplate("status"), configMap("cm-a"))
+	events := runApply(t, newApplier(t, f, false), infos, apply.Options{
+		NoPrune:          true,
+		ReconcileTimeout: time.Minute,
+		PollInterval:     time.Second,
+		EmitStatusEvents: true,
+	})
+
+	current := false
+	for _, e := range events {
+		switch e.Type {
+		case event.TimeoutType:
+			t.Errorf("unexpected timeout waiting for resources to reconcile")
+		case event.StatusType:
+			if e.StatusEvent.Resource != nil && e.StatusEvent.Resource.Identifier.Name == "cm-a" &&
+				e.StatusEvent.Resource.Status == status.CurrentStatus {
+				current = true
+			}
+		}
+	}
+	assert.True(t, current, "expected a Current status event for cm-a")
+}
+
+// assertInventory checks that the inventory contains exactly the
+// expected objects.
+func assertInventory(t *testing.T, actual []object.ObjMetadata, expected ...object.ObjMetadata) {
+	var actualIds, expectedIds []string
+	for _, obj := range actual {
+		actualIds = append(actualIds, obj.String())
+	}
+	for _, obj := range expected {
+		expectedIds = append(expectedIds, obj.String())
+	}
+	sort.Strings(actualIds)
+	sort.Strings(expectedIds)
+	assert.Equal(t, expectedIds, actualIds)
+}
@@ -0,0 +1,227 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// +build integration
+
+// Package integration contains end-to-end tests for apply and prune
+// running against a real API server and etcd started by envtest. The
+// tests require the test binaries (etcd, kube-apiserver) pointed to by
+// the KUBEBUILDER_ASSETS environment variable, and are only built with
+// the integration build tag:
+//
+//	KUBEBUILDER_ASSETS=/usr/local/kubebuilder/bin go test -tags integration ./test/integration/...
+package integration
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/inventory"
+	"sigs.k8s.io/cli-utils/pkg/manifestreader"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// config is the configuration for the API server started by TestMain.
+var config *rest.Config
+
+func TestMain(m *testing.M) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		fmt.Fprintln(os.Stderr, "KUBEBUILDER_ASSETS must point to the envtest binaries")
+		os.Exit(1)
+	}
+	env := &envtest.Environment{}
+	var err error
+	config, err = env.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error starting test environment: %v\n", err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	if err := env.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "error stopping test environment: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// newFactory returns a Factory for the API server started by TestMain,
+// with the discovery cache in a temporary directory removed by the
+// returned cleanup function.
+func newFactory(t *testing.T) (cmdutil.Factory, func()) {
+	cacheDir, err := ioutil.TempDir("", "cli-utils-integration")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	flags := genericclioptions.NewConfigFlags(false)
+	flags.APIServer = &config.Host
+	flags.CacheDir = &cacheDir
+	f := cmdutil.NewFactory(cmdutil.NewMatchVersionFlags(flags))
+	return f, func() { _ = os.RemoveAll(cacheDir) }
+}
+
+// newNamespace creates a namespace with a unique name for a test and
+// returns its name.
+func newNamespace(t *testing.T, f cmdutil.Factory) string {
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ns, err := clientset.CoreV1().Namespaces().Create(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "integration-",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating namespace: %v", err)
+	}
+	return ns.Name
+}
+
+// newApplier returns an initialized Applier for the API server started
+// by TestMain. Server-side apply is used if serverSide is set.
+func newApplier(t *testing.T, f cmdutil.Factory, serverSide bool) *apply.Applier {
+	applier := apply.NewApplier(f, genericclioptions.IOStreams{
+		In:     os.Stdin,
+		Out:    ioutil.Discard,
+		ErrOut: ioutil.Discard,
+	})
+	cmd := &cobra.Command{}
+	if err := applier.SetFlags(cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var notUsedFlag bool
+	// This flag needs to be set as there is a dependency on it.
+	cmd.Flags().BoolVar(&notUsedFlag, "dry-run", notUsedFlag, "")
+	cmdutil.AddValidateFlags(cmd)
+	cmdutil.AddServerSideApplyFlags(cmd)
+	if serverSide {
+		if err := cmd.Flags().Set("server-side", "true"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := applier.Initialize(cmd); err != nil {
+		t.Fatalf("unexpected error initializing applier: %v", err)
+	}
+	return applier
+}
+
+// inventoryTemplate returns the manifest for an inventory object
+// template with the passed inventory id.
+func inventoryTemplate(id string) string {
+	return fmt.Sprintf(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: inventory
+  labels:
+    cli-utils.sigs.k8s.io/inventory-id: %s
+`, id)
+}
+
+// configMap returns the manifest for a ConfigMap with the passed name.
+func configMap(name string) string {
+	return fmt.Sprintf(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+data:
+  key: value
+`, name)
+}
+
+// readManifests reads the passed manifests into infos, defaulting
+// their namespace to namespace.
+func readManifests(t *testing.T, f cmdutil.Factory, namespace string, manifests ...string) []*resource.Info {
+	infos, err := (&manifestreader.StreamManifestReader{
+		ReaderName: "integration",
+		Reader:     strings.NewReader(strings.Join(manifests, "\n---\n")),
+		ReaderOptions: manifestreader.ReaderOptions{
+			Factory:   f,
+			Namespace: namespace,
+		},
+	}).Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading manifests: %v", err)
+	}
+	return infos
+}
+
+// runApply runs the applier with the passed infos and options, and
+// returns all events. Error events fail the test.
+func runApply(t *testing.T, applier *apply.Applier, infos []*resource.Info, options apply.Options) []event.Event {
+	var events []event.Event
+	for e := range applier.Run(context.Background(), infos, options) {
+		if e.Type == event.ErrorType {
+			t.Fatalf("unexpected error event: %v", e.ErrorEvent.Err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// objectName returns the name of the passed object from an event.
+func objectName(t *testing.T, obj interface{}) string {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		t.Fatalf("expected *unstructured.Unstructured, got %T", obj)
+	}
+	return u.GetName()
+}
+
+// configMapExists fetches the ConfigMap with the passed name, returning
+// false if it does not exist.
+func configMapExists(t *testing.T, f cmdutil.Factory, namespace, name string) bool {
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	return err == nil
+}
+
+// inventoryObjects returns the objects recorded in the inventory
+// object in the cluster for the passed inventory template.
+func inventoryObjects(t *testing.T, f cmdutil.Factory, template *resource.Info) []object.ObjMetadata {
+	invClient, err := inventory.NewInventoryClient(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	invs, err := invClient.GetPreviousInventoryObjects(template)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(invs) != 1 {
+		t.Fatalf("expected 1 inventory object in the cluster, got %d", len(invs))
+	}
+	objs, err := inventory.WrapInventoryObj(invs[0]).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return objs
+}
+
+// configMapID returns the object metadata for the ConfigMap with the
+// passed namespace and name.
+func configMapID(namespace, name string) object.ObjMetadata {
+	return object.ObjMetadata{
+		Namespace: namespace,
+		Name:      name,
+		GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+	}
+}